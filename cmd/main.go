@@ -1,22 +1,58 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
+	"time"
+	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/notifications"
 	config "symphony_chat/internal/infrastructure/configs"
 	"symphony_chat/internal/infrastructure/database"
+	"symphony_chat/internal/infrastructure/logging"
+	"symphony_chat/internal/infrastructure/tracing"
 	transaction"symphony_chat/internal/infrastructure/transaction/postgres"
 
+	chatPostgresRepo "symphony_chat/internal/infrastructure/chat/postgres"
+	jwtKeys "symphony_chat/internal/infrastructure/jwt/keys"
 	jwtPostgresRepo "symphony_chat/internal/infrastructure/jwt/postgres"
+	sessionPostgresRepo "symphony_chat/internal/infrastructure/sessions/postgres"
+	loginAttemptPostgresRepo "symphony_chat/internal/infrastructure/login_attempt/postgres"
+	twoFactorPostgresRepo "symphony_chat/internal/infrastructure/two_factor/postgres"
+	passwordResetPostgresRepo "symphony_chat/internal/infrastructure/password_reset/postgres"
+	"symphony_chat/internal/infrastructure/metrics"
+	localNotifier "symphony_chat/internal/infrastructure/notifications/local"
+	oidcPostgresRepo "symphony_chat/internal/infrastructure/oidc/postgres"
+	oidcProvider "symphony_chat/internal/infrastructure/oidc/provider"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
 	authUserPostgresRepo "symphony_chat/internal/infrastructure/users/postgres"
 
+	account "symphony_chat/internal/service/account"
 	authentication "symphony_chat/internal/service/auth/authentication"
 	registration "symphony_chat/internal/service/auth/registration"
+	lockout "symphony_chat/internal/service/auth/lockout"
+	twoFactor "symphony_chat/internal/service/auth/two_factor"
+	oidcService "symphony_chat/internal/service/auth/oidc"
+	password "symphony_chat/internal/service/auth/password"
+	chatService "symphony_chat/internal/service/chat"
+	health "symphony_chat/internal/service/health"
+	profile "symphony_chat/internal/service/profile"
+	roleSync "symphony_chat/internal/service/role_sync"
 	jwtService "symphony_chat/internal/service/jwt"
 
 	authHandlerHTTP "symphony_chat/internal/application/auth/http"
+	chatHandlerHTTP "symphony_chat/internal/application/chat/http"
+	healthHandlerHTTP "symphony_chat/internal/application/health/http"
+	usersHandlerHTTP "symphony_chat/internal/application/users/http"
+	websocketHandler "symphony_chat/internal/application/websocket/handler"
 
+	"symphony_chat/internal/application/cookies"
 	middleware "symphony_chat/internal/application/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -25,73 +61,200 @@ import (
 
 func main() {
 
-	// Loading .env file
-	err := godotenv.Load()
+	// Loading .env file (it is optional, variables can be set in environment)
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fatal("Error loading .env file", err)
+	}
+
+	// Subcommand for database migrations: migrate [flags] up | down N | status | create NAME
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if errors.Is(err, errMigrateUsage) {
+			fmt.Fprintf(os.Stderr, "%v\n\n%s\n", err, migrateUsage)
+			os.Exit(2)
+		}
+		if err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+
+	// Loading config (defaults < config file < environment variables < flags)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+		fatal("Failed to load config", err)
 	}
 
-	// Creating configs
+	// Logger with request, connection, user and chat ids from context
+	slog.SetDefault(logging.NewLogger(os.Stdout, cfg.Log))
+
+	// Tracing (spans are exported only if tracing.exporter is not none)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
 
-	// Database config
-	postgresConfig := database.PostgresConfig{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("DB_SSLMODE"),
+	// JWT signing keys
+	var jwtKeySet jwt.KeySet
+	// Config validation allows ephemeral key only if jwt.ephemeral_keys is enabled
+	if cfg.JWT.EphemeralKeys {
+		slog.Warn("jwt.ephemeral_keys is enabled, tokens will be invalid after restart, use it only for development")
+		jwtKeySet, err = jwtKeys.GenerateEphemeralKeySet()
+	} else {
+		jwtKeySet, err = jwtKeys.LoadKeySet(cfg.JWT.KeysDir, cfg.JWT.SigningKeyID)
+	}
+	if err != nil {
+		fatal("Failed to load JWT signing keys", err)
 	}
 
-	// JWTtoken config
-	accessTTLinMinutes, err := strconv.ParseUint(os.Getenv("ACCESS_TTL_IN_MINUTES"), 10, 32)
+	// Cookie config (refresh token and csrf cookies), it was validated by config.Load
+	cookieConfig, err := cfg.Cookie.CookieConfig()
 	if err != nil {
-		log.Fatal("Failed to parse JWT_ACCESS_TTL_IN_MINUTES:", err)
+		fatal("Failed to create cookie config", err)
 	}
+	cookieManager := cookies.NewCookieManager(cookieConfig)
 
-	refreshTTLinDays, err := strconv.ParseUint(os.Getenv("REFRESH_TTL_IN_DAYS"), 10, 32)
+	// Password hashing config
+	passwordHasher, err := passwordhash.NewPasswordHasher(passwordhash.WithArgon2Params(cfg.Password.Argon2Params()))
 	if err != nil {
-		log.Fatal("Failed to parse JWT_REFRESH_TTL_IN_DAYS:", err)
+		fatal("Failed to create password hasher", err)
 	}
 
-	jwtConfig := config.NewJWTConfig(
-		os.Getenv("JWT_SECRET"),
-		uint(accessTTLinMinutes),
-		uint(refreshTTLinDays),
-	)
+	// Password policy config
+	var bannedPasswords []string
+	if cfg.Password.BannedListFile != "" {
+		bannedPasswords, err = passwordhash.LoadBannedPasswords(cfg.Password.BannedListFile)
+		if err != nil {
+			fatal("Failed to load banned passwords", err)
+		}
+	}
+	passwordPolicy := passwordhash.NewPasswordPolicy(cfg.Password.MinLength, cfg.Password.MaxLength, cfg.Password.AllowUnicode, bannedPasswords)
 
 	// Creating database connection
-	db, err := database.NewPostgresConnection(postgresConfig)
+	db, err := database.NewPostgresConnection(postgresConfigOf(cfg.Database))
 	if err != nil {
-		log.Fatal("Failed to create database connection:", err)
+		fatal("Failed to create database connection", err)
+	}
+
+	// Migrations are embedded into the binary unless database.migrations_dir overrides them
+	migrationsFS := migrationsSource(cfg.Database)
+
+	// Applying migrations when deployment has no separate migration step
+	if cfg.Database.MigrateOnStartup {
+		if err := migrateUp(context.Background(), db, migrationsFS); err != nil {
+			fatal("Failed to apply migrations", err)
+		}
+	}
+
+	// Metrics (exposed on /metrics)
+	appMetrics := metrics.NewMetrics()
+	if err := appMetrics.RegisterDatabase(db, cfg.Database.Name); err != nil {
+		fatal("Failed to register database metrics", err)
+	}
+
+	// Schema version that readiness check expects
+	expectedMigrationVersion, err := database.LatestMigrationVersion(migrationsFS)
+	if err != nil {
+		fatal("Failed to get latest migration version", err)
 	}
 
 	// Creating Repositories
 	authUserRepo := authUserPostgresRepo.NewPostgresAuthUserRepo(db)
+	chatUserRepo := authUserPostgresRepo.NewPostgresChatUserRepo(db)
+	userBlockRepo := authUserPostgresRepo.NewPostgresUserBlockRepo(db)
 	jwtRepo := jwtPostgresRepo.NewPostgresJWTtokenRepo(db)
+	revokedTokenRepo := jwtPostgresRepo.NewPostgresRevokedTokenRepo(db)
+	sessionRepo := sessionPostgresRepo.NewPostgresSessionRepo(db)
+	loginAttemptRepo := loginAttemptPostgresRepo.NewPostgresLoginAttemptRepo(db)
+	twoFactorRepo := twoFactorPostgresRepo.NewPostgresTwoFactorRepo(db)
+	passwordResetRepo := passwordResetPostgresRepo.NewPostgresPasswordResetRepo(db)
+	oidcRepo := oidcPostgresRepo.NewPostgresOIDCRepo(db)
+	chatRepo := chatPostgresRepo.NewPostgresChatRepo(db)
+	chatParticipantRepo := chatPostgresRepo.NewPostgresChatParticipantRepo(db)
+	chatRoleRepo := chatPostgresRepo.NewPostgresChatRoleRepo(db)
+	chatMessageRepo := chatPostgresRepo.NewPostgresChatMessageRepo(db)
+	chatAuditRepo := chatPostgresRepo.NewPostgresChatAuditRepo(db)
 
 	// Creating services
 
 	//Transaction manager
-	transactionManager := transaction.NewPostgresTransactionManager(db)
+	transactionManager := transaction.NewPostgresTransactionManager(db, transaction.WithMetrics(appMetrics))
+
+	// Built-in chat roles are restored in the database if they are missing or were edited by hand
+	roleSyncService, err := roleSync.NewRoleSyncService(
+		roleSync.WithChatRoleRepository(chatRoleRepo),
+		roleSync.WithTransactionManager(transactionManager),
+	)
+	if err != nil {
+		fatal("Failed to create role sync service", err)
+	}
+	if err := syncChatRoles(context.Background(), roleSyncService); err != nil {
+		fatal("Failed to sync built-in chat roles", err)
+	}
+
+	// Denylist of revoked access tokens
+	tokenDenylist := jwtService.NewTokenDenylist(revokedTokenRepo)
+	if err := tokenDenylist.Sync(context.Background()); err != nil {
+		fatal("Failed to load token denylist", err)
+	}
+	// Background jobs are stopped during graceful shutdown
+	backgroundCtx, stopBackgroundJobs := context.WithCancel(context.Background())
+	defer stopBackgroundJobs()
+	go tokenDenylist.Run(backgroundCtx, time.Minute)
 
 	// JWTtoken service
 	jwtService, err := jwtService.NewJWTtokenService(
-		jwtService.WithJWTConfig(jwtConfig),
+		jwtService.WithJWTConfig(cfg.JWT),
+		jwtService.WithKeySet(jwtKeySet),
 		jwtService.WithJWTtokenRepository(jwtRepo),
+		jwtService.WithTransactionManager(transactionManager),
+		jwtService.WithSessionRepository(sessionRepo),
+		jwtService.WithTokenDenylist(tokenDenylist),
 	)
 	if err != nil {
-		log.Fatal("Failed to create JWT service:", err)
+		fatal("Failed to create JWT service", err)
 	}
+	go jwtService.RunRefreshTokenPurge(backgroundCtx, time.Hour)
 
 	// Registration service
 	registrationService, err := registration.NewRegistrationService(
 		registration.WithAuthUserRepository(authUserRepo),
+		registration.WithChatUserRepository(chatUserRepo),
 		registration.WithJWTtokenService(jwtService),
 		registration.WithTransactionManager(transactionManager),
+		registration.WithPasswordHasher(passwordHasher),
+		registration.WithPasswordPolicy(passwordPolicy),
+		registration.WithCookieManager(cookieManager),
 	)
 	if err != nil {
-		log.Fatal("Failed to create registration service:", err)
+		fatal("Failed to create registration service", err)
+	}
+
+	// Lockout service (failed login attempts per login and per ip)
+	lockoutService, err := lockout.NewLockoutService(
+		lockout.WithLoginAttemptRepository(loginAttemptRepo),
+		lockout.WithTransactionManager(transactionManager),
+		lockout.WithLoginPolicy(cfg.Lockout.LoginPolicy()),
+		lockout.WithIPPolicy(cfg.Lockout.IPPolicy()),
+	)
+	if err != nil {
+		fatal("Failed to create lockout service", err)
+	}
+	go lockoutService.Run(backgroundCtx, 10*time.Minute)
+
+	// Two factor service
+	twoFactorService, err := twoFactor.NewTwoFactorService(
+		twoFactor.WithAuthUserRepository(authUserRepo),
+		twoFactor.WithTwoFactorRepository(twoFactorRepo),
+		twoFactor.WithTransactionManager(transactionManager),
+		twoFactor.WithPasswordHasher(passwordHasher),
+		twoFactor.WithJWTtokenService(jwtService),
+	)
+	if err != nil {
+		fatal("Failed to create two factor service", err)
 	}
 
 	// Authentication service
@@ -99,9 +262,96 @@ func main() {
 		authentication.WithAuthUserRepository(authUserRepo),
 		authentication.WithJWTtokenService(jwtService),
 		authentication.WithTransactionManager(transactionManager),
+		authentication.WithSessionRepository(sessionRepo),
+		authentication.WithLockoutService(lockoutService),
+		authentication.WithTwoFactorService(twoFactorService),
+		authentication.WithPasswordHasher(passwordHasher),
+		authentication.WithCookieManager(cookieManager),
+	)
+	if err != nil {
+		fatal("Failed to create authentication service", err)
+	}
+
+	// OIDC service (external provider is configured only if oidc.issuer_url is set)
+	oidcConfigs := []oidcService.OIDCConfiguration{
+		oidcService.WithOIDCRepository(oidcRepo),
+		oidcService.WithAuthUserRepository(authUserRepo),
+		oidcService.WithChatUserRepository(chatUserRepo),
+		oidcService.WithJWTtokenService(jwtService),
+		oidcService.WithTwoFactorService(twoFactorService),
+		oidcService.WithTransactionManager(transactionManager),
+		oidcService.WithCookieManager(cookieManager),
+	}
+	if cfg.OIDC.Enabled() {
+		providerConfig := oidcProvider.ProviderConfig{
+			Name:         cfg.OIDC.ProviderName,
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}
+
+		discoveryCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		provider, err := oidcProvider.NewOIDCProvider(discoveryCtx, providerConfig, nil)
+		cancel()
+		if err != nil {
+			fatal("Failed to create OIDC provider", err)
+		}
+		oidcConfigs = append(oidcConfigs, oidcService.WithIdentityProvider(provider))
+	}
+
+	oidcService, err := oidcService.NewOIDCService(oidcConfigs...)
+	if err != nil {
+		fatal("Failed to create OIDC service", err)
+	}
+
+	// Notifier (reset tokens are written to the file if notifications_file is set,
+	// otherwise notifications are written to the log with redacted bodies unless log_notification_bodies is enabled)
+	var notifier notifications.Notifier = localNotifier.NewLogNotifier(cfg.LogNotificationBodies)
+	if cfg.NotificationsFile != "" {
+		notifier = localNotifier.NewFileNotifier(cfg.NotificationsFile)
+	} else if cfg.LogNotificationBodies {
+		slog.Warn("log_notification_bodies is enabled, reset tokens are written to the log, use it only for development")
+	}
+
+	// Password service
+	passwordService, err := password.NewPasswordService(
+		password.WithAuthUserRepository(authUserRepo),
+		password.WithPasswordResetRepository(passwordResetRepo),
+		password.WithJWTtokenService(jwtService),
+		password.WithNotifier(notifier),
+		password.WithTransactionManager(transactionManager),
+		password.WithPasswordHasher(passwordHasher),
+		password.WithPasswordPolicy(passwordPolicy),
+	)
+	if err != nil {
+		fatal("Failed to create password service", err)
+	}
+
+	// Permission cache, nil disables it
+	var permissionCache *chatService.PermissionCache
+	if cfg.PermissionCache.Enabled() {
+		permissionCache = chatService.NewPermissionCache(cfg.PermissionCache.MaxEntries, cfg.PermissionCache.TTL(), appMetrics)
+		if err := appMetrics.RegisterPermissionCache(permissionCache); err != nil {
+			fatal("Failed to register permission cache metrics", err)
+		}
+	}
+
+	// Chat service
+	chatService, err := chatService.NewChatService(
+		chatService.WithChatUserRepository(chatUserRepo),
+		chatService.WithChatRepository(chatRepo),
+		chatService.WithChatParticipantRepository(chatParticipantRepo),
+		chatService.WithChatRolesRepository(chatRoleRepo),
+		chatService.WithChatMessageRepository(chatMessageRepo),
+		chatService.WithChatAuditRepository(chatAuditRepo),
+		chatService.WithUserBlockRepository(userBlockRepo),
+		chatService.WithTransactionManager(transactionManager),
+		chatService.WithPermissionCache(permissionCache),
 	)
 	if err != nil {
-		log.Fatal("Failed to create authentication service:", err)
+		fatal("Failed to create chat service", err)
 	}
 
 	// Creating handlers
@@ -109,17 +359,213 @@ func main() {
 	// Auth handler
 	authHandler := authHandlerHTTP.NewAuthHandler(registrationService, authenticationService)
 
+	// Password handler
+	passwordHandler := authHandlerHTTP.NewPasswordHandler(passwordService)
+
+	// OIDC handler
+	oidcHandler := authHandlerHTTP.NewOIDCHandler(oidcService, authenticationService)
+
+	// Two factor handler
+	twoFactorHandler := authHandlerHTTP.NewTwoFactorHandler(twoFactorService)
+
+	// JWKS handler
+	jwksHandler := authHandlerHTTP.NewJWKSHandler(jwtService)
+
+	// Chat handler
+	chatHandler := chatHandlerHTTP.NewChatHandler(chatService)
+
+	// Websocket handler
+	wsHandler := websocketHandler.NewWebsocketHandler(chatService, cfg.WebSocket, appMetrics)
+	if err := appMetrics.RegisterHub(wsHandler); err != nil {
+		fatal("Failed to register websocket hub metrics", err)
+	}
+	tokenDenylist.OnRevoke(wsHandler.DisconnectClientsByRevokedID)
+
+	// Account service
+	accountService, err := account.NewAccountService(
+		account.WithAuthUserRepository(authUserRepo),
+		account.WithChatUserRepository(chatUserRepo),
+		account.WithJWTtokenService(jwtService),
+		account.WithChatService(chatService),
+		account.WithConnectionCloser(wsHandler),
+		account.WithTransactionManager(transactionManager),
+		account.WithPasswordHasher(passwordHasher),
+		account.WithCookieManager(cookieManager),
+	)
+	if err != nil {
+		fatal("Failed to create account service", err)
+	}
+
+	// Account handler
+	accountHandler := authHandlerHTTP.NewAccountHandler(accountService)
+
+	// Profile service (presence in user search is taken from websocket connections)
+	profileService, err := profile.NewProfileService(
+		profile.WithChatUserRepository(chatUserRepo),
+		profile.WithUserBlockRepository(userBlockRepo),
+		profile.WithPresenceProvider(wsHandler),
+		profile.WithTransactionManager(transactionManager),
+	)
+	if err != nil {
+		fatal("Failed to create profile service", err)
+	}
+
+	// Profile handler
+	profileHandler := usersHandlerHTTP.NewProfileHandler(profileService)
+
+	// Health service (readiness depends on database, migrations and websocket hub)
+	healthService, err := health.NewHealthService(
+		health.WithDatabase(db),
+		health.WithExpectedMigrationVersion(expectedMigrationVersion),
+		health.WithConnectionAcceptor(wsHandler),
+	)
+	if err != nil {
+		fatal("Failed to create health service", err)
+	}
+
+	// Health handler
+	healthHandler := healthHandlerHTTP.NewHealthHandler(healthService)
+
+	// Auth middleware (expired access token is rejected, client refreshes it with POST /refresh)
+	authMiddleware := middleware.AuthMiddleware(jwtService)
+
 	// Создаем роутер
-	r := gin.Default()
+	r := gin.New()
+	// Forwarded headers are taken only from configured proxies, otherwise client could change its ip for lockout
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("Failed to set trusted proxies", err)
+	}
+	r.Use(gin.Recovery())
+	r.Use(middleware.TracingMiddleware(cfg.Tracing.ServiceName))
+	r.Use(middleware.LoggingMiddleware())
+	r.Use(middleware.MetricsMiddleware(appMetrics))
 
 	// Базовый маршрут для проверки
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 	r.POST("/signup", authHandler.SignUp)
 	r.POST("/login", authHandler.LogIn)
-	r.POST("/logout", middleware.AuthMiddleware(jwtService), authHandler.LogOut)
+	r.POST("/login/2fa", authHandler.LogInTwoFactor)
+	r.GET("/oidc/:provider/login", oidcHandler.StartLogin)
+	r.GET("/oidc/:provider/callback", oidcHandler.Callback)
+	r.POST("/oidc/:provider/link", authMiddleware, oidcHandler.StartLink)
+	r.POST("/refresh", middleware.CSRFMiddleware(cookieManager), authHandler.Refresh)
+	r.POST("/logout", authMiddleware, authHandler.LogOut)
+	r.POST("/password/change", authMiddleware, passwordHandler.ChangePassword)
+	r.POST("/password/forgot", passwordHandler.ForgotPassword)
+	r.POST("/password/reset", passwordHandler.ResetPassword)
+	r.POST("/2fa/enroll", authMiddleware, twoFactorHandler.Enroll)
+	r.POST("/2fa/enable", authMiddleware, twoFactorHandler.Enable)
+	r.POST("/2fa/recovery-codes", authMiddleware, twoFactorHandler.RegenerateRecoveryCodes)
+	r.POST("/2fa/disable", authMiddleware, twoFactorHandler.Disable)
+	r.GET("/me", authMiddleware, profileHandler.GetMyProfile)
+	r.PATCH("/me", authMiddleware, profileHandler.UpdateMyProfile)
+	r.GET("/users", authMiddleware, profileHandler.SearchUsers)
+	r.GET("/users/:username", authMiddleware, profileHandler.GetProfileByUsername)
+	r.POST("/users/:username/block", authMiddleware, profileHandler.BlockUser)
+	r.DELETE("/users/:username/block", authMiddleware, profileHandler.UnblockUser)
+	r.DELETE("/me", authMiddleware, accountHandler.DeleteAccount)
+	r.GET("/sessions", authMiddleware, authHandler.GetSessions)
+	r.DELETE("/sessions/:session_id", authMiddleware, authHandler.RevokeSession)
+	r.POST("/sessions/revoke-others", authMiddleware, authHandler.RevokeOtherSessions)
+
+	r.GET("/ws", authMiddleware, wsHandler.HandleWebSocket)
+	r.GET("/chats/:chat_id/audit-log", authMiddleware, chatHandler.GetChatAuditLog)
 
 	// Запускаем сервер
-	log.Println("Starting server at :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: r,
 	}
+
+	// Metrics are not authenticated, so they are served by internal listener instead of the public router
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", appMetrics.Handler())
+	metricsServer := &http.Server{
+		Addr:    cfg.Server.MetricsAddr,
+		Handler: metricsMux,
+	}
+
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("Starting metrics server", "addr", cfg.Server.MetricsAddr)
+		serverErr <- metricsServer.ListenAndServe()
+	}()
+	go func() {
+		if cfg.Server.TLSEnabled() {
+			slog.Info("Starting server with TLS", "addr", cfg.Server.Addr)
+			serverErr <- server.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			slog.Info("Starting server", "addr", cfg.Server.Addr)
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+
+	// Waiting for SIGTERM (or Ctrl+C) to shut down gracefully
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serverErr:
+		fatal("Failed to start server", err)
+	case <-signalCtx.Done():
+		stopSignals()
+	}
+
+	// Readiness fails first, so load balancer stops sending traffic while the server still accepts connections
+	slog.Info("Draining server", "drain_delay", cfg.Server.DrainDelay())
+	healthService.StartDraining()
+	time.Sleep(cfg.Server.DrainDelay())
+
+	slog.Info("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout())
+	defer cancel()
+
+	// New connections are not accepted, in-flight requests are awaited (hijacked websocket connections are not)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to finish in-flight requests", "error", err)
+	}
+
+	// Websocket clients get "going away" close frame after in-flight messages are handled and send buffers are flushed
+	if err := wsHandler.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to close websocket connections gracefully", "error", err)
+	}
+
+	stopBackgroundJobs()
+
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to shut down metrics server", "error", err)
+	}
+
+	if err := transactionManager.Close(shutdownCtx); err != nil {
+		slog.Error("Failed to wait for active transactions", "error", err)
+	}
+
+	if err := db.Close(); err != nil {
+		slog.Error("Failed to close database connection", "error", err)
+	}
+
+	// Spans that were not exported yet are flushed
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server stopped")
+}
+
+func postgresConfigOf(dc config.DatabaseConfig) database.PostgresConfig {
+	return database.PostgresConfig{
+		Host:     dc.Host,
+		Port:     dc.Port,
+		User:     dc.User,
+		Password: dc.Password,
+		DBName:   dc.Name,
+		SSLMode:  dc.SSLMode,
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
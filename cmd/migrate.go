@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	chatPostgresRepo "symphony_chat/internal/infrastructure/chat/postgres"
+	config "symphony_chat/internal/infrastructure/configs"
+	"symphony_chat/internal/infrastructure/database"
+	"symphony_chat/internal/infrastructure/logging"
+	transaction "symphony_chat/internal/infrastructure/transaction/postgres"
+	roleSync "symphony_chat/internal/service/role_sync"
+	"symphony_chat/migrations"
+)
+
+const migrateUsage = `Usage: symphony_chat migrate [flags] <command>
+
+Commands:
+  up           apply all migrations that are not applied yet and sync built-in chat roles
+  down N       roll back N newest migrations
+  status       show version of the database, applied migrations and drift of built-in chat roles
+  create NAME  create empty up and down files in database.migrations_dir (migrations directory by default)
+
+Flags are the same as flags of the server, see symphony_chat -h`
+
+// Wrong command or arguments, usage is printed instead of the error log
+var errMigrateUsage = errors.New("invalid migrate command")
+
+// Directory of migrations in the source tree, "migrate create" writes to it when database.migrations_dir is not set
+const defaultMigrationsDir = "migrations"
+
+// Subcommand migrates database with migrations that are embedded into the binary (or read from database.migrations_dir)
+func runMigrate(args []string) error {
+	cfg, rest, err := config.LoadWithArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	command, steps, err := parseMigrateCommand(rest)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(logging.NewLogger(os.Stdout, cfg.Log))
+
+	// Files are created in the source tree, so database is not needed
+	if command == "create" {
+		dir := cfg.Database.MigrationsDir
+		if dir == "" {
+			dir = defaultMigrationsDir
+		}
+		upPath, downPath, err := database.CreateMigration(dir, rest[1])
+		if err != nil {
+			return err
+		}
+		slog.Info("Created migration", "up", upPath, "down", downPath)
+		return nil
+	}
+
+	// Ctrl+C cancels waiting for migration lock and the running migration (its transaction is rolled back)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.NewPostgresConnection(postgresConfigOf(cfg.Database))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	source := migrationsSource(cfg.Database)
+
+	switch command {
+	case "up":
+		if err := migrateUp(ctx, db, source); err != nil {
+			return err
+		}
+		roleSyncService, err := newRoleSyncService(db)
+		if err != nil {
+			return err
+		}
+		return syncChatRoles(ctx, roleSyncService)
+	case "down":
+		return migrateDown(ctx, db, source, steps)
+	default:
+		if err := printMigrationStatus(ctx, db, source); err != nil {
+			return err
+		}
+		roleSyncService, err := newRoleSyncService(db)
+		if err != nil {
+			return err
+		}
+		return printRoleDrift(ctx, roleSyncService)
+	}
+}
+
+// Function checks arguments before database is touched, steps are returned for down command
+func parseMigrateCommand(args []string) (string, int, error) {
+	if len(args) == 0 {
+		return "", 0, fmt.Errorf("%w: command is required", errMigrateUsage)
+	}
+
+	switch command := args[0]; command {
+	case "up", "status":
+		if len(args) != 1 {
+			return "", 0, fmt.Errorf("%w: %s has no arguments", errMigrateUsage, command)
+		}
+		return command, 0, nil
+	case "down":
+		if len(args) != 2 {
+			return "", 0, fmt.Errorf("%w: down requires number of migrations", errMigrateUsage)
+		}
+		steps, err := strconv.Atoi(args[1])
+		if err != nil || steps < 1 {
+			return "", 0, fmt.Errorf("%w: number of migrations must be a positive integer, got %q", errMigrateUsage, args[1])
+		}
+		return command, steps, nil
+	case "create":
+		if len(args) != 2 {
+			return "", 0, fmt.Errorf("%w: create requires migration name", errMigrateUsage)
+		}
+		return command, 0, nil
+	default:
+		return "", 0, fmt.Errorf("%w: unknown command %q", errMigrateUsage, command)
+	}
+}
+
+// Migrations that are embedded into the binary are used unless database.migrations_dir overrides them
+func migrationsSource(dbConfig config.DatabaseConfig) fs.FS {
+	if dbConfig.MigrationsDir == "" {
+		return migrations.FS
+	}
+
+	slog.Warn("Migrations are read from database.migrations_dir instead of the binary", "dir", dbConfig.MigrationsDir)
+	return os.DirFS(dbConfig.MigrationsDir)
+}
+
+// Also used by the server when database.migrate_on_startup is set
+func migrateUp(ctx context.Context, db *sql.DB, source fs.FS) error {
+	migrator, err := database.NewMigrator(db, source)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		slog.Info("Database is up to date")
+	}
+	return nil
+}
+
+func migrateDown(ctx context.Context, db *sql.DB, source fs.FS, steps int) error {
+	migrator, err := database.NewMigrator(db, source)
+	if err != nil {
+		return err
+	}
+
+	rolledBack, err := migrator.Down(ctx, steps)
+	for _, migration := range rolledBack {
+		slog.Info("Rolled back migration", "version", migration.Version, "name", migration.Name)
+	}
+	return err
+}
+
+func printMigrationStatus(ctx context.Context, db *sql.DB, source fs.FS) error {
+	migrator, err := database.NewMigrator(db, source)
+	if err != nil {
+		return err
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database version: %d", status.Version)
+	if status.Dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, migration := range status.Migrations {
+		state := "pending"
+		if migration.Applied {
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\n", migration.Version, migration.Name, state)
+	}
+	return w.Flush()
+}
+
+// Role sync of migrate command doesn't need metrics of the server
+func newRoleSyncService(db *sql.DB) (*roleSync.RoleSyncService, error) {
+	return roleSync.NewRoleSyncService(
+		roleSync.WithChatRoleRepository(chatPostgresRepo.NewPostgresChatRoleRepo(db)),
+		roleSync.WithTransactionManager(transaction.NewPostgresTransactionManager(db)),
+	)
+}
+
+// Also used by the server on startup, every drift that was fixed is logged
+func syncChatRoles(ctx context.Context, roleSyncService *roleSync.RoleSyncService) error {
+	drift, err := roleSyncService.Sync(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, roleDrift := range drift {
+		if roleDrift.Unknown {
+			slog.Warn("Chat role in database is not a built-in role", "role_id", roleDrift.RoleID, "role", roleDrift.RoleName)
+			continue
+		}
+		slog.Warn("Built-in chat role differed from its definition and was restored", "role_id", roleDrift.RoleID, "drift", roleDrift.String())
+	}
+	return nil
+}
+
+func printRoleDrift(ctx context.Context, roleSyncService *roleSync.RoleSyncService) error {
+	drift, err := roleSyncService.CheckDrift(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	if len(drift) == 0 {
+		fmt.Println("Built-in chat roles: in sync")
+		return nil
+	}
+
+	fmt.Println("Built-in chat roles: drift (missing and edited roles are restored by migrate up or server restart)")
+	for _, roleDrift := range drift {
+		fmt.Println("  " + roleDrift.String())
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	publicDto "symphony_chat/internal/application/dto"
+	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/users"
+	accountService "symphony_chat/internal/service/account"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService *accountService.AccountService
+}
+
+func NewAccountHandler(accountService *accountService.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+//DELETE /me
+//Password is required, so stolen access token is not enough for deleting account
+//Accounts without password (OIDC) confirm deletion by recent sign in instead
+func (ah *AccountHandler) DeleteAccount(c *gin.Context) {
+	claims, exists := c.Get("token_claims")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "TOKEN_CLAIMS_WERE_NOT_PROVIDED",
+			"message": "token claims were not provided",
+		})
+		return
+	}
+
+	var request publicDto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "Invalid input format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := ah.accountService.DeleteAccount(c.Request.Context(), claims.(jwt.TokenClaims), request.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrWrongPassword):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": "WRONG_PASSWORD",
+				"message": "wrong password for this user",
+			})
+		case errors.Is(err, users.ErrReauthenticationRequired):
+			respondReauthenticationRequired(c)
+		case errors.Is(err, users.ErrAuthUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"code": "AUTH_USER_NOT_FOUND",
+				"message": "auth user not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	ah.accountService.ClearRefreshTokenCookie(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "ACCOUNT_DELETED",
+		"message": "account was deleted",
+	})
+}
@@ -2,16 +2,20 @@ package http
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	publicDto "symphony_chat/internal/application/dto"
 	"symphony_chat/internal/domain/jwt"
+	loginattempt "symphony_chat/internal/domain/login_attempt"
+	"symphony_chat/internal/domain/sessions"
 	"symphony_chat/internal/domain/users"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
 	as "symphony_chat/internal/service/auth/authentication"
 	rs "symphony_chat/internal/service/auth/registration"
 	utils "symphony_chat/utils/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
@@ -27,8 +31,8 @@ func NewAuthHandler(registrationService *rs.RegistrationService, authenticationS
 }
 
 func (ah *AuthHandler) SignUp(c *gin.Context) {
-	var loginCredentials publicDto.LoginCredentials
-	if err := c.ShouldBindJSON(&loginCredentials); err != nil {
+	var signUpRequest publicDto.SignUpRequest
+	if err := c.ShouldBindJSON(&signUpRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": "INVALID_INPUT",
 			"message": "Invalid input format",
@@ -39,26 +43,39 @@ func (ah *AuthHandler) SignUp(c *gin.Context) {
 
 	//Validation user input
 
-	if !utils.IsCorrectLoginFormat(loginCredentials.Login) || !utils.IsCorrectPasswordFormat(loginCredentials.Password) {
+	//Password is validated by registration service, because password policy is configurable
+	if !utils.IsCorrectLoginFormat(signUpRequest.Login) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": "INVALID_LOGIN_OR_PASSWORD_FORMAT",
 			"message": "login or password format is not valid",
-			"details": "login must be between 6 and 30 characters and password must be between 10 and 25 characters in length",
+			"details": "login must be between 6 and 30 characters in length",
 		})
 		return
 	}
 
-	tokens, err := ah.registrationService.SignUpUser(c.Request.Context(), loginCredentials)
+	if err := users.ValidateUsername(users.NormalizeUsername(signUpRequest.Username)); err != nil {
+		respondInvalidUsername(c, err)
+		return
+	}
+
+	tokens, err := ah.registrationService.SignUpUser(c.Request.Context(), signUpRequest, deviceInfoFromRequest(c))
 	if err != nil {
 		var authErr *users.AuthError
 		var tokenErr *jwt.TokenError
+		var passwordErr *passwordhash.PasswordError
 
 		switch {
+		case errors.As(err, &passwordErr):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": "INVALID_LOGIN_OR_PASSWORD_FORMAT",
+				"message": "login or password format is not valid",
+				"details": passwordErr.Message,
+			})
 		case errors.As(err, &authErr):
 			//Auth errors
 
 			switch authErr.Code {
-			case "CHECK_USER_EXISTENSE_ERROR", "CREATE_AUTH_USER_ERROR":
+			case "CHECK_USER_EXISTENSE_ERROR", "CREATE_AUTH_USER_ERROR", "CREATE_CHAT_USER_ERROR":
 				c.JSON(http.StatusInternalServerError, gin.H{
 					"code": "DATABASE_ERROR",
 					"message": "internal server error, please try again later",
@@ -76,6 +93,15 @@ func (ah *AuthHandler) SignUp(c *gin.Context) {
 					"message": "user with this login already exists",
 				})
 
+			case "USERNAME_ALREADY_TAKEN":
+				c.JSON(http.StatusConflict, gin.H{
+					"code": "USERNAME_ALREADY_TAKEN",
+					"message": "user with this username already exists",
+				})
+
+			case "INVALID_USERNAME", "USERNAME_RESERVED":
+				respondInvalidUsername(c, authErr)
+
 			default:
 				//Unexpected error
 				c.JSON(http.StatusInternalServerError, gin.H{
@@ -133,12 +159,16 @@ func (ah *AuthHandler) LogIn(c *gin.Context) {
 		return
 	}
 
-	tokens, err := ah.authenticationService.LogIn(c.Request.Context(),loginCredentials)
+	loginResult, err := ah.authenticationService.LogIn(c.Request.Context(),loginCredentials, deviceInfoFromRequest(c))
 	if err != nil {
 		var authErr *users.AuthError
 		var tokenErr *jwt.TokenError
+		var lockedErr *loginattempt.AccountLockedError
 
 		switch {
+		case errors.As(err, &lockedErr):
+			respondAccountLocked(c, lockedErr)
+
 		case errors.As(err, &authErr):
 			switch authErr.Code {
 			case "AUTH_USER_NOT_FOUND":
@@ -184,6 +214,18 @@ func (ah *AuthHandler) LogIn(c *gin.Context) {
 		return
 	}
 
+	//Tokens are issued only after second step
+	if loginResult.TwoFactorRequired {
+		c.JSON(http.StatusOK, gin.H{
+			"code": "TWO_FACTOR_REQUIRED",
+			"challenge_token": loginResult.ChallengeToken,
+			"expires_at": loginResult.ChallengeExpiresAt,
+		})
+		return
+	}
+
+	tokens := loginResult.Tokens
+
 	//Updating only refresh token in cookies
 	ah.authenticationService.UpdateRefreshTokenInHTTPCookie(c, tokens.RefreshToken.GetToken())
 	
@@ -193,17 +235,54 @@ func (ah *AuthHandler) LogIn(c *gin.Context) {
 	})
 }
 
+//POST /login/2fa
+//Challenge token from LogIn is exchanged for tokens together with totp or recovery code
+func (ah *AuthHandler) LogInTwoFactor(c *gin.Context) {
+	var request publicDto.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.ChallengeToken == "" || request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "challenge_token and code are required",
+		})
+		return
+	}
+
+	tokens, err := ah.authenticationService.LogInWithTwoFactor(c.Request.Context(), request.ChallengeToken, request.Code, deviceInfoFromRequest(c))
+	if err != nil {
+		var lockedErr *loginattempt.AccountLockedError
+
+		switch {
+		case errors.As(err, &lockedErr):
+			respondAccountLocked(c, lockedErr)
+		case respondTwoFactorError(c, err):
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	ah.authenticationService.UpdateRefreshTokenInHTTPCookie(c, tokens.RefreshToken.GetToken())
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  publicDto.ToJWTTokenDTO(tokens.AccessToken),
+		"refresh_token": publicDto.ToJWTTokenDTO(tokens.RefreshToken),
+	})
+}
+
 func (ah *AuthHandler) LogOut(c *gin.Context) {
-	userID, exists := c.Get("user_id")
+	claims, exists := c.Get("token_claims")
 	if !exists {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"code": "USER_ID_WAS_NOT_PROVIDED",
-			"message": "user id was not provided",
+			"code": "TOKEN_CLAIMS_WERE_NOT_PROVIDED",
+			"message": "token claims were not provided",
 		})
 		return
 	}
 
-	err := ah.authenticationService.LogOut(c.Request.Context(), userID.(uuid.UUID))
+	err := ah.authenticationService.LogOut(c.Request.Context(), claims.(jwt.TokenClaims))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": "LOGOUT_ERROR",
@@ -221,3 +300,99 @@ func (ah *AuthHandler) LogOut(c *gin.Context) {
 		"message":  "clear_tokens",
 	})
 }
+
+//POST /refresh
+func (ah *AuthHandler) Refresh(c *gin.Context) {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil || refreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": "REFRESH_TOKEN_WAS_NOT_SET_IN_COOKIE",
+			"message": "refresh token was not set in cookie",
+		})
+		return
+	}
+
+	tokens, err := ah.authenticationService.RefreshTokens(c.Request.Context(), refreshToken, deviceInfoFromRequest(c))
+	if err != nil {
+		var tokenErr *jwt.TokenError
+
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			ah.authenticationService.ClearRefreshTokenCookie(c)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": "REFRESH_TOKEN_EXPIRED",
+				"message": "refresh token is expired, please log in again",
+			})
+		case errors.Is(err, jwt.ErrRefreshTokenReused),
+			errors.Is(err, jwt.ErrRefreshTokenRevoked),
+			errors.Is(err, jwt.ErrTokenNotFound),
+			errors.Is(err, jwt.ErrWrongTokenType):
+			errors.As(err, &tokenErr)
+			ah.authenticationService.ClearRefreshTokenCookie(c)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": tokenErr.Code,
+				"message": tokenErr.Message,
+			})
+		case errors.As(err, &tokenErr) && jwt.IsTokenFormatErrorCode(tokenErr.Code):
+			ah.authenticationService.ClearRefreshTokenCookie(c)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": "INVALID_TOKEN_FORMAT",
+				"message": tokenErr.Message,
+				"details": tokenErr.Err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	//Updating only refresh token in cookies
+	ah.authenticationService.UpdateRefreshTokenInHTTPCookie(c, tokens.RefreshToken.GetToken())
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  publicDto.ToJWTTokenDTO(tokens.AccessToken),
+		"refresh_token": publicDto.ToJWTTokenDTO(tokens.RefreshToken),
+	})
+}
+
+//Function collects information about device that sent request
+func deviceInfoFromRequest(c *gin.Context) sessions.DeviceInfo {
+	return sessions.DeviceInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
+
+//Locked login gets Retry-After header with seconds until lockout ends
+func respondAccountLocked(c *gin.Context, lockedErr *loginattempt.AccountLockedError) {
+	retryAfterSeconds := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
+	c.JSON(http.StatusTooManyRequests, gin.H {
+		"code": lockedErr.Code,
+		"message": "too many failed login attempts, please try again later",
+		"retry_after_seconds": retryAfterSeconds,
+	})
+}
+
+//Passwordless (OIDC) accounts have to sign in through identity provider again before sensitive action
+func respondReauthenticationRequired(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"code": users.ErrReauthenticationRequired.Code,
+		"message": "sign in again through identity provider to confirm this action",
+	})
+}
+
+func respondInvalidUsername(c *gin.Context, err error) {
+	var authErr *users.AuthError
+	if !errors.As(err, &authErr) {
+		authErr = users.ErrInvalidUsername
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code": authErr.Code,
+		"message": authErr.Message,
+	})
+}
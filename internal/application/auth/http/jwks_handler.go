@@ -0,0 +1,26 @@
+package http
+
+import (
+	"net/http"
+	publicDto "symphony_chat/internal/application/dto"
+	jwtService "symphony_chat/internal/service/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	jwtService *jwtService.JWTtokenService
+}
+
+func NewJWKSHandler(jwtService *jwtService.JWTtokenService) *JWKSHandler {
+	return &JWKSHandler{
+		jwtService: jwtService,
+	}
+}
+
+//GET /.well-known/jwks.json
+//Public keys that other services use for verifying tokens
+func (jh *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, publicDto.ToJWKSDTO(jh.jwtService.GetVerificationKeys()))
+}
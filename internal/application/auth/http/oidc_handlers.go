@@ -0,0 +1,177 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"symphony_chat/internal/application/cookies"
+	publicDto "symphony_chat/internal/application/dto"
+	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/oidc"
+	as "symphony_chat/internal/service/auth/authentication"
+	oidcService "symphony_chat/internal/service/auth/oidc"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcService           *oidcService.OIDCService
+	authenticationService *as.AuthenticationService
+}
+
+func NewOIDCHandler(oidcService *oidcService.OIDCService, authenticationService *as.AuthenticationService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:           oidcService,
+		authenticationService: authenticationService,
+	}
+}
+
+//GET /oidc/:provider/login
+//Client must redirect user to returned url, state cookie that is set here must be kept until callback
+func (oh *OIDCHandler) StartLogin(c *gin.Context) {
+	authorizationURL, rawState, err := oh.oidcService.StartLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	oh.oidcService.SetStateCookie(c, rawState)
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorization_url": authorizationURL,
+	})
+}
+
+//POST /oidc/:provider/link
+//External account is linked to logged in user on callback
+func (oh *OIDCHandler) StartLink(c *gin.Context) {
+	userID, _, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	authorizationURL, rawState, err := oh.oidcService.StartLink(c.Request.Context(), c.Param("provider"), userID)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	oh.oidcService.SetStateCookie(c, rawState)
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorization_url": authorizationURL,
+	})
+}
+
+//GET /oidc/:provider/callback?code=&state=
+//Identity provider redirects user here, response is the same as response of /login
+//Callback is accepted only in the browser that started the flow (state cookie)
+func (oh *OIDCHandler) Callback(c *gin.Context) {
+	//State cookie is needed only once, so it is cleared whatever the result is
+	stateCookie, _ := c.Cookie(cookies.OIDCStateCookie)
+	oh.oidcService.ClearStateCookie(c)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": oidc.ErrAuthorizationDenied.Code,
+			"message": oidc.ErrAuthorizationDenied.Message,
+			"details": providerErr + " " + c.Query("error_description"),
+		})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "state and code are required",
+		})
+		return
+	}
+
+	result, err := oh.oidcService.HandleCallback(c.Request.Context(), c.Param("provider"), state, stateCookie, code, deviceInfoFromRequest(c))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	if result.Linked {
+		c.JSON(http.StatusOK, gin.H{
+			"code": "OIDC_IDENTITY_LINKED",
+			"message": "external account was linked",
+		})
+		return
+	}
+
+	//Tokens are issued only after second step
+	if result.Login.TwoFactorRequired {
+		c.JSON(http.StatusOK, gin.H{
+			"code": "TWO_FACTOR_REQUIRED",
+			"challenge_token": result.Login.ChallengeToken,
+			"expires_at": result.Login.ChallengeExpiresAt,
+		})
+		return
+	}
+
+	tokens := result.Login.Tokens
+
+	//Updating only refresh token in cookies
+	oh.authenticationService.UpdateRefreshTokenInHTTPCookie(c, tokens.RefreshToken.GetToken())
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  publicDto.ToJWTTokenDTO(tokens.AccessToken),
+		"refresh_token": publicDto.ToJWTTokenDTO(tokens.RefreshToken),
+		"created": result.Created,
+	})
+}
+
+func respondOIDCError(c *gin.Context, err error) {
+	var oidcErr *oidc.OIDCError
+	var tokenErr *jwt.TokenError
+
+	switch {
+	case errors.As(err, &oidcErr):
+		switch oidcErr.Code {
+		case "OIDC_PROVIDER_NOT_FOUND":
+			c.JSON(http.StatusNotFound, gin.H{
+				"code": oidcErr.Code,
+				"message": oidcErr.Message,
+			})
+
+		case "OIDC_STATE_NOT_FOUND", "OIDC_STATE_EXPIRED", "OIDC_STATE_ALREADY_USED", "OIDC_STATE_MISMATCH":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": oidcErr.Code,
+				"message": oidcErr.Message,
+			})
+
+		case "OIDC_CODE_EXCHANGE_FAILED", "OIDC_INVALID_ID_TOKEN":
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": oidcErr.Code,
+				"message": oidcErr.Message,
+			})
+
+		case "OIDC_IDENTITY_LINKED_TO_OTHER_USER", "OIDC_PROVIDER_ALREADY_LINKED":
+			c.JSON(http.StatusConflict, gin.H{
+				"code": oidcErr.Code,
+				"message": oidcErr.Message,
+			})
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+
+	case errors.As(err, &tokenErr) && tokenErr.Code == "CREATE_JWT_TOKENS_ERROR":
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "TOKEN_GENERATION_FAILED",
+			"message": "failed to generate tokens, please try again later",
+		})
+
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "INTERNAL_SERVER_ERROR",
+			"message": "internal server error, please try again later",
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	publicDto "symphony_chat/internal/application/dto"
+	passwordreset "symphony_chat/internal/domain/password_reset"
+	"symphony_chat/internal/domain/users"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
+	ps "symphony_chat/internal/service/auth/password"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordService *ps.PasswordService
+}
+
+func NewPasswordHandler(passwordService *ps.PasswordService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: passwordService,
+	}
+}
+
+//POST /password/change
+func (ph *PasswordHandler) ChangePassword(c *gin.Context) {
+	userID, sessionID, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	var request publicDto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "Invalid input format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	revokedSessionIDs, err := ph.passwordService.ChangePassword(c.Request.Context(), userID, sessionID, request.CurrentPassword, request.NewPassword)
+	if err != nil {
+		var passwordErr *passwordhash.PasswordError
+
+		switch {
+		case errors.As(err, &passwordErr):
+			respondInvalidPasswordFormat(c, passwordErr)
+		case errors.Is(err, users.ErrWrongPassword):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": "WRONG_PASSWORD",
+				"message": "current password is wrong",
+			})
+		case errors.Is(err, users.ErrReauthenticationRequired):
+			respondReauthenticationRequired(c)
+		case errors.Is(err, users.ErrAuthUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"code": "AUTH_USER_NOT_FOUND",
+				"message": "auth user not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "PASSWORD_CHANGED",
+		"revoked_session_ids": revokedSessionIDs,
+	})
+}
+
+//POST /password/forgot
+//Response doesn't depend on existence of the user
+func (ph *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var request publicDto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Login == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "login is required",
+		})
+		return
+	}
+
+	if err := ph.passwordService.RequestPasswordReset(c.Request.Context(), request.Login); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "INTERNAL_SERVER_ERROR",
+			"message": "internal server error, please try again later",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"code": "PASSWORD_RESET_REQUESTED",
+		"message": "if user with this login exists, reset token was sent",
+	})
+}
+
+//POST /password/reset
+func (ph *PasswordHandler) ResetPassword(c *gin.Context) {
+	var request publicDto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "token and new password are required",
+		})
+		return
+	}
+
+	err := ph.passwordService.ResetPassword(c.Request.Context(), request.Token, request.NewPassword)
+	if err != nil {
+		var resetErr *passwordreset.PasswordResetError
+		var passwordErr *passwordhash.PasswordError
+
+		switch {
+		case errors.As(err, &passwordErr):
+			respondInvalidPasswordFormat(c, passwordErr)
+		case errors.Is(err, passwordreset.ErrResetTokenNotFound),
+			errors.Is(err, passwordreset.ErrResetTokenExpired),
+			errors.Is(err, passwordreset.ErrResetTokenUsed):
+			errors.As(err, &resetErr)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": resetErr.Code,
+				"message": resetErr.Message,
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "PASSWORD_RESET",
+		"message": "password was changed, please log in again",
+	})
+}
+
+func respondInvalidPasswordFormat(c *gin.Context, passwordErr *passwordhash.PasswordError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code": "INVALID_PASSWORD_FORMAT",
+		"message": "password format is not valid",
+		"details": passwordErr.Message,
+	})
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	publicDto "symphony_chat/internal/application/dto"
+	"symphony_chat/internal/domain/sessions"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+//GET /sessions
+func (ah *AuthHandler) GetSessions(c *gin.Context) {
+	userID, sessionID, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	activeSessions, err := ah.authenticationService.GetActiveSessions(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "DATABASE_ERROR",
+			"message": "internal server error, please try again later",
+		})
+		return
+	}
+
+	sessionsDTO := make([]publicDto.SessionDTO, 0, len(activeSessions))
+	for _, session := range activeSessions {
+		sessionsDTO = append(sessionsDTO, publicDto.ToSessionDTO(session, sessionID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessionsDTO,
+	})
+}
+
+//DELETE /sessions/:session_id
+func (ah *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, _, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	revokingSessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "session id must be uuid",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err = ah.authenticationService.RevokeSession(c.Request.Context(), userID, revokingSessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sessions.ErrSessionNotFound), errors.Is(err, sessions.ErrSessionRevoked):
+			c.JSON(http.StatusNotFound, gin.H{
+				"code": sessions.ErrSessionNotFound.Code,
+				"message": "active session with this id not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "SESSION_REVOKED",
+		"session_id": revokingSessionID,
+	})
+}
+
+//POST /sessions/revoke-others
+func (ah *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, sessionID, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	revokedSessionIDs, err := ah.authenticationService.RevokeOtherSessions(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "INTERNAL_SERVER_ERROR",
+			"message": "internal server error, please try again later",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "OTHER_SESSIONS_REVOKED",
+		"revoked_session_ids": revokedSessionIDs,
+	})
+}
+
+//Function gets ids that were set by AuthMiddleware
+//If ids are missing, it writes error response and returns false
+func getUserAndSessionIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, userExists := c.Get("user_id")
+	sessionID, sessionExists := c.Get("session_id")
+	if !userExists || !sessionExists {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "USER_ID_WAS_NOT_PROVIDED",
+			"message": "user id or session id was not provided",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID.(uuid.UUID), sessionID.(uuid.UUID), true
+}
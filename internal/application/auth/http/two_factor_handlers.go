@@ -0,0 +1,186 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	publicDto "symphony_chat/internal/application/dto"
+	twofactor "symphony_chat/internal/domain/two_factor"
+	"symphony_chat/internal/domain/users"
+	twoFactorService "symphony_chat/internal/service/auth/two_factor"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *twoFactorService.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService *twoFactorService.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+//POST /2fa/enroll
+//Secret is returned only once, two factor stays disabled until first code is confirmed on /2fa/enable
+func (th *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, _, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := th.twoFactorService.Enroll(c.Request.Context(), userID)
+	if err != nil {
+		if !respondTwoFactorError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret": enrollment.Secret,
+		"provisioning_uri": enrollment.ProvisioningURI,
+	})
+}
+
+//POST /2fa/enable
+//Recovery codes are returned only once
+func (th *TwoFactorHandler) Enable(c *gin.Context) {
+	userID, _, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	var request publicDto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "code is required",
+		})
+		return
+	}
+
+	recoveryCodes, err := th.twoFactorService.Enable(c.Request.Context(), userID, request.Code)
+	if err != nil {
+		if !respondTwoFactorError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "TWO_FACTOR_ENABLED",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+//POST /2fa/recovery-codes
+//All previous recovery codes stop working
+func (th *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	var request publicDto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "code is required",
+		})
+		return
+	}
+
+	recoveryCodes, err := th.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), userID, request.Code)
+	if err != nil {
+		if !respondTwoFactorError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+//POST /2fa/disable
+//Password is not required for accounts without password (OIDC), they need recent sign in instead
+func (th *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, sessionID, ok := getUserAndSessionIDs(c)
+	if !ok {
+		return
+	}
+
+	var request publicDto.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "code is required",
+		})
+		return
+	}
+
+	err := th.twoFactorService.Disable(c.Request.Context(), userID, sessionID, request.Password, request.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrWrongPassword):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code": "WRONG_PASSWORD",
+				"message": "wrong password for this user",
+			})
+		case errors.Is(err, users.ErrReauthenticationRequired):
+			respondReauthenticationRequired(c)
+		case respondTwoFactorError(c, err):
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "TWO_FACTOR_DISABLED",
+		"message": "two factor authentication was disabled",
+	})
+}
+
+//Function writes response for known two factor errors and returns false for others
+func respondTwoFactorError(c *gin.Context, err error) bool {
+	var twoFactorErr *twofactor.TwoFactorError
+	if !errors.As(err, &twoFactorErr) {
+		return false
+	}
+
+	switch twoFactorErr.Code {
+	case twofactor.ErrTwoFactorNotEnrolled.Code, twofactor.ErrTwoFactorNotEnabled.Code, twofactor.ErrTwoFactorAlreadyEnabled.Code:
+		c.JSON(http.StatusConflict, gin.H{
+			"code": twoFactorErr.Code,
+			"message": twoFactorErr.Message,
+		})
+	case twofactor.ErrInvalidTwoFactorCode.Code,
+		twofactor.ErrChallengeNotFound.Code,
+		twofactor.ErrChallengeExpired.Code,
+		twofactor.ErrChallengeUsed.Code,
+		twofactor.ErrTooManyChallengeAttempts.Code:
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code": twoFactorErr.Code,
+			"message": twoFactorErr.Message,
+		})
+	default:
+		return false
+	}
+
+	return true
+}
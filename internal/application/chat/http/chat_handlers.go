@@ -0,0 +1,153 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	publicDto "symphony_chat/internal/application/dto"
+	chataudit "symphony_chat/internal/domain/chat_audit"
+	chatparticipant "symphony_chat/internal/domain/chat_participant"
+	"symphony_chat/internal/domain/roles"
+	service "symphony_chat/internal/service/chat"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ChatHandler struct {
+	chatService *service.ChatService
+}
+
+func NewChatHandler(chatService *service.ChatService) *ChatHandler {
+	return &ChatHandler{
+		chatService: chatService,
+	}
+}
+
+//GET /chats/:chat_id/audit-log
+//Query params: action (can be repeated), actor_id, target_id, from, to (RFC3339), limit, offset
+func (ch *ChatHandler) GetChatAuditLog(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "USER_ID_WAS_NOT_PROVIDED",
+			"message": "user id was not provided",
+		})
+		return
+	}
+
+	chatID, err := uuid.Parse(c.Param("chat_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "chat id must be uuid",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filter, err := parseChatAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "invalid audit log filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	entries, err := ch.chatService.GetChatAuditLog(c.Request.Context(), chatID, userID.(uuid.UUID), filter)
+	if err != nil {
+		var auditErr *chataudit.ChatAuditError
+
+		switch {
+		case errors.Is(err, chatparticipant.ErrChatParticipantNotFound), errors.Is(err, roles.ErrInsufficientPermissions):
+			c.JSON(http.StatusForbidden, gin.H{
+				"code": roles.ErrInsufficientPermissions.Code,
+				"message": "only owners and admins of the chat can see its audit log",
+			})
+
+		case errors.As(err, &auditErr) && auditErr.Code != "DATABASE_ERROR":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": auditErr.Code,
+				"message": auditErr.Message,
+			})
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code": "INTERNAL_SERVER_ERROR",
+				"message": "internal server error, please try again later",
+			})
+		}
+		return
+	}
+
+	entriesDTO := make([]publicDto.ChatAuditEntryDTO, 0, len(entries))
+	for _, entry := range entries {
+		entriesDTO = append(entriesDTO, publicDto.ToChatAuditEntryDTO(entry))
+	}
+
+	response := gin.H{
+		"entries": entriesDTO,
+	}
+
+	pageSize := filter.Limit
+	if pageSize == 0 {
+		pageSize = chataudit.DefaultAuditLogPageSize
+	}
+
+	//Full page means that there can be more entries
+	if len(entries) == pageSize {
+		response["next_offset"] = filter.Offset + len(entries)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func parseChatAuditFilter(c *gin.Context) (chataudit.ChatAuditFilter, error) {
+	var filter chataudit.ChatAuditFilter
+	var err error
+
+	for _, action := range c.QueryArray("action") {
+		filter.Actions = append(filter.Actions, chataudit.AuditAction(action))
+	}
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		if filter.ActorID, err = uuid.Parse(actorID); err != nil {
+			return chataudit.ChatAuditFilter{}, err
+		}
+	}
+
+	if targetID := c.Query("target_id"); targetID != "" {
+		if filter.TargetID, err = uuid.Parse(targetID); err != nil {
+			return chataudit.ChatAuditFilter{}, err
+		}
+	}
+
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return chataudit.ChatAuditFilter{}, err
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return chataudit.ChatAuditFilter{}, err
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return chataudit.ChatAuditFilter{}, err
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil {
+			return chataudit.ChatAuditFilter{}, err
+		}
+	}
+
+	return filter, nil
+}
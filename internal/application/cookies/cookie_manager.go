@@ -0,0 +1,113 @@
+package cookies
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	config "symphony_chat/internal/infrastructure/configs"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RefreshTokenCookie = "refresh_token"
+	//Csrf cookie is readable by javascript, client sends its value back in CSRFTokenHeader
+	CSRFTokenCookie = "csrf_token"
+	CSRFTokenHeader = "X-CSRF-Token"
+	//Oidc state cookie binds authorization flow to the browser that started it
+	OIDCStateCookie = "oidc_state"
+)
+
+//Size of random csrf token in bytes
+const csrfTokenSize = 32
+
+//Access token is sent only in Authorization header, cookie that older versions set is cleared on logout
+const legacyAccessTokenCookie = "access_token"
+
+//CookieManager sets auth cookies with configured attributes
+//Every time refresh token cookie is set, double-submit csrf token is set too,
+//requests that are authenticated by refresh token cookie must send it in X-CSRF-Token header
+type CookieManager struct {
+	config config.CookieConfig
+}
+
+func NewCookieManager(cookieConfig config.CookieConfig) *CookieManager {
+	return &CookieManager{
+		config: cookieConfig,
+	}
+}
+
+func (cm *CookieManager) SetRefreshToken(c *gin.Context, refreshToken string, maxAge int) {
+	cm.setCookie(c, RefreshTokenCookie, refreshToken, maxAge, true)
+	cm.setCSRFToken(c, maxAge)
+}
+
+func (cm *CookieManager) ClearRefreshToken(c *gin.Context) {
+	cm.setCookie(c, RefreshTokenCookie, "", -1, true)
+	cm.setCookie(c, CSRFTokenCookie, "", -1, false)
+	cm.setCookie(c, legacyAccessTokenCookie, "", -1, true)
+}
+
+//Cookie is sent on redirect from identity provider only with lax same site, so configured same site is not used
+func (cm *CookieManager) SetOIDCState(c *gin.Context, stateHash string, maxAge int) {
+	cm.setCookieWithSameSite(c, OIDCStateCookie, stateHash, maxAge, true, http.SameSiteLaxMode)
+}
+
+func (cm *CookieManager) ClearOIDCState(c *gin.Context) {
+	cm.setCookieWithSameSite(c, OIDCStateCookie, "", -1, true, http.SameSiteLaxMode)
+}
+
+//Method checks that csrf token in header matches csrf cookie
+func (cm *CookieManager) HasValidCSRFToken(c *gin.Context) bool {
+	cookieToken, err := c.Cookie(CSRFTokenCookie)
+	if err != nil || cookieToken == "" {
+		return false
+	}
+
+	headerToken := c.GetHeader(CSRFTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) == 1
+}
+
+//Safe methods don't change state, so they are not protected by csrf token
+func IsStateChangingMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+//Csrf token of the client is kept when refresh token is rotated, so parallel requests don't fail
+//New token is also returned in header for clients that can't read cookies of api domain
+func (cm *CookieManager) setCSRFToken(c *gin.Context, maxAge int) {
+	csrfToken, err := c.Cookie(CSRFTokenCookie)
+	if err != nil || csrfToken == "" {
+		randomBytes := make([]byte, csrfTokenSize)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return
+		}
+		csrfToken = base64.RawURLEncoding.EncodeToString(randomBytes)
+	}
+
+	cm.setCookie(c, CSRFTokenCookie, csrfToken, maxAge, false)
+	c.Header(CSRFTokenHeader, csrfToken)
+}
+
+func (cm *CookieManager) setCookie(c *gin.Context, name string, value string, maxAge int, httpOnly bool) {
+	cm.setCookieWithSameSite(c, name, value, maxAge, httpOnly, cm.config.SameSite)
+}
+
+func (cm *CookieManager) setCookieWithSameSite(c *gin.Context, name string, value string, maxAge int, httpOnly bool, sameSite http.SameSite) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     cm.config.Path,
+		Domain:   cm.config.Domain,
+		Secure:   cm.config.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	})
+}
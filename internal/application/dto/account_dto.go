@@ -0,0 +1,5 @@
+package publicdto
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
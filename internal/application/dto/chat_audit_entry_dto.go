@@ -0,0 +1,38 @@
+package publicdto
+
+import (
+	"time"
+
+	chataudit "symphony_chat/internal/domain/chat_audit"
+
+	"github.com/google/uuid"
+)
+
+type ChatAuditEntryDTO struct {
+	ID          uuid.UUID             `json:"id"`
+	ChatID      uuid.UUID             `json:"chat_id"`
+	ActorID     uuid.UUID             `json:"actor_id"`
+	TargetID    *uuid.UUID            `json:"target_id,omitempty"`
+	Action      chataudit.AuditAction `json:"action"`
+	BeforeValue string                `json:"before_value"`
+	AfterValue  string                `json:"after_value"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+func ToChatAuditEntryDTO(entry chataudit.ChatAuditEntry) ChatAuditEntryDTO {
+	dto := ChatAuditEntryDTO{
+		ID:          entry.GetID(),
+		ChatID:      entry.GetChatID(),
+		ActorID:     entry.GetActorID(),
+		Action:      entry.GetAction(),
+		BeforeValue: entry.GetBeforeValue(),
+		AfterValue:  entry.GetAfterValue(),
+		CreatedAt:   entry.GetCreatedAt(),
+	}
+
+	if targetID := entry.GetTargetID(); targetID != uuid.Nil {
+		dto.TargetID = &targetID
+	}
+
+	return dto
+}
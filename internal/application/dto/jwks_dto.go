@@ -0,0 +1,58 @@
+package publicdto
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"symphony_chat/internal/domain/jwt"
+)
+
+//Public key in JWK format (RFC 7517)
+type JWKDTO struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	//RSA key parameters
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	//Ed25519 key parameters
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSDTO struct {
+	Keys []JWKDTO `json:"keys"`
+}
+
+func ToJWKSDTO(keys []jwt.SigningKey) JWKSDTO {
+	jwks := JWKSDTO{
+		Keys: make([]JWKDTO, 0, len(keys)),
+	}
+
+	for _, key := range keys {
+		jwk := JWKDTO{
+			Kid: key.GetID(),
+			Use: "sig",
+			Alg: key.GetMethod().Alg(),
+		}
+
+		switch publicKey := key.GetPublicKey().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,53 @@
+package publicdto
+
+import (
+	"symphony_chat/internal/domain/users"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SignUpRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Username string `json:"username"`
+}
+
+//Fields that are nil are not changed
+type UpdateProfileRequest struct {
+	Username *string `json:"username"`
+}
+
+type ProfileDTO struct {
+	ID         uuid.UUID `json:"id"`
+	Username   string    `json:"username"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func ToProfileDTO(chatUser users.ChatUser) ProfileDTO {
+	return ProfileDTO{
+		ID:         chatUser.GetID(),
+		Username:   chatUser.GetUsername(),
+		Status:     string(chatUser.GetStatus()),
+		CreatedAt:  chatUser.GetCreatedAt(),
+		LastSeenAt: chatUser.GetLastSeenAt(),
+	}
+}
+
+type UserSummaryDTO struct {
+	ID         uuid.UUID `json:"id"`
+	Username   string    `json:"username"`
+	Online     bool      `json:"online"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func ToUserSummaryDTO(chatUser users.ChatUser, online bool) UserSummaryDTO {
+	return UserSummaryDTO{
+		ID:         chatUser.GetID(),
+		Username:   chatUser.GetUsername(),
+		Online:     online,
+		LastSeenAt: chatUser.GetLastSeenAt(),
+	}
+}
@@ -0,0 +1,28 @@
+package publicdto
+
+import (
+	"symphony_chat/internal/domain/sessions"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionDTO struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+func ToSessionDTO(session sessions.Session, currentSessionID uuid.UUID) SessionDTO {
+	return SessionDTO{
+		ID:         session.GetID(),
+		UserAgent:  session.GetUserAgent(),
+		IP:         session.GetIP(),
+		CreatedAt:  session.GetCreatedAt(),
+		LastUsedAt: session.GetLastUsedAt(),
+		Current:    session.GetID() == currentSessionID,
+	}
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+	healthdto "symphony_chat/internal/dto/health"
+	healthService "symphony_chat/internal/service/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthService *healthService.HealthService
+}
+
+func NewHealthHandler(healthService *healthService.HealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+//GET /healthz
+//Liveness probe, it fails only when process can't handle requests
+func (hh *HealthHandler) Liveness(c *gin.Context) {
+	respondHealthReport(c, hh.healthService.Liveness(c.Request.Context()))
+}
+
+//GET /readyz
+//Readiness probe, instance that is not ready must not get traffic
+func (hh *HealthHandler) Readiness(c *gin.Context) {
+	respondHealthReport(c, hh.healthService.Readiness(c.Request.Context()))
+}
+
+func respondHealthReport(c *gin.Context, report healthdto.HealthReport) {
+	//Probes must always see current state
+	c.Header("Cache-Control", "no-store")
+
+	if !report.IsUp() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
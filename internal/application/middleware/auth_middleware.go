@@ -2,14 +2,17 @@ package middleware
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
+	"symphony_chat/internal/infrastructure/logging"
 	jwtService "symphony_chat/internal/service/jwt"
 	jwt "symphony_chat/internal/domain/jwt"
 
 	"github.com/gin-gonic/gin"
 )
 
+//Expired access token is rejected with TOKEN_EXPIRED, client gets a new one from POST /refresh
 func AuthMiddleware(js *jwtService.JWTtokenService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokenString := ctx.GetHeader("Authorization")
@@ -30,75 +33,57 @@ func AuthMiddleware(js *jwtService.JWTtokenService) gin.HandlerFunc {
 			return 
 		}
 
-		authID, err := js.ValidateToken(parts[1])
+		claims, err := js.ParseAccessTokenClaims(parts[1])
 		if err == nil {
-			ctx.Set("user_id", authID)
+			setClaims(ctx, claims)
 			ctx.Next()
 			return
 		}
 
-		if !errors.Is(err, jwt.ErrTokenExpired) {
-			statusCode, response := mapValidateError(err)
-			ctx.AbortWithStatusJSON(statusCode, response)
-			return 
-		}
-
-		refreshToken, err := ctx.Cookie("refresh_token")
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code": ErrRefreshTokenWasNotSetInCookie.Code,
-				"message": ErrRefreshTokenWasNotSetInCookie.Message,
-			})
-			return 
-		}
-
-		authID, err = js.ValidateToken(refreshToken)
-		if err != nil {
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"code": ErrRefreshTokenInCookieWasExpired.Code,
-					"message": ErrRefreshTokenInCookieWasExpired.Message,
-				})
-                return
-			} else {
-				httpStatus, response := mapValidateError(err)
-				ctx.AbortWithStatusJSON(httpStatus, response)
-				return
-			}
-		}
-
-		newAccessToken, err := js.GetUpdatedAccessToken(authID)
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-                "code": ErrCreatingAccessToken.Code,
-				"message": ErrCreatingAccessToken.Message,
-            })
-			return 
-		}
+		//Expired token is not replaced here, client must call POST /refresh with refresh token cookie
+		statusCode, response := mapValidateError(err)
+		ctx.AbortWithStatusJSON(statusCode, response)
+	}
+}
 
-		ctx.Header("New-Access-Token", newAccessToken.GetToken())
+//User id is also added to request context, so logs of services and repositories carry it
+func setClaims(ctx *gin.Context, claims jwt.TokenClaims) {
+	ctx.Set("user_id", claims.UserID)
+	ctx.Set("session_id", claims.SessionID)
+	ctx.Set("token_claims", claims)
 
-		ctx.Set("user_id", authID)
-		ctx.Next()
-	}
+	requestCtx := logging.WithAttrs(ctx.Request.Context(), slog.String(logging.UserIDKey, claims.UserID.String()))
+	ctx.Request = ctx.Request.WithContext(requestCtx)
 }
 
+//Errors that are not token errors (database error of denylist lookup) are internal errors
 func mapValidateError(err error) (int, gin.H) {
 	var jwtError *jwt.TokenError
-	errors.As(err, &jwtError)
+	if !errors.As(err, &jwtError) {
+		return http.StatusInternalServerError, gin.H{
+			"code": "INTERNAL_SERVER_ERROR",
+			"message": "internal server error",
+		}
+	}
+
+	if jwt.IsTokenFormatErrorCode(jwtError.Code) {
+		return http.StatusUnauthorized, gin.H{
+			"code": "INVALID_TOKEN_FORMAT",
+			"message": jwtError.Message,
+			"details": errorDetails(jwtError),
+		}
+	}
 
 	switch  jwtError.Code{
-		case "INVALID_TOKEN_SIGNING_METHOD",
-		    "INVALID_TOKEN_FORMAT",
-			"INVALID_TOKEN_SIGNATURE",
-			"PARSE_TOKEN_ERROR",
-			"INVALID_TOKEN_CLAIMS_FORMAT",
-			"SUB_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
-			"SUB_CLAIM_CANT_BE_PARSED_TO_UUID":
+		case "TOKEN_REVOKED":
+			return http.StatusUnauthorized, gin.H{
+				"code": "TOKEN_REVOKED",
+				"message": jwtError.Message,
+			}
+		case "WRONG_TOKEN_TYPE":
 			return http.StatusUnauthorized, gin.H{
-				"code": "INVALID_TOKEN_FORMAT",
+				"code": "WRONG_TOKEN_TYPE",
 				"message": jwtError.Message,
-				"details": jwtError.Err.Error(),
 			}
 		case "TOKEN_EXPIRED":
 			return http.StatusUnauthorized, gin.H{
@@ -109,7 +94,15 @@ func mapValidateError(err error) (int, gin.H) {
 			return http.StatusInternalServerError, gin.H{
 				"code": "INTERNAL_SERVER_ERROR",
 				"message": "internal server error",
-				"details": jwtError.Err.Error(),
+				"details": errorDetails(jwtError),
 			}
 	}
+}
+
+//Wrapped error of token error can be nil
+func errorDetails(jwtError *jwt.TokenError) string {
+	if jwtError.Err == nil {
+		return jwtError.Message
+	}
+	return jwtError.Err.Error()
 }
\ No newline at end of file
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"symphony_chat/internal/application/cookies"
+
+	"github.com/gin-gonic/gin"
+)
+
+//Middleware protects endpoints that are authenticated only by refresh token cookie (double-submit csrf token)
+func CSRFMiddleware(cm *cookies.CookieManager) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if cookies.IsStateChangingMethod(ctx.Request.Method) && !cm.HasValidCSRFToken(ctx) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code": ErrInvalidCSRFToken.Code,
+				"message": ErrInvalidCSRFToken.Message,
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
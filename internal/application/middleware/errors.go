@@ -25,18 +25,8 @@ var (
 		Message: "invalid access token format",
 	}
 
-	ErrRefreshTokenWasNotSetInCookie = &AuthMiddlewareErr {
-		Code: "REFRESH_TOKEN_WAS_NOT_SET_IN_COOKIE",
-		Message: "refresh token was not set in cookie",
-	}
-
-	ErrRefreshTokenInCookieWasExpired = &AuthMiddlewareErr {
-		Code: "REFRESH_TOKEN_IN_COOKIE_WAS_EXPIRED",
-		Message: "refresh token in cookie was expired",
-	}
-
-	ErrCreatingAccessToken = &AuthMiddlewareErr {
-		Code: "ACCESS_TOKEN_CREATION_FAILED",
-		Message: "access token cant be created",
+	ErrInvalidCSRFToken = &AuthMiddlewareErr {
+		Code: "INVALID_CSRF_TOKEN",
+		Message: "csrf token in header doesn't match csrf cookie",
 	}
 )
\ No newline at end of file
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"symphony_chat/internal/infrastructure/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+//Request id of proxy is kept only if it is short and safe to write to logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+//Middleware gives every request an id that is returned in X-Request-ID header and is added to request context,
+//when request is finished it is logged with route, status and duration (server errors with errors of the handler)
+func LoggingMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(RequestIDHeader, requestID)
+
+		requestCtx := logging.WithAttrs(ctx.Request.Context(), slog.String(logging.RequestIDKey, requestID))
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", ctx.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		//Request context has user id if AuthMiddleware was passed
+		slog.LogAttrs(ctx.Request.Context(), level, "http request", attrs...)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"symphony_chat/internal/infrastructure/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+//Requests are labeled by route pattern, requests that didn't match any route share one label
+func MetricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.ObserveHTTPRequest(methodLabel(ctx.Request.Method), route, ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+//Client can send any method, so only standard methods get their own label
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
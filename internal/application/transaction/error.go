@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrorCancelledContext = errors.New("cancelled context was provided")
 	ErrorCommitTx = errors.New("error occurs while committing transaction")
+	ErrorTransactionManagerClosed = errors.New("transaction manager is closed, server is shutting down")
 )
\ No newline at end of file
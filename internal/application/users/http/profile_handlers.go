@@ -0,0 +1,213 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	publicDto "symphony_chat/internal/application/dto"
+	"symphony_chat/internal/domain/users"
+	profileService "symphony_chat/internal/service/profile"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ProfileHandler struct {
+	profileService *profileService.ProfileService
+}
+
+func NewProfileHandler(profileService *profileService.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+//GET /me
+func (ph *ProfileHandler) GetMyProfile(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		return
+	}
+
+	chatUser, err := ph.profileService.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, publicDto.ToProfileDTO(chatUser))
+}
+
+//PATCH /me
+//Only fields that are present in body are changed
+func (ph *ProfileHandler) UpdateMyProfile(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		return
+	}
+
+	var request publicDto.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Username == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": "INVALID_INPUT",
+			"message": "nothing to update, username is the only editable field",
+		})
+		return
+	}
+
+	chatUser, err := ph.profileService.ChangeUsername(c.Request.Context(), userID, *request.Username)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, publicDto.ToProfileDTO(chatUser))
+}
+
+//GET /users/:username
+func (ph *ProfileHandler) GetProfileByUsername(c *gin.Context) {
+	chatUser, err := ph.profileService.GetProfileByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, publicDto.ToProfileDTO(chatUser))
+}
+
+//GET /users
+//Query params: q (at least 2 characters), limit, offset
+func (ph *ProfileHandler) SearchUsers(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		return
+	}
+
+	query := users.UserSearchQuery{
+		Query:      c.Query("q"),
+		SearcherID: userID,
+	}
+
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			respondProfileError(c, users.ErrWrongSearchPagination)
+			return
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil {
+			respondProfileError(c, users.ErrWrongSearchPagination)
+			return
+		}
+	}
+
+	summaries, err := ph.profileService.SearchUsers(c.Request.Context(), query)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	usersDTO := make([]publicDto.UserSummaryDTO, 0, len(summaries))
+	for _, summary := range summaries {
+		usersDTO = append(usersDTO, publicDto.ToUserSummaryDTO(summary.User, summary.Online))
+	}
+
+	response := gin.H{
+		"users": usersDTO,
+	}
+
+	pageSize := query.Limit
+	if pageSize == 0 {
+		pageSize = users.DefaultUserSearchPageSize
+	}
+
+	//Full page means that there can be more users
+	if len(summaries) == pageSize {
+		response["next_offset"] = query.Offset + len(summaries)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+//POST /users/:username/block
+//Blocked user doesn't see blocker in user search
+func (ph *ProfileHandler) BlockUser(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := ph.profileService.BlockUser(c.Request.Context(), userID, c.Param("username")); err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "USER_BLOCKED",
+		"message": "user was blocked",
+	})
+}
+
+//DELETE /users/:username/block
+func (ph *ProfileHandler) UnblockUser(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := ph.profileService.UnblockUser(c.Request.Context(), userID, c.Param("username")); err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": "USER_UNBLOCKED",
+		"message": "user was unblocked",
+	})
+}
+
+func getUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "USER_ID_WAS_NOT_PROVIDED",
+			"message": "user id was not provided",
+		})
+		return uuid.Nil, false
+	}
+
+	return userID.(uuid.UUID), true
+}
+
+func respondProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, users.ErrChatUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code": users.ErrChatUserNotFound.Code,
+			"message": "user not found",
+		})
+	case errors.Is(err, users.ErrInvalidUsername),
+		errors.Is(err, users.ErrUsernameReserved),
+		errors.Is(err, users.ErrWrongSearchQuery),
+		errors.Is(err, users.ErrWrongSearchPagination),
+		errors.Is(err, users.ErrCantBlockYourself):
+		var authErr *users.AuthError
+		errors.As(err, &authErr)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": authErr.Code,
+			"message": authErr.Message,
+		})
+	case errors.Is(err, users.ErrUsernameAlreadyTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"code": users.ErrUsernameAlreadyTaken.Code,
+			"message": "user with this username already exists",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": "INTERNAL_SERVER_ERROR",
+			"message": "internal server error, please try again later",
+		})
+	}
+}
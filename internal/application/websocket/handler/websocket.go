@@ -1,70 +1,98 @@
 package websocket
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
+	"symphony_chat/internal/domain/jwt"
+	config "symphony_chat/internal/infrastructure/configs"
+	"symphony_chat/internal/infrastructure/logging"
+	"symphony_chat/internal/infrastructure/metrics"
 	"symphony_chat/internal/infrastructure/websocket/chathub"
 	"symphony_chat/internal/infrastructure/websocket/client"
 	service "symphony_chat/internal/service/chat"
+	"symphony_chat/utils/shutdown"
 )
 
+//Reason of close frame that clients get during graceful shutdown
+const shutdownCloseReason = "server is shutting down"
+
 type WebsocketHandler struct {
 	hub *chathub.Hub
 	upgrader websocket.Upgrader
+	limits config.WebSocketConfig
+	metrics *metrics.Metrics
+	//Upgrades that are in progress, new upgrades are rejected during graceful shutdown
+	upgrades shutdown.Gate
 }
 
-func NewWebsocketHandler(chatService *service.ChatService) *WebsocketHandler {
+//Metrics can be nil
+func NewWebsocketHandler(chatService *service.ChatService, limits config.WebSocketConfig, m *metrics.Metrics) *WebsocketHandler {
 	return &WebsocketHandler {
-		hub: chathub.NewHub(chatService),
+		hub: chathub.NewHub(chatService, limits, m),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
-			ReadBufferSize: 1024,
-			WriteBufferSize: 1024,
+			ReadBufferSize: limits.ReadBufferSize,
+			WriteBufferSize: limits.WriteBufferSize,
 		},
+		limits: limits,
+		metrics: m,
 	}
 }
 
 
 func (wh *WebsocketHandler) HandleWebSocket(c *gin.Context) {
-	conn, err := wh.upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"code": "INTERNAL_SERVER_ERROR",
-			"message": "internal server error, cannot upgrade http connection to websocket",
-			"details": err.Error(),
+	if !wh.upgrades.Enter() {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"code": "SERVER_SHUTTING_DOWN",
+			"message": "server is shutting down, please reconnect later",
 		})
 		return
 	}
+	defer wh.upgrades.Leave()
 
-	userIdValue, exists := c.Get("user_id")
+	//Access token (and its presence in denylist) is checked by AuthMiddleware during handshake
+	claimsValue, exists := c.Get("token_claims")
 	if !exists {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 			"code": "INTERNAL_SERVER_ERROR",
-			"message": "problem with getting user id from context",
-			"details": "user id was not provided",
+			"message": "problem with getting token claims from context",
+			"details": "token claims were not provided",
 		})
-		conn.Close()
 		return
 	}
 
-	userID, ok := userIdValue.(uuid.UUID)
+	claims, ok := claimsValue.(jwt.TokenClaims)
 	if !ok {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 			"code": "INTERNAL_SERVER_ERROR",
-			"message": "problem with parsing user id",
-			"details": "user id was not parsed to uuid",
+			"message": "problem with parsing token claims",
+			"details": "token claims have wrong type",
 		})
-		conn.Close()
 		return
 	}
 
-	client := client.NewClient(conn, userID, wh.hub)
+	conn, err := wh.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		//Upgrader already wrote error response
+		return
+	}
+
+	//Connection outlives handshake request, so only values of its context are kept (request and user ids)
+	connCtx := logging.WithAttrs(
+		context.WithoutCancel(c.Request.Context()),
+		slog.String(logging.ConnectionIDKey, uuid.NewString()),
+	)
+	slog.InfoContext(connCtx, "websocket connection opened")
+
+	client := client.NewClient(connCtx, conn, claims.UserID, claims.TokenID, claims.SessionID, wh.hub, wh.limits, wh.metrics)
 
 	wh.hub.AddActiveClient(client)
 
@@ -72,3 +100,42 @@ func (wh *WebsocketHandler) HandleWebSocket(c *gin.Context) {
 	go client.WritePump()
 }
 
+//This method tells if user has active websocket connection
+func (wh *WebsocketHandler) IsUserOnline(userID uuid.UUID) bool {
+	return wh.hub.IsUserOnline(userID)
+}
+
+//This method closes websocket connections of the user
+func (wh *WebsocketHandler) DisconnectClientsOfUser(userID uuid.UUID) {
+	wh.hub.DisconnectClientsOfUser(userID)
+}
+
+//This method closes websocket connections that were opened with revoked access token or in revoked session
+func (wh *WebsocketHandler) DisconnectClientsByRevokedID(revokedID uuid.UUID) {
+	wh.hub.DisconnectClientsByRevokedID(revokedID)
+}
+
+func (wh *WebsocketHandler) ActiveClientsCount() int {
+	return wh.hub.ActiveClientsCount()
+}
+
+func (wh *WebsocketHandler) ActiveChatsCount() int {
+	return wh.hub.ActiveChatsCount()
+}
+
+//This method tells if new websocket connections are accepted (false during graceful shutdown)
+func (wh *WebsocketHandler) IsAcceptingConnections() bool {
+	return !wh.upgrades.IsClosed()
+}
+
+//This method is used during graceful shutdown: new upgrades are rejected,
+//then hub drains messages and closes connections of all clients with "going away" close frame
+func (wh *WebsocketHandler) Shutdown(ctx context.Context) error {
+	//Connections are closed even if some upgrade was not finished in time
+	upgradesErr := wh.upgrades.Close(ctx)
+
+	if err := wh.hub.Shutdown(ctx, shutdownCloseReason); err != nil {
+		return err
+	}
+	return upgradesErr
+}
@@ -12,6 +12,8 @@ type Chat struct {
 	name		string
 	createdAt   time.Time
 	updatedAt   time.Time
+	//Minimum interval between messages of the same non-admin participant (0 means slow mode is off)
+	slowModeInterval time.Duration
 }
 
 
@@ -31,6 +33,14 @@ func (c Chat) GetUpdatedAt() time.Time {
 	return c.updatedAt
 }
 
+func (c Chat) GetSlowModeInterval() time.Duration {
+	return c.slowModeInterval
+}
+
+func (c Chat) IsSlowModeEnabled() bool {
+	return c.slowModeInterval > 0
+}
+
 func NewChat(name string) (Chat, error) {
 	if len(name) == 0 {
 		return Chat{}, ErrWrongChatName
@@ -48,20 +58,34 @@ func NewChat(name string) (Chat, error) {
 	}, nil
 }
 
-func ChatFromDB(id uuid.UUID, name string, createdAt time.Time, updatedAt time.Time) Chat {
+func ChatFromDB(id uuid.UUID, name string, createdAt time.Time, updatedAt time.Time, slowModeInterval time.Duration) Chat {
 	return Chat {
 		id: id, 
 		name: name,
 		createdAt: createdAt,
 		updatedAt: updatedAt,
+		slowModeInterval: slowModeInterval,
 	}
 }
 
+//Function that validates slow mode interval before saving it
+func ValidateSlowModeInterval(interval time.Duration) error {
+	if interval < 0 || interval > MaxSlowModeInterval {
+		return ErrWrongSlowModeInterval
+	}
+
+	return nil
+}
+
+//Slow mode interval can't be longer than that
+const MaxSlowModeInterval = time.Hour
+
 type ChatRepository interface {
 	GetChatByID(context.Context, uuid.UUID) (Chat, error)
 	GetChatsByIDs(context.Context, []uuid.UUID) ([]Chat, error)
 	AddChat(context.Context, Chat) error
 	UpdateChatName(context.Context, uuid.UUID, string) error
 	UpdateChatUpdatedAt(ctx context.Context, chatID uuid.UUID, updatedAt time.Time) error
+	UpdateChatSlowModeInterval(ctx context.Context, chatID uuid.UUID, interval time.Duration) error
 	DeleteChat(context.Context, uuid.UUID) error
 }
\ No newline at end of file
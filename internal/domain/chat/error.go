@@ -25,5 +25,10 @@ var (
 		Message: "chat not found",
 	}
 
+	ErrWrongSlowModeInterval = &ChatError {
+		Code: "WRONG_SLOW_MODE_INTERVAL",
+		Message: "slow mode interval must be between 0 seconds and 1 hour",
+	}
+
 	
 )
\ No newline at end of file
@@ -10,6 +10,7 @@ const (
 	CreateChatAction ChatActionType = "CREATE_CHAT"
 	RenameChatAction ChatActionType = "RENAME_CHAT"
 	DeleteChatAction ChatActionType = "DELETE_CHAT"
+	SetChatSlowModeAction ChatActionType = "SET_CHAT_SLOW_MODE"
 
 	//Members actions
 	AddMemberToChatAction ChatActionType = "ADD_MEMBER_TO_CHAT"
@@ -37,6 +38,7 @@ const (
 	UserEnteredChatEvent EventType = "USER_ENTERED_CHAT"
 	UserLeftChatEvent EventType = "USER_LEFT_CHAT"
 	ChatNameUpdatedEvent EventType = "CHAT_NAME_UPDATED"
+	ChatSlowModeUpdatedEvent EventType = "CHAT_SLOW_MODE_UPDATED"
 	UserSentMessageEvent EventType = "USER_SENT_MESSAGE"
 	UserEditedMessageEvent EventType = "USER_EDITED_MESSAGE"
 	UserDeletedMessageEvent EventType = "USER_DELETED_MESSAGE"
@@ -0,0 +1,152 @@
+package chataudit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Audit log is deleted together with its chat, so deletion of the chat itself is not audited
+type AuditAction string
+
+const (
+	ChatRenamedAction AuditAction = "CHAT_RENAMED"
+	ChatSlowModeChangedAction AuditAction = "CHAT_SLOW_MODE_CHANGED"
+	MemberAddedAction AuditAction = "MEMBER_ADDED"
+	MemberKickedAction AuditAction = "MEMBER_KICKED"
+	MemberPromotedToAdminAction AuditAction = "MEMBER_PROMOTED_TO_ADMIN"
+	AdminDemotedToMemberAction AuditAction = "ADMIN_DEMOTED_TO_MEMBER"
+	ChatOwnershipTransferredAction AuditAction = "CHAT_OWNERSHIP_TRANSFERRED"
+)
+
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case ChatRenamedAction,
+		ChatSlowModeChangedAction,
+		MemberAddedAction,
+		MemberKickedAction,
+		MemberPromotedToAdminAction,
+		AdminDemotedToMemberAction,
+		ChatOwnershipTransferredAction:
+		return true
+	default:
+		return false
+	}
+}
+
+//ChatAuditEntry is a record about administrative action in chat
+type ChatAuditEntry struct {
+	id          uuid.UUID
+	chatID      uuid.UUID
+	actorID     uuid.UUID
+	//targetID is uuid.Nil when action has no target user (e.g. chat rename)
+	targetID    uuid.UUID
+	action      AuditAction
+	beforeValue string
+	afterValue  string
+	createdAt   time.Time
+}
+
+func (e ChatAuditEntry) GetID() uuid.UUID {
+	return e.id
+}
+
+func (e ChatAuditEntry) GetChatID() uuid.UUID {
+	return e.chatID
+}
+
+func (e ChatAuditEntry) GetActorID() uuid.UUID {
+	return e.actorID
+}
+
+func (e ChatAuditEntry) GetTargetID() uuid.UUID {
+	return e.targetID
+}
+
+func (e ChatAuditEntry) GetAction() AuditAction {
+	return e.action
+}
+
+func (e ChatAuditEntry) GetBeforeValue() string {
+	return e.beforeValue
+}
+
+func (e ChatAuditEntry) GetAfterValue() string {
+	return e.afterValue
+}
+
+func (e ChatAuditEntry) GetCreatedAt() time.Time {
+	return e.createdAt
+}
+
+func NewChatAuditEntry(chatID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID, action AuditAction, beforeValue string, afterValue string) ChatAuditEntry {
+	return ChatAuditEntry{
+		id:          uuid.New(),
+		chatID:      chatID,
+		actorID:     actorID,
+		targetID:    targetID,
+		action:      action,
+		beforeValue: beforeValue,
+		afterValue:  afterValue,
+		createdAt:   time.Now(),
+	}
+}
+
+func ChatAuditEntryFromDB(id uuid.UUID, chatID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID, action AuditAction, beforeValue string, afterValue string, createdAt time.Time) ChatAuditEntry {
+	return ChatAuditEntry{
+		id:          id,
+		chatID:      chatID,
+		actorID:     actorID,
+		targetID:    targetID,
+		action:      action,
+		beforeValue: beforeValue,
+		afterValue:  afterValue,
+		createdAt:   createdAt,
+	}
+}
+
+const (
+	DefaultAuditLogPageSize = 50
+	MaxAuditLogPageSize = 200
+)
+
+//Filter for querying audit log of the chat
+//Zero values of fields mean that filter by this field is not applied
+type ChatAuditFilter struct {
+	Actions  []AuditAction
+	ActorID  uuid.UUID
+	TargetID uuid.UUID
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+//Method checks filter values and sets default page size
+func (f ChatAuditFilter) Normalize() (ChatAuditFilter, error) {
+	for _, action := range f.Actions {
+		if !action.IsValid() {
+			return ChatAuditFilter{}, ErrWrongAuditAction
+		}
+	}
+
+	if f.Limit < 0 || f.Limit > MaxAuditLogPageSize || f.Offset < 0 {
+		return ChatAuditFilter{}, ErrWrongAuditPagination
+	}
+
+	if f.Limit == 0 {
+		f.Limit = DefaultAuditLogPageSize
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return ChatAuditFilter{}, ErrWrongAuditTimeRange
+	}
+
+	return f, nil
+}
+
+type ChatAuditRepository interface {
+	AddChatAuditEntry(ctx context.Context, entry ChatAuditEntry) error
+	GetChatAuditEntries(ctx context.Context, chatID uuid.UUID, filter ChatAuditFilter) ([]ChatAuditEntry, error)
+}
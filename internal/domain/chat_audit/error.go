@@ -0,0 +1,31 @@
+package chataudit
+
+type ChatAuditError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *ChatAuditError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+var (
+	ErrWrongAuditAction = &ChatAuditError{
+		Code: "WRONG_AUDIT_ACTION",
+		Message: "unknown audit action in filter",
+	}
+
+	ErrWrongAuditPagination = &ChatAuditError{
+		Code: "WRONG_AUDIT_PAGINATION",
+		Message: "limit must be between 0 and 200 and offset can't be negative",
+	}
+
+	ErrWrongAuditTimeRange = &ChatAuditError{
+		Code: "WRONG_AUDIT_TIME_RANGE",
+		Message: "'to' must not be before 'from'",
+	}
+)
@@ -50,6 +50,8 @@ func ChatParticipantFromDB(chatID uuid.UUID, userID uuid.UUID, roleID uuid.UUID,
 
 type ChatParticipantRepository interface {
 	GetChatParticipantByIDs(ctx context.Context, chatID uuid.UUID, userID uuid.UUID) (ChatParticipant, error)
+	//Method locks found row until the end of the transaction
+	LockChatParticipant(ctx context.Context, chatID uuid.UUID, userID uuid.UUID) (ChatParticipant, error)
 	GetAllChatParticipantsByChatID(ctx context.Context, chatID uuid.UUID) ([]ChatParticipant, error)
 	GetAllChatsByUserID(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
 
@@ -27,8 +27,73 @@ var (
 		Message: "token is expired",
 	}
 
+	ErrRefreshTokenRevoked = &TokenError {
+		Code: "REFRESH_TOKEN_REVOKED",
+		Message: "refresh token was revoked",
+	}
+
+	ErrRefreshTokenReused = &TokenError {
+		Code: "REFRESH_TOKEN_REUSE_DETECTED",
+		Message: "refresh token was already used, all tokens of this session were revoked",
+	}
+
+	ErrTokenRevoked = &TokenError {
+		Code: "TOKEN_REVOKED",
+		Message: "token was revoked",
+	}
+
+	ErrWrongTokenType = &TokenError {
+		Code: "WRONG_TOKEN_TYPE",
+		Message: "token of this type can't be used here",
+	}
+
+	//Signing key errors
+	ErrSigningKeyIDIsEmpty = &TokenError {
+		Code: "SIGNING_KEY_ID_IS_EMPTY",
+		Message: "signing key id can't be empty",
+	}
+
+	ErrSigningKeyTooWeak = &TokenError {
+		Code: "SIGNING_KEY_TOO_WEAK",
+		Message: "rsa signing key must be at least 2048 bits",
+	}
+
+	ErrUnsupportedSigningKey = &TokenError {
+		Code: "UNSUPPORTED_SIGNING_KEY",
+		Message: "only rsa and ed25519 keys are supported",
+	}
+
+	ErrSigningKeyNotFound = &TokenError {
+		Code: "SIGNING_KEY_NOT_FOUND",
+		Message: "signing key with this id not found or has no private part",
+	}
+
 	ErrTokenNotValid = &TokenError {
 		Code: "TOKEN_NOT_VALID",
 		Message: "token is expired",
 	}
-)
\ No newline at end of file
+)
+
+//Function checks whether code belongs to error of token that can't be parsed or has invalid claims
+func IsTokenFormatErrorCode(code string) bool {
+	switch code {
+	case "INVALID_TOKEN_SIGNING_METHOD",
+		"INVALID_TOKEN_FORMAT",
+		"INVALID_TOKEN_SIGNATURE",
+		"PARSE_TOKEN_ERROR",
+		"INVALID_TOKEN_CLAIMS_FORMAT",
+		"SUB_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+		"SUB_CLAIM_CANT_BE_PARSED_TO_UUID",
+		"SID_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+		"SID_CLAIM_CANT_BE_PARSED_TO_UUID",
+		"TYP_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+		"JTI_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+		"JTI_CLAIM_CANT_BE_PARSED_TO_UUID",
+		"EXP_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+		"KID_HEADER_WAS_NOT_PROVIDED_IN_TOKEN",
+		"UNKNOWN_TOKEN_KEY_ID":
+		return true
+	default:
+		return false
+	}
+}
@@ -8,9 +8,31 @@ import (
 	"github.com/google/uuid"
 )
 
+//TokenType is put in typ claim, so refresh token can't be used as access token and vice versa
+type TokenType string
+
+const (
+	AccessTokenType  TokenType = "access"
+	RefreshTokenType TokenType = "refresh"
+)
+
 type JWTtoken struct {
 	auth_user_id uuid.UUID
 	token        string
+	tokenType    TokenType
+	//familyID is id of the session (device) token was issued for
+	//all rotated refresh tokens of one session share it
+	familyID     uuid.UUID
+}
+
+//Claims that are extracted from valid token
+type TokenClaims struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	Type      TokenType
+	//TokenID is jti claim of the token
+	TokenID   uuid.UUID
+	ExpiresAt time.Time
 }
 
 func (jt JWTtoken) GetAuthUserID() uuid.UUID {
@@ -21,17 +43,36 @@ func (jt JWTtoken) GetToken() string {
 	return jt.token
 }
 
+func (jt JWTtoken) GetFamilyID() uuid.UUID {
+	return jt.familyID
+}
+
+func (jt JWTtoken) GetTokenType() TokenType {
+	return jt.tokenType
+}
+
 // /Function for generating new JWT token
-func NewJWT(userID uuid.UUID, minutesTTL uint, daysTTL uint, secretKey []byte) (JWTtoken, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+// /Token is signed with given key, id of the key is put in kid header
+func NewJWT(userID uuid.UUID, sessionID uuid.UUID, tokenType TokenType, minutesTTL uint, daysTTL uint, signingKey SigningKey) (JWTtoken, error) {
+	if !signingKey.CanSign() {
+		return JWTtoken{}, ErrSigningKeyNotFound
+	}
+
+	token := jwt.NewWithClaims(signingKey.GetMethod(),
 		jwt.MapClaims{
 			"sub": userID,
+			//jti makes every token unique, even if two tokens were issued in the same second
+			"jti": uuid.New(),
+			"sid": sessionID,
+			"typ": tokenType,
 			"iat": time.Now().Unix(),
 			"exp": time.Now().Add(time.Duration(minutesTTL)*time.Minute + time.Duration(daysTTL)*time.Hour*24).Unix(),
 		},
 	)
 
-	signedToken, err := token.SignedString(secretKey)
+	token.Header["kid"] = signingKey.GetID()
+
+	signedToken, err := token.SignedString(signingKey.privateKey)
 	if err != nil {
 		return JWTtoken{}, err
 	}
@@ -39,20 +80,74 @@ func NewJWT(userID uuid.UUID, minutesTTL uint, daysTTL uint, secretKey []byte) (
 	return JWTtoken{
 		auth_user_id: userID,
 		token:        signedToken,
+		tokenType:    tokenType,
+		familyID:     sessionID,
 	}, nil
 }
 
 // /Function that converts JWTtoken from database format to domain format
-func FromDB(authUserID uuid.UUID, token string) JWTtoken {
+// /Only refresh tokens are stored in database
+func FromDB(authUserID uuid.UUID, token string, familyID uuid.UUID) JWTtoken {
 	return JWTtoken{
 		auth_user_id: authUserID,
 		token:        token,
+		tokenType:    RefreshTokenType,
+		familyID:     familyID,
 	}
 }
 
+//StoredRefreshToken is refresh token with its rotation state from storage
+type StoredRefreshToken struct {
+	token     JWTtoken
+	createdAt time.Time
+	rotatedAt time.Time
+	revokedAt time.Time
+}
+
+func (st StoredRefreshToken) GetToken() JWTtoken {
+	return st.token
+}
+
+func (st StoredRefreshToken) GetCreatedAt() time.Time {
+	return st.createdAt
+}
+
+//Rotated token was already exchanged for a new one, so it can't be used again
+func (st StoredRefreshToken) IsRotated() bool {
+	return !st.rotatedAt.IsZero()
+}
+
+func (st StoredRefreshToken) IsRevoked() bool {
+	return !st.revokedAt.IsZero()
+}
+
+func StoredRefreshTokenFromDB(token JWTtoken, createdAt time.Time, rotatedAt time.Time, revokedAt time.Time) StoredRefreshToken {
+	return StoredRefreshToken{
+		token:     token,
+		createdAt: createdAt,
+		rotatedAt: rotatedAt,
+		revokedAt: revokedAt,
+	}
+}
+
+//Repository of access tokens that were revoked before their expiration (jti denylist)
+type RevokedTokenRepository interface {
+	AddRevokedToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error
+	//Method returns revoked tokens that are not expired yet with their expiration time
+	GetRevokedTokens(ctx context.Context, now time.Time) (map[uuid.UUID]time.Time, error)
+	//Method deletes entries of tokens that would have already expired
+	DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) (int64, error)
+}
+
 type JwtRepository interface {
 	AddJWTtoken(ctx context.Context, token JWTtoken) error
 	GetJWTtoken(ctx context.Context, authUserID uuid.UUID) (JWTtoken, error)
-	UpdateJWTtoken(ctx context.Context, authUserID uuid.UUID, newToken string) error
+	//Method locks found token row until the end of the transaction
+	GetStoredRefreshToken(ctx context.Context, token string) (StoredRefreshToken, error)
+	MarkJWTtokenRotated(ctx context.Context, token string, rotatedAt time.Time) error
+	RevokeJWTtokenFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error
+	RevokeAllJWTtokensOfUser(ctx context.Context, authUserID uuid.UUID, revokedAt time.Time) error
 	DeleteJWTtoken(ctx context.Context, authUserID uuid.UUID) error
+	//Method deletes refresh tokens that were created before createdBefore, rotated and revoked ones included
+	DeleteExpiredJWTtokens(ctx context.Context, createdBefore time.Time) (int64, error)
 }
@@ -0,0 +1,146 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+//Minimal size of RSA key that is accepted for RS256
+const minRSAKeyBits = 2048
+
+//SigningKey is asymmetric key that is used for signing and verifying tokens
+//Key without private part can only verify tokens (retired key that is kept until its tokens expire)
+type SigningKey struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+}
+
+func (sk SigningKey) GetID() string {
+	return sk.id
+}
+
+func (sk SigningKey) GetMethod() jwt.SigningMethod {
+	return sk.method
+}
+
+func (sk SigningKey) GetPublicKey() crypto.PublicKey {
+	return sk.publicKey
+}
+
+func (sk SigningKey) CanSign() bool {
+	return sk.privateKey != nil
+}
+
+//Function creates key that can sign tokens, RS256 is used for RSA keys and EdDSA for Ed25519 keys
+func NewSigningKey(id string, privateKey crypto.PrivateKey) (SigningKey, error) {
+	if id == "" {
+		return SigningKey{}, ErrSigningKeyIDIsEmpty
+	}
+
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return SigningKey{}, ErrSigningKeyTooWeak
+		}
+		return SigningKey{id: id, method: jwt.SigningMethodRS256, privateKey: key, publicKey: &key.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return SigningKey{id: id, method: jwt.SigningMethodEdDSA, privateKey: key, publicKey: key.Public()}, nil
+	default:
+		return SigningKey{}, ErrUnsupportedSigningKey
+	}
+}
+
+//Function creates key that can only verify tokens
+func NewVerificationKey(id string, publicKey crypto.PublicKey) (SigningKey, error) {
+	if id == "" {
+		return SigningKey{}, ErrSigningKeyIDIsEmpty
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return SigningKey{}, ErrSigningKeyTooWeak
+		}
+		return SigningKey{id: id, method: jwt.SigningMethodRS256, publicKey: key}, nil
+	case ed25519.PublicKey:
+		return SigningKey{id: id, method: jwt.SigningMethodEdDSA, publicKey: key}, nil
+	default:
+		return SigningKey{}, ErrUnsupportedSigningKey
+	}
+}
+
+//Function generates new Ed25519 signing key, it is used when no keys were configured
+func GenerateSigningKey(id string) (SigningKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return SigningKey{}, &TokenError{
+			Code: "SIGNING_KEY_GENERATION_FAILED",
+			Message: "signing key cant be generated",
+			Err: err,
+		}
+	}
+
+	return NewSigningKey(id, privateKey)
+}
+
+//KeySet is one key that signs new tokens and all keys that are still accepted for verification
+type KeySet struct {
+	signingKey       SigningKey
+	verificationKeys map[string]SigningKey
+}
+
+func NewKeySet(signingKeyID string, keys ...SigningKey) (KeySet, error) {
+	ks := KeySet{
+		verificationKeys: make(map[string]SigningKey, len(keys)),
+	}
+
+	for _, key := range keys {
+		if _, exists := ks.verificationKeys[key.GetID()]; exists {
+			return KeySet{}, &TokenError{
+				Code: "DUPLICATE_SIGNING_KEY_ID",
+				Message: "several keys have the same id",
+				Err: errors.New("duplicate key id: " + key.GetID()),
+			}
+		}
+		ks.verificationKeys[key.GetID()] = key
+	}
+
+	signingKey, exists := ks.verificationKeys[signingKeyID]
+	if !exists || !signingKey.CanSign() {
+		return KeySet{}, ErrSigningKeyNotFound
+	}
+	ks.signingKey = signingKey
+
+	return ks, nil
+}
+
+func (ks KeySet) GetSigningKey() SigningKey {
+	return ks.signingKey
+}
+
+func (ks KeySet) GetVerificationKey(id string) (SigningKey, bool) {
+	key, exists := ks.verificationKeys[id]
+	return key, exists
+}
+
+//Method returns verification keys sorted by id
+func (ks KeySet) GetVerificationKeys() []SigningKey {
+	keys := make([]SigningKey, 0, len(ks.verificationKeys))
+	for _, key := range ks.verificationKeys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].GetID() < keys[j].GetID()
+	})
+
+	return keys
+}
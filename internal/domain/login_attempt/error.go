@@ -0,0 +1,38 @@
+package loginattempt
+
+import "time"
+
+type LoginAttemptError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *LoginAttemptError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+//Error that is returned when login or ip is locked after too many failed attempts
+//RetryAfter tells how long client has to wait before next attempt
+type AccountLockedError struct {
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return e.Message + ": retry after " + e.RetryAfter.String()
+}
+
+const AccountTemporarilyLockedCode = "ACCOUNT_TEMPORARILY_LOCKED"
+
+func NewAccountLockedError(retryAfter time.Duration) *AccountLockedError {
+	return &AccountLockedError{
+		Code: AccountTemporarilyLockedCode,
+		Message: "too many failed login attempts",
+		RetryAfter: retryAfter,
+	}
+}
@@ -0,0 +1,169 @@
+package loginattempt
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Failed attempts are tracked separately for every login and for every ip
+type KeyType string
+
+const (
+	LoginKey KeyType = "LOGIN"
+	IPKey    KeyType = "IP"
+)
+
+type AttemptKey struct {
+	Type  KeyType
+	Value string
+}
+
+//LockoutPolicy describes when key is locked and for how long
+//First lockout happens after MaxFailedAttempts failures and lasts BaseLockout,
+//every next failure doubles lockout duration until MaxLockout is reached
+type LockoutPolicy struct {
+	MaxFailedAttempts int
+	BaseLockout       time.Duration
+	MaxLockout        time.Duration
+	//Failures counter is reset when there were no failed attempts during this time
+	ResetAfter time.Duration
+}
+
+//Method returns for how long key is locked after failedCount failures, zero means no lockout
+func (lp LockoutPolicy) LockoutDuration(failedCount int) time.Duration {
+	if lp.MaxFailedAttempts <= 0 || failedCount < lp.MaxFailedAttempts {
+		return 0
+	}
+
+	lockout := lp.BaseLockout
+	for i := lp.MaxFailedAttempts; i < failedCount && lockout < lp.MaxLockout; i++ {
+		lockout *= 2
+	}
+
+	if lockout > lp.MaxLockout {
+		return lp.MaxLockout
+	}
+	return lockout
+}
+
+//LoginAttempts is a counter of failed attempts of one key
+type LoginAttempts struct {
+	key          AttemptKey
+	failedCount  int
+	lastFailedAt time.Time
+	lockedUntil  time.Time
+}
+
+func NewLoginAttempts(key AttemptKey) LoginAttempts {
+	return LoginAttempts{
+		key: key,
+	}
+}
+
+func LoginAttemptsFromDB(key AttemptKey, failedCount int, lastFailedAt time.Time, lockedUntil time.Time) LoginAttempts {
+	return LoginAttempts{
+		key:          key,
+		failedCount:  failedCount,
+		lastFailedAt: lastFailedAt,
+		lockedUntil:  lockedUntil,
+	}
+}
+
+func (la LoginAttempts) GetKey() AttemptKey {
+	return la.key
+}
+
+func (la LoginAttempts) GetFailedCount() int {
+	return la.failedCount
+}
+
+func (la LoginAttempts) GetLastFailedAt() time.Time {
+	return la.lastFailedAt
+}
+
+func (la LoginAttempts) GetLockedUntil() time.Time {
+	return la.lockedUntil
+}
+
+func (la LoginAttempts) IsLocked(now time.Time) bool {
+	return la.lockedUntil.After(now)
+}
+
+func (la LoginAttempts) RetryAfter(now time.Time) time.Duration {
+	if !la.IsLocked(now) {
+		return 0
+	}
+	return la.lockedUntil.Sub(now)
+}
+
+//Method counts one more failure and locks key if policy requires it
+//Lockout event is returned only when key was locked by this failure
+func (la *LoginAttempts) RegisterFailure(now time.Time, policy LockoutPolicy) (LockoutEvent, bool) {
+	if !la.lastFailedAt.IsZero() && !la.IsLocked(now) && now.Sub(la.lastFailedAt) > policy.ResetAfter {
+		la.failedCount = 0
+	}
+
+	la.failedCount++
+	la.lastFailedAt = now
+
+	lockout := policy.LockoutDuration(la.failedCount)
+	if lockout == 0 {
+		return LockoutEvent{}, false
+	}
+
+	la.lockedUntil = now.Add(lockout)
+	return NewLockoutEvent(la.key, la.failedCount, la.lockedUntil, now), true
+}
+
+//LockoutEvent is a record about every lockout, it is kept for security audit
+type LockoutEvent struct {
+	id          uuid.UUID
+	key         AttemptKey
+	failedCount int
+	lockedUntil time.Time
+	createdAt   time.Time
+}
+
+func NewLockoutEvent(key AttemptKey, failedCount int, lockedUntil time.Time, createdAt time.Time) LockoutEvent {
+	return LockoutEvent{
+		id:          uuid.New(),
+		key:         key,
+		failedCount: failedCount,
+		lockedUntil: lockedUntil,
+		createdAt:   createdAt,
+	}
+}
+
+func (le LockoutEvent) GetID() uuid.UUID {
+	return le.id
+}
+
+func (le LockoutEvent) GetKey() AttemptKey {
+	return le.key
+}
+
+func (le LockoutEvent) GetFailedCount() int {
+	return le.failedCount
+}
+
+func (le LockoutEvent) GetLockedUntil() time.Time {
+	return le.lockedUntil
+}
+
+func (le LockoutEvent) GetCreatedAt() time.Time {
+	return le.createdAt
+}
+
+type LoginAttemptRepository interface {
+	//Method returns empty counter if there were no failed attempts for this key
+	GetLoginAttempts(ctx context.Context, key AttemptKey) (LoginAttempts, error)
+	//Method creates counter for key if it doesn't exist and locks it until end of transaction
+	LockLoginAttempts(ctx context.Context, key AttemptKey) (LoginAttempts, error)
+	SaveLoginAttempts(ctx context.Context, attempts LoginAttempts) error
+	DeleteLoginAttempts(ctx context.Context, key AttemptKey) error
+	//Method deletes counters of key type whose last failure was before failedBefore and which are not locked at now
+	DeleteStaleLoginAttempts(ctx context.Context, keyType KeyType, failedBefore time.Time, now time.Time) (int64, error)
+	AddLockoutEvent(ctx context.Context, event LockoutEvent) error
+}
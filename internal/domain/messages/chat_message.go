@@ -76,12 +76,16 @@ type ChatMessageRepository interface {
 	GetChatMessagesByChatId(ctx context.Context, chatID uuid.UUID) ([]ChatMessage, error)
 	GetChatMessagesByContentAndChatID(ctx context.Context, content string, chatID uuid.UUID) ([]ChatMessage, error)
 	GetChatMessageSenderID(ctx context.Context, messageID uuid.UUID) (uuid.UUID, error)
+	GetLastChatMessageCreatedAtBySenderID(ctx context.Context, chatID uuid.UUID, senderID uuid.UUID) (time.Time, error)
 
 	AddChatMessage(ctx context.Context, message ChatMessage) error
 
 	UpdateChatMessageContent(ctx context.Context, messageID uuid.UUID, content string) error
 	UpdateChatMessageStatus(ctx context.Context, messageID uuid.UUID, status MessageStatus) error
 	
+	//Method makes another user sender of all messages of the sender (used when account is deleted)
+	ReassignChatMessagesOfSender(ctx context.Context, senderID uuid.UUID, newSenderID uuid.UUID) error
+
 	DeleteChatMessage(ctx context.Context, messageID uuid.UUID) error
 	DeleteAllChatMessagesByChatID(ctx context.Context, chatID uuid.UUID) error
 }
\ No newline at end of file
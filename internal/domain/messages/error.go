@@ -1,5 +1,7 @@
 package messages 
 
+import "time"
+
 type ChatMessageError struct {
 	Code  string
 	Message string
@@ -23,4 +25,37 @@ var (
 		Code: "EMPTY_CHAT_MESSAGE",
 		Message: "chat message cannot be empty",
 	}
-)
\ No newline at end of file
+)
+
+//Error that is returned when user sends messages too often
+//RetryAfter tells how long user has to wait before sending next message
+type ChatMessageRateLimitError struct {
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *ChatMessageRateLimitError) Error() string {
+	return e.Message + ": retry after " + e.RetryAfter.String()
+}
+
+const (
+	SlowModeActiveCode = "SLOW_MODE_ACTIVE"
+	MessageRateLimitExceededCode = "MESSAGE_RATE_LIMIT_EXCEEDED"
+)
+
+func NewSlowModeError(retryAfter time.Duration) *ChatMessageRateLimitError {
+	return &ChatMessageRateLimitError{
+		Code: SlowModeActiveCode,
+		Message: "slow mode is enabled in this chat",
+		RetryAfter: retryAfter,
+	}
+}
+
+func NewMessageRateLimitError(retryAfter time.Duration) *ChatMessageRateLimitError {
+	return &ChatMessageRateLimitError{
+		Code: MessageRateLimitExceededCode,
+		Message: "too many messages were sent",
+		RetryAfter: retryAfter,
+	}
+}
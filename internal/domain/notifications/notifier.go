@@ -0,0 +1,15 @@
+package notifications
+
+import "context"
+
+//Notification is message for the user, recipient is login of the user
+type Notification struct {
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+//Notifier delivers notifications to users (email, sms, log in development)
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
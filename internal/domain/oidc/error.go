@@ -0,0 +1,71 @@
+package oidc
+
+type OIDCError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *OIDCError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+var (
+	ErrProviderNotFound = &OIDCError{
+		Code: "OIDC_PROVIDER_NOT_FOUND",
+		Message: "oidc provider with this name is not configured",
+	}
+
+	ErrLoginStateNotFound = &OIDCError{
+		Code: "OIDC_STATE_NOT_FOUND",
+		Message: "oidc state not found",
+	}
+
+	ErrLoginStateExpired = &OIDCError{
+		Code: "OIDC_STATE_EXPIRED",
+		Message: "oidc state is expired",
+	}
+
+	ErrLoginStateUsed = &OIDCError{
+		Code: "OIDC_STATE_ALREADY_USED",
+		Message: "oidc state was already used",
+	}
+
+	ErrLoginStateMismatch = &OIDCError{
+		Code: "OIDC_STATE_MISMATCH",
+		Message: "oidc state was started in other browser",
+	}
+
+	ErrIdentityNotFound = &OIDCError{
+		Code: "OIDC_IDENTITY_NOT_FOUND",
+		Message: "oidc identity not found",
+	}
+
+	ErrIdentityLinkedToOtherUser = &OIDCError{
+		Code: "OIDC_IDENTITY_LINKED_TO_OTHER_USER",
+		Message: "this external account is already linked to other user",
+	}
+
+	ErrProviderAlreadyLinked = &OIDCError{
+		Code: "OIDC_PROVIDER_ALREADY_LINKED",
+		Message: "other external account of this provider is already linked to the user",
+	}
+
+	ErrAuthorizationDenied = &OIDCError{
+		Code: "OIDC_AUTHORIZATION_DENIED",
+		Message: "identity provider denied authorization",
+	}
+
+	ErrCodeExchangeFailed = &OIDCError{
+		Code: "OIDC_CODE_EXCHANGE_FAILED",
+		Message: "authorization code cant be exchanged for tokens",
+	}
+
+	ErrInvalidIDToken = &OIDCError{
+		Code: "OIDC_INVALID_ID_TOKEN",
+		Message: "id token of identity provider is not valid",
+	}
+)
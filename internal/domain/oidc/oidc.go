@@ -0,0 +1,239 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Size of random state, nonce and code verifier in bytes
+const randomValueSize = 32
+
+//Identity links account of external identity provider to auth user
+//Provider and subject together identify the external account
+type Identity struct {
+	id          uuid.UUID
+	authUserID  uuid.UUID
+	provider    string
+	subject     string
+	email       string
+	createdAt   time.Time
+	lastLoginAt time.Time
+}
+
+func (i Identity) GetID() uuid.UUID {
+	return i.id
+}
+
+func (i Identity) GetAuthUserID() uuid.UUID {
+	return i.authUserID
+}
+
+func (i Identity) GetProvider() string {
+	return i.provider
+}
+
+func (i Identity) GetSubject() string {
+	return i.subject
+}
+
+func (i Identity) GetEmail() string {
+	return i.email
+}
+
+func (i Identity) GetCreatedAt() time.Time {
+	return i.createdAt
+}
+
+func (i Identity) GetLastLoginAt() time.Time {
+	return i.lastLoginAt
+}
+
+func NewIdentity(authUserID uuid.UUID, provider string, subject string, email string) Identity {
+	now := time.Now()
+
+	return Identity{
+		id:          uuid.New(),
+		authUserID:  authUserID,
+		provider:    provider,
+		subject:     subject,
+		email:       email,
+		createdAt:   now,
+		lastLoginAt: now,
+	}
+}
+
+func IdentityFromDB(id uuid.UUID, authUserID uuid.UUID, provider string, subject string, email string, createdAt time.Time, lastLoginAt time.Time) Identity {
+	return Identity{
+		id:          id,
+		authUserID:  authUserID,
+		provider:    provider,
+		subject:     subject,
+		email:       email,
+		createdAt:   createdAt,
+		lastLoginAt: lastLoginAt,
+	}
+}
+
+//LoginState is created when user is redirected to identity provider and is used once on callback
+//It keeps PKCE code verifier and nonce on server side, only hash of state is stored
+//When linkAuthUserID is set, callback links external account to this user instead of logging in
+type LoginState struct {
+	stateHash      string
+	provider       string
+	codeVerifier   string
+	nonce          string
+	linkAuthUserID uuid.UUID
+	createdAt      time.Time
+	expiresAt      time.Time
+	usedAt         time.Time
+}
+
+func (ls LoginState) GetStateHash() string {
+	return ls.stateHash
+}
+
+func (ls LoginState) GetProvider() string {
+	return ls.provider
+}
+
+func (ls LoginState) GetCodeVerifier() string {
+	return ls.codeVerifier
+}
+
+func (ls LoginState) GetNonce() string {
+	return ls.nonce
+}
+
+func (ls LoginState) GetLinkAuthUserID() uuid.UUID {
+	return ls.linkAuthUserID
+}
+
+func (ls LoginState) IsLink() bool {
+	return ls.linkAuthUserID != uuid.Nil
+}
+
+func (ls LoginState) GetCreatedAt() time.Time {
+	return ls.createdAt
+}
+
+func (ls LoginState) GetExpiresAt() time.Time {
+	return ls.expiresAt
+}
+
+func (ls LoginState) GetUsedAt() time.Time {
+	return ls.usedAt
+}
+
+//Method checks that state can be used for callback
+func (ls LoginState) Validate(now time.Time) error {
+	if !ls.usedAt.IsZero() {
+		return ErrLoginStateUsed
+	}
+
+	if !now.Before(ls.expiresAt) {
+		return ErrLoginStateExpired
+	}
+
+	return nil
+}
+
+//Function creates state for provider, returned string is the state that is sent to identity provider
+func NewLoginState(provider string, linkAuthUserID uuid.UUID, ttl time.Duration) (LoginState, string, error) {
+	rawState, err := randomValue()
+	if err != nil {
+		return LoginState{}, "", err
+	}
+
+	codeVerifier, err := randomValue()
+	if err != nil {
+		return LoginState{}, "", err
+	}
+
+	nonce, err := randomValue()
+	if err != nil {
+		return LoginState{}, "", err
+	}
+
+	now := time.Now()
+
+	return LoginState{
+		stateHash:      HashState(rawState),
+		provider:       provider,
+		codeVerifier:   codeVerifier,
+		nonce:          nonce,
+		linkAuthUserID: linkAuthUserID,
+		createdAt:      now,
+		expiresAt:      now.Add(ttl),
+	}, rawState, nil
+}
+
+func LoginStateFromDB(stateHash string, provider string, codeVerifier string, nonce string, linkAuthUserID uuid.UUID, createdAt time.Time, expiresAt time.Time, usedAt time.Time) LoginState {
+	return LoginState{
+		stateHash:      stateHash,
+		provider:       provider,
+		codeVerifier:   codeVerifier,
+		nonce:          nonce,
+		linkAuthUserID: linkAuthUserID,
+		createdAt:      createdAt,
+		expiresAt:      expiresAt,
+		usedAt:         usedAt,
+	}
+}
+
+//Function returns hash under which state is stored
+func HashState(rawState string) string {
+	hash := sha256.Sum256([]byte(rawState))
+	return hex.EncodeToString(hash[:])
+}
+
+//Function returns PKCE code challenge for S256 method (RFC 7636)
+func CodeChallengeS256(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func randomValue() (string, error) {
+	randomBytes := make([]byte, randomValueSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", &OIDCError{
+			Code: "OIDC_STATE_GENERATION_FAILED",
+			Message: "oidc state cant be generated",
+			Err: err,
+		}
+	}
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+//Claims of verified id token that are used for login
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+//IdentityProvider is external OpenID Connect provider that supports authorization code flow with PKCE
+type IdentityProvider interface {
+	GetName() string
+	GetAuthorizationURL(state string, nonce string, codeChallenge string) string
+	//Method exchanges code for tokens and returns claims of id token, id token must have the same nonce
+	ExchangeCode(ctx context.Context, code string, codeVerifier string, nonce string) (Claims, error)
+}
+
+type OIDCRepository interface {
+	AddLoginState(ctx context.Context, state LoginState) error
+	//Method locks found state row until the end of the transaction
+	GetLoginStateByHash(ctx context.Context, stateHash string) (LoginState, error)
+	MarkLoginStateUsed(ctx context.Context, stateHash string, usedAt time.Time) error
+	GetIdentity(ctx context.Context, provider string, subject string) (Identity, error)
+	GetIdentityOfUser(ctx context.Context, provider string, authUserID uuid.UUID) (Identity, error)
+	AddIdentity(ctx context.Context, identity Identity) error
+	UpdateIdentityLastLogin(ctx context.Context, id uuid.UUID, email string, lastLoginAt time.Time) error
+}
@@ -0,0 +1,31 @@
+package passwordreset
+
+type PasswordResetError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *PasswordResetError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+var (
+	ErrResetTokenNotFound = &PasswordResetError{
+		Code: "RESET_TOKEN_NOT_FOUND",
+		Message: "reset token not found",
+	}
+
+	ErrResetTokenExpired = &PasswordResetError{
+		Code: "RESET_TOKEN_EXPIRED",
+		Message: "reset token is expired",
+	}
+
+	ErrResetTokenUsed = &PasswordResetError{
+		Code: "RESET_TOKEN_ALREADY_USED",
+		Message: "reset token was already used",
+	}
+)
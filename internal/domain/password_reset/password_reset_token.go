@@ -0,0 +1,103 @@
+package passwordreset
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Size of random part of reset token in bytes
+const resetTokenSize = 32
+
+//PasswordResetToken is single-use token that allows to set new password without knowing current one
+//Only hash of the token is stored, token itself is sent to the user
+type PasswordResetToken struct {
+	id         uuid.UUID
+	authUserID uuid.UUID
+	tokenHash  string
+	createdAt  time.Time
+	expiresAt  time.Time
+	usedAt     time.Time
+}
+
+func (pt PasswordResetToken) GetID() uuid.UUID {
+	return pt.id
+}
+
+func (pt PasswordResetToken) GetAuthUserID() uuid.UUID {
+	return pt.authUserID
+}
+
+func (pt PasswordResetToken) GetTokenHash() string {
+	return pt.tokenHash
+}
+
+func (pt PasswordResetToken) GetCreatedAt() time.Time {
+	return pt.createdAt
+}
+
+func (pt PasswordResetToken) GetExpiresAt() time.Time {
+	return pt.expiresAt
+}
+
+func (pt PasswordResetToken) IsUsed() bool {
+	return !pt.usedAt.IsZero()
+}
+
+func (pt PasswordResetToken) IsExpired(now time.Time) bool {
+	return !now.Before(pt.expiresAt)
+}
+
+//Function creates reset token for the user, returned string is the token that must be sent to the user
+func NewPasswordResetToken(authUserID uuid.UUID, ttl time.Duration) (PasswordResetToken, string, error) {
+	randomBytes := make([]byte, resetTokenSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return PasswordResetToken{}, "", &PasswordResetError{
+			Code: "RESET_TOKEN_GENERATION_FAILED",
+			Message: "reset token cant be generated",
+			Err: err,
+		}
+	}
+
+	rawToken := base64.RawURLEncoding.EncodeToString(randomBytes)
+	now := time.Now()
+
+	return PasswordResetToken{
+		id:         uuid.New(),
+		authUserID: authUserID,
+		tokenHash:  HashResetToken(rawToken),
+		createdAt:  now,
+		expiresAt:  now.Add(ttl),
+	}, rawToken, nil
+}
+
+func PasswordResetTokenFromDB(id uuid.UUID, authUserID uuid.UUID, tokenHash string, createdAt time.Time, expiresAt time.Time, usedAt time.Time) PasswordResetToken {
+	return PasswordResetToken{
+		id:         id,
+		authUserID: authUserID,
+		tokenHash:  tokenHash,
+		createdAt:  createdAt,
+		expiresAt:  expiresAt,
+		usedAt:     usedAt,
+	}
+}
+
+//Function returns hash under which token is stored
+func HashResetToken(rawToken string) string {
+	hash := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(hash[:])
+}
+
+type PasswordResetRepository interface {
+	AddPasswordResetToken(ctx context.Context, token PasswordResetToken) error
+	//Method locks found token row until the end of the transaction
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	MarkPasswordResetTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+	//Method makes all not used tokens of the user unusable (when new token is issued or password is changed)
+	InvalidatePasswordResetTokensOfUser(ctx context.Context, authUserID uuid.UUID, invalidatedAt time.Time) error
+}
@@ -21,6 +21,8 @@ var (
 			PermissionUpdateChatName,
 			PermissionRemoveMember,
 			PermissionManageRoles,
+			PermissionManageSlowMode,
+			PermissionViewAuditLog,
 			PermissionAddMember,
 			PermissionAddMessage,
 			PermissionDeleteMessage,
@@ -35,6 +37,8 @@ var (
 			PermissionAddMember,
 			PermissionRemoveMember,
 			PermissionUpdateChatName,
+			PermissionManageSlowMode,
+			PermissionViewAuditLog,
 			PermissionAddMessage,
 			PermissionDeleteMessage,
 			PermissionEditMessage,
@@ -54,12 +58,30 @@ var (
 	}
 )
 
+//Built-in roles are defined here and are synced into the database on startup
+func BuiltInChatRoles() []ChatRole {
+	return []ChatRole{OwnerChatRole, AdminChatRole, MemberChatRole}
+}
+
+//Function returns one of the built-in roles (owner, admin, member) by its id
+func GetBuiltInChatRoleByID(id uuid.UUID) (ChatRole, error) {
+	for _, role := range BuiltInChatRoles() {
+		if role.id == id {
+			return role, nil
+		}
+	}
+
+	return ChatRole{}, ErrChatRoleNotFound
+}
+
 type Permission string 
 
 const (
 	PermissionAddMember Permission = "ADD_MEMBER_TO_CHAT"
 	PermissionRemoveMember Permission = "REMOVE_MEMBER_FROM_CHAT"
 	PermissionManageRoles Permission = "MANAGE_ROLES_OF_CHAT"
+	PermissionManageSlowMode Permission = "MANAGE_SLOW_MODE_OF_CHAT"
+	PermissionViewAuditLog Permission = "VIEW_AUDIT_LOG_OF_CHAT"
 	PermissionDeleteChat Permission = "DELETE_CHAT"
 	PermissionUpdateChatName Permission = "UPDATE_CHAT_NAME"
 	PermissionAddMessage Permission = "ADD_MESSAGE_TO_CHAT"
@@ -91,5 +113,7 @@ type ChatRoleRepository interface {
 	GetChatRoleByID(ctx context.Context, id uuid.UUID) (ChatRole, error)
 	GetChatRoleByName(ctx context.Context, name string) (ChatRole, error)
 	GetChatRoles(ctx context.Context) ([]ChatRole, error)
+	//Role is created or its name and permissions are replaced
+	SaveChatRole(ctx context.Context, role ChatRole) error
 }
 
@@ -0,0 +1,26 @@
+package sessions
+
+type SessionError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *SessionError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+var (
+	ErrSessionNotFound = &SessionError{
+		Code: "SESSION_NOT_FOUND",
+		Message: "session not found",
+	}
+
+	ErrSessionRevoked = &SessionError{
+		Code: "SESSION_REVOKED",
+		Message: "session was revoked",
+	}
+)
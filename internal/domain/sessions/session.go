@@ -0,0 +1,92 @@
+package sessions
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Session is one logged in device of the user
+//Session id is also a family id of all refresh tokens issued for this device
+type Session struct {
+	id         uuid.UUID
+	authUserID uuid.UUID
+	userAgent  string
+	ip         string
+	createdAt  time.Time
+	lastUsedAt time.Time
+	revokedAt  time.Time
+}
+
+//Information about device that is taken from the http request
+type DeviceInfo struct {
+	UserAgent string
+	IP        string
+}
+
+func (s Session) GetID() uuid.UUID {
+	return s.id
+}
+
+func (s Session) GetAuthUserID() uuid.UUID {
+	return s.authUserID
+}
+
+func (s Session) GetUserAgent() string {
+	return s.userAgent
+}
+
+func (s Session) GetIP() string {
+	return s.ip
+}
+
+func (s Session) GetCreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s Session) GetLastUsedAt() time.Time {
+	return s.lastUsedAt
+}
+
+func (s Session) IsRevoked() bool {
+	return !s.revokedAt.IsZero()
+}
+
+func NewSession(authUserID uuid.UUID, device DeviceInfo) Session {
+	now := time.Now()
+
+	return Session{
+		id:         uuid.New(),
+		authUserID: authUserID,
+		userAgent:  device.UserAgent,
+		ip:         device.IP,
+		createdAt:  now,
+		lastUsedAt: now,
+	}
+}
+
+func SessionFromDB(id uuid.UUID, authUserID uuid.UUID, userAgent string, ip string, createdAt time.Time, lastUsedAt time.Time, revokedAt time.Time) Session {
+	return Session{
+		id:         id,
+		authUserID: authUserID,
+		userAgent:  userAgent,
+		ip:         ip,
+		createdAt:  createdAt,
+		lastUsedAt: lastUsedAt,
+		revokedAt:  revokedAt,
+	}
+}
+
+type SessionRepository interface {
+	AddSession(ctx context.Context, session Session) error
+	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (Session, error)
+	//Method returns only not revoked sessions
+	GetActiveSessionsOfUser(ctx context.Context, authUserID uuid.UUID) ([]Session, error)
+	UpdateSessionLastUsed(ctx context.Context, sessionID uuid.UUID, lastUsedAt time.Time, ip string) error
+	RevokeSession(ctx context.Context, sessionID uuid.UUID, revokedAt time.Time) error
+	//Method revokes all sessions of user except one and returns ids of revoked sessions
+	RevokeOtherSessionsOfUser(ctx context.Context, authUserID uuid.UUID, exceptSessionID uuid.UUID, revokedAt time.Time) ([]uuid.UUID, error)
+	//Method deletes all sessions of user and returns ids of deleted sessions
+	DeleteSessionsOfUser(ctx context.Context, authUserID uuid.UUID) ([]uuid.UUID, error)
+}
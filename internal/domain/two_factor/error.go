@@ -0,0 +1,56 @@
+package twofactor
+
+type TwoFactorError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *TwoFactorError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+var (
+	ErrTwoFactorNotEnrolled = &TwoFactorError{
+		Code: "TWO_FACTOR_NOT_ENROLLED",
+		Message: "two factor authentication was not enrolled",
+	}
+
+	ErrTwoFactorAlreadyEnabled = &TwoFactorError{
+		Code: "TWO_FACTOR_ALREADY_ENABLED",
+		Message: "two factor authentication is already enabled",
+	}
+
+	ErrTwoFactorNotEnabled = &TwoFactorError{
+		Code: "TWO_FACTOR_NOT_ENABLED",
+		Message: "two factor authentication is not enabled",
+	}
+
+	ErrInvalidTwoFactorCode = &TwoFactorError{
+		Code: "INVALID_TWO_FACTOR_CODE",
+		Message: "two factor code is invalid",
+	}
+
+	ErrChallengeNotFound = &TwoFactorError{
+		Code: "TWO_FACTOR_CHALLENGE_NOT_FOUND",
+		Message: "two factor challenge not found",
+	}
+
+	ErrChallengeExpired = &TwoFactorError{
+		Code: "TWO_FACTOR_CHALLENGE_EXPIRED",
+		Message: "two factor challenge is expired",
+	}
+
+	ErrChallengeUsed = &TwoFactorError{
+		Code: "TWO_FACTOR_CHALLENGE_ALREADY_USED",
+		Message: "two factor challenge was already used",
+	}
+
+	ErrTooManyChallengeAttempts = &TwoFactorError{
+		Code: "TOO_MANY_TWO_FACTOR_ATTEMPTS",
+		Message: "too many wrong codes were sent for this challenge",
+	}
+)
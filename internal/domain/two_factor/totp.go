@@ -0,0 +1,108 @@
+package twofactor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//Parameters of RFC 6238 codes, they are the defaults of all authenticator apps
+const (
+	totpPeriodSeconds = 30
+	totpDigits        = 6
+	//Codes of previous and next period are accepted too, because clocks of devices drift
+	totpSkew = 1
+	//Size of secret in bytes, RFC 4226 recommends 160 bits
+	totpSecretSize = 20
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+//Function generates base32 encoded secret that is shown to the user during enrollment
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", &TwoFactorError{
+			Code: "TOTP_SECRET_GENERATION_FAILED",
+			Message: "totp secret cant be generated",
+			Err: err,
+		}
+	}
+
+	return secretEncoding.EncodeToString(secret), nil
+}
+
+//Function returns number of time step that contains t
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriodSeconds
+}
+
+//Function calculates code of the time step (HOTP from RFC 4226 with step as counter)
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", &TwoFactorError{
+			Code: "INVALID_TOTP_SECRET",
+			Message: "totp secret is not valid base32",
+			Err: err,
+		}
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, truncated%modulo), nil
+}
+
+//Function checks code against steps around now and returns step of matched code
+func MatchTOTPCode(secret string, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	currentStep := TOTPStep(now)
+	for step := currentStep - totpSkew; step <= currentStep+totpSkew; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+//Function returns otpauth uri that authenticator apps read from qr code
+func TOTPProvisioningURI(issuer string, accountName string, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriodSeconds))
+
+	label := url.PathEscape(issuer + ":" + accountName)
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
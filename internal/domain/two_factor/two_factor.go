@@ -0,0 +1,278 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//TwoFactor is totp authenticator of the user
+//It is created on enrollment and becomes enabled only after user confirms first code
+type TwoFactor struct {
+	authUserID   uuid.UUID
+	secret       string
+	enabledAt    time.Time
+	lastUsedStep int64
+	createdAt    time.Time
+}
+
+func (tf TwoFactor) GetAuthUserID() uuid.UUID {
+	return tf.authUserID
+}
+
+func (tf TwoFactor) GetSecret() string {
+	return tf.secret
+}
+
+func (tf TwoFactor) GetEnabledAt() time.Time {
+	return tf.enabledAt
+}
+
+func (tf TwoFactor) GetLastUsedStep() int64 {
+	return tf.lastUsedStep
+}
+
+func (tf TwoFactor) GetCreatedAt() time.Time {
+	return tf.createdAt
+}
+
+func (tf TwoFactor) IsEnabled() bool {
+	return !tf.enabledAt.IsZero()
+}
+
+func (tf *TwoFactor) Enable(now time.Time) {
+	tf.enabledAt = now
+}
+
+//Method checks code and remembers its step, so the same code can't be used twice
+func (tf *TwoFactor) VerifyCode(code string, now time.Time) bool {
+	step, ok := MatchTOTPCode(tf.secret, code, now)
+	if !ok || step <= tf.lastUsedStep {
+		return false
+	}
+
+	tf.lastUsedStep = step
+	return true
+}
+
+func NewTwoFactor(authUserID uuid.UUID) (TwoFactor, error) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return TwoFactor{}, err
+	}
+
+	return TwoFactor{
+		authUserID: authUserID,
+		secret:     secret,
+		createdAt:  time.Now(),
+	}, nil
+}
+
+func TwoFactorFromDB(authUserID uuid.UUID, secret string, enabledAt time.Time, lastUsedStep int64, createdAt time.Time) TwoFactor {
+	return TwoFactor{
+		authUserID:   authUserID,
+		secret:       secret,
+		enabledAt:    enabledAt,
+		lastUsedStep: lastUsedStep,
+		createdAt:    createdAt,
+	}
+}
+
+//Number of recovery codes that are issued when two factor is enabled
+const RecoveryCodesCount = 10
+
+//Size of random part of recovery code in bytes (8 base32 characters)
+const recoveryCodeSize = 5
+
+//RecoveryCode is one-time code that replaces totp code when authenticator is lost
+//Only hash of the code is stored
+type RecoveryCode struct {
+	id         uuid.UUID
+	authUserID uuid.UUID
+	codeHash   string
+	createdAt  time.Time
+}
+
+func (rc RecoveryCode) GetID() uuid.UUID {
+	return rc.id
+}
+
+func (rc RecoveryCode) GetAuthUserID() uuid.UUID {
+	return rc.authUserID
+}
+
+func (rc RecoveryCode) GetCodeHash() string {
+	return rc.codeHash
+}
+
+func (rc RecoveryCode) GetCreatedAt() time.Time {
+	return rc.createdAt
+}
+
+//Function creates recovery codes for the user, returned strings are the codes that must be shown to the user
+func NewRecoveryCodes(authUserID uuid.UUID, count int) ([]RecoveryCode, []string, error) {
+	codes := make([]RecoveryCode, 0, count)
+	rawCodes := make([]string, 0, count)
+	now := time.Now()
+
+	for i := 0; i < count; i++ {
+		randomBytes := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, nil, &TwoFactorError{
+				Code: "RECOVERY_CODE_GENERATION_FAILED",
+				Message: "recovery code cant be generated",
+				Err: err,
+			}
+		}
+
+		encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes))
+		rawCode := encoded[:4] + "-" + encoded[4:]
+
+		codes = append(codes, RecoveryCode{
+			id:         uuid.New(),
+			authUserID: authUserID,
+			codeHash:   HashRecoveryCode(rawCode),
+			createdAt:  now,
+		})
+		rawCodes = append(rawCodes, rawCode)
+	}
+
+	return codes, rawCodes, nil
+}
+
+//Function returns hash under which recovery code is stored, case and dashes are ignored
+func HashRecoveryCode(rawCode string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(rawCode), "-", ""))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
+
+//How many wrong codes can be sent for one challenge
+const MaxChallengeAttempts = 5
+
+//Size of random part of challenge token in bytes
+const challengeTokenSize = 32
+
+//Challenge is issued after correct password when two factor is enabled
+//It is exchanged for auth tokens together with totp or recovery code
+type Challenge struct {
+	id             uuid.UUID
+	authUserID     uuid.UUID
+	tokenHash      string
+	createdAt      time.Time
+	expiresAt      time.Time
+	failedAttempts int
+	usedAt         time.Time
+}
+
+func (ch Challenge) GetID() uuid.UUID {
+	return ch.id
+}
+
+func (ch Challenge) GetAuthUserID() uuid.UUID {
+	return ch.authUserID
+}
+
+func (ch Challenge) GetTokenHash() string {
+	return ch.tokenHash
+}
+
+func (ch Challenge) GetCreatedAt() time.Time {
+	return ch.createdAt
+}
+
+func (ch Challenge) GetExpiresAt() time.Time {
+	return ch.expiresAt
+}
+
+func (ch Challenge) GetFailedAttempts() int {
+	return ch.failedAttempts
+}
+
+func (ch Challenge) IsUsed() bool {
+	return !ch.usedAt.IsZero()
+}
+
+func (ch Challenge) IsExpired(now time.Time) bool {
+	return !now.Before(ch.expiresAt)
+}
+
+//Method returns error if challenge can't be exchanged for tokens anymore
+func (ch Challenge) Validate(now time.Time) error {
+	switch {
+	case ch.IsUsed():
+		return ErrChallengeUsed
+	case ch.IsExpired(now):
+		return ErrChallengeExpired
+	case ch.failedAttempts >= MaxChallengeAttempts:
+		return ErrTooManyChallengeAttempts
+	}
+	return nil
+}
+
+//Function creates challenge for the user, returned string is the token that is sent to the client
+func NewChallenge(authUserID uuid.UUID, ttl time.Duration) (Challenge, string, error) {
+	randomBytes := make([]byte, challengeTokenSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return Challenge{}, "", &TwoFactorError{
+			Code: "CHALLENGE_GENERATION_FAILED",
+			Message: "two factor challenge cant be generated",
+			Err: err,
+		}
+	}
+
+	rawToken := base64.RawURLEncoding.EncodeToString(randomBytes)
+	now := time.Now()
+
+	return Challenge{
+		id:         uuid.New(),
+		authUserID: authUserID,
+		tokenHash:  HashChallengeToken(rawToken),
+		createdAt:  now,
+		expiresAt:  now.Add(ttl),
+	}, rawToken, nil
+}
+
+func ChallengeFromDB(id uuid.UUID, authUserID uuid.UUID, tokenHash string, createdAt time.Time, expiresAt time.Time, failedAttempts int, usedAt time.Time) Challenge {
+	return Challenge{
+		id:             id,
+		authUserID:     authUserID,
+		tokenHash:      tokenHash,
+		createdAt:      createdAt,
+		expiresAt:      expiresAt,
+		failedAttempts: failedAttempts,
+		usedAt:         usedAt,
+	}
+}
+
+//Function returns hash under which challenge token is stored
+func HashChallengeToken(rawToken string) string {
+	hash := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(hash[:])
+}
+
+type TwoFactorRepository interface {
+	//Method locks found row until the end of the transaction
+	GetTwoFactor(ctx context.Context, authUserID uuid.UUID) (TwoFactor, error)
+	//Method creates or replaces two factor of the user
+	SaveTwoFactor(ctx context.Context, twoFactor TwoFactor) error
+	DeleteTwoFactor(ctx context.Context, authUserID uuid.UUID) error
+
+	AddRecoveryCodes(ctx context.Context, codes []RecoveryCode) error
+	DeleteRecoveryCodesOfUser(ctx context.Context, authUserID uuid.UUID) error
+	//Method marks not used code as used, ErrInvalidTwoFactorCode is returned if there is no such code
+	UseRecoveryCode(ctx context.Context, authUserID uuid.UUID, codeHash string, usedAt time.Time) error
+
+	AddChallenge(ctx context.Context, challenge Challenge) error
+	//Method locks found row until the end of the transaction
+	GetChallengeByHash(ctx context.Context, tokenHash string) (Challenge, error)
+	IncrementChallengeFailedAttempts(ctx context.Context, id uuid.UUID) error
+	MarkChallengeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
@@ -26,6 +26,11 @@ func (au AuthUser) GetPassword() string {
 	return au.password
 }
 
+//Accounts created through identity provider have no password
+func (au AuthUser) HasPassword() bool {
+	return au.password != ""
+}
+
 func (au AuthUser) GetRegistrationAt() time.Time {
 	return au.registrationAt
 }
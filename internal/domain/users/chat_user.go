@@ -15,6 +15,13 @@ type ChatUser struct {
 	lastSeenAt time.Time
 }
 
+//Placeholder that becomes sender of messages of deleted accounts
+var DeletedChatUser = ChatUser{
+	id:       uuid.MustParse("00000000-0000-0000-0000-00000000dead"),
+	username: "deleted_user",
+	status:   Offline,
+}
+
 type UserStatus string
 
 const (
@@ -70,4 +77,6 @@ type ChatUserRepository interface {
 	UpdateUsername(ctx context.Context, chatUserId uuid.UUID, newUsername string) error
 	UpdateStatus(ctx context.Context, chatUserId uuid.UUID, newStatus UserStatus) error
 	UpdateLastSeenAt(ctx context.Context, chatUserId uuid.UUID, newLastSeenAt time.Time) error
+	//Method matches usernames by prefix or trigram similarity, prefix matches go first
+	SearchChatUsers(ctx context.Context, query UserSearchQuery) ([]ChatUser, error)
 }
@@ -24,8 +24,53 @@ var (
 		Message: "wrong password for this user",
 	}
 
+	ErrReauthenticationRequired = &AuthError {
+		Code: "REAUTHENTICATION_REQUIRED",
+		Message: "sign in again to confirm this action",
+	}
+
+	ErrChatUserNotFound = &AuthError {
+		Code: "CHAT_USER_NOT_FOUND",
+		Message: "chat user not found in storage",
+	}
+
 	ErrLoginAlreadyExists = &AuthError {
 		Code: "LOGIN_ALREADY_EXISTS",
 		Message: "login already exists",
 	}
+
+	ErrInvalidUsername = &AuthError {
+		Code: "INVALID_USERNAME",
+		Message: "username must be 3-32 characters long, contain only latin letters, digits and underscore and start with a letter",
+	}
+
+	ErrUsernameReserved = &AuthError {
+		Code: "USERNAME_RESERVED",
+		Message: "this username is reserved",
+	}
+
+	ErrUsernameAlreadyTaken = &AuthError {
+		Code: "USERNAME_ALREADY_TAKEN",
+		Message: "username is already taken",
+	}
+
+	ErrWrongSearchQuery = &AuthError {
+		Code: "WRONG_SEARCH_QUERY",
+		Message: "search query must contain at least 2 characters and no spaces",
+	}
+
+	ErrWrongSearchPagination = &AuthError {
+		Code: "WRONG_SEARCH_PAGINATION",
+		Message: "limit must be between 0 and 50 and offset can't be negative",
+	}
+
+	ErrCantBlockYourself = &AuthError {
+		Code: "CANT_BLOCK_YOURSELF",
+		Message: "user can't block himself",
+	}
+
+	ErrBlockedByUser = &AuthError {
+		Code: "BLOCKED_BY_USER",
+		Message: "user blocked you, so you can't add him to the chat",
+	}
 )
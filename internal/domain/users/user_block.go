@@ -0,0 +1,15 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Blocked user can't find blocker in user search and can't add him to chats
+type UserBlockRepository interface {
+	BlockUser(ctx context.Context, blockerID uuid.UUID, blockedID uuid.UUID, blockedAt time.Time) error
+	UnblockUser(ctx context.Context, blockerID uuid.UUID, blockedID uuid.UUID) error
+	IsBlocked(ctx context.Context, blockerID uuid.UUID, blockedID uuid.UUID) (bool, error)
+}
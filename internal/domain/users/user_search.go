@@ -0,0 +1,41 @@
+package users
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultUserSearchPageSize = 20
+	MaxUserSearchPageSize = 50
+	MinUserSearchQueryLength = 2
+)
+
+//Query for searching chat users by username
+//Searcher is never returned and users who blocked searcher are excluded
+type UserSearchQuery struct {
+	Query      string
+	SearcherID uuid.UUID
+	Limit      int
+	Offset     int
+}
+
+//Method checks query values, lowercases query and sets default page size
+func (q UserSearchQuery) Normalize() (UserSearchQuery, error) {
+	q.Query = NormalizeUsername(q.Query)
+	if utf8.RuneCountInString(q.Query) < MinUserSearchQueryLength || strings.ContainsAny(q.Query, " \t\n") {
+		return UserSearchQuery{}, ErrWrongSearchQuery
+	}
+
+	if q.Limit < 0 || q.Limit > MaxUserSearchPageSize || q.Offset < 0 {
+		return UserSearchQuery{}, ErrWrongSearchPagination
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultUserSearchPageSize
+	}
+
+	return q, nil
+}
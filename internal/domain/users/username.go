@@ -0,0 +1,37 @@
+package users
+
+import (
+	"regexp"
+	"strings"
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{2,31}$`)
+
+//Usernames that can't be taken by users, deleted_user belongs to the placeholder of deleted accounts
+var reservedUsernames = map[string]struct{}{
+	DeletedChatUser.username: {},
+	"admin":                  {},
+	"administrator":          {},
+	"moderator":              {},
+	"root":                   {},
+	"support":                {},
+	"system":                 {},
+}
+
+//Usernames are stored in lower case, so they are unique regardless of case
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+//Function checks normalized username
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	if _, reserved := reservedUsernames[username]; reserved {
+		return ErrUsernameReserved
+	}
+
+	return nil
+}
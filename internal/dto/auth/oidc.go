@@ -0,0 +1,9 @@
+package authdto
+
+//Result of oidc callback
+//Callback either logs user in (tokens or two factor challenge) or links external account to logged in user
+type OIDCCallbackResult struct {
+	Login   LoginResult
+	Linked  bool
+	Created bool
+}
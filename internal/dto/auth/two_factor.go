@@ -0,0 +1,18 @@
+package authdto
+
+import "time"
+
+//Secret of new authenticator, it is shown to the user only once
+type TwoFactorEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+//Result of password step of login
+//When two factor is enabled tokens are not issued and challenge token must be exchanged on /login/2fa
+type LoginResult struct {
+	Tokens             AuthTokens
+	TwoFactorRequired  bool
+	ChallengeToken     string
+	ChallengeExpiresAt time.Time
+}
@@ -0,0 +1,23 @@
+package healthdto
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+	//Instance is shutting down, load balancer has to stop sending traffic before server stops accepting connections
+	StatusDraining = "draining"
+)
+
+type ComponentStatus struct {
+	Status  string `json:"status"`
+	Details string `json:"details,omitempty"`
+}
+
+//Report is up only when every component is up
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+func (hr HealthReport) IsUp() bool {
+	return hr.Status == StatusUp
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"symphony_chat/internal/application/transaction"
+	chataudit "symphony_chat/internal/domain/chat_audit"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PostgresChatAuditRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresChatAuditRepo(db *sql.DB) *PostgresChatAuditRepo {
+	return &PostgresChatAuditRepo{
+		db: db,
+	}
+}
+
+func (pr *PostgresChatAuditRepo) AddChatAuditEntry(ctx context.Context, entry chataudit.ChatAuditEntry) error {
+	tx := pr.GetTransaction(ctx)
+
+	var targetID uuid.NullUUID
+	if entry.GetTargetID() != uuid.Nil {
+		targetID = uuid.NullUUID{UUID: entry.GetTargetID(), Valid: true}
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO chat_audit_log (id, chat_id, actor_id, target_id, action, before_value, after_value, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		entry.GetID(),
+		entry.GetChatID(),
+		entry.GetActorID(),
+		targetID,
+		entry.GetAction(),
+		entry.GetBeforeValue(),
+		entry.GetAfterValue(),
+		entry.GetCreatedAt(),
+	)
+
+	if err != nil {
+		return &chataudit.ChatAuditError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add chat audit entry",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresChatAuditRepo) GetChatAuditEntries(ctx context.Context, chatID uuid.UUID, filter chataudit.ChatAuditFilter) ([]chataudit.ChatAuditEntry, error) {
+	tx := pr.GetTransaction(ctx)
+
+	conditions := []string{"chat_id = $1"}
+	args := []interface{}{chatID}
+
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+
+	if len(filter.Actions) != 0 {
+		actions := make([]string, 0, len(filter.Actions))
+		for _, action := range filter.Actions {
+			actions = append(actions, string(action))
+		}
+		args = append(args, pq.Array(actions))
+		conditions = append(conditions, "action = ANY($"+strconv.Itoa(len(args))+")")
+	}
+
+	if filter.ActorID != uuid.Nil {
+		addCondition("actor_id =", filter.ActorID)
+	}
+
+	if filter.TargetID != uuid.Nil {
+		addCondition("target_id =", filter.TargetID)
+	}
+
+	if !filter.From.IsZero() {
+		addCondition("created_at >=", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		addCondition("created_at <=", filter.To)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := `SELECT id, chat_id, actor_id, target_id, action, before_value, after_value, created_at
+		FROM chat_audit_log
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC, id
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &chataudit.ChatAuditError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get chat audit entries",
+			Err: err,
+		}
+	}
+
+	defer rows.Close()
+
+	entries := make([]chataudit.ChatAuditEntry, 0, filter.Limit)
+
+	for rows.Next() {
+		var id uuid.UUID
+		var foundChatID uuid.UUID
+		var actorID uuid.UUID
+		var targetID uuid.NullUUID
+		var action chataudit.AuditAction
+		var beforeValue string
+		var afterValue string
+		var createdAt time.Time
+
+		if err := rows.Scan(&id, &foundChatID, &actorID, &targetID, &action, &beforeValue, &afterValue, &createdAt); err != nil {
+			return nil, &chataudit.ChatAuditError{
+				Code: "DATABASE_ERROR",
+				Message: "failed to scan chat audit entry",
+				Err: err,
+			}
+		}
+
+		entries = append(entries, chataudit.ChatAuditEntryFromDB(id, foundChatID, actorID, targetID.UUID, action, beforeValue, afterValue, createdAt))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &chataudit.ChatAuditError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to iterate over chat audit entries",
+			Err: err,
+		}
+	}
+
+	return entries, nil
+}
+
+func (pr *PostgresChatAuditRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+	return pr.db
+}
@@ -15,8 +15,8 @@ type PostgresChatMessageRepo struct {
 	db *sql.DB
 }
 
-func NewPostgresChatMessageRepo(db *sql.DB) PostgresChatMessageRepo {
-	return PostgresChatMessageRepo{
+func NewPostgresChatMessageRepo(db *sql.DB) *PostgresChatMessageRepo {
+	return &PostgresChatMessageRepo{
 		db: db,
 	}
 }
@@ -61,7 +61,7 @@ func (pr *PostgresChatMessageRepo) GetChatMessageById(ctx context.Context, messa
 	return messages.ChatMessageFromDB(id, chatID, senderID, content, createdAt, status), nil
 }
 
-func (pr *PostgresChatMessageRepo) GetChatMessagesByChatID(ctx context.Context, chatID uuid.UUID) ([]messages.ChatMessage, error) {
+func (pr *PostgresChatMessageRepo) GetChatMessagesByChatId(ctx context.Context, chatID uuid.UUID) ([]messages.ChatMessage, error) {
 	tx := pr.GetTransaction(ctx)
 
 	rows, err := tx.QueryContext(
@@ -135,6 +135,36 @@ func (pr *PostgresChatMessageRepo) GetChatMessageSenderID(ctx context.Context, m
 	return senderID, nil
 }
 
+func (pr *PostgresChatMessageRepo) GetLastChatMessageCreatedAtBySenderID(ctx context.Context, chatID uuid.UUID, senderID uuid.UUID) (time.Time, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var createdAt time.Time
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT created_at
+		FROM chat_message WHERE chat_id = $1 AND sender_id = $2
+		ORDER BY created_at DESC
+		LIMIT 1`,
+		chatID,
+		senderID,
+	).Scan(&createdAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, messages.ErrChatMessageNotFound
+		}
+
+		return time.Time{}, &messages.ChatMessageError {
+			Code: "DATABASE_ERROR",
+			Message: "failed to get last chat message of sender",
+			Err: err,
+		}
+	}
+
+	return createdAt, nil
+}
+
 func (pr *PostgresChatMessageRepo) GetChatMessagesByContentAndChatID(ctx context.Context, content string, chatID uuid.UUID) ([]messages.ChatMessage, error) {
 	tx := pr.GetTransaction(ctx)
 
@@ -285,6 +315,26 @@ func (pr *PostgresChatMessageRepo) UpdateChatMessageStatus(ctx context.Context,
 	return nil
 }
 
+func (pr *PostgresChatMessageRepo) ReassignChatMessagesOfSender(ctx context.Context, senderID uuid.UUID, newSenderID uuid.UUID) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE chat_message SET sender_id = $1 WHERE sender_id = $2`,
+		newSenderID, senderID,
+	)
+
+	if err != nil {
+		return &messages.ChatMessageError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to reassign chat messages of sender",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
 func (pr *PostgresChatMessageRepo) DeleteChatMessage(ctx context.Context, messageID uuid.UUID) error {
 	tx := pr.GetTransaction(ctx)
 
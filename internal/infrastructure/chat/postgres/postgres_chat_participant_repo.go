@@ -46,6 +46,38 @@ func (pr *PostgresChatParticipantRepo) GetChatParticipantByIDs(ctx context.Conte
 	return chatparticipant.ChatParticipantFromDB(foundChatID, foundUserID, roleID, joinedAt), nil
 }
 
+func (pr *PostgresChatParticipantRepo) LockChatParticipant(ctx context.Context, chatID uuid.UUID, userID uuid.UUID) (chatparticipant.ChatParticipant, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var foundChatID uuid.UUID
+	var foundUserID uuid.UUID
+	var roleID uuid.UUID
+	var joinedAt time.Time
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT chat_id, user_id, role_id, joined_at
+		FROM chat_participant WHERE chat_id = $1 AND user_id = $2
+		FOR UPDATE`,
+		chatID,
+		userID,
+	).Scan(&foundChatID, &foundUserID, &roleID, &joinedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return chatparticipant.ChatParticipant{}, chatparticipant.ErrChatParticipantNotFound
+		}
+
+		return chatparticipant.ChatParticipant{}, &chatparticipant.ChatParticipantError{
+			Code:    "DATABASE_ERROR",
+			Message: "failed to lock chat participant",
+			Err:     err,
+		}
+	}
+
+	return chatparticipant.ChatParticipantFromDB(foundChatID, foundUserID, roleID, joinedAt), nil
+}
+
 func (pr *PostgresChatParticipantRepo) GetAllChatParticipantsByChatID(ctx context.Context, chatID uuid.UUID) ([]chatparticipant.ChatParticipant, error) {
 	tx := pr.GetTransaction(ctx)
 
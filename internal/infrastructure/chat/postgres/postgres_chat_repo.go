@@ -29,12 +29,13 @@ func (pr *PostgresChatRepo) GetChatByID(ctx context.Context, chat_id uuid.UUID)
 	var name string
 	var createdAt time.Time
 	var updatedAt time.Time
+	var slowModeIntervalSeconds int64
 
 	err := tx.QueryRowContext(
 		ctx,
-		"SELECT id, name, created_at, updated_at FROM chats WHERE id = $1",
+		"SELECT id, name, created_at, updated_at, slow_mode_interval_seconds FROM chat WHERE id = $1",
 		chat_id,
-	).Scan(&id, &name, &createdAt, &updatedAt)
+	).Scan(&id, &name, &createdAt, &updatedAt, &slowModeIntervalSeconds)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -48,7 +49,7 @@ func (pr *PostgresChatRepo) GetChatByID(ctx context.Context, chat_id uuid.UUID)
 		}
 	}
 
-	return chat.ChatFromDB(id, name, createdAt, updatedAt), nil
+	return chat.ChatFromDB(id, name, createdAt, updatedAt, time.Duration(slowModeIntervalSeconds)*time.Second), nil
 }
 
 func (pr *PostgresChatRepo) GetChatsByIDs(ctx context.Context, chatIDs []uuid.UUID) ([]chat.Chat, error) {
@@ -56,7 +57,7 @@ func (pr *PostgresChatRepo) GetChatsByIDs(ctx context.Context, chatIDs []uuid.UU
 
 	rows, err := tx.QueryContext(
 		ctx,
-		`SELECT id, name, created_at, updated_at
+		`SELECT id, name, created_at, updated_at, slow_mode_interval_seconds
 		FROM chat
 		WHERE id = ANY($1)`,
 		pq.Array(chatIDs),
@@ -80,8 +81,9 @@ func (pr *PostgresChatRepo) GetChatsByIDs(ctx context.Context, chatIDs []uuid.UU
 		var name string
 		var createdAt time.Time
 		var updatedAt time.Time
+		var slowModeIntervalSeconds int64
 
-		if err := rows.Scan(&chatID, &name, &createdAt, &updatedAt); err != nil {
+		if err := rows.Scan(&chatID, &name, &createdAt, &updatedAt, &slowModeIntervalSeconds); err != nil {
 			return []chat.Chat{}, &chat.ChatError{
 				Code: "DATABASE_ERROR",
 				Message: "failed to get chats",
@@ -89,7 +91,7 @@ func (pr *PostgresChatRepo) GetChatsByIDs(ctx context.Context, chatIDs []uuid.UU
 			}
 		}
 
-		chats = append(chats, chat.ChatFromDB(chatID, name, createdAt, updatedAt))
+		chats = append(chats, chat.ChatFromDB(chatID, name, createdAt, updatedAt, time.Duration(slowModeIntervalSeconds)*time.Second))
 	}
 
 	return chats, nil
@@ -100,8 +102,8 @@ func (pr *PostgresChatRepo) AddChat(ctx context.Context, chatDB chat.Chat) error
 
 	result, err := tx.ExecContext(
 		ctx,
-		"INSERT INTO chats (id, name, created_at, updated_at) VALUES ($1, $2, $3, $4)",
-		chatDB.GetID(), chatDB.GetName(), chatDB.GetCreatedAt(), chatDB.GetUpdatedAt(),
+		"INSERT INTO chat (id, name, created_at, updated_at, slow_mode_interval_seconds) VALUES ($1, $2, $3, $4, $5)",
+		chatDB.GetID(), chatDB.GetName(), chatDB.GetCreatedAt(), chatDB.GetUpdatedAt(), int64(chatDB.GetSlowModeInterval()/time.Second),
 	)
 
 	if err != nil {
@@ -199,6 +201,40 @@ func (pr *PostgresChatRepo) UpdateChatUpdatedAt(ctx context.Context, chatID uuid
 	return nil
 }
 
+func (pr *PostgresChatRepo) UpdateChatSlowModeInterval(ctx context.Context, chatID uuid.UUID, interval time.Duration) error {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE chat SET slow_mode_interval_seconds = $1 WHERE id = $2",
+		int64(interval/time.Second),
+		chatID,
+	)
+
+	if err != nil {
+		return &chat.ChatError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update chat slow mode interval",
+			Err:     err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &chat.ChatError {
+			Code: "DATABASE_ERROR",
+			Message: "failed to get affected rows after updated chat slow mode interval",
+			Err:     err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return chat.ErrChatNotFound
+	}
+
+	return nil
+}
+
 func (pr *PostgresChatRepo) DeleteChat(ctx context.Context, chatId uuid.UUID) error {
 	tx := pr.GetTransaction(ctx)
 
@@ -8,6 +8,7 @@ import (
 	"symphony_chat/internal/domain/roles"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type PostgresChatRoleRepo struct {
@@ -26,9 +27,9 @@ func (pr *PostgresChatRoleRepo) GetChatRoleByID(ctx context.Context, role_id uui
 
 	rows, err := tx.QueryContext(
 		ctx,
-		`SELECT id, name, permissions 
+		`SELECT id, name, permission
 		FROM chat_role
-		INNER JOIN chat_role_permission ON chat_role.id = chat_role_permission.role_id
+		LEFT JOIN chat_role_permission ON chat_role.id = chat_role_permission.role_id
 		WHERE chat_role.id = $1`,
 		role_id,
 	)
@@ -47,25 +48,7 @@ func (pr *PostgresChatRoleRepo) GetChatRoleByID(ctx context.Context, role_id uui
 
 	defer rows.Close()
 
-	var id uuid.UUID
-	var name string
-	var permissions []roles.Permission
-
-	for rows.Next() {
-		var permission string
-
-		if err := rows.Scan(&id, &name, &permission); err != nil {
-			return roles.ChatRole{}, &roles.ChatRoleError {
-				Code: "DATABASE_ERROR",
-				Message: "failed to scan chat role",
-				Err: err,
-			}
-		}
-
-		permissions = append(permissions, roles.Permission(permission))
-	}
-
-	return roles.ChatRoleFromDB(id, name, permissions), nil
+	return scanChatRole(rows)
 }
 
 func (pr *PostgresChatRoleRepo) GetChatRoleByName(ctx context.Context, roleName string) (roles.ChatRole, error) {
@@ -76,7 +59,7 @@ func (pr *PostgresChatRoleRepo) GetChatRoleByName(ctx context.Context, roleName
 		ctx,
 		`SELECT id, name, permission
 		FROM chat_role
-		INNER JOIN chat_role_permission ON chat_role.id = chat_role_permission.role_id
+		LEFT JOIN chat_role_permission ON chat_role.id = chat_role_permission.role_id
 		WHERE chat_role.name = $1`,
 		roleName,
 	)
@@ -95,25 +78,7 @@ func (pr *PostgresChatRoleRepo) GetChatRoleByName(ctx context.Context, roleName
 
 	defer rows.Close()
 
-	var id uuid.UUID
-	var name string
-	var permissions []roles.Permission
-
-	for rows.Next() {
-		var permission string
-
-		if err := rows.Scan(&id, &name, &permission); err != nil {
-			return roles.ChatRole{}, &roles.ChatRoleError {
-				Code: "DATABASE_ERROR",
-				Message: "failed to scan chat role",
-				Err: err,
-			}
-		}
-
-		permissions = append(permissions, roles.Permission(permission))
-	}
-
-	return roles.ChatRoleFromDB(id, name, permissions), nil
+	return scanChatRole(rows)
 
 }
 
@@ -123,8 +88,8 @@ func (pr *PostgresChatRoleRepo) GetChatRoles(ctx context.Context) ([]roles.ChatR
 
 	rows, err := tx.QueryContext(
 		ctx,
-		`SELECT id, name, permissions FROM chat_role
-		INNER JOIN chat_role_permission ON chat_role.id = chat_role_permission.role_id`,
+		`SELECT id, name, permission FROM chat_role
+		LEFT JOIN chat_role_permission ON chat_role.id = chat_role_permission.role_id`,
 	)
 
 	if err != nil {
@@ -149,7 +114,8 @@ func (pr *PostgresChatRoleRepo) GetChatRoles(ctx context.Context) ([]roles.ChatR
 	for rows.Next() {
 		var id uuid.UUID
 		var name string
-		var permission roles.Permission
+		//Role without permissions has one row with NULL permission
+		var permission sql.NullString
 
 		if err := rows.Scan(&id, &name, &permission); err != nil {
 			return []roles.ChatRole{}, &roles.ChatRoleError{
@@ -169,17 +135,29 @@ func (pr *PostgresChatRoleRepo) GetChatRoles(ctx context.Context) ([]roles.ChatR
 				permissions: make([]roles.Permission, 0),
 			}
 
-			role.permissions = append(role.permissions, permission)
+			if permission.Valid {
+				role.permissions = append(role.permissions, roles.Permission(permission.String))
+			}
 
 			foundRoles[id] = role
 
 		} else {
-			role.permissions = append(role.permissions, permission)
+			if permission.Valid {
+				role.permissions = append(role.permissions, roles.Permission(permission.String))
+			}
 			foundRoles[id] = role
 		}
 
 	}
 
+	if err := rows.Err(); err != nil {
+		return []roles.ChatRole{}, &roles.ChatRoleError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to read chat roles",
+			Err: err,
+		}
+	}
+
 	chatRoles := make([]roles.ChatRole, 0, len(foundRoles))
 
 	for id, role := range foundRoles {
@@ -189,6 +167,99 @@ func (pr *PostgresChatRoleRepo) GetChatRoles(ctx context.Context) ([]roles.ChatR
 	return chatRoles, nil
 }
 
+//Permissions that are not in the role are deleted, so hand-made changes are reverted too
+func (pr *PostgresChatRoleRepo) SaveChatRole(ctx context.Context, role roles.ChatRole) error {
+	tx := pr.GetTransaction(ctx)
+
+	permissions := make([]string, 0, len(role.GetPermissions()))
+	for _, permission := range role.GetPermissions() {
+		permissions = append(permissions, string(permission))
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO chat_role (id, name) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name`,
+		role.GetID(), role.GetName(),
+	)
+	if err != nil {
+		return &roles.ChatRoleError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to save chat role",
+			Err: err,
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM chat_role_permission WHERE role_id = $1 AND permission <> ALL($2)`,
+		role.GetID(), pq.Array(permissions),
+	)
+	if err != nil {
+		return &roles.ChatRoleError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete permissions of chat role",
+			Err: err,
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO chat_role_permission (role_id, permission)
+		SELECT $1, unnest($2::varchar[])
+		ON CONFLICT DO NOTHING`,
+		role.GetID(), pq.Array(permissions),
+	)
+	if err != nil {
+		return &roles.ChatRoleError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to save permissions of chat role",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+//Rows of one role are joined with its permissions, role is not found if there are no rows
+func scanChatRole(rows *sql.Rows) (roles.ChatRole, error) {
+	var id uuid.UUID
+	var name string
+	var permissions []roles.Permission
+	found := false
+
+	for rows.Next() {
+		var permission sql.NullString
+
+		if err := rows.Scan(&id, &name, &permission); err != nil {
+			return roles.ChatRole{}, &roles.ChatRoleError {
+				Code: "DATABASE_ERROR",
+				Message: "failed to scan chat role",
+				Err: err,
+			}
+		}
+
+		found = true
+		if permission.Valid {
+			permissions = append(permissions, roles.Permission(permission.String))
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return roles.ChatRole{}, &roles.ChatRoleError {
+			Code: "DATABASE_ERROR",
+			Message: "failed to read chat role",
+			Err: err,
+		}
+	}
+
+	if !found {
+		return roles.ChatRole{}, roles.ErrChatRoleNotFound
+	}
+
+	return roles.ChatRoleFromDB(id, name, permissions), nil
+}
+
 func (pr *PostgresChatRoleRepo) GetTransaction(ctx context.Context) transaction.DBTX {
 	if tx := transaction.IsTransaction(ctx); tx != nil {
 		return tx
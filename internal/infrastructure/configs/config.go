@@ -0,0 +1,84 @@
+package config
+
+import "errors"
+
+//Config is the whole configuration of the server
+//It is built by Load from defaults, config file, environment variables and flags
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt"`
+	Cookie    CookieSettings  `yaml:"cookie" toml:"cookie"`
+	Password  PasswordConfig  `yaml:"password" toml:"password"`
+	Lockout   LockoutConfig   `yaml:"lockout" toml:"lockout"`
+	WebSocket WebSocketConfig `yaml:"websocket" toml:"websocket"`
+	OIDC      OIDCConfig      `yaml:"oidc" toml:"oidc"`
+	//Cache of roles and memberships that are used to check permissions of websocket actions
+	PermissionCache PermissionCacheConfig `yaml:"permission_cache" toml:"permission_cache"`
+	//Reset tokens are written to this file instead of the log
+	NotificationsFile string `yaml:"notifications_file" toml:"notifications_file" env:"NOTIFICATIONS_FILE"`
+	//Without notifications file notifications are written to the log, their bodies (with reset tokens) are redacted
+	//unless this is enabled, it must be enabled only in development
+	LogNotificationBodies bool `yaml:"log_notification_bodies" toml:"log_notification_bodies" env:"LOG_NOTIFICATION_BODIES"`
+}
+
+func DefaultConfig() Config {
+	cookieConfig := DefaultCookieConfig()
+
+	return Config{
+		Server: ServerConfig{
+			Addr:                     ":8080",
+			MetricsAddr:              ":9090",
+			ShutdownTimeoutInSeconds: 30,
+			DrainDelayInSeconds:      5,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatJSON,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			ServiceName: "symphony_chat",
+			SampleRatio: 1,
+		},
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    "5432",
+			SSLMode: "require",
+		},
+		JWT: NewJWTConfig(15, 30),
+		Cookie: CookieSettings{
+			Path:     cookieConfig.Path,
+			Secure:   cookieConfig.Secure,
+			SameSite: "lax",
+		},
+		Password:  DefaultPasswordConfig(),
+		Lockout:   DefaultLockoutConfig(),
+		WebSocket: DefaultWebSocketConfig(),
+		PermissionCache: PermissionCacheConfig{
+			MaxEntries:   10000,
+			TTLInSeconds: 60,
+		},
+	}
+}
+
+//Method returns all problems of configuration at once
+func (c Config) Validate() error {
+	var errs []error
+
+	errs = append(errs, c.Server.validate()...)
+	errs = append(errs, c.Log.validate()...)
+	errs = append(errs, c.Tracing.validate()...)
+	errs = append(errs, c.Database.validate()...)
+	errs = append(errs, c.JWT.validate()...)
+	errs = append(errs, c.Cookie.validate()...)
+	errs = append(errs, c.Password.validate()...)
+	errs = append(errs, c.Lockout.validate()...)
+	errs = append(errs, c.WebSocket.validate()...)
+	errs = append(errs, c.OIDC.validate()...)
+	errs = append(errs, c.PermissionCache.validate()...)
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+//Attributes of refresh token and csrf cookies
+//Empty domain makes host-only cookie
+type CookieConfig struct {
+	Domain   string
+	Path     string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+func DefaultCookieConfig() CookieConfig {
+	return CookieConfig{
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+//SameSite is one of "lax", "strict" or "none", browsers accept SameSite=None only with Secure
+func NewCookieConfig(domain string, path string, secure bool, sameSite string) (CookieConfig, error) {
+	cookieConfig := CookieConfig{
+		Domain: domain,
+		Path:   path,
+		Secure: secure,
+	}
+
+	if cookieConfig.Path == "" {
+		cookieConfig.Path = "/"
+	}
+
+	switch strings.ToLower(sameSite) {
+	case "", "lax":
+		cookieConfig.SameSite = http.SameSiteLaxMode
+	case "strict":
+		cookieConfig.SameSite = http.SameSiteStrictMode
+	case "none":
+		if !secure {
+			return CookieConfig{}, errors.New("cookie with SameSite=None must be secure")
+		}
+		cookieConfig.SameSite = http.SameSiteNoneMode
+	default:
+		return CookieConfig{}, errors.New("unknown cookie SameSite mode: " + sameSite)
+	}
+
+	return cookieConfig, nil
+}
+
+//Cookie attributes as they are written in config file and environment variables
+type CookieSettings struct {
+	Domain   string `yaml:"domain" toml:"domain" env:"COOKIE_DOMAIN"`
+	Path     string `yaml:"path" toml:"path" env:"COOKIE_PATH"`
+	Secure   bool   `yaml:"secure" toml:"secure" env:"COOKIE_SECURE"`
+	SameSite string `yaml:"samesite" toml:"samesite" env:"COOKIE_SAMESITE"`
+}
+
+func (cs CookieSettings) CookieConfig() (CookieConfig, error) {
+	return NewCookieConfig(cs.Domain, cs.Path, cs.Secure, cs.SameSite)
+}
+
+func (cs CookieSettings) validate() []error {
+	if _, err := cs.CookieConfig(); err != nil {
+		return []error{errors.New("cookie: " + err.Error())}
+	}
+	return nil
+}
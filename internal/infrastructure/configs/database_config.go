@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+)
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+	//Optional, migrations are read from this directory instead of the ones that are embedded into the binary
+	//"migrate create" writes new migrations to it (migrations directory of the source tree by default)
+	MigrationsDir string `yaml:"migrations_dir" toml:"migrations_dir" env:"DB_MIGRATIONS_DIR"`
+	//Server applies embedded migrations before it starts, replicas wait for each other on advisory lock
+	MigrateOnStartup bool `yaml:"migrate_on_startup" toml:"migrate_on_startup" env:"DB_MIGRATE_ON_STARTUP"`
+}
+
+func (dc DatabaseConfig) validate() []error {
+	var errs []error
+
+	if dc.Host == "" {
+		errs = append(errs, errors.New("database.host is required"))
+	}
+	if port, err := strconv.ParseUint(dc.Port, 10, 16); err != nil || port == 0 {
+		errs = append(errs, errors.New("database.port must be a number from 1 to 65535, got \""+dc.Port+"\""))
+	}
+	if dc.User == "" {
+		errs = append(errs, errors.New("database.user is required"))
+	}
+	if dc.Name == "" {
+		errs = append(errs, errors.New("database.name is required"))
+	}
+
+	//Modes that are supported by lib/pq
+	switch dc.SSLMode {
+	case "disable", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, errors.New("database.sslmode must be one of disable, require, verify-ca, verify-full, got \""+dc.SSLMode+"\""))
+	}
+
+	return errs
+}
@@ -1,15 +1,50 @@
 package config
 
+import "errors"
+
+//Keys dir is required, ephemeral signing key is generated only if EphemeralKeys is explicitly enabled
 type JWTConfig struct {
-	SecretKey          string
-	AccessTTLinMinutes uint
-	RefreshTTLinDays   uint
+	AccessTTLinMinutes uint   `yaml:"access_ttl_in_minutes" toml:"access_ttl_in_minutes" env:"ACCESS_TTL_IN_MINUTES"`
+	RefreshTTLinDays   uint   `yaml:"refresh_ttl_in_days" toml:"refresh_ttl_in_days" env:"REFRESH_TTL_IN_DAYS"`
+	KeysDir            string `yaml:"keys_dir" toml:"keys_dir" env:"JWT_KEYS_DIR"`
+	SigningKeyID       string `yaml:"signing_key_id" toml:"signing_key_id" env:"JWT_SIGNING_KEY_ID"`
+	//Only for development: tokens become invalid after restart and replicas can't verify tokens of each other
+	EphemeralKeys bool `yaml:"ephemeral_keys" toml:"ephemeral_keys" env:"JWT_EPHEMERAL_KEYS"`
+	//How long after sign in user of passwordless (OIDC) account can confirm sensitive actions without signing in again
+	ReauthWindowInMinutes uint `yaml:"reauth_window_in_minutes" toml:"reauth_window_in_minutes" env:"JWT_REAUTH_WINDOW_IN_MINUTES"`
 }
 
-func NewJWTConfig(secretKey string, accessTTLinMinutes uint, refreshTTLinDays uint) JWTConfig {
+const defaultReauthWindowInMinutes = 10
+
+func NewJWTConfig(accessTTLinMinutes uint, refreshTTLinDays uint) JWTConfig {
 	return JWTConfig{
-		SecretKey:          secretKey,
-		AccessTTLinMinutes: accessTTLinMinutes,
-		RefreshTTLinDays:   refreshTTLinDays,
+		AccessTTLinMinutes:    accessTTLinMinutes,
+		RefreshTTLinDays:      refreshTTLinDays,
+		ReauthWindowInMinutes: defaultReauthWindowInMinutes,
+	}
+}
+
+func (jc JWTConfig) validate() []error {
+	var errs []error
+
+	if jc.AccessTTLinMinutes == 0 {
+		errs = append(errs, errors.New("jwt.access_ttl_in_minutes must be positive"))
 	}
+	if jc.RefreshTTLinDays == 0 {
+		errs = append(errs, errors.New("jwt.refresh_ttl_in_days must be positive"))
+	}
+	if jc.ReauthWindowInMinutes == 0 {
+		errs = append(errs, errors.New("jwt.reauth_window_in_minutes must be positive"))
+	}
+	if jc.SigningKeyID != "" && jc.KeysDir == "" {
+		errs = append(errs, errors.New("jwt.signing_key_id is set, but jwt.keys_dir is empty"))
+	}
+	if jc.KeysDir == "" && !jc.EphemeralKeys {
+		errs = append(errs, errors.New("jwt.keys_dir must be set, jwt.ephemeral_keys can be enabled only for development"))
+	}
+	if jc.KeysDir != "" && jc.EphemeralKeys {
+		errs = append(errs, errors.New("jwt.keys_dir and jwt.ephemeral_keys can't be used together"))
+	}
+
+	return errs
 }
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+//Environment variable with path to config file, -config flag overrides it
+const ConfigFileEnv = "CONFIG_FILE"
+
+//Load builds configuration in order of precedence: defaults, config file (yaml or toml), environment variables, flags
+//Every option has flag that is named by its path in config file (-database.host) and environment variable from env tag,
+//empty environment variables are treated as not set. Configuration is validated before it is returned
+func Load(args []string) (Config, error) {
+	cfg, rest, err := LoadWithArgs(args)
+	if err != nil {
+		return Config{}, err
+	}
+	if len(rest) > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+
+	return cfg, nil
+}
+
+//LoadWithArgs is Load for subcommands, arguments that follow flags are returned (migrate -config app.yaml up)
+func LoadWithArgs(args []string) (Config, []string, error) {
+	cfg := DefaultConfig()
+	options := collectOptions(reflect.ValueOf(&cfg).Elem(), "")
+
+	flagSet := flag.NewFlagSet("symphony_chat", flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv(ConfigFileEnv), "path to yaml or toml config file (env "+ConfigFileEnv+")")
+
+	flags := make([]*optionFlag, 0, len(options))
+	for _, opt := range options {
+		f := &optionFlag{option: opt}
+		flagSet.Var(f, opt.path, opt.usage())
+		flags = append(flags, f)
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	var errs []error
+	for _, opt := range options {
+		if err := opt.applyEnv(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return Config{}, nil, errors.Join(errs...)
+	}
+
+	//Values of flags were checked during parsing
+	for _, f := range flags {
+		if f.isSet {
+			setValue(f.option.value, f.raw)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	return cfg, flagSet.Args(), nil
+}
+
+//Format of the file is chosen by its extension, unknown keys are rejected so typos are not ignored
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		//Empty file is decoded as io.EOF
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			var strictErr *toml.StrictMissingError
+			if errors.As(err, &strictErr) {
+				return fmt.Errorf("failed to parse config file %s: unknown keys:\n%s", path, strictErr.String())
+			}
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", ext)
+	}
+
+	return nil
+}
+
+//Leaf field of Config
+type option struct {
+	path  string
+	env   string
+	value reflect.Value
+}
+
+//Function walks nested structs, path of the field is built from yaml keys
+func collectOptions(v reflect.Value, prefix string) []option {
+	var options []option
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := field.Tag.Get("yaml")
+		if name == "" || name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			options = append(options, collectOptions(v.Field(i), name)...)
+			continue
+		}
+
+		options = append(options, option{
+			path:  name,
+			env:   field.Tag.Get("env"),
+			value: v.Field(i),
+		})
+	}
+
+	return options
+}
+
+func (o option) usage() string {
+	if o.env == "" {
+		return o.path
+	}
+	return o.path + " (env " + o.env + ")"
+}
+
+func (o option) applyEnv() error {
+	if o.env == "" {
+		return nil
+	}
+
+	raw := os.Getenv(o.env)
+	if raw == "" {
+		return nil
+	}
+
+	if err := setValue(o.value, raw); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", o.env, err)
+	}
+	return nil
+}
+
+//Function parses string from environment variable or flag into the field
+//Lists are separated by commas or spaces
+func setValue(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer of %d bits", raw, v.Type().Bits())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a non-negative integer of %d bits", raw, v.Type().Bits())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported option type %s", v.Type())
+		}
+		items := strings.FieldsFunc(raw, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported option type %s", v.Type())
+	}
+
+	return nil
+}
+
+//Flag keeps raw value until file and environment variables are applied, so flags have the highest precedence
+type optionFlag struct {
+	option option
+	raw    string
+	isSet  bool
+}
+
+func (f *optionFlag) String() string {
+	//flag package calls String on zero value to find out if default is empty
+	if f == nil || !f.option.value.IsValid() {
+		return ""
+	}
+	if f.isSet {
+		return f.raw
+	}
+	if f.option.value.Kind() == reflect.Slice {
+		return strings.Join(f.option.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(f.option.value.Interface())
+}
+
+func (f *optionFlag) Set(raw string) error {
+	if err := setValue(reflect.New(f.option.value.Type()).Elem(), raw); err != nil {
+		return err
+	}
+
+	f.raw = raw
+	f.isSet = true
+	return nil
+}
+
+func (f *optionFlag) IsBoolFlag() bool {
+	return f.option.value.IsValid() && f.option.value.Kind() == reflect.Bool
+}
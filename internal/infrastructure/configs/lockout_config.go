@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	loginattempt "symphony_chat/internal/domain/login_attempt"
+	"time"
+)
+
+//Lockout policies of failed login attempts, counters are kept separately for every login and for every ip
+//Ip policy should be softer, because many users can share one ip
+type LockoutConfig struct {
+	LoginMaxFailedAttempts    int  `yaml:"login_max_failed_attempts" toml:"login_max_failed_attempts" env:"LOCKOUT_LOGIN_MAX_FAILED_ATTEMPTS"`
+	LoginBaseLockoutInSeconds uint `yaml:"login_base_lockout_in_seconds" toml:"login_base_lockout_in_seconds" env:"LOCKOUT_LOGIN_BASE_LOCKOUT_IN_SECONDS"`
+	LoginMaxLockoutInSeconds  uint `yaml:"login_max_lockout_in_seconds" toml:"login_max_lockout_in_seconds" env:"LOCKOUT_LOGIN_MAX_LOCKOUT_IN_SECONDS"`
+	LoginResetAfterInSeconds  uint `yaml:"login_reset_after_in_seconds" toml:"login_reset_after_in_seconds" env:"LOCKOUT_LOGIN_RESET_AFTER_IN_SECONDS"`
+	IPMaxFailedAttempts       int  `yaml:"ip_max_failed_attempts" toml:"ip_max_failed_attempts" env:"LOCKOUT_IP_MAX_FAILED_ATTEMPTS"`
+	IPBaseLockoutInSeconds    uint `yaml:"ip_base_lockout_in_seconds" toml:"ip_base_lockout_in_seconds" env:"LOCKOUT_IP_BASE_LOCKOUT_IN_SECONDS"`
+	IPMaxLockoutInSeconds     uint `yaml:"ip_max_lockout_in_seconds" toml:"ip_max_lockout_in_seconds" env:"LOCKOUT_IP_MAX_LOCKOUT_IN_SECONDS"`
+	IPResetAfterInSeconds     uint `yaml:"ip_reset_after_in_seconds" toml:"ip_reset_after_in_seconds" env:"LOCKOUT_IP_RESET_AFTER_IN_SECONDS"`
+}
+
+func DefaultLockoutConfig() LockoutConfig {
+	return LockoutConfig{
+		LoginMaxFailedAttempts:    5,
+		LoginBaseLockoutInSeconds: 30,
+		LoginMaxLockoutInSeconds:  3600,
+		LoginResetAfterInSeconds:  3600,
+		IPMaxFailedAttempts:       20,
+		IPBaseLockoutInSeconds:    30,
+		IPMaxLockoutInSeconds:     3600,
+		IPResetAfterInSeconds:     3600,
+	}
+}
+
+func (lc LockoutConfig) LoginPolicy() loginattempt.LockoutPolicy {
+	return loginattempt.LockoutPolicy{
+		MaxFailedAttempts: lc.LoginMaxFailedAttempts,
+		BaseLockout:       time.Duration(lc.LoginBaseLockoutInSeconds) * time.Second,
+		MaxLockout:        time.Duration(lc.LoginMaxLockoutInSeconds) * time.Second,
+		ResetAfter:        time.Duration(lc.LoginResetAfterInSeconds) * time.Second,
+	}
+}
+
+func (lc LockoutConfig) IPPolicy() loginattempt.LockoutPolicy {
+	return loginattempt.LockoutPolicy{
+		MaxFailedAttempts: lc.IPMaxFailedAttempts,
+		BaseLockout:       time.Duration(lc.IPBaseLockoutInSeconds) * time.Second,
+		MaxLockout:        time.Duration(lc.IPMaxLockoutInSeconds) * time.Second,
+		ResetAfter:        time.Duration(lc.IPResetAfterInSeconds) * time.Second,
+	}
+}
+
+func (lc LockoutConfig) validate() []error {
+	var errs []error
+
+	for _, policy := range []struct {
+		name              string
+		maxFailedAttempts int
+		baseLockout       uint
+		maxLockout        uint
+		resetAfter        uint
+	}{
+		{"login", lc.LoginMaxFailedAttempts, lc.LoginBaseLockoutInSeconds, lc.LoginMaxLockoutInSeconds, lc.LoginResetAfterInSeconds},
+		{"ip", lc.IPMaxFailedAttempts, lc.IPBaseLockoutInSeconds, lc.IPMaxLockoutInSeconds, lc.IPResetAfterInSeconds},
+	} {
+		prefix := "lockout." + policy.name + "_"
+
+		if policy.maxFailedAttempts <= 0 {
+			errs = append(errs, errors.New(prefix+"max_failed_attempts must be positive"))
+		}
+		if policy.baseLockout == 0 {
+			errs = append(errs, errors.New(prefix+"base_lockout_in_seconds must be positive"))
+		}
+		if policy.maxLockout < policy.baseLockout {
+			errs = append(errs, errors.New(prefix+"max_lockout_in_seconds must not be less than "+prefix+"base_lockout_in_seconds"))
+		}
+		if policy.resetAfter == 0 {
+			errs = append(errs, errors.New(prefix+"reset_after_in_seconds must be positive"))
+		}
+	}
+
+	return errs
+}
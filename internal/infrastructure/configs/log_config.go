@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+)
+
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+type LogConfig struct {
+	//One of debug, info, warn, error
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+func (lc LogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(lc.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+func (lc LogConfig) validate() []error {
+	var errs []error
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(lc.Level)); err != nil {
+		errs = append(errs, errors.New("log.level must be one of debug, info, warn, error, got \""+lc.Level+"\""))
+	}
+
+	if lc.Format != LogFormatJSON && lc.Format != LogFormatText {
+		errs = append(errs, errors.New("log.format must be json or text, got \""+lc.Format+"\""))
+	}
+
+	return errs
+}
@@ -0,0 +1,37 @@
+package config
+
+import "errors"
+
+//External OpenID Connect provider is enabled only when issuer url is set
+type OIDCConfig struct {
+	ProviderName string   `yaml:"provider_name" toml:"provider_name" env:"OIDC_PROVIDER_NAME"`
+	IssuerURL    string   `yaml:"issuer_url" toml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID     string   `yaml:"client_id" toml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes       []string `yaml:"scopes" toml:"scopes" env:"OIDC_SCOPES"`
+}
+
+func (oc OIDCConfig) Enabled() bool {
+	return oc.IssuerURL != ""
+}
+
+func (oc OIDCConfig) validate() []error {
+	if !oc.Enabled() {
+		return nil
+	}
+
+	var errs []error
+
+	if oc.ProviderName == "" {
+		errs = append(errs, errors.New("oidc.provider_name is required when oidc.issuer_url is set"))
+	}
+	if oc.ClientID == "" {
+		errs = append(errs, errors.New("oidc.client_id is required when oidc.issuer_url is set"))
+	}
+	if oc.RedirectURL == "" {
+		errs = append(errs, errors.New("oidc.redirect_url is required when oidc.issuer_url is set"))
+	}
+
+	return errs
+}
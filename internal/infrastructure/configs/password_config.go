@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
+)
+
+//Argon2 parameters and password policy, defaults are taken from password_hash package
+type PasswordConfig struct {
+	Argon2MemoryKiB   uint32 `yaml:"argon2_memory_kib" toml:"argon2_memory_kib" env:"PASSWORD_ARGON2_MEMORY_KIB"`
+	Argon2Iterations  uint32 `yaml:"argon2_iterations" toml:"argon2_iterations" env:"PASSWORD_ARGON2_ITERATIONS"`
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism" toml:"argon2_parallelism" env:"PASSWORD_ARGON2_PARALLELISM"`
+	MinLength         int    `yaml:"min_length" toml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MaxLength         int    `yaml:"max_length" toml:"max_length" env:"PASSWORD_MAX_LENGTH"`
+	AllowUnicode      bool   `yaml:"allow_unicode" toml:"allow_unicode" env:"PASSWORD_ALLOW_UNICODE"`
+	BannedListFile    string `yaml:"banned_list_file" toml:"banned_list_file" env:"PASSWORD_BANNED_LIST_FILE"`
+}
+
+func DefaultPasswordConfig() PasswordConfig {
+	policy := passwordhash.DefaultPasswordPolicy()
+
+	return PasswordConfig{
+		Argon2MemoryKiB:   passwordhash.DefaultArgon2Params.MemoryKiB,
+		Argon2Iterations:  passwordhash.DefaultArgon2Params.Iterations,
+		Argon2Parallelism: passwordhash.DefaultArgon2Params.Parallelism,
+		MinLength:         policy.MinLength,
+		MaxLength:         policy.MaxLength,
+		AllowUnicode:      policy.AllowUnicode,
+	}
+}
+
+//Salt and key lengths are not configurable
+func (pc PasswordConfig) Argon2Params() passwordhash.Argon2Params {
+	params := passwordhash.DefaultArgon2Params
+	params.MemoryKiB = pc.Argon2MemoryKiB
+	params.Iterations = pc.Argon2Iterations
+	params.Parallelism = pc.Argon2Parallelism
+	return params
+}
+
+func (pc PasswordConfig) validate() []error {
+	var errs []error
+
+	if pc.Argon2MemoryKiB == 0 || pc.Argon2Iterations == 0 || pc.Argon2Parallelism == 0 {
+		errs = append(errs, errors.New("password.argon2_memory_kib, password.argon2_iterations and password.argon2_parallelism must be positive"))
+	}
+	if pc.MinLength <= 0 {
+		errs = append(errs, errors.New("password.min_length must be positive"))
+	}
+	if pc.MaxLength < pc.MinLength {
+		errs = append(errs, errors.New("password.max_length must not be less than password.min_length"))
+	}
+
+	return errs
+}
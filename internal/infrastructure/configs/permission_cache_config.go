@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+//In-process cache of chat roles and of participants' roles that is used by permission checks
+type PermissionCacheConfig struct {
+	//Max number of entries in each cache, 0 disables caching
+	MaxEntries int `yaml:"max_entries" toml:"max_entries" env:"PERMISSION_CACHE_MAX_ENTRIES"`
+	//Entries are reloaded after this time even if nothing invalidated them
+	TTLInSeconds uint `yaml:"ttl_in_seconds" toml:"ttl_in_seconds" env:"PERMISSION_CACHE_TTL_IN_SECONDS"`
+}
+
+func (pc PermissionCacheConfig) Enabled() bool {
+	return pc.MaxEntries > 0
+}
+
+func (pc PermissionCacheConfig) TTL() time.Duration {
+	return time.Duration(pc.TTLInSeconds) * time.Second
+}
+
+func (pc PermissionCacheConfig) validate() []error {
+	var errs []error
+
+	if pc.MaxEntries < 0 {
+		errs = append(errs, errors.New("permission_cache.max_entries must not be negative"))
+	}
+	if pc.Enabled() && pc.TTLInSeconds == 0 {
+		errs = append(errs, errors.New("permission_cache.ttl_in_seconds must be positive when cache is enabled"))
+	}
+
+	return errs
+}
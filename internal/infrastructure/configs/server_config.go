@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+//Server is started with TLS only when both certificate and key files are set
+type ServerConfig struct {
+	Addr        string `yaml:"addr" toml:"addr" env:"SERVER_ADDR"`
+	TLSCertFile string `yaml:"tls_cert_file" toml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" toml:"tls_key_file" env:"TLS_KEY_FILE"`
+	//Metrics are served without authentication by separate listener, its port must not be exposed publicly
+	MetricsAddr string `yaml:"metrics_addr" toml:"metrics_addr" env:"METRICS_ADDR"`
+	//IPs or CIDRs of reverse proxies whose X-Forwarded-For header is trusted, client ip is used for lockout and sessions
+	//Empty list trusts no proxy, so ip of connection is used and forwarded headers can't be spoofed
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	//How long server waits for requests, websocket clients and transactions after SIGTERM
+	ShutdownTimeoutInSeconds uint `yaml:"shutdown_timeout_in_seconds" toml:"shutdown_timeout_in_seconds" env:"SHUTDOWN_TIMEOUT_IN_SECONDS"`
+	//How long readiness probe reports draining before server stops accepting connections,
+	//it must be longer than probe period of load balancer
+	DrainDelayInSeconds uint `yaml:"drain_delay_in_seconds" toml:"drain_delay_in_seconds" env:"DRAIN_DELAY_IN_SECONDS"`
+}
+
+func (sc ServerConfig) TLSEnabled() bool {
+	return sc.TLSCertFile != "" && sc.TLSKeyFile != ""
+}
+
+func (sc ServerConfig) ShutdownTimeout() time.Duration {
+	return time.Duration(sc.ShutdownTimeoutInSeconds) * time.Second
+}
+
+func (sc ServerConfig) DrainDelay() time.Duration {
+	return time.Duration(sc.DrainDelayInSeconds) * time.Second
+}
+
+func (sc ServerConfig) validate() []error {
+	var errs []error
+
+	if sc.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if sc.MetricsAddr == "" {
+		errs = append(errs, errors.New("server.metrics_addr is required"))
+	} else if sc.MetricsAddr == sc.Addr {
+		errs = append(errs, errors.New("server.metrics_addr must differ from server.addr"))
+	}
+	for _, proxy := range sc.TrustedProxies {
+		if net.ParseIP(proxy) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			errs = append(errs, errors.New("server.trusted_proxies must contain IPs or CIDRs, got "+proxy))
+		}
+	}
+	if sc.ShutdownTimeoutInSeconds == 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout_in_seconds must be positive"))
+	}
+
+	if (sc.TLSCertFile == "") != (sc.TLSKeyFile == "") {
+		errs = append(errs, errors.New("server.tls_cert_file and server.tls_key_file must be set together"))
+	}
+
+	for _, file := range []struct{ key, path string }{
+		{"server.tls_cert_file", sc.TLSCertFile},
+		{"server.tls_key_file", sc.TLSKeyFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			errs = append(errs, errors.New(file.key+" can't be read: "+err.Error()))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	TracingExporterNone     = "none"
+	TracingExporterStdout   = "stdout"
+	TracingExporterOTLPFile = "otlp-file"
+	TracingExporterOTLPGRPC = "otlp-grpc"
+	TracingExporterOTLPHTTP = "otlp-http"
+)
+
+type TracingConfig struct {
+	//One of none, stdout, otlp-file, otlp-grpc, otlp-http
+	Exporter    string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	ServiceName string `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME"`
+	//Spans of stdout and otlp-file exporters are appended to this file, stdout exporter writes to stdout if it is empty
+	File string `yaml:"file" toml:"file" env:"TRACING_FILE"`
+	//Collector address (localhost:4317 for grpc, localhost:4318 for http), OTEL_EXPORTER_OTLP_* variables are used if it is empty
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool   `yaml:"otlp_insecure" toml:"otlp_insecure" env:"TRACING_OTLP_INSECURE"`
+	//Share of traces that are started by this server and recorded, from 0 to 1
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+func (tc TracingConfig) Enabled() bool {
+	return tc.Exporter != TracingExporterNone
+}
+
+func (tc TracingConfig) validate() []error {
+	var errs []error
+
+	switch tc.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLPGRPC, TracingExporterOTLPHTTP:
+	case TracingExporterOTLPFile:
+		if tc.File == "" {
+			errs = append(errs, errors.New("tracing.file must be set for otlp-file exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be one of none, stdout, otlp-file, otlp-grpc, otlp-http, got %q", tc.Exporter))
+	}
+
+	if tc.Enabled() && tc.ServiceName == "" {
+		errs = append(errs, errors.New("tracing.service_name must be set"))
+	}
+
+	if tc.SampleRatio < 0 || tc.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", tc.SampleRatio))
+	}
+
+	return errs
+}
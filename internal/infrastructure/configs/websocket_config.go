@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+//Limits of websocket connections and of actions that are sent through them
+type WebSocketConfig struct {
+	//Max size of the connection's incoming messages
+	MaxMessageSizeInBytes int64 `yaml:"max_message_size_in_bytes" toml:"max_message_size_in_bytes" env:"WS_MAX_MESSAGE_SIZE_IN_BYTES"`
+	ReadBufferSize        int   `yaml:"read_buffer_size" toml:"read_buffer_size" env:"WS_READ_BUFFER_SIZE"`
+	WriteBufferSize       int   `yaml:"write_buffer_size" toml:"write_buffer_size" env:"WS_WRITE_BUFFER_SIZE"`
+	//Size of the client's queues of messages that are not sent or not handled yet
+	SendQueueSize    int `yaml:"send_queue_size" toml:"send_queue_size" env:"WS_SEND_QUEUE_SIZE"`
+	ReceiveQueueSize int `yaml:"receive_queue_size" toml:"receive_queue_size" env:"WS_RECEIVE_QUEUE_SIZE"`
+	//Connection is closed if pong is not received during this time, pings are sent a bit more often
+	PongWaitInSeconds uint `yaml:"pong_wait_in_seconds" toml:"pong_wait_in_seconds" env:"WS_PONG_WAIT_IN_SECONDS"`
+	//Token bucket of SEND_MESSAGE actions per user
+	SendMessageBurst                        int  `yaml:"send_message_burst" toml:"send_message_burst" env:"WS_SEND_MESSAGE_BURST"`
+	SendMessageRefillIntervalInMilliseconds uint `yaml:"send_message_refill_interval_in_milliseconds" toml:"send_message_refill_interval_in_milliseconds" env:"WS_SEND_MESSAGE_REFILL_INTERVAL_IN_MILLISECONDS"`
+}
+
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		MaxMessageSizeInBytes:                   1024,
+		ReadBufferSize:                          1024,
+		WriteBufferSize:                         1024,
+		SendQueueSize:                           256,
+		ReceiveQueueSize:                        256,
+		PongWaitInSeconds:                       60,
+		SendMessageBurst:                        10,
+		SendMessageRefillIntervalInMilliseconds: 500,
+	}
+}
+
+func (wc WebSocketConfig) PongWait() time.Duration {
+	return time.Duration(wc.PongWaitInSeconds) * time.Second
+}
+
+func (wc WebSocketConfig) PingPeriod() time.Duration {
+	return (wc.PongWait() * 9) / 10
+}
+
+func (wc WebSocketConfig) SendMessageRefillInterval() time.Duration {
+	return time.Duration(wc.SendMessageRefillIntervalInMilliseconds) * time.Millisecond
+}
+
+func (wc WebSocketConfig) validate() []error {
+	var errs []error
+
+	if wc.MaxMessageSizeInBytes <= 0 {
+		errs = append(errs, errors.New("websocket.max_message_size_in_bytes must be positive"))
+	}
+	if wc.ReadBufferSize <= 0 || wc.WriteBufferSize <= 0 {
+		errs = append(errs, errors.New("websocket.read_buffer_size and websocket.write_buffer_size must be positive"))
+	}
+	if wc.SendQueueSize <= 0 || wc.ReceiveQueueSize <= 0 {
+		errs = append(errs, errors.New("websocket.send_queue_size and websocket.receive_queue_size must be positive"))
+	}
+	if wc.PongWaitInSeconds == 0 {
+		errs = append(errs, errors.New("websocket.pong_wait_in_seconds must be positive"))
+	}
+	if wc.SendMessageBurst <= 0 || wc.SendMessageRefillIntervalInMilliseconds == 0 {
+		errs = append(errs, errors.New("websocket.send_message_burst and websocket.send_message_refill_interval_in_milliseconds must be positive"))
+	}
+
+	return errs
+}
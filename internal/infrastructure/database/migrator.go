@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//Key of advisory lock that is held while migrations are applied,
+//so replicas that start at the same time don't migrate database twice
+const migrationLockKey int64 = 4_612_001_732_190_458_221
+
+var ErrDirtyMigration = errors.New("database is dirty, failed migration must be fixed manually and dirty flag in schema_migrations must be reset")
+
+//Migrator applies migrations in the same table as golang-migrate, so databases that were migrated by it keep working
+//Every migration is applied in its own transaction together with the new version
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+type MigrationState struct {
+	Migration
+	Applied bool
+}
+
+type MigrationStatus struct {
+	Version    uint
+	Dirty      bool
+	Migrations []MigrationState
+}
+
+func NewMigrator(db *sql.DB, source fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+//Method applies all migrations that are newer than version of the database and returns them
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+
+			if err := applyMigration(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+//Method rolls back steps newest applied migrations and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, errors.New("number of migrations to roll back must be positive")
+	}
+
+	var rolledBack []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		index := m.indexOf(version)
+		if version != 0 && index == -1 {
+			return fmt.Errorf("database is at version %d that is not known to this binary", version)
+		}
+
+		for ; steps > 0 && index >= 0; steps, index = steps-1, index-1 {
+			migration := m.migrations[index]
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			var previousVersion uint
+			if index > 0 {
+				previousVersion = m.migrations[index-1].Version
+			}
+
+			if err := applyMigration(ctx, conn, migration.Down, previousVersion); err != nil {
+				return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			rolledBack = append(rolledBack, migration)
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+//Method tells version of the database and which migrations are applied
+//It only reads, so it doesn't wait for the migration lock and doesn't create schema_migrations
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	var status MigrationStatus
+
+	var tableExists bool
+	if err := m.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&tableExists); err != nil {
+		return status, fmt.Errorf("failed to check schema_migrations table: %w", err)
+	}
+
+	//Database without the table has no migrations applied
+	if tableExists {
+		version, dirty, err := CurrentMigrationVersion(ctx, m.db)
+		if err != nil {
+			return status, err
+		}
+		status.Version, status.Dirty = version, dirty
+	}
+
+	for _, migration := range m.migrations {
+		status.Migrations = append(status.Migrations, MigrationState{
+			Migration: migration,
+			Applied:   migration.Version <= status.Version,
+		})
+	}
+
+	return status, nil
+}
+
+func (m *Migrator) indexOf(version uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+//Advisory lock belongs to the session, so everything is done on one connection
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	defer func() {
+		//Lock is released even if ctx is cancelled, otherwise connection is dropped so lock is not left in the pool
+		if _, unlockErr := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockKey); unlockErr != nil {
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+			err = errors.Join(err, fmt.Errorf("failed to release migration lock: %w", unlockErr))
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func currentVersion(ctx context.Context, conn *sql.Conn) (uint, error) {
+	version, dirty, err := CurrentMigrationVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w (version %d)", ErrDirtyMigration, version)
+	}
+	return version, nil
+}
+
+//Statements of the file and the new version are committed together, version 0 means that no migrations are applied
+func applyMigration(ctx context.Context, conn *sql.Conn, statements string, newVersion uint) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//Query without arguments is sent as simple query, so file can have several statements
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if newVersion != 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, newVersion); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+//Function creates empty up and down files of the next migration in dir and returns their paths
+func CreateMigration(dir string, name string) (string, string, error) {
+	name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("migration name must contain only letters, digits and underscores, got %q", name)
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("migrations directory %s does not exist", dir)
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	var version uint = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", version, name))
+	upPath, downPath := base+".up.sql", base+".down.sql"
+
+	for _, path := range []string{upPath, downPath} {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create migration file: %w", err)
+		}
+		file.Close()
+	}
+
+	return upPath, downPath, nil
+}
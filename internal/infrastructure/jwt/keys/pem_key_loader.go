@@ -0,0 +1,94 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"symphony_chat/internal/domain/jwt"
+)
+
+const (
+	privateKeySuffix = ".pem"
+	publicKeySuffix  = ".pub.pem"
+)
+
+//Function loads keys from directory, name of the file without suffix is id (kid) of the key
+//  <kid>.pem     - private key (PKCS8 or PKCS1), it can sign and verify tokens
+//  <kid>.pub.pem - public key (PKIX), retired key that only verifies tokens until they expire
+//Key with signingKeyID is used for signing new tokens
+func LoadKeySet(dir string, signingKeyID string) (jwt.KeySet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return jwt.KeySet{}, fmt.Errorf("failed to read jwt keys directory: %w", err)
+	}
+
+	var signingKeys []jwt.SigningKey
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), privateKeySuffix) {
+			continue
+		}
+
+		key, err := loadKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return jwt.KeySet{}, fmt.Errorf("failed to load jwt key %s: %w", entry.Name(), err)
+		}
+
+		signingKeys = append(signingKeys, key)
+	}
+
+	return jwt.NewKeySet(signingKeyID, signingKeys...)
+}
+
+func loadKey(path string) (jwt.SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return jwt.SigningKey{}, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return jwt.SigningKey{}, errors.New("file doesn't contain pem block")
+	}
+
+	name := filepath.Base(path)
+
+	if strings.HasSuffix(name, publicKeySuffix) {
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return jwt.SigningKey{}, err
+		}
+
+		return jwt.NewVerificationKey(strings.TrimSuffix(name, publicKeySuffix), publicKey)
+	}
+
+	var privateKey crypto.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return jwt.SigningKey{}, err
+	}
+
+	return jwt.NewSigningKey(strings.TrimSuffix(name, privateKeySuffix), privateKey)
+}
+
+//Function generates key set with one Ed25519 key that lives only in memory
+//Tokens signed with it become invalid after restart, so it is used only when keys are not configured
+func GenerateEphemeralKeySet() (jwt.KeySet, error) {
+	const ephemeralKeyID = "ephemeral"
+
+	key, err := jwt.GenerateSigningKey(ephemeralKeyID)
+	if err != nil {
+		return jwt.KeySet{}, err
+	}
+
+	return jwt.NewKeySet(ephemeralKeyID, key)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"symphony_chat/internal/application/transaction"
 	"symphony_chat/internal/domain/jwt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -26,8 +27,8 @@ func (pr *PostgresJWTtokenRepo) AddJWTtoken(ctx context.Context, token jwt.JWTto
 
 	_, err := tx.ExecContext(
 		ctx,
-		"INSERT INTO jwt_token (auth_user_id, token) VALUES ($1, $2)",
-		token.GetAuthUserID(), token.GetToken(),
+		"INSERT INTO jwt_token (auth_user_id, token, family_id) VALUES ($1, $2, $3)",
+		token.GetAuthUserID(), token.GetToken(), token.GetFamilyID(),
 	)
 	if err != nil {
 		return &jwt.TokenError{
@@ -43,13 +44,18 @@ func (pr *PostgresJWTtokenRepo) AddJWTtoken(ctx context.Context, token jwt.JWTto
 func (pr *PostgresJWTtokenRepo) GetJWTtoken(ctx context.Context, userID uuid.UUID) (jwt.JWTtoken, error) {
 	var authUserID uuid.UUID
 	var token string
+	var familyID uuid.UUID
 
 	tx := pr.GetTransaction(ctx)
 
 	err := tx.QueryRowContext(
 		ctx,
-		"SELECT auth_user_id, token FROM jwt_token WHERE auth_user_id = $1", userID,
-	).Scan(&authUserID, &token)
+		`SELECT auth_user_id, token, family_id FROM jwt_token
+		WHERE auth_user_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1`,
+		userID,
+	).Scan(&authUserID, &token, &familyID)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -63,22 +69,113 @@ func (pr *PostgresJWTtokenRepo) GetJWTtoken(ctx context.Context, userID uuid.UUI
 		}
 	}
 
-	return jwt.FromDB(authUserID, token), nil
+	return jwt.FromDB(authUserID, token, familyID), nil
 }
 
-func (pr *PostgresJWTtokenRepo) UpdateJWTtoken(ctx context.Context, authUserID uuid.UUID, newToken string) error {
-	
+func (pr *PostgresJWTtokenRepo) GetStoredRefreshToken(ctx context.Context, refreshToken string) (jwt.StoredRefreshToken, error) {
+	var authUserID uuid.UUID
+	var token string
+	var familyID uuid.UUID
+	var createdAt time.Time
+	var rotatedAt sql.NullTime
+	var revokedAt sql.NullTime
+
+	tx := pr.GetTransaction(ctx)
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT auth_user_id, token, family_id, created_at, rotated_at, revoked_at
+		FROM jwt_token WHERE token = $1
+		FOR UPDATE`,
+		refreshToken,
+	).Scan(&authUserID, &token, &familyID, &createdAt, &rotatedAt, &revokedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return jwt.StoredRefreshToken{}, jwt.ErrTokenNotFound
+		}
+
+		return jwt.StoredRefreshToken{}, &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get stored refresh token",
+			Err: err,
+		}
+	}
+
+	return jwt.StoredRefreshTokenFromDB(
+		jwt.FromDB(authUserID, token, familyID),
+		createdAt,
+		rotatedAt.Time,
+		revokedAt.Time,
+	), nil
+}
+
+func (pr *PostgresJWTtokenRepo) MarkJWTtokenRotated(ctx context.Context, token string, rotatedAt time.Time) error {
+
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE jwt_token SET rotated_at = $1 WHERE token = $2 AND rotated_at IS NULL",
+		rotatedAt, token,
+	)
+	if err != nil {
+		return &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to mark jwt_token as rotated",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get affected rows after marking jwt_token as rotated",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return jwt.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+func (pr *PostgresJWTtokenRepo) RevokeJWTtokenFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error {
+
 	tx := pr.GetTransaction(ctx)
 
 	_, err := tx.ExecContext(
 		ctx,
-		"UPDATE jwt_token SET token = $1 WHERE auth_user_id = $2",
-		newToken, authUserID,
+		"UPDATE jwt_token SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+		revokedAt, familyID,
 	)
 	if err != nil {
 		return &jwt.TokenError{
 			Code: "DATABASE_ERROR",
-			Message: "failed to update jwt_token",
+			Message: "failed to revoke jwt_token family",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresJWTtokenRepo) RevokeAllJWTtokensOfUser(ctx context.Context, authUserID uuid.UUID, revokedAt time.Time) error {
+
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		"UPDATE jwt_token SET revoked_at = $1 WHERE auth_user_id = $2 AND revoked_at IS NULL",
+		revokedAt, authUserID,
+	)
+	if err != nil {
+		return &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to revoke all jwt_tokens of user",
 			Err: err,
 		}
 	}
@@ -106,6 +203,34 @@ func (pr *PostgresJWTtokenRepo) DeleteJWTtoken(ctx context.Context, authUserID u
 	return nil
 }
 
+func (pr *PostgresJWTtokenRepo) DeleteExpiredJWTtokens(ctx context.Context, createdBefore time.Time) (int64, error) {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM jwt_token WHERE created_at < $1",
+		createdBefore,
+	)
+	if err != nil {
+		return 0, &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete expired jwt_token",
+			Err: err,
+		}
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get count of deleted jwt_token",
+			Err: err,
+		}
+	}
+
+	return deleted, nil
+}
+
 func (pr *PostgresJWTtokenRepo) GetTransaction(ctx context.Context) transaction.DBTX {
 	if tx := transaction.IsTransaction(ctx); tx != nil {
 		return tx
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/jwt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresRevokedTokenRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresRevokedTokenRepo(db *sql.DB) *PostgresRevokedTokenRepo {
+	return &PostgresRevokedTokenRepo{
+		db: db,
+	}
+}
+
+func (pr *PostgresRevokedTokenRepo) AddRevokedToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO revoked_access_token (token_id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (token_id) DO NOTHING`,
+		tokenID, expiresAt,
+	)
+	if err != nil {
+		return &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add revoked access token",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresRevokedTokenRepo) GetRevokedTokens(ctx context.Context, now time.Time) (map[uuid.UUID]time.Time, error) {
+	tx := pr.GetTransaction(ctx)
+
+	rows, err := tx.QueryContext(
+		ctx,
+		"SELECT token_id, expires_at FROM revoked_access_token WHERE expires_at > $1",
+		now,
+	)
+	if err != nil {
+		return nil, &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get revoked access tokens",
+			Err: err,
+		}
+	}
+	defer rows.Close()
+
+	revokedTokens := make(map[uuid.UUID]time.Time)
+	for rows.Next() {
+		var tokenID uuid.UUID
+		var expiresAt time.Time
+
+		if err := rows.Scan(&tokenID, &expiresAt); err != nil {
+			return nil, &jwt.TokenError{
+				Code: "DATABASE_ERROR",
+				Message: "failed to scan revoked access token",
+				Err: err,
+			}
+		}
+
+		revokedTokens[tokenID] = expiresAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to iterate over revoked access tokens",
+			Err: err,
+		}
+	}
+
+	return revokedTokens, nil
+}
+
+func (pr *PostgresRevokedTokenRepo) DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) (int64, error) {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM revoked_access_token WHERE expires_at <= $1",
+		now,
+	)
+	if err != nil {
+		return 0, &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete expired revoked access tokens",
+			Err: err,
+		}
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, &jwt.TokenError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get count of deleted revoked access tokens",
+			Err: err,
+		}
+	}
+
+	return deleted, nil
+}
+
+func (pr *PostgresRevokedTokenRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return pr.db
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	config "symphony_chat/internal/infrastructure/configs"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Keys of attributes that are carried by context
+const (
+	RequestIDKey    = "request_id"
+	ConnectionIDKey = "connection_id"
+	UserIDKey       = "user_id"
+	ChatIDKey       = "chat_id"
+	TraceIDKey      = "trace_id"
+	SpanIDKey       = "span_id"
+)
+
+type ctxKey struct{}
+
+//Function returns context whose log records get attrs
+//Attribute with the same key replaces the previous one (chat id of the next websocket message)
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing := attrsFromContext(ctx)
+
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, attr := range existing {
+		if !containsKey(attrs, attr.Key) {
+			merged = append(merged, attr)
+		}
+	}
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, ctxKey{}, merged)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+func containsKey(attrs []slog.Attr, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+//ContextHandler adds attributes of context to every record, so slog.InfoContext(ctx, ...)
+//in handlers, services and repositories is logged with request, connection, user and chat ids
+//Ids of recorded span are added too, so log lines can be found by trace
+type ContextHandler struct {
+	slog.Handler
+}
+
+func (h ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.AddAttrs(attrsFromContext(ctx)...)
+
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsSampled() {
+			record.AddAttrs(
+				slog.String(TraceIDKey, spanContext.TraceID().String()),
+				slog.String(SpanIDKey, spanContext.SpanID().String()),
+			)
+		}
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return ContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h ContextHandler) WithGroup(name string) slog.Handler {
+	return ContextHandler{h.Handler.WithGroup(name)}
+}
+
+//Config was validated by config.Load, so unknown level and format are not expected here
+func NewLogger(w io.Writer, logConfig config.LogConfig) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level: logConfig.SlogLevel(),
+	}
+
+	var handler slog.Handler
+	if logConfig.Format == config.LogFormatText {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(ContextHandler{handler})
+}
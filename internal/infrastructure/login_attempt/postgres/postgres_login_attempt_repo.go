@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"symphony_chat/internal/application/transaction"
+	loginattempt "symphony_chat/internal/domain/login_attempt"
+	"time"
+)
+
+type PostgresLoginAttemptRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresLoginAttemptRepo(db *sql.DB) *PostgresLoginAttemptRepo {
+	return &PostgresLoginAttemptRepo{
+		db: db,
+	}
+}
+
+func (pr *PostgresLoginAttemptRepo) GetLoginAttempts(ctx context.Context, key loginattempt.AttemptKey) (loginattempt.LoginAttempts, error) {
+	return pr.getLoginAttempts(ctx, key, `SELECT failed_count, last_failed_at, locked_until
+		FROM login_attempt WHERE key_type = $1 AND key_value = $2`)
+}
+
+func (pr *PostgresLoginAttemptRepo) LockLoginAttempts(ctx context.Context, key loginattempt.AttemptKey) (loginattempt.LoginAttempts, error) {
+	tx := pr.GetTransaction(ctx)
+
+	//Row is created first, so concurrent failures of new key wait for each other on row lock
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO login_attempt (key_type, key_value) VALUES ($1, $2)
+		ON CONFLICT (key_type, key_value) DO NOTHING`,
+		key.Type, key.Value,
+	)
+	if err != nil {
+		return loginattempt.LoginAttempts{}, &loginattempt.LoginAttemptError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to create login attempts counter",
+			Err: err,
+		}
+	}
+
+	return pr.getLoginAttempts(ctx, key, `SELECT failed_count, last_failed_at, locked_until
+		FROM login_attempt WHERE key_type = $1 AND key_value = $2
+		FOR UPDATE`)
+}
+
+func (pr *PostgresLoginAttemptRepo) getLoginAttempts(ctx context.Context, key loginattempt.AttemptKey, query string) (loginattempt.LoginAttempts, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var failedCount int
+	var lastFailedAt sql.NullTime
+	var lockedUntil sql.NullTime
+
+	err := tx.QueryRowContext(ctx, query, key.Type, key.Value).Scan(&failedCount, &lastFailedAt, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return loginattempt.NewLoginAttempts(key), nil
+		}
+
+		return loginattempt.LoginAttempts{}, &loginattempt.LoginAttemptError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get login attempts",
+			Err: err,
+		}
+	}
+
+	return loginattempt.LoginAttemptsFromDB(key, failedCount, lastFailedAt.Time, lockedUntil.Time), nil
+}
+
+func (pr *PostgresLoginAttemptRepo) SaveLoginAttempts(ctx context.Context, attempts loginattempt.LoginAttempts) error {
+	tx := pr.GetTransaction(ctx)
+
+	var lockedUntil sql.NullTime
+	if !attempts.GetLockedUntil().IsZero() {
+		lockedUntil = sql.NullTime{Time: attempts.GetLockedUntil(), Valid: true}
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO login_attempt (key_type, key_value, failed_count, last_failed_at, locked_until)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key_type, key_value) DO UPDATE SET
+			failed_count = EXCLUDED.failed_count,
+			last_failed_at = EXCLUDED.last_failed_at,
+			locked_until = EXCLUDED.locked_until`,
+		attempts.GetKey().Type,
+		attempts.GetKey().Value,
+		attempts.GetFailedCount(),
+		attempts.GetLastFailedAt(),
+		lockedUntil,
+	)
+	if err != nil {
+		return &loginattempt.LoginAttemptError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to save login attempts",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresLoginAttemptRepo) DeleteLoginAttempts(ctx context.Context, key loginattempt.AttemptKey) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM login_attempt WHERE key_type = $1 AND key_value = $2",
+		key.Type, key.Value,
+	)
+	if err != nil {
+		return &loginattempt.LoginAttemptError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete login attempts",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+//Counters without failures are created by lock of successful attempts, they are deleted too
+func (pr *PostgresLoginAttemptRepo) DeleteStaleLoginAttempts(ctx context.Context, keyType loginattempt.KeyType, failedBefore time.Time, now time.Time) (int64, error) {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM login_attempt
+		WHERE key_type = $1
+			AND (last_failed_at IS NULL OR last_failed_at < $2)
+			AND (locked_until IS NULL OR locked_until <= $3)`,
+		keyType, failedBefore, now,
+	)
+	if err != nil {
+		return 0, &loginattempt.LoginAttemptError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete stale login attempts",
+			Err: err,
+		}
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, &loginattempt.LoginAttemptError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get count of deleted login attempts",
+			Err: err,
+		}
+	}
+
+	return deleted, nil
+}
+
+func (pr *PostgresLoginAttemptRepo) AddLockoutEvent(ctx context.Context, event loginattempt.LockoutEvent) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO login_lockout_event (id, key_type, key_value, failed_count, locked_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		event.GetID(),
+		event.GetKey().Type,
+		event.GetKey().Value,
+		event.GetFailedCount(),
+		event.GetLockedUntil(),
+		event.GetCreatedAt(),
+	)
+	if err != nil {
+		return &loginattempt.LoginAttemptError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add lockout event",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresLoginAttemptRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return pr.db
+}
@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "symphony_chat"
+
+//HubStats gives current state of websocket hub
+type HubStats interface {
+	ActiveClientsCount() int
+	ActiveChatsCount() int
+}
+
+//PermissionCacheStats gives current number of cached entries by cache name
+type PermissionCacheStats interface {
+	EntriesCount() map[string]int
+}
+
+//Metrics are exposed in prometheus format on /metrics
+//Methods can be called on nil *Metrics, so components that were created without metrics don't check it
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	chatActions          *prometheus.CounterVec
+	fanOutSize           prometheus.Histogram
+	sendBufferSaturation prometheus.Histogram
+	transactionDuration  *prometheus.HistogramVec
+
+	permissionCacheLookups       *prometheus.CounterVec
+	permissionCacheEvictions     *prometheus.CounterVec
+	permissionCacheInvalidations *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled HTTP requests by route, method and status code.",
+		}, []string{"method", "route", "status"}),
+
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+
+		chatActions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ws_chat_actions_total",
+			Help:      "Number of websocket messages handled by the hub by chat action and result.",
+		}, []string{"action", "result"}),
+
+		fanOutSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ws_event_fan_out_size",
+			Help:      "Number of clients that one chat event is sent to.",
+			Buckets:   []float64{0, 1, 2, 5, 10, 25, 50, 100, 250, 500, 1000},
+		}),
+
+		sendBufferSaturation: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ws_client_send_buffer_saturation_ratio",
+			Help:      "Fill ratio of client's send buffer when message is queued, 1 means that sending blocks.",
+			Buckets:   []float64{0, 0.1, 0.25, 0.5, 0.75, 0.9, 1},
+		}),
+
+		transactionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_transaction_duration_seconds",
+			Help:      "Duration of database transactions by result (commit, rollback, error).",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+
+		permissionCacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "permission_cache_lookups_total",
+			Help:      "Number of permission cache lookups by cache (role, membership) and result (hit, miss).",
+		}, []string{"cache", "result"}),
+
+		permissionCacheEvictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "permission_cache_evictions_total",
+			Help:      "Number of permission cache entries removed because cache is full or entry is expired.",
+		}, []string{"cache", "reason"}),
+
+		permissionCacheInvalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "permission_cache_invalidations_total",
+			Help:      "Number of permission cache invalidations by cache.",
+		}, []string{"cache"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.chatActions,
+		m.fanOutSize,
+		m.sendBufferSaturation,
+		m.transactionDuration,
+		m.permissionCacheLookups,
+		m.permissionCacheEvictions,
+		m.permissionCacheInvalidations,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+//Gauges are computed from hub state when metrics are scraped
+func (m *Metrics) RegisterHub(hub HubStats) error {
+	activeConnections := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_active_connections",
+		Help:      "Number of active websocket connections.",
+	}, func() float64 {
+		return float64(hub.ActiveClientsCount())
+	})
+
+	activeChats := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_hub_active_chats",
+		Help:      "Number of chats that have at least one connected member.",
+	}, func() float64 {
+		return float64(hub.ActiveChatsCount())
+	})
+
+	if err := m.registry.Register(activeConnections); err != nil {
+		return err
+	}
+	return m.registry.Register(activeChats)
+}
+
+//Gauge is computed from cache state when metrics are scraped
+func (m *Metrics) RegisterPermissionCache(cache PermissionCacheStats) error {
+	return m.registry.Register(&permissionCacheCollector{
+		cache: cache,
+		entries: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "permission_cache_entries"),
+			"Number of entries in permission cache by cache.",
+			[]string{"cache"}, nil,
+		),
+	})
+}
+
+//Pool stats (open, in use, idle connections, waits) are taken from sql.DB when metrics are scraped
+func (m *Metrics) RegisterDatabase(db *sql.DB, dbName string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+//Route is the pattern of the route (/users/:username), so ids don't create new series
+func (m *Metrics) ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveChatAction(action string, result string) {
+	if m == nil {
+		return
+	}
+
+	m.chatActions.WithLabelValues(action, result).Inc()
+}
+
+func (m *Metrics) ObserveFanOut(clientsCount int) {
+	if m == nil {
+		return
+	}
+
+	m.fanOutSize.Observe(float64(clientsCount))
+}
+
+func (m *Metrics) ObserveSendBufferSaturation(queued int, capacity int) {
+	if m == nil || capacity == 0 {
+		return
+	}
+
+	m.sendBufferSaturation.Observe(float64(queued) / float64(capacity))
+}
+
+func (m *Metrics) ObserveTransaction(result string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.transactionDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObservePermissionCacheLookup(cache string, hit bool) {
+	if m == nil {
+		return
+	}
+
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.permissionCacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+//Reason is capacity or expired
+func (m *Metrics) ObservePermissionCacheEviction(cache string, reason string) {
+	if m == nil {
+		return
+	}
+
+	m.permissionCacheEvictions.WithLabelValues(cache, reason).Inc()
+}
+
+func (m *Metrics) ObservePermissionCacheInvalidation(cache string) {
+	if m == nil {
+		return
+	}
+
+	m.permissionCacheInvalidations.WithLabelValues(cache).Inc()
+}
+
+type permissionCacheCollector struct {
+	cache   PermissionCacheStats
+	entries *prometheus.Desc
+}
+
+func (c *permissionCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+}
+
+func (c *permissionCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for cache, count := range c.cache.EntriesCount() {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(count), cache)
+	}
+}
@@ -0,0 +1,83 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"symphony_chat/internal/domain/notifications"
+	"sync"
+	"time"
+)
+
+//Body of notification is replaced with this value in the log, because it contains secrets (reset tokens)
+const redactedNotificationBody = "[REDACTED]"
+
+//LogNotifier writes notifications to the application log, it is used in development
+//Bodies of notifications are redacted unless logging of them is explicitly enabled
+type LogNotifier struct {
+	logBodies bool
+}
+
+func NewLogNotifier(logBodies bool) *LogNotifier {
+	return &LogNotifier{
+		logBodies: logBodies,
+	}
+}
+
+func (ln *LogNotifier) Notify(ctx context.Context, notification notifications.Notification) error {
+	body := redactedNotificationBody
+	if ln.logBodies {
+		body = notification.Body
+	}
+
+	slog.InfoContext(ctx, "notification", "recipient", notification.Recipient, "subject", notification.Subject, "body", body)
+	return nil
+}
+
+//FileNotifier appends notifications to the file as json lines, so tests can read them
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{
+		path: path,
+	}
+}
+
+type fileNotification struct {
+	Recipient string    `json:"recipient"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+func (fn *FileNotifier) Notify(ctx context.Context, notification notifications.Notification) error {
+	line, err := json.Marshal(fileNotification{
+		Recipient: notification.Recipient,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+		SentAt:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	file, err := os.OpenFile(fn.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open notifications file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/oidc"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresOIDCRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresOIDCRepo(db *sql.DB) *PostgresOIDCRepo {
+	return &PostgresOIDCRepo{
+		db: db,
+	}
+}
+
+func (or *PostgresOIDCRepo) AddLoginState(ctx context.Context, state oidc.LoginState) error {
+	tx := or.GetTransaction(ctx)
+
+	var linkAuthUserID uuid.NullUUID
+	if state.IsLink() {
+		linkAuthUserID = uuid.NullUUID{UUID: state.GetLinkAuthUserID(), Valid: true}
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO oidc_login_state (state_hash, provider, code_verifier, nonce, link_auth_user_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		state.GetStateHash(),
+		state.GetProvider(),
+		state.GetCodeVerifier(),
+		state.GetNonce(),
+		linkAuthUserID,
+		state.GetCreatedAt(),
+		state.GetExpiresAt(),
+	)
+	if err != nil {
+		return &oidc.OIDCError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add oidc login state",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (or *PostgresOIDCRepo) GetLoginStateByHash(ctx context.Context, stateHash string) (oidc.LoginState, error) {
+	tx := or.GetTransaction(ctx)
+
+	var storedHash string
+	var provider string
+	var codeVerifier string
+	var nonce string
+	var linkAuthUserID uuid.NullUUID
+	var createdAt time.Time
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT state_hash, provider, code_verifier, nonce, link_auth_user_id, created_at, expires_at, used_at
+		FROM oidc_login_state WHERE state_hash = $1
+		FOR UPDATE`,
+		stateHash,
+	).Scan(&storedHash, &provider, &codeVerifier, &nonce, &linkAuthUserID, &createdAt, &expiresAt, &usedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return oidc.LoginState{}, oidc.ErrLoginStateNotFound
+		}
+
+		return oidc.LoginState{}, &oidc.OIDCError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get oidc login state",
+			Err: err,
+		}
+	}
+
+	return oidc.LoginStateFromDB(storedHash, provider, codeVerifier, nonce, linkAuthUserID.UUID, createdAt, expiresAt, usedAt.Time), nil
+}
+
+func (or *PostgresOIDCRepo) MarkLoginStateUsed(ctx context.Context, stateHash string, usedAt time.Time) error {
+	tx := or.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE oidc_login_state SET used_at = $1 WHERE state_hash = $2 AND used_at IS NULL",
+		usedAt, stateHash,
+	)
+	if err != nil {
+		return &oidc.OIDCError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to mark oidc login state as used",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &oidc.OIDCError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to mark oidc login state as used",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return oidc.ErrLoginStateUsed
+	}
+
+	return nil
+}
+
+func (or *PostgresOIDCRepo) GetIdentity(ctx context.Context, provider string, subject string) (oidc.Identity, error) {
+	return or.getIdentity(
+		ctx,
+		`SELECT id, auth_user_id, provider, subject, email, created_at, last_login_at
+		FROM oidc_identity WHERE provider = $1 AND subject = $2`,
+		provider, subject,
+	)
+}
+
+func (or *PostgresOIDCRepo) GetIdentityOfUser(ctx context.Context, provider string, authUserID uuid.UUID) (oidc.Identity, error) {
+	return or.getIdentity(
+		ctx,
+		`SELECT id, auth_user_id, provider, subject, email, created_at, last_login_at
+		FROM oidc_identity WHERE provider = $1 AND auth_user_id = $2`,
+		provider, authUserID,
+	)
+}
+
+func (or *PostgresOIDCRepo) getIdentity(ctx context.Context, query string, args ...any) (oidc.Identity, error) {
+	tx := or.GetTransaction(ctx)
+
+	var id uuid.UUID
+	var authUserID uuid.UUID
+	var provider string
+	var subject string
+	var email string
+	var createdAt time.Time
+	var lastLoginAt time.Time
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&id, &authUserID, &provider, &subject, &email, &createdAt, &lastLoginAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return oidc.Identity{}, oidc.ErrIdentityNotFound
+		}
+
+		return oidc.Identity{}, &oidc.OIDCError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get oidc identity",
+			Err: err,
+		}
+	}
+
+	return oidc.IdentityFromDB(id, authUserID, provider, subject, email, createdAt, lastLoginAt), nil
+}
+
+func (or *PostgresOIDCRepo) AddIdentity(ctx context.Context, identity oidc.Identity) error {
+	tx := or.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO oidc_identity (id, auth_user_id, provider, subject, email, created_at, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		identity.GetID(),
+		identity.GetAuthUserID(),
+		identity.GetProvider(),
+		identity.GetSubject(),
+		identity.GetEmail(),
+		identity.GetCreatedAt(),
+		identity.GetLastLoginAt(),
+	)
+	if err != nil {
+		return &oidc.OIDCError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add oidc identity",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (or *PostgresOIDCRepo) UpdateIdentityLastLogin(ctx context.Context, id uuid.UUID, email string, lastLoginAt time.Time) error {
+	tx := or.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		"UPDATE oidc_identity SET email = $1, last_login_at = $2 WHERE id = $3",
+		email, lastLoginAt, id,
+	)
+	if err != nil {
+		return &oidc.OIDCError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update oidc identity",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (or *PostgresOIDCRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return or.db
+}
@@ -0,0 +1,392 @@
+package provider
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	domainJWT "symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+//Allowed clock difference between the server and identity provider
+const idTokenLeeway = time.Minute
+
+//Limit of response body of identity provider
+const maxResponseSize = 1 << 20
+
+type ProviderConfig struct {
+	//Name is used in urls (/oidc/:provider/login) and is stored with linked identities
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+//OIDCProvider implements authorization code flow with PKCE for OpenID Connect provider
+//Endpoints are taken from discovery document, signing keys of id tokens are loaded from jwks_uri
+//and are reloaded when id token is signed by unknown key
+type OIDCProvider struct {
+	config     ProviderConfig
+	httpClient *http.Client
+
+	authorizationEndpoint string
+	tokenEndpoint         string
+	jwksURI               string
+
+	mu   sync.RWMutex
+	keys map[string]domainJWT.SigningKey
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+//Function loads discovery document of the provider, issuer in the document must match configured issuer
+func NewOIDCProvider(ctx context.Context, config ProviderConfig, httpClient *http.Client) (*OIDCProvider, error) {
+	if config.Name == "" || config.IssuerURL == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("oidc provider name, issuer url, client id and redirect url are required")
+	}
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	op := &OIDCProvider{
+		config:     config,
+		httpClient: httpClient,
+		keys:       make(map[string]domainJWT.SigningKey),
+	}
+
+	var discovery discoveryDocument
+	discoveryURL := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := op.getJSON(ctx, discoveryURL, &discovery); err != nil {
+		return nil, fmt.Errorf("failed to load oidc discovery document: %w", err)
+	}
+
+	if discovery.Issuer != config.IssuerURL {
+		return nil, fmt.Errorf("oidc issuer mismatch: expected %s, got %s", config.IssuerURL, discovery.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document has no authorization, token or jwks endpoint")
+	}
+
+	op.authorizationEndpoint = discovery.AuthorizationEndpoint
+	op.tokenEndpoint = discovery.TokenEndpoint
+	op.jwksURI = discovery.JWKSURI
+
+	return op, nil
+}
+
+func (op *OIDCProvider) GetName() string {
+	return op.config.Name
+}
+
+func (op *OIDCProvider) GetAuthorizationURL(state string, nonce string, codeChallenge string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {op.config.ClientID},
+		"redirect_uri":          {op.config.RedirectURL},
+		"scope":                 {strings.Join(op.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(op.authorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return op.authorizationEndpoint + separator + query.Encode()
+}
+
+func (op *OIDCProvider) ExchangeCode(ctx context.Context, code string, codeVerifier string, nonce string) (oidc.Claims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {op.config.RedirectURL},
+		"client_id":     {op.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, op.tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oidc.Claims{}, codeExchangeError(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	//client_secret_basic, public clients rely on PKCE only
+	if op.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(op.config.ClientID), url.QueryEscape(op.config.ClientSecret))
+	}
+
+	res, err := op.httpClient.Do(req)
+	if err != nil {
+		return oidc.Claims{}, codeExchangeError(err)
+	}
+	defer res.Body.Close()
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(&tokens); err != nil {
+		return oidc.Claims{}, codeExchangeError(fmt.Errorf("token response with status %d cant be decoded: %w", res.StatusCode, err))
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return oidc.Claims{}, codeExchangeError(fmt.Errorf("token endpoint returned %d: %s %s", res.StatusCode, tokens.Error, tokens.ErrorDescription))
+	}
+
+	if tokens.IDToken == "" {
+		return oidc.Claims{}, codeExchangeError(errors.New("token response has no id_token"))
+	}
+
+	return op.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+//Method checks signature, issuer, audience, expiration and nonce of id token
+func (op *OIDCProvider) verifyIDToken(ctx context.Context, rawIDToken string, nonce string) (oidc.Claims, error) {
+	var claims idTokenClaims
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(op.config.IssuerURL),
+		jwt.WithAudience(op.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+
+	_, err := parser.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		key, err := op.getKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if key.GetMethod().Alg() != token.Method.Alg() {
+			return nil, errors.New("id token algorithm doesn't match key")
+		}
+
+		return key.GetPublicKey(), nil
+	})
+	if err != nil {
+		return oidc.Claims{}, invalidIDTokenError(err)
+	}
+
+	if claims.Subject == "" {
+		return oidc.Claims{}, invalidIDTokenError(errors.New("sub claim is empty"))
+	}
+
+	if claims.Nonce != nonce {
+		return oidc.Claims{}, invalidIDTokenError(errors.New("nonce doesn't match"))
+	}
+
+	//Token for several audiences must be issued to this client
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != op.config.ClientID {
+		return oidc.Claims{}, invalidIDTokenError(errors.New("azp claim doesn't match client id"))
+	}
+
+	return oidc.Claims{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+//Method returns key with this id, keys are reloaded once if key is unknown (provider rotated keys)
+func (op *OIDCProvider) getKey(ctx context.Context, kid string) (domainJWT.SigningKey, error) {
+	op.mu.RLock()
+	key, exists := op.findKey(kid)
+	op.mu.RUnlock()
+	if exists {
+		return key, nil
+	}
+
+	if err := op.loadKeys(ctx); err != nil {
+		return domainJWT.SigningKey{}, err
+	}
+
+	op.mu.RLock()
+	defer op.mu.RUnlock()
+
+	key, exists = op.findKey(kid)
+	if !exists {
+		return domainJWT.SigningKey{}, fmt.Errorf("unknown id token key id %q", kid)
+	}
+
+	return key, nil
+}
+
+//Token without kid can be verified only if provider has single key
+func (op *OIDCProvider) findKey(kid string) (domainJWT.SigningKey, bool) {
+	if kid == "" {
+		if len(op.keys) != 1 {
+			return domainJWT.SigningKey{}, false
+		}
+		for _, key := range op.keys {
+			return key, true
+		}
+	}
+
+	key, exists := op.keys[kid]
+	return key, exists
+}
+
+func (op *OIDCProvider) loadKeys(ctx context.Context) error {
+	var keySet jwks
+	if err := op.getJSON(ctx, op.jwksURI, &keySet); err != nil {
+		return fmt.Errorf("failed to load oidc jwks: %w", err)
+	}
+
+	keys := make(map[string]domainJWT.SigningKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := jwk.publicKey()
+		if err != nil {
+			//Keys of unsupported types are skipped, provider can publish several types
+			continue
+		}
+
+		//Verification key requires id, so key without kid gets placeholder id, in the map it is still stored under empty kid
+		id := jwk.Kid
+		if id == "" {
+			id = "default"
+		}
+
+		key, err := domainJWT.NewVerificationKey(id, publicKey)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	op.mu.Lock()
+	op.keys = keys
+	op.mu.Unlock()
+
+	return nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("wrong size of ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func (op *OIDCProvider) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := op.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(target)
+}
+
+func codeExchangeError(err error) error {
+	return &oidc.OIDCError{
+		Code: oidc.ErrCodeExchangeFailed.Code,
+		Message: oidc.ErrCodeExchangeFailed.Message,
+		Err: err,
+	}
+}
+
+func invalidIDTokenError(err error) error {
+	return &oidc.OIDCError{
+		Code: oidc.ErrInvalidIDToken.Code,
+		Message: oidc.ErrInvalidIDToken.Message,
+		Err: err,
+	}
+}
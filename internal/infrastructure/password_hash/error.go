@@ -0,0 +1,41 @@
+package passwordhash
+
+type PasswordError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *PasswordError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+var (
+	ErrPasswordTooShort = &PasswordError{
+		Code: "PASSWORD_TOO_SHORT",
+		Message: "password is too short",
+	}
+
+	ErrPasswordTooLong = &PasswordError{
+		Code: "PASSWORD_TOO_LONG",
+		Message: "password is too long",
+	}
+
+	ErrPasswordNotASCII = &PasswordError{
+		Code: "PASSWORD_CONTAINS_NON_ASCII",
+		Message: "password can contain only ascii characters",
+	}
+
+	ErrPasswordBanned = &PasswordError{
+		Code: "PASSWORD_TOO_COMMON",
+		Message: "password is too common",
+	}
+
+	ErrUnknownHashFormat = &PasswordError{
+		Code: "UNKNOWN_PASSWORD_HASH_FORMAT",
+		Message: "password hash has unknown format",
+	}
+)
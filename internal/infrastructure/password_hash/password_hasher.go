@@ -0,0 +1,170 @@
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//Parameters of Argon2id, they are written into every hash, so they can be changed without breaking old hashes
+type Argon2Params struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+//Parameters recommended by OWASP for Argon2id
+var DefaultArgon2Params = Argon2Params{
+	MemoryKiB:   64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const argon2idPrefix = "$argon2id$"
+
+//PasswordHasher creates Argon2id hashes in PHC string format and verifies Argon2id and legacy bcrypt hashes
+//Hash needs rehash if it is bcrypt hash or Argon2id hash with other parameters
+type PasswordHasher struct {
+	params Argon2Params
+}
+
+type PasswordHasherConfiguration func(*PasswordHasher) error
+
+func NewPasswordHasher(configs ...PasswordHasherConfiguration) (*PasswordHasher, error) {
+	ph := &PasswordHasher{
+		params: DefaultArgon2Params,
+	}
+
+	for _, cfg := range configs {
+		err := cfg(ph)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ph, nil
+}
+
+func WithArgon2Params(params Argon2Params) PasswordHasherConfiguration {
+	return func(ph *PasswordHasher) error {
+		if params.MemoryKiB == 0 || params.Iterations == 0 || params.Parallelism == 0 || params.SaltLength == 0 || params.KeyLength == 0 {
+			return &PasswordError{
+				Code: "INVALID_ARGON2_PARAMS",
+				Message: "argon2 parameters must be positive",
+			}
+		}
+
+		ph.params = params
+		return nil
+	}
+}
+
+//Function returns hasher with default parameters, it is used by services that were not configured with other hasher
+func DefaultPasswordHasher() *PasswordHasher {
+	return &PasswordHasher{
+		params: DefaultArgon2Params,
+	}
+}
+
+func (ph *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, ph.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", &PasswordError{
+			Code: "PASSWORD_HASHING_ERROR",
+			Message: "salt cant be generated",
+			Err: err,
+		}
+	}
+
+	key := argon2.IDKey([]byte(password), salt, ph.params.Iterations, ph.params.MemoryKiB, ph.params.Parallelism, ph.params.KeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		ph.params.MemoryKiB,
+		ph.params.Iterations,
+		ph.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+//Method checks password against hash, malformed hash never matches
+//needsRehash is true when password matched, but hash was not created with current algorithm and parameters
+func (ph *PasswordHasher) Verify(password string, hash string) (matched bool, needsRehash bool) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		params, salt, key, err := decodeArgon2idHash(hash)
+		if err != nil {
+			return false, false
+		}
+
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.MemoryKiB, params.Parallelism, params.KeyLength)
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false
+		}
+
+		current := ph.params
+		return true, params.MemoryKiB != current.MemoryKiB ||
+			params.Iterations != current.Iterations ||
+			params.Parallelism != current.Parallelism ||
+			params.SaltLength != current.SaltLength ||
+			params.KeyLength != current.KeyLength
+
+	case isBcryptHash(hash):
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return false, false
+		}
+		return true, true
+
+	default:
+		return false, false
+	}
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+//Function parses $argon2id$v=19$m=65536,t=3,p=2$salt$key
+func decodeArgon2idHash(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.MemoryKiB, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,99 @@
+package passwordhash
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//PasswordPolicy describes which passwords can be set
+//Length is counted in characters, not bytes
+type PasswordPolicy struct {
+	MinLength       int
+	MaxLength       int
+	AllowUnicode    bool
+	bannedPasswords map[string]struct{}
+}
+
+//Banned passwords are compared regardless of case
+func NewPasswordPolicy(minLength int, maxLength int, allowUnicode bool, bannedPasswords []string) PasswordPolicy {
+	banned := make(map[string]struct{}, len(bannedPasswords))
+	for _, password := range bannedPasswords {
+		if password = strings.TrimSpace(password); password != "" {
+			banned[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	return PasswordPolicy{
+		MinLength:       minLength,
+		MaxLength:       maxLength,
+		AllowUnicode:    allowUnicode,
+		bannedPasswords: banned,
+	}
+}
+
+//Default lengths are recommended by NIST, unicode passwords are allowed
+func DefaultPasswordPolicy() PasswordPolicy {
+	return NewPasswordPolicy(10, 128, true, nil)
+}
+
+func (pp PasswordPolicy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+
+	if length < pp.MinLength {
+		return ErrPasswordTooShort
+	}
+
+	if pp.MaxLength > 0 && length > pp.MaxLength {
+		return ErrPasswordTooLong
+	}
+
+	if !pp.AllowUnicode {
+		for _, char := range password {
+			if char > unicode.MaxASCII {
+				return ErrPasswordNotASCII
+			}
+		}
+	}
+
+	if _, banned := pp.bannedPasswords[strings.ToLower(password)]; banned {
+		return ErrPasswordBanned
+	}
+
+	return nil
+}
+
+//Function reads banned passwords from file, one password per line, lines starting with # are skipped
+func LoadBannedPasswords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &PasswordError{
+			Code: "BANNED_PASSWORDS_LOADING_ERROR",
+			Message: "banned passwords file cant be opened",
+			Err: err,
+		}
+	}
+	defer file.Close()
+
+	var passwords []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, &PasswordError{
+			Code: "BANNED_PASSWORDS_LOADING_ERROR",
+			Message: "banned passwords file cant be read",
+			Err: err,
+		}
+	}
+
+	return passwords, nil
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"symphony_chat/internal/application/transaction"
+	passwordreset "symphony_chat/internal/domain/password_reset"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresPasswordResetRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresPasswordResetRepo(db *sql.DB) *PostgresPasswordResetRepo {
+	return &PostgresPasswordResetRepo{
+		db: db,
+	}
+}
+
+func (pr *PostgresPasswordResetRepo) AddPasswordResetToken(ctx context.Context, token passwordreset.PasswordResetToken) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO password_reset_token (id, auth_user_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		token.GetID(),
+		token.GetAuthUserID(),
+		token.GetTokenHash(),
+		token.GetCreatedAt(),
+		token.GetExpiresAt(),
+	)
+	if err != nil {
+		return &passwordreset.PasswordResetError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add password reset token",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresPasswordResetRepo) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (passwordreset.PasswordResetToken, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var id uuid.UUID
+	var authUserID uuid.UUID
+	var storedHash string
+	var createdAt time.Time
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT id, auth_user_id, token_hash, created_at, expires_at, used_at
+		FROM password_reset_token WHERE token_hash = $1
+		FOR UPDATE`,
+		tokenHash,
+	).Scan(&id, &authUserID, &storedHash, &createdAt, &expiresAt, &usedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return passwordreset.PasswordResetToken{}, passwordreset.ErrResetTokenNotFound
+		}
+
+		return passwordreset.PasswordResetToken{}, &passwordreset.PasswordResetError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get password reset token",
+			Err: err,
+		}
+	}
+
+	return passwordreset.PasswordResetTokenFromDB(id, authUserID, storedHash, createdAt, expiresAt, usedAt.Time), nil
+}
+
+func (pr *PostgresPasswordResetRepo) MarkPasswordResetTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE password_reset_token SET used_at = $1 WHERE id = $2 AND used_at IS NULL",
+		usedAt, id,
+	)
+	if err != nil {
+		return &passwordreset.PasswordResetError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to mark password reset token as used",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &passwordreset.PasswordResetError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to mark password reset token as used",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return passwordreset.ErrResetTokenUsed
+	}
+
+	return nil
+}
+
+func (pr *PostgresPasswordResetRepo) InvalidatePasswordResetTokensOfUser(ctx context.Context, authUserID uuid.UUID, invalidatedAt time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE password_reset_token SET expires_at = $1
+		WHERE auth_user_id = $2 AND used_at IS NULL AND expires_at > $1`,
+		invalidatedAt, authUserID,
+	)
+	if err != nil {
+		return &passwordreset.PasswordResetError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to invalidate password reset tokens of user",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresPasswordResetRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return pr.db
+}
@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/sessions"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresSessionRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresSessionRepo(db *sql.DB) *PostgresSessionRepo {
+	return &PostgresSessionRepo{
+		db: db,
+	}
+}
+
+func (pr *PostgresSessionRepo) AddSession(ctx context.Context, session sessions.Session) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO auth_session (id, auth_user_id, user_agent, ip, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		session.GetID(),
+		session.GetAuthUserID(),
+		session.GetUserAgent(),
+		session.GetIP(),
+		session.GetCreatedAt(),
+		session.GetLastUsedAt(),
+	)
+	if err != nil {
+		return &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add session",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresSessionRepo) GetSessionByID(ctx context.Context, sessionID uuid.UUID) (sessions.Session, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var id uuid.UUID
+	var authUserID uuid.UUID
+	var userAgent string
+	var ip string
+	var createdAt time.Time
+	var lastUsedAt time.Time
+	var revokedAt sql.NullTime
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT id, auth_user_id, user_agent, ip, created_at, last_used_at, revoked_at
+		FROM auth_session WHERE id = $1`,
+		sessionID,
+	).Scan(&id, &authUserID, &userAgent, &ip, &createdAt, &lastUsedAt, &revokedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sessions.Session{}, sessions.ErrSessionNotFound
+		}
+
+		return sessions.Session{}, &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get session by id",
+			Err: err,
+		}
+	}
+
+	return sessions.SessionFromDB(id, authUserID, userAgent, ip, createdAt, lastUsedAt, revokedAt.Time), nil
+}
+
+func (pr *PostgresSessionRepo) GetActiveSessionsOfUser(ctx context.Context, authUserID uuid.UUID) ([]sessions.Session, error) {
+	tx := pr.GetTransaction(ctx)
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, user_agent, ip, created_at, last_used_at
+		FROM auth_session
+		WHERE auth_user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_used_at DESC`,
+		authUserID,
+	)
+	if err != nil {
+		return nil, &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get sessions of user",
+			Err: err,
+		}
+	}
+
+	defer rows.Close()
+
+	foundSessions := make([]sessions.Session, 0)
+
+	for rows.Next() {
+		var id uuid.UUID
+		var userAgent string
+		var ip string
+		var createdAt time.Time
+		var lastUsedAt time.Time
+
+		if err := rows.Scan(&id, &userAgent, &ip, &createdAt, &lastUsedAt); err != nil {
+			return nil, &sessions.SessionError{
+				Code: "DATABASE_ERROR",
+				Message: "failed to scan session",
+				Err: err,
+			}
+		}
+
+		foundSessions = append(foundSessions, sessions.SessionFromDB(id, authUserID, userAgent, ip, createdAt, lastUsedAt, time.Time{}))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to iterate over sessions",
+			Err: err,
+		}
+	}
+
+	return foundSessions, nil
+}
+
+func (pr *PostgresSessionRepo) UpdateSessionLastUsed(ctx context.Context, sessionID uuid.UUID, lastUsedAt time.Time, ip string) error {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE auth_session SET last_used_at = $1, ip = $2 WHERE id = $3",
+		lastUsedAt, ip, sessionID,
+	)
+	if err != nil {
+		return &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update session last_used_at",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get affected rows after updated session",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return sessions.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (pr *PostgresSessionRepo) RevokeSession(ctx context.Context, sessionID uuid.UUID, revokedAt time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE auth_session SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL",
+		revokedAt, sessionID,
+	)
+	if err != nil {
+		return &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to revoke session",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get affected rows after revoked session",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return sessions.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (pr *PostgresSessionRepo) RevokeOtherSessionsOfUser(ctx context.Context, authUserID uuid.UUID, exceptSessionID uuid.UUID, revokedAt time.Time) ([]uuid.UUID, error) {
+	tx := pr.GetTransaction(ctx)
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`UPDATE auth_session SET revoked_at = $1
+		WHERE auth_user_id = $2 AND id <> $3 AND revoked_at IS NULL
+		RETURNING id`,
+		revokedAt, authUserID, exceptSessionID,
+	)
+	if err != nil {
+		return nil, &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to revoke other sessions of user",
+			Err: err,
+		}
+	}
+
+	defer rows.Close()
+
+	revokedSessionIDs := make([]uuid.UUID, 0)
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, &sessions.SessionError{
+				Code: "DATABASE_ERROR",
+				Message: "failed to scan revoked session id",
+				Err: err,
+			}
+		}
+
+		revokedSessionIDs = append(revokedSessionIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to iterate over revoked sessions",
+			Err: err,
+		}
+	}
+
+	return revokedSessionIDs, nil
+}
+
+func (pr *PostgresSessionRepo) DeleteSessionsOfUser(ctx context.Context, authUserID uuid.UUID) ([]uuid.UUID, error) {
+	tx := pr.GetTransaction(ctx)
+
+	rows, err := tx.QueryContext(
+		ctx,
+		"DELETE FROM auth_session WHERE auth_user_id = $1 RETURNING id",
+		authUserID,
+	)
+	if err != nil {
+		return nil, &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete sessions of user",
+			Err: err,
+		}
+	}
+
+	defer rows.Close()
+
+	deletedSessionIDs := make([]uuid.UUID, 0)
+
+	for rows.Next() {
+		var id uuid.UUID
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, &sessions.SessionError{
+				Code: "DATABASE_ERROR",
+				Message: "failed to scan deleted session id",
+				Err: err,
+			}
+		}
+
+		deletedSessionIDs = append(deletedSessionIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &sessions.SessionError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to iterate over deleted sessions",
+			Err: err,
+		}
+	}
+
+	return deletedSessionIDs, nil
+}
+
+func (pr *PostgresSessionRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return pr.db
+}
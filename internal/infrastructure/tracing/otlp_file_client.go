@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+//fileClient writes every batch of spans as one line of OTLP JSON (ExportTraceServiceRequest),
+//the format is read by otlpjsonfile receiver of OpenTelemetry Collector, so traces can be viewed later
+type fileClient struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ otlptrace.Client = (*fileClient)(nil)
+
+func newFileClient(file *os.File) *fileClient {
+	return &fileClient{file: file}
+}
+
+func (fc *fileClient) Start(ctx context.Context) error {
+	return nil
+}
+
+func (fc *fileClient) Stop(ctx context.Context) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	return fc.file.Close()
+}
+
+func (fc *fileClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	line, err := marshalOTLPJSON(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+	line = append(line, '\n')
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if _, err := fc.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write spans to trace file: %w", err)
+	}
+	return nil
+}
+
+//OTLP JSON differs from protojson: enums are numbers and trace and span ids are hex strings instead of base64
+func marshalOTLPJSON(request *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	//Numbers are kept as they are, float64 would lose precision
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	if err := hexEncodeIDs(document); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(document)
+}
+
+//Ids are in spans and their links
+func hexEncodeIDs(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			switch key {
+			case "traceId", "spanId", "parentSpanId":
+				encoded, ok := field.(string)
+				if !ok {
+					continue
+				}
+				id, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil {
+					return fmt.Errorf("invalid %s: %w", key, err)
+				}
+				v[key] = hex.EncodeToString(id)
+			default:
+				if err := hexEncodeIDs(field); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := hexEncodeIDs(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
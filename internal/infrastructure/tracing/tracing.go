@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	config "symphony_chat/internal/infrastructure/configs"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Name of instrumentation scope of spans that are created by the server itself
+const instrumentationName = "symphony_chat"
+
+//Function sets global tracer provider and W3C trace context propagator
+//Returned function flushes spans that were not exported yet and stops exporter
+//If exporter is none, only propagator is set and spans are not recorded
+func Setup(ctx context.Context, tracingConfig config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !tracingConfig.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeOutput, err := newExporter(ctx, tracingConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(semconv.ServiceName(tracingConfig.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		//Decision of the caller is respected, so traces are not broken in the middle
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(tracingConfig.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeOutput())
+	}, nil
+}
+
+//Returned function closes file that exporter writes to
+func newExporter(ctx context.Context, tracingConfig config.TracingConfig) (sdktrace.SpanExporter, func() error, error) {
+	noClose := func() error { return nil }
+
+	switch tracingConfig.Exporter {
+	case config.TracingExporterStdout:
+		var output io.Writer = os.Stdout
+		closeOutput := noClose
+		if tracingConfig.File != "" {
+			file, err := openTraceFile(tracingConfig.File)
+			if err != nil {
+				return nil, nil, err
+			}
+			output, closeOutput = file, file.Close
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(output))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+		return exporter, closeOutput, nil
+
+	case config.TracingExporterOTLPFile:
+		file, err := openTraceFile(tracingConfig.File)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		//File is closed when exporter is shut down
+		exporter, err := otlptrace.New(ctx, newFileClient(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to create otlp file trace exporter: %w", err)
+		}
+		return exporter, noClose, nil
+
+	case config.TracingExporterOTLPGRPC:
+		var options []otlptracegrpc.Option
+		if tracingConfig.OTLPEndpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(tracingConfig.OTLPEndpoint))
+		}
+		if tracingConfig.OTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+
+		exporter, err := otlptracegrpc.New(ctx, options...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create otlp grpc trace exporter: %w", err)
+		}
+		return exporter, noClose, nil
+
+	case config.TracingExporterOTLPHTTP:
+		var options []otlptracehttp.Option
+		if tracingConfig.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(tracingConfig.OTLPEndpoint))
+		}
+		if tracingConfig.OTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create otlp http trace exporter: %w", err)
+		}
+		return exporter, noClose, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q", tracingConfig.Exporter)
+	}
+}
+
+func openTraceFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	return file, nil
+}
+
+//Function starts span of the server, it is a child of the span in ctx
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+//Function marks span as failed, nil error is ignored
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
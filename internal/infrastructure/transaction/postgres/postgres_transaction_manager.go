@@ -5,28 +5,65 @@ import (
 	"database/sql"
 	"fmt"
 	"symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/infrastructure/metrics"
+	"symphony_chat/internal/infrastructure/tracing"
+	"symphony_chat/utils/shutdown"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type PostgresTransactionManager struct {
 	db *sql.DB
+	//Active transactions are awaited before database is closed
+	transactions shutdown.Gate
+	metrics *metrics.Metrics
 }
 
-func NewPostgresTransactionManager(db *sql.DB) *PostgresTransactionManager {
-	return &PostgresTransactionManager{
+type TransactionManagerConfiguration func(*PostgresTransactionManager)
+
+//Durations of transactions are observed only if metrics are set
+func WithMetrics(m *metrics.Metrics) TransactionManagerConfiguration {
+	return func(ptm *PostgresTransactionManager) {
+		ptm.metrics = m
+	}
+}
+
+func NewPostgresTransactionManager(db *sql.DB, configs ...TransactionManagerConfiguration) *PostgresTransactionManager {
+	ptm := &PostgresTransactionManager{
 		db: db,
 	}
+
+	for _, cfg := range configs {
+		cfg(ptm)
+	}
+
+	return ptm
 }
 
 func (ptm *PostgresTransactionManager) WithinTransaction(ctx context.Context, txFunc func(txCtx context.Context) error) error {
+	if !ptm.transactions.Enter() {
+		return transaction.ErrorTransactionManagerClosed
+	}
+	defer ptm.transactions.Leave()
+
+	start := time.Now()
+
+	//Queries of the transaction are children of this span
+	ctx, span := tracing.Start(ctx, "WithinTransaction")
+	defer span.End()
 
 	tx, err := ptm.db.BeginTx(ctx, nil)
 	if err != nil {
+		tracing.RecordError(span, err)
 		return transaction.ErrorCancelledContext
 	}
 
 	defer func() {
 		if p := recover(); p != nil {
 			tx.Rollback()
+			ptm.metrics.ObserveTransaction("rollback", time.Since(start))
+			span.SetAttributes(attribute.String("db.transaction.result", "rollback"))
 			panic(p)
 		}
 	}()
@@ -36,12 +73,25 @@ func (ptm *PostgresTransactionManager) WithinTransaction(ctx context.Context, tx
 	err = txFunc(txCtx)
 	if err != nil {
 		tx.Rollback()
+		ptm.metrics.ObserveTransaction("rollback", time.Since(start))
+		span.SetAttributes(attribute.String("db.transaction.result", "rollback"))
+		tracing.RecordError(span, err)
 		return err
 	}
 
 	if err = tx.Commit(); err != nil {
+		ptm.metrics.ObserveTransaction("error", time.Since(start))
+		span.SetAttributes(attribute.String("db.transaction.result", "error"))
+		tracing.RecordError(span, err)
 		return fmt.Errorf("%w: %w", transaction.ErrorCommitTx, err)
 	}
-	
+
+	ptm.metrics.ObserveTransaction("commit", time.Since(start))
+	span.SetAttributes(attribute.String("db.transaction.result", "commit"))
 	return nil
 }
+
+//Method is used during graceful shutdown: new transactions are rejected and active ones are awaited
+func (ptm *PostgresTransactionManager) Close(ctx context.Context) error {
+	return ptm.transactions.Close(ctx)
+}
@@ -0,0 +1,292 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"symphony_chat/internal/application/transaction"
+	twofactor "symphony_chat/internal/domain/two_factor"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresTwoFactorRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresTwoFactorRepo(db *sql.DB) *PostgresTwoFactorRepo {
+	return &PostgresTwoFactorRepo{
+		db: db,
+	}
+}
+
+func (pr *PostgresTwoFactorRepo) GetTwoFactor(ctx context.Context, authUserID uuid.UUID) (twofactor.TwoFactor, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var secret string
+	var enabledAt sql.NullTime
+	var lastUsedStep int64
+	var createdAt time.Time
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT secret, enabled_at, last_used_step, created_at
+		FROM two_factor WHERE auth_user_id = $1
+		FOR UPDATE`,
+		authUserID,
+	).Scan(&secret, &enabledAt, &lastUsedStep, &createdAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return twofactor.TwoFactor{}, twofactor.ErrTwoFactorNotEnrolled
+		}
+
+		return twofactor.TwoFactor{}, &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get two factor",
+			Err: err,
+		}
+	}
+
+	return twofactor.TwoFactorFromDB(authUserID, secret, enabledAt.Time, lastUsedStep, createdAt), nil
+}
+
+func (pr *PostgresTwoFactorRepo) SaveTwoFactor(ctx context.Context, twoFactor twofactor.TwoFactor) error {
+	tx := pr.GetTransaction(ctx)
+
+	var enabledAt sql.NullTime
+	if twoFactor.IsEnabled() {
+		enabledAt = sql.NullTime{Time: twoFactor.GetEnabledAt(), Valid: true}
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO two_factor (auth_user_id, secret, enabled_at, last_used_step, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (auth_user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			enabled_at = EXCLUDED.enabled_at,
+			last_used_step = EXCLUDED.last_used_step,
+			created_at = EXCLUDED.created_at`,
+		twoFactor.GetAuthUserID(),
+		twoFactor.GetSecret(),
+		enabledAt,
+		twoFactor.GetLastUsedStep(),
+		twoFactor.GetCreatedAt(),
+	)
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to save two factor",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) DeleteTwoFactor(ctx context.Context, authUserID uuid.UUID) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(ctx, "DELETE FROM two_factor WHERE auth_user_id = $1", authUserID)
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete two factor",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) AddRecoveryCodes(ctx context.Context, codes []twofactor.RecoveryCode) error {
+	tx := pr.GetTransaction(ctx)
+
+	for _, code := range codes {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO two_factor_recovery_code (id, auth_user_id, code_hash, created_at)
+			VALUES ($1, $2, $3, $4)`,
+			code.GetID(),
+			code.GetAuthUserID(),
+			code.GetCodeHash(),
+			code.GetCreatedAt(),
+		)
+		if err != nil {
+			return &twofactor.TwoFactorError{
+				Code: "DATABASE_ERROR",
+				Message: "failed to add recovery code",
+				Err: err,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) DeleteRecoveryCodesOfUser(ctx context.Context, authUserID uuid.UUID) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(ctx, "DELETE FROM two_factor_recovery_code WHERE auth_user_id = $1", authUserID)
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete recovery codes",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) UseRecoveryCode(ctx context.Context, authUserID uuid.UUID, codeHash string, usedAt time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE two_factor_recovery_code SET used_at = $1
+		WHERE auth_user_id = $2 AND code_hash = $3 AND used_at IS NULL`,
+		usedAt, authUserID, codeHash,
+	)
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to use recovery code",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to use recovery code",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return twofactor.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) AddChallenge(ctx context.Context, challenge twofactor.Challenge) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO two_factor_challenge (id, auth_user_id, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		challenge.GetID(),
+		challenge.GetAuthUserID(),
+		challenge.GetTokenHash(),
+		challenge.GetCreatedAt(),
+		challenge.GetExpiresAt(),
+	)
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add two factor challenge",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) GetChallengeByHash(ctx context.Context, tokenHash string) (twofactor.Challenge, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var id uuid.UUID
+	var authUserID uuid.UUID
+	var storedHash string
+	var createdAt time.Time
+	var expiresAt time.Time
+	var failedAttempts int
+	var usedAt sql.NullTime
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT id, auth_user_id, token_hash, created_at, expires_at, failed_attempts, used_at
+		FROM two_factor_challenge WHERE token_hash = $1
+		FOR UPDATE`,
+		tokenHash,
+	).Scan(&id, &authUserID, &storedHash, &createdAt, &expiresAt, &failedAttempts, &usedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return twofactor.Challenge{}, twofactor.ErrChallengeNotFound
+		}
+
+		return twofactor.Challenge{}, &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get two factor challenge",
+			Err: err,
+		}
+	}
+
+	return twofactor.ChallengeFromDB(id, authUserID, storedHash, createdAt, expiresAt, failedAttempts, usedAt.Time), nil
+}
+
+func (pr *PostgresTwoFactorRepo) IncrementChallengeFailedAttempts(ctx context.Context, id uuid.UUID) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		"UPDATE two_factor_challenge SET failed_attempts = failed_attempts + 1 WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update two factor challenge",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) MarkChallengeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE two_factor_challenge SET used_at = $1 WHERE id = $2 AND used_at IS NULL",
+		usedAt, id,
+	)
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to mark two factor challenge as used",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &twofactor.TwoFactorError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to mark two factor challenge as used",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return twofactor.ErrChallengeUsed
+	}
+
+	return nil
+}
+
+func (pr *PostgresTwoFactorRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return pr.db
+}
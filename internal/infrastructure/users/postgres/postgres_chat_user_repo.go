@@ -1,12 +1,22 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"errors"
+	"strings"
+	"symphony_chat/internal/application/transaction"
 	"symphony_chat/internal/domain/users"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+//Postgres error code of unique constraint violation and name of unique constraint of username
+const (
+	uniqueViolationCode      = "23505"
+	usernameUniqueConstraint = "chat_user_username_key"
 )
 
 type PostgresChatUserRepo struct {
@@ -19,100 +29,259 @@ func NewPostgresChatUserRepo(db *sql.DB) *PostgresChatUserRepo {
 	}
 }
 
-func (pr *PostgresChatUserRepo) GetChatUserById(chat_user_id uuid.UUID) (users.ChatUser, error) {
+func (pr *PostgresChatUserRepo) GetChatUserByID(ctx context.Context, chat_user_id uuid.UUID) (users.ChatUser, error) {
 	var id uuid.UUID
 	var username string
 	var status users.UserStatus
 	var created_at time.Time
 	var last_seen_at time.Time
 
-	err := pr.db.QueryRow(
+	tx := pr.GetTransaction(ctx)
+
+	err := tx.QueryRowContext(
+		ctx,
 		"SELECT id, username, status, created_at, last_seen_at FROM chat_user WHERE id = $1",
 		chat_user_id,
 	).Scan(&id, &username, &status, &created_at, &last_seen_at)
 
 	if err != nil {
-		return users.ChatUser{}, fmt.Errorf("failed to get chat_user by id: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return users.ChatUser{}, users.ErrChatUserNotFound
+		}
+
+		return users.ChatUser{}, &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get chat_user by id",
+			Err: err,
+		}
 	}
 
 	return users.ChatUserFromDB(id, username, status, created_at, last_seen_at), nil
 }
 
-func (pr *PostgresChatUserRepo) GetChatUserByUsername(chat_user_username string) (users.ChatUser, error) {
+func (pr *PostgresChatUserRepo) GetChatUserByUsername(ctx context.Context, chat_user_username string) (users.ChatUser, error) {
 	var id uuid.UUID
 	var username string
 	var status users.UserStatus
 	var created_at time.Time
 	var last_seen_at time.Time
 
-	err := pr.db.QueryRow(
+	tx := pr.GetTransaction(ctx)
+
+	err := tx.QueryRowContext(
+		ctx,
 		"SELECT id, username, status, created_at, last_seen_at FROM chat_user WHERE username = $1",
 		chat_user_username,
 	).Scan(&id, &username, &status, &created_at, &last_seen_at)
 
 	if err != nil {
-		return users.ChatUser{}, fmt.Errorf("failed to get chat_user by username: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return users.ChatUser{}, users.ErrChatUserNotFound
+		}
+
+		return users.ChatUser{}, &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to get chat_user by username",
+			Err: err,
+		}
 	}
 
 	return users.ChatUserFromDB(id, username, status, created_at, last_seen_at), nil
 }
 
-func (pr *PostgresChatUserRepo) AddChatUser(chat_user users.ChatUser) error {
-	_, err := pr.db.Exec(
+func (pr *PostgresChatUserRepo) AddChatUser(ctx context.Context, chat_user users.ChatUser) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
 		"INSERT INTO chat_user (id, username, status, created_at, last_seen_at) VALUES ($1, $2, $3, $4, $5)",
 		chat_user.GetID(), chat_user.GetUsername(), chat_user.GetStatus(), chat_user.GetCreatedAt(), chat_user.GetLastSeenAt(),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to add chat_user: %w", err)
+		if isUsernameUniqueViolation(err) {
+			return users.ErrUsernameAlreadyTaken
+		}
+
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to add chat_user",
+			Err: err,
+		}
 	}
 
 	return nil
 }
 
-func (pr *PostgresChatUserRepo) DeleteChatUserByID(chat_user_id uuid.UUID) error {
-	_, err := pr.db.Exec(
+func (pr *PostgresChatUserRepo) DeleteChatUserByID(ctx context.Context, chat_user_id uuid.UUID) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
 		"DELETE FROM chat_user WHERE id = $1",
 		chat_user_id,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to delete chat_user: %w", err)
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to delete chat_user",
+			Err: err,
+		}
 	}
 
 	return nil
 }
 
-func (pr *PostgresChatUserRepo) UpdateUsername(chat_user_id uuid.UUID, new_username string) error {
-	_, err := pr.db.Exec(
+func (pr *PostgresChatUserRepo) UpdateUsername(ctx context.Context, chat_user_id uuid.UUID, new_username string) error {
+	tx := pr.GetTransaction(ctx)
+
+	result, err := tx.ExecContext(
+		ctx,
 		"UPDATE chat_user SET username = $1 WHERE id = $2",
 		new_username, chat_user_id,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update username for chat_user: %w", err)
+		if isUsernameUniqueViolation(err) {
+			return users.ErrUsernameAlreadyTaken
+		}
+
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update username for chat_user",
+			Err: err,
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update username for chat_user",
+			Err: err,
+		}
+	}
+
+	if rowsAffected == 0 {
+		return users.ErrChatUserNotFound
 	}
 
 	return nil
 }
 
-func (pr *PostgresChatUserRepo) UpdateStatus(chat_user_id uuid.UUID, new_status users.UserStatus) error {
-	_, err := pr.db.Exec(
+func (pr *PostgresChatUserRepo) UpdateStatus(ctx context.Context, chat_user_id uuid.UUID, new_status users.UserStatus) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
 		"UPDATE chat_user SET status = $1 WHERE id = $2",
 		new_status, chat_user_id,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update status for chat_user: %w", err)
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update status for chat_user",
+			Err: err,
+		}
 	}
 
 	return nil
 }
 
-func (pr *PostgresChatUserRepo) UpdateLastSeenAt(chat_user_id uuid.UUID, new_last_seen_at time.Time) error {
-	_, err := pr.db.Exec(
+func (pr *PostgresChatUserRepo) UpdateLastSeenAt(ctx context.Context, chat_user_id uuid.UUID, new_last_seen_at time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
 		"UPDATE chat_user SET last_seen_at = $1 WHERE id = $2",
 		new_last_seen_at, chat_user_id,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update last_seen_at for chat_user: %w", err)
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to update last_seen_at for chat_user",
+			Err: err,
+		}
 	}
 
 	return nil
 }
+
+func (pr *PostgresChatUserRepo) SearchChatUsers(ctx context.Context, query users.UserSearchQuery) ([]users.ChatUser, error) {
+	tx := pr.GetTransaction(ctx)
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT cu.id, cu.username, cu.status, cu.created_at, cu.last_seen_at
+		FROM chat_user cu
+		WHERE (cu.username LIKE $1 ESCAPE '\' OR cu.username % $2)
+			AND cu.id <> $3
+			AND cu.id <> $4
+			AND NOT EXISTS (
+				SELECT 1 FROM user_block ub WHERE ub.blocker_id = cu.id AND ub.blocked_id = $3
+			)
+		ORDER BY (cu.username LIKE $1 ESCAPE '\') DESC, similarity(cu.username, $2) DESC, cu.username
+		LIMIT $5 OFFSET $6`,
+		escapeLikePattern(query.Query) + "%",
+		query.Query,
+		query.SearcherID,
+		users.DeletedChatUser.GetID(),
+		query.Limit,
+		query.Offset,
+	)
+	if err != nil {
+		return nil, &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to search chat_users",
+			Err: err,
+		}
+	}
+	defer rows.Close()
+
+	var chatUsers []users.ChatUser
+	for rows.Next() {
+		var id uuid.UUID
+		var username string
+		var status users.UserStatus
+		var created_at time.Time
+		var last_seen_at time.Time
+
+		if err := rows.Scan(&id, &username, &status, &created_at, &last_seen_at); err != nil {
+			return nil, &users.AuthError{
+				Code: "DATABASE_ERROR",
+				Message: "failed to scan chat_user",
+				Err: err,
+			}
+		}
+
+		chatUsers = append(chatUsers, users.ChatUserFromDB(id, username, status, created_at, last_seen_at))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to search chat_users",
+			Err: err,
+		}
+	}
+
+	return chatUsers, nil
+}
+
+//Function that gets transaction from context
+//If there is no transaction in context, it returns pr.db
+func (pr *PostgresChatUserRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return pr.db
+}
+
+func isUsernameUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode && pqErr.Constraint == usernameUniqueConstraint
+}
+
+//Function escapes wildcards of LIKE, underscore is allowed in usernames
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/users"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PostgresUserBlockRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresUserBlockRepo(db *sql.DB) *PostgresUserBlockRepo {
+	return &PostgresUserBlockRepo{
+		db: db,
+	}
+}
+
+func (pr *PostgresUserBlockRepo) BlockUser(ctx context.Context, blockerID uuid.UUID, blockedID uuid.UUID, blockedAt time.Time) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO user_block (blocker_id, blocked_id, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING`,
+		blockerID, blockedID, blockedAt,
+	)
+	if err != nil {
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to block user",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresUserBlockRepo) UnblockUser(ctx context.Context, blockerID uuid.UUID, blockedID uuid.UUID) error {
+	tx := pr.GetTransaction(ctx)
+
+	_, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM user_block WHERE blocker_id = $1 AND blocked_id = $2",
+		blockerID, blockedID,
+	)
+	if err != nil {
+		return &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to unblock user",
+			Err: err,
+		}
+	}
+
+	return nil
+}
+
+func (pr *PostgresUserBlockRepo) IsBlocked(ctx context.Context, blockerID uuid.UUID, blockedID uuid.UUID) (bool, error) {
+	tx := pr.GetTransaction(ctx)
+
+	var blocked bool
+	err := tx.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM user_block WHERE blocker_id = $1 AND blocked_id = $2)",
+		blockerID, blockedID,
+	).Scan(&blocked)
+	if err != nil {
+		return false, &users.AuthError{
+			Code: "DATABASE_ERROR",
+			Message: "failed to check user block",
+			Err: err,
+		}
+	}
+
+	return blocked, nil
+}
+
+func (pr *PostgresUserBlockRepo) GetTransaction(ctx context.Context) transaction.DBTX {
+	if tx := transaction.IsTransaction(ctx); tx != nil {
+		return tx
+	}
+
+	return pr.db
+}
@@ -19,4 +19,12 @@ var (
 		Code:    "ACTIVE_CLIENT_NOT_FOUND",
 		Message: "active client not found",
 	}
+	ErrUnknownChatAction = &HubError{
+		Code:    "UNKNOWN_CHAT_ACTION",
+		Message: "unknown chat action",
+	}
+	ErrInvalidSlowModeInterval = &HubError{
+		Code:    "INVALID_SLOW_MODE_INTERVAL",
+		Message: "slow_mode_interval_seconds must be a non-negative whole number of seconds",
+	}
 )
\ No newline at end of file
@@ -3,15 +3,26 @@ package chathub
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"math"
+	"symphony_chat/internal/domain/chat"
 	actions "symphony_chat/internal/domain/chat_actions"
+	"symphony_chat/internal/domain/messages"
+	config "symphony_chat/internal/infrastructure/configs"
+	"symphony_chat/internal/infrastructure/logging"
+	"symphony_chat/internal/infrastructure/metrics"
+	"symphony_chat/internal/infrastructure/tracing"
 	"symphony_chat/internal/infrastructure/websocket/client"
 	websocketmessage "symphony_chat/internal/infrastructure/websocket/websocket_message"
 	"symphony_chat/internal/service/chat"
+	"symphony_chat/utils/shutdown"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 
@@ -24,13 +35,26 @@ type Hub struct {
 
 	chatService *service.ChatService
 
+	//Limiter of SEND_MESSAGE actions per user
+	messageLimiter *MessageRateLimiter
+
+	//Messages that are being handled, they are awaited during graceful shutdown
+	handling shutdown.Gate
+
+	metrics *metrics.Metrics
+
 	mu sync.RWMutex
 }
 
-func NewHub(chatService *service.ChatService) *Hub {
+//User can make limits.SendMessageBurst SEND_MESSAGE actions in a row
+//and gets one more action back every limits.SendMessageRefillInterval()
+func NewHub(chatService *service.ChatService, limits config.WebSocketConfig, m *metrics.Metrics) *Hub {
 	return &Hub {
+		metrics: m,
 		activeClients: make(map[uuid.UUID]*client.Client),
 		activeChats: make(map[uuid.UUID]map[uuid.UUID]*client.Client),
+		chatService: chatService,
+		messageLimiter: NewMessageRateLimiter(limits.SendMessageBurst, limits.SendMessageRefillInterval()),
 	}
 }
 
@@ -60,6 +84,24 @@ func (h *Hub) GetActiveClient(userID uuid.UUID) *client.Client {
 	return h.activeClients[userID]
 }
 
+func (h *Hub) ActiveClientsCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.activeClients)
+}
+
+func (h *Hub) ActiveChatsCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.activeChats)
+}
+
+//Method tells if user has active websocket connection
+func (h *Hub) IsUserOnline(userID uuid.UUID) bool {
+	activeClient := h.GetActiveClient(userID)
+	return activeClient != nil && activeClient.IsStillConnected()
+}
+
 func (h *Hub) GetActiveClientsOfChat(chatID uuid.UUID) []*client.Client {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -72,7 +114,10 @@ func (h *Hub) GetActiveClientsOfChat(chatID uuid.UUID) []*client.Client {
 
 //This is the general method for sending events to clients of the 
 func (h *Hub) SendWsEventToChatClients(chatID uuid.UUID, clients []*client.Client, wsEvent websocketmessage.WsClientEvent) {
+	h.invalidateCachedPermissions(chatID, wsEvent)
+
 	wsEventBytes, _ := json.Marshal(wsEvent)
+	h.metrics.ObserveFanOut(len(clients))
 
 	for _, client := range clients {
 		if client.IsStillConnected() {
@@ -81,6 +126,33 @@ func (h *Hub) SendWsEventToChatClients(chatID uuid.UUID, clients []*client.Clien
 	}
 }
 
+//Membership events invalidate cached permissions of the affected user too,
+//so the cache doesn't depend only on ChatService methods invalidating it
+func (h *Hub) invalidateCachedPermissions(chatID uuid.UUID, wsEvent websocketmessage.WsClientEvent) {
+	var userIDKey string
+	switch wsEvent.EventType {
+	case actions.UserLeftChatEvent:
+		userIDKey = "user_id"
+	case actions.UserEnteredChatEvent:
+		userIDKey = "invited_user_id"
+	case actions.UserWasKickedFromChatEvent:
+		userIDKey = "kicked_user_id"
+	case actions.UserWasPromotedToChatAdminEvent:
+		userIDKey = "promoted_user_id"
+	case actions.UserWasDemotedFromChatAdminEvent:
+		userIDKey = "demoted_user_id"
+	default:
+		return
+	}
+
+	userID, ok := wsEvent.Payload[userIDKey].(uuid.UUID)
+	if !ok {
+		h.chatService.InvalidateCachedPermissionsOfChat(chatID)
+		return
+	}
+	h.chatService.InvalidateCachedPermissions(chatID, userID)
+}
+
 //This is the general method for sending response to the client's request
 func (h *Hub) SendWsResponseToClient(client *client.Client, wsResponse websocketmessage.WsMessageResponse) {
 	wsResponseBytes, _ := json.Marshal(wsResponse)
@@ -120,18 +192,57 @@ func (h *Hub) RemoveActiveClient(client *client.Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	chatIDs, err := h.chatService.GetChatsOfUser(context.Background(), client.GetID())
+	chatIDs, err := h.chatService.GetChatsOfUser(client.Context(), client.GetID())
 	if err != nil {
-		//TODO: handle error
+		//Client must not stay in active chats, so all of them are checked
+		slog.ErrorContext(client.Context(), "failed to get chats of disconnected user, removing client from all active chats", "error", err)
+		for chatID := range h.activeChats {
+			h.removeClientFromActiveChat(chatID, client.GetID())
+		}
 	}
 
 	for _, chatID := range chatIDs {
-		delete(h.activeChats[chatID.GetID()], client.GetID())
-		if len(h.activeChats[chatID.GetID()]) == 0 {
-			delete(h.activeChats, chatID.GetID())
-		}
+		h.removeClientFromActiveChat(chatID.GetID(), client.GetID())
 	}
 	delete(h.activeClients, client.GetID())
+	h.messageLimiter.Forget(client.GetID(), time.Now())
+}
+
+//Chat without clients is removed from active chats, caller must hold the lock
+func (h *Hub) removeClientFromActiveChat(chatID uuid.UUID, userID uuid.UUID) {
+	delete(h.activeChats[chatID], userID)
+	if len(h.activeChats[chatID]) == 0 {
+		delete(h.activeChats, chatID)
+	}
+}
+
+//This method closes connections that were opened with revoked access token or in revoked session
+//Denylist doesn't tell whether id is jti or sid, ids are random uuids, so both are compared
+func (h *Hub) DisconnectClientsByRevokedID(revokedID uuid.UUID) {
+	h.mu.RLock()
+	var revokedClients []*client.Client
+	for _, activeClient := range h.activeClients {
+		if activeClient.GetAccessTokenID() == revokedID || activeClient.GetSessionID() == revokedID {
+			revokedClients = append(revokedClients, activeClient)
+		}
+	}
+	h.mu.RUnlock()
+
+	//Closing removes client from hub, so it is done without lock
+	for _, revokedClient := range revokedClients {
+		revokedClient.CloseConnection()
+	}
+}
+
+//This method closes connection of the user (used when account is deleted)
+func (h *Hub) DisconnectClientsOfUser(userID uuid.UUID) {
+	h.mu.RLock()
+	activeClient, exists := h.activeClients[userID]
+	h.mu.RUnlock()
+
+	if exists {
+		activeClient.CloseConnection()
+	}
 }
 
 //This method needs to be used when active client creates new chat
@@ -146,28 +257,140 @@ func (h *Hub) RemoveActiveChat(chatID uuid.UUID) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	delete(h.activeChats, chatID)
+	h.chatService.InvalidateCachedPermissionsOfChat(chatID)
+}
+
+//This method sends FAILED response with time after which client can retry his action
+func (h *Hub) SendRateLimitResponseToClient(client *client.Client, chatID uuid.UUID, rateLimitErr *messages.ChatMessageRateLimitError) {
+	wsRes := websocketmessage.WsMessageResponse {
+		ChatActionResult: actions.Failed,
+		Payload: map[string]interface{} {
+			"chat_id": chatID,
+			"error": rateLimitErr.Message,
+			"code": rateLimitErr.Code,
+			"retry_after_ms": rateLimitErr.RetryAfter.Milliseconds(),
+		},
+	}
+
+	h.SendWsResponseToClient(client, wsRes)
+}
+
+//This method sends FAILED response with code of the error to the client
+func (h *Hub) SendErrorResponseToClient(client *client.Client, chatID uuid.UUID, hubErr *HubError) {
+	wsRes := websocketmessage.WsMessageResponse {
+		ChatActionResult: actions.Failed,
+		Payload: map[string]interface{} {
+			"chat_id": chatID,
+			"error": hubErr.Message,
+			"code": hubErr.Code,
+		},
+	}
+
+	h.SendWsResponseToClient(client, wsRes)
+}
+
+//Function reads slow mode interval from payload of SET_CHAT_SLOW_MODE message
+//Interval must be a non-negative whole number of seconds, its upper bound is checked by ChatService
+func ParseSlowModeInterval(payload map[string]interface{}) (time.Duration, error) {
+	intervalSeconds, ok := payload["slow_mode_interval_seconds"].(float64)
+	if !ok || intervalSeconds < 0 || intervalSeconds != math.Trunc(intervalSeconds) {
+		return 0, ErrInvalidSlowModeInterval
+	}
+
+	//Bigger values would overflow duration, they are rejected by ChatService anyway
+	if intervalSeconds > chat.MaxSlowModeInterval.Seconds() {
+		return 0, chat.ErrWrongSlowModeInterval
+	}
+
+	return time.Duration(intervalSeconds) * time.Second, nil
+}
+
+//This method stops handling of new messages, waits for messages that are being handled
+//and then closes connections of all clients with "going away" close frame
+//Connections that were not closed until context is done are closed without flushing
+func (h *Hub) Shutdown(ctx context.Context, reason string) error {
+	handlingErr := h.handling.Close(ctx)
+
+	h.mu.RLock()
+	activeClients := make([]*client.Client, 0, len(h.activeClients))
+	for _, activeClient := range h.activeClients {
+		activeClients = append(activeClients, activeClient)
+	}
+	h.mu.RUnlock()
+
+	//Closing removes client from hub, so it is done without lock
+	for _, activeClient := range activeClients {
+		activeClient.CloseGoingAway(reason)
+	}
+
+	for _, activeClient := range activeClients {
+		select {
+		case <-activeClient.Closed():
+		case <-ctx.Done():
+			activeClient.CloseConnection()
+		}
+	}
+
+	if handlingErr != nil {
+		return handlingErr
+	}
+	return ctx.Err()
 }
 
 //This method handles messages from clients
-func (h *Hub) HandleMessage(message []byte) {
+//Messages that are received during graceful shutdown are dropped
+//Context of the connection is extended with chat id of the message, so logs of services and repositories carry it
+func (h *Hub) HandleMessage(ctx context.Context, message []byte) {
+	if !h.handling.Enter() {
+		return
+	}
+	defer h.handling.Leave()
+
 	var msg websocketmessage.WsMessageRequest 
 	if err := json.Unmarshal(message, &msg); err != nil {
-		log.Printf("error unmarshalling message: %v", err)
+		slog.WarnContext(ctx, "failed to unmarshal websocket message", "error", err)
+		h.metrics.ObserveChatAction(invalidChatActionLabel, string(actions.Failed))
 		return
 	}
 
+	//Connection can live for hours, so every action starts its own trace that is linked to the handshake request
+	ctx, span := tracing.Start(ctx, "websocket "+chatActionLabel(msg.ChatAction),
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("chat.action", chatActionLabel(msg.ChatAction))),
+	)
+	defer span.End()
+
+	if chatID, ok := msg.Payload["chat_id"].(string); ok {
+		ctx = logging.WithAttrs(ctx, slog.String(logging.ChatIDKey, chatID))
+		span.SetAttributes(attribute.String("chat.id", chatID))
+	}
+
+	if err := h.handleChatAction(ctx, msg); err != nil {
+		tracing.RecordError(span, err)
+		slog.WarnContext(ctx, "chat action failed", "chat_action", msg.ChatAction, "error", err)
+		h.metrics.ObserveChatAction(chatActionLabel(msg.ChatAction), string(actions.Failed))
+		return
+	}
+	h.metrics.ObserveChatAction(chatActionLabel(msg.ChatAction), string(actions.Success))
+}
+
+//This method performs chat action of the message, error is returned when action failed
+func (h *Hub) handleChatAction(ctx context.Context, msg websocketmessage.WsMessageRequest) error {
+
 	switch msg.ChatAction {
 	case actions.CreateChatAction:
 		userID, _ := uuid.Parse(msg.Payload["user_id"].(string))
 		chatName, _ := msg.Payload["chat_name"].(string)
 		activeClient := h.GetActiveClient(userID)
 
-		chat, err := h.chatService.CreateChat(context.Background(), userID, chatName)
+		chat, err := h.chatService.CreateChat(ctx, userID, chatName)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		h.AddCreatedChat(chat.GetID(), activeClient)
@@ -189,12 +412,12 @@ func (h *Hub) HandleMessage(message []byte) {
 		userID, _ := uuid.Parse(msg.Payload["user_id"].(string))
 		activeClient := h.GetActiveClient(userID)
 
-		err := h.chatService.DeleteChat(context.Background(), chatID, userID)
+		err := h.chatService.DeleteChat(ctx, chatID, userID)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		h.RemoveActiveChat(chatID)
@@ -216,13 +439,13 @@ func (h *Hub) HandleMessage(message []byte) {
 		newChatName, _ := msg.Payload["new_chat_name"].(string)
 		activeClient := h.GetActiveClient(userID)
 
-		newName, err := h.chatService.RenameChat(context.Background(), chatID, newChatName, userID)
+		newName, err := h.chatService.RenameChat(ctx, chatID, newChatName, userID)
 
 		if err != nil {
 			if activeClient.IsStillConnected(){
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		if activeClient.IsStillConnected() {
@@ -247,18 +470,60 @@ func (h *Hub) HandleMessage(message []byte) {
 			},
 		}
 		go h.SendWsEventToChatClients(chatID, h.GetActiveClientsOfChat(chatID), wsEvent)
+	case actions.SetChatSlowModeAction:
+		chatID, _ := uuid.Parse(msg.Payload["chat_id"].(string))
+		userID, _ := uuid.Parse(msg.Payload["user_id"].(string))
+		activeClient := h.GetActiveClient(userID)
+
+		requestedInterval, err := ParseSlowModeInterval(msg.Payload)
+		if err != nil {
+			if activeClient.IsStillConnected() {
+				h.SendErrorResponseToClient(activeClient, chatID, ErrInvalidSlowModeInterval)
+			}
+			return err
+		}
+
+		interval, err := h.chatService.SetChatSlowMode(ctx, chatID, requestedInterval, userID)
+		if err != nil {
+			if activeClient.IsStillConnected() {
+				activeClient.GetMessageFromServer([]byte(err.Error()))
+			}
+			return err
+		}
+
+		if activeClient.IsStillConnected() {
+			wsRes := websocketmessage.WsMessageResponse {
+				ChatActionResult: actions.Success,
+				Payload: map[string]interface{} {
+					"chat_id": chatID,
+					"slow_mode_interval_seconds": int64(interval / time.Second),
+				},
+			}
+
+			go h.SendWsResponseToClient(activeClient, wsRes)
+		}
+
+		wsEvent := websocketmessage.WsClientEvent {
+			EventType: actions.ChatSlowModeUpdatedEvent,
+			Payload: map[string]interface{} {
+				"chat_id": chatID,
+				"user_id": userID,
+				"slow_mode_interval_seconds": int64(interval / time.Second),
+			},
+		}
+		go h.SendWsEventToChatClients(chatID, h.GetActiveClientsOfChat(chatID), wsEvent)
 	case actions.LeaveChatAction:
 
 		chatID, _ := uuid.Parse(msg.Payload["chat_id"].(string))
 		userID, _ := uuid.Parse(msg.Payload["user_id"].(string))
 		activeClient := h.GetActiveClient(userID)
 
-		err := h.chatService.LeaveChat(context.Background(), chatID, userID)
+		err := h.chatService.LeaveChat(ctx, chatID, userID)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		h.ActiveClientLeftChat(userID, chatID)
@@ -288,12 +553,12 @@ func (h *Hub) HandleMessage(message []byte) {
 
 		activeClient := h.GetActiveClient(userID)
 
-		err := h.chatService.AddUserToChat(context.Background(), chatID, userID, invitedUserID)
+		err := h.chatService.AddUserToChat(ctx, chatID, userID, invitedUserID)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		h.AddActiveClientToChat(chatID, invitedUserID)
@@ -328,12 +593,12 @@ func (h *Hub) HandleMessage(message []byte) {
 
 		activeClient := h.GetActiveClient(userID)
 
-		err := h.chatService.RemoveUserFromChat(context.Background(), chatID, userID, removingUserID)
+		err := h.chatService.RemoveUserFromChat(ctx, chatID, userID, removingUserID)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		h.ActiveClientWasKickedFromChat(chatID, userID, removingUserID)
@@ -368,12 +633,12 @@ func (h *Hub) HandleMessage(message []byte) {
 
 		activeClient := h.GetActiveClient(userID)
 
-		err := h.chatService.PromoteUserToChatAdmin(context.Background(), chatID, userID, promotedUserID)
+		err := h.chatService.PromoteUserToChatAdmin(ctx, chatID, userID, promotedUserID)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		if activeClient.IsStillConnected() {
@@ -406,12 +671,12 @@ func (h *Hub) HandleMessage(message []byte) {
 
 		activeClient := h.GetActiveClient(userID)
 
-		err := h.chatService.DemoteChatAdminToChatMember(context.Background(), chatID, userID, demotedUserID)
+		err := h.chatService.DemoteChatAdminToChatMember(ctx, chatID, userID, demotedUserID)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		if activeClient.IsStillConnected() {
@@ -444,12 +709,25 @@ func (h *Hub) HandleMessage(message []byte) {
 
 		activeClient := h.GetActiveClient(userID)
 
-		id, err := h.chatService.SendMessage(context.Background(), chatID, userID, message)
+		if allowed, retryAfter := h.messageLimiter.Allow(userID, time.Now()); !allowed {
+			rateLimitErr := messages.NewMessageRateLimitError(retryAfter)
+			if activeClient.IsStillConnected() {
+				h.SendRateLimitResponseToClient(activeClient, chatID, rateLimitErr)
+			}
+			return rateLimitErr
+		}
+
+		id, err := h.chatService.SendMessage(ctx, chatID, userID, message)
 		if err != nil {
 			if activeClient.IsStillConnected() {
-				activeClient.GetMessageFromServer([]byte(err.Error()))
+				var rateLimitErr *messages.ChatMessageRateLimitError
+				if errors.As(err, &rateLimitErr) {
+					h.SendRateLimitResponseToClient(activeClient, chatID, rateLimitErr)
+				} else {
+					activeClient.GetMessageFromServer([]byte(err.Error()))
+				}
 			}
-			return
+			return err
 		}
 
 		if activeClient.IsStillConnected() {
@@ -483,12 +761,12 @@ func (h *Hub) HandleMessage(message []byte) {
 
 		activeClient := h.GetActiveClient(userID)
 
-		newMsg, err := h.chatService.EditMessage(context.Background(), chatID, messageID, userID, message)
+		newMsg, err := h.chatService.EditMessage(ctx, chatID, messageID, userID, message)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return 
+			return err
 		}
 
 		if activeClient.IsStillConnected() {
@@ -523,12 +801,12 @@ func (h *Hub) HandleMessage(message []byte) {
 
 		activeClient := h.GetActiveClient(userID)
 
-		err := h.chatService.DeleteMessage(context.Background(), chatID, messageID, userID)
+		err := h.chatService.DeleteMessage(ctx, chatID, messageID, userID)
 		if err != nil {
 			if activeClient.IsStillConnected() {
 				activeClient.GetMessageFromServer([]byte(err.Error()))
 			}
-			return
+			return err
 		}
 
 		if activeClient.IsStillConnected() {
@@ -554,7 +832,11 @@ func (h *Hub) HandleMessage(message []byte) {
 		}
 
 		go h.SendWsEventToChatClients(chatID, h.GetActiveClientsOfChat(chatID), wsEvent)
+	default:
+		return ErrUnknownChatAction
 	}
+
+	return nil
 }
 
 
@@ -0,0 +1,31 @@
+package chathub
+
+import actions "symphony_chat/internal/domain/chat_actions"
+
+const (
+	//Label of messages that are not valid json
+	invalidChatActionLabel = "INVALID"
+	//Label of actions that hub doesn't know, client can send any string as action
+	unknownChatActionLabel = "UNKNOWN"
+)
+
+//Function limits label values to known actions, so clients can't create new metric series
+func chatActionLabel(action actions.ChatActionType) string {
+	switch action {
+	case actions.LeaveChatAction,
+		actions.CreateChatAction,
+		actions.RenameChatAction,
+		actions.DeleteChatAction,
+		actions.SetChatSlowModeAction,
+		actions.AddMemberToChatAction,
+		actions.RemoveMemberFromChatAction,
+		actions.PromoteUserToChatAdminAction,
+		actions.DemoteChatAdminToChatMemberAction,
+		actions.SendMessageAction,
+		actions.DeleteMessageAction,
+		actions.EditMessageAction:
+		return string(action)
+	default:
+		return unknownChatActionLabel
+	}
+}
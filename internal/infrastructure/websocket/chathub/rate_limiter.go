@@ -0,0 +1,80 @@
+package chathub
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Token bucket of one user
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+//Per-user token bucket limiter that is used by the Hub for SEND_MESSAGE actions
+type MessageRateLimiter struct {
+	burst          int
+	refillInterval time.Duration
+	buckets        map[uuid.UUID]*tokenBucket
+	mu             sync.Mutex
+}
+
+func NewMessageRateLimiter(burst int, refillInterval time.Duration) *MessageRateLimiter {
+	return &MessageRateLimiter{
+		burst:          burst,
+		refillInterval: refillInterval,
+		buckets:        make(map[uuid.UUID]*tokenBucket),
+	}
+}
+
+//Method takes one token from user's bucket
+//If bucket is empty, it returns false and time after which next token will be available
+func (l *MessageRateLimiter) Allow(userID uuid.UUID, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, exists := l.buckets[userID]
+	if !exists {
+		bucket = &tokenBucket{
+			tokens:     float64(l.burst),
+			lastRefill: now,
+		}
+		l.buckets[userID] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastRefill)
+	if elapsed > 0 {
+		bucket.tokens += float64(elapsed) / float64(l.refillInterval)
+		if bucket.tokens > float64(l.burst) {
+			bucket.tokens = float64(l.burst)
+		}
+		bucket.lastRefill = now
+	}
+
+	if bucket.tokens < 1 {
+		retryAfter := time.Duration((1 - bucket.tokens) * float64(l.refillInterval))
+		return false, retryAfter
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+//Method removes user's bucket if it is already refilled (used when user disconnects)
+//Not refilled buckets are kept, so reconnecting doesn't reset the limit
+func (l *MessageRateLimiter) Forget(userID uuid.UUID, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, exists := l.buckets[userID]
+	if !exists {
+		return
+	}
+
+	refilledTokens := bucket.tokens + float64(now.Sub(bucket.lastRefill))/float64(l.refillInterval)
+	if refilledTokens >= float64(l.burst) {
+		delete(l.buckets, userID)
+	}
+}
@@ -1,39 +1,43 @@
 package client
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"sync"
 	"time"
 
+	config "symphony_chat/internal/infrastructure/configs"
+	"symphony_chat/internal/infrastructure/metrics"
+
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
-const (
-	//Max size of the connection's incoming messages
-	MaxMessageSize = 1024
-
-	pongWait = 60 * time.Second
-
-	pingPeriod = (pongWait * 9) / 10
-
-	//Size of the buffers for sending and receiving messages
-	sendBufferSize = 256
-	receiveBufferSize = 256
-)
-
 type MessageReceiver interface {
-	HandleMessage(message []byte)
+	HandleMessage(ctx context.Context, message []byte)
 	RemoveActiveClient(client *Client)
 }
 
 type Client struct {
+	// ctx carries connection, request and user ids for logs, it is not cancelled when request ends
+	ctx context.Context
+
 	// conn is the websocket connection
 	conn *websocket.Conn
 
 	// closeOnce ensures that CloseConnection is called at most once
 	closeOnce sync.Once
 
+	// closed is closed when connection is closed
+	closed chan struct{}
+
+	// goingAwayOnce ensures that CloseGoingAway is called at most once
+	goingAwayOnce sync.Once
+
+	// goingAway tells WritePump to flush sendBuffer and send close frame with goingAwayReason
+	goingAway chan struct{}
+	goingAwayReason string
+
 	// sendBuffer is a channel for receiving messages from Hub
 	sendBuffer chan []byte
 
@@ -43,28 +47,61 @@ type Client struct {
 	// userID defines a user of the current connection
 	userID uuid.UUID
 
+	// accessTokenID is jti of the access token connection was opened with
+	accessTokenID uuid.UUID
+
+	// sessionID is sid of the access token, connection is closed when session is revoked
+	sessionID uuid.UUID
+
 	// msgReceiver is a receiver for messages from current Client
 	msgReceiver MessageReceiver
+
+	// limits are message size, queue sizes and ping/pong timings of the connection
+	limits config.WebSocketConfig
+
+	// metrics observe saturation of sendBuffer
+	metrics *metrics.Metrics
 }
 
 func (c *Client) GetID() uuid.UUID {
 	return c.userID
 }
 
-func NewClient(conn *websocket.Conn, userID uuid.UUID, msgReceiver MessageReceiver) *Client {
+func (c *Client) GetAccessTokenID() uuid.UUID {
+	return c.accessTokenID
+}
+
+func (c *Client) GetSessionID() uuid.UUID {
+	return c.sessionID
+}
+
+//Context of the connection is used for logging, so it should not be cancelled with handshake request
+func NewClient(ctx context.Context, conn *websocket.Conn, userID uuid.UUID, accessTokenID uuid.UUID, sessionID uuid.UUID, msgReceiver MessageReceiver, limits config.WebSocketConfig, m *metrics.Metrics) *Client {
 	if conn == nil {
 		return nil
 	}
 
 	return &Client{
+		ctx: ctx,
 		conn: conn,
-		sendBuffer: make(chan []byte, sendBufferSize),
-		receiveBuffer: make(chan []byte, receiveBufferSize),
+		closed: make(chan struct{}),
+		goingAway: make(chan struct{}),
+		sendBuffer: make(chan []byte, limits.SendQueueSize),
+		receiveBuffer: make(chan []byte, limits.ReceiveQueueSize),
 		userID: userID,
+		accessTokenID: accessTokenID,
+		sessionID: sessionID,
 		msgReceiver: msgReceiver,
+		limits: limits,
+		metrics: m,
 	}
 }
 
+//Context has ids of the connection and the user, it is used by the hub for logs
+func (c *Client) Context() context.Context {
+	return c.ctx
+}
+
 func (c *Client) IsStillConnected() bool {
 	if c.conn == nil {
 		return false
@@ -79,15 +116,32 @@ func (c *Client) CloseConnection() {
 		close(c.receiveBuffer)
 		c.conn.Close()
 		c.msgReceiver.RemoveActiveClient(c)
+		close(c.closed)
+		slog.InfoContext(c.ctx, "websocket connection closed")
 	})
 } 
 
+//Method is used during graceful shutdown, after messages for the client are not produced anymore
+//WritePump sends messages that are already queued, then close frame with "going away" code and closes connection
+func (c *Client) CloseGoingAway(reason string) {
+	c.goingAwayOnce.Do(func() {
+		c.goingAwayReason = reason
+		close(c.goingAway)
+	})
+}
+
+//Channel is closed when connection of the client is closed
+func (c *Client) Closed() <-chan struct{} {
+	return c.closed
+}
+
 func (c *Client) GetMessageFromServer(message []byte) {
+	c.metrics.ObserveSendBufferSaturation(len(c.sendBuffer), cap(c.sendBuffer))
 	c.sendBuffer <- message
 }
 
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(pingPeriod)
+	ticker := time.NewTicker(c.limits.PingPeriod())
 	defer func() {
 		ticker.Stop()
 		c.CloseConnection()
@@ -104,10 +158,14 @@ func (c *Client) WritePump() {
 			}
 			
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(pongWait))
+			c.conn.SetWriteDeadline(time.Now().Add(c.limits.PongWait()))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+
+		case <-c.goingAway:
+			c.flushAndSendGoingAway()
+			return
 		}
 	}
 
@@ -118,10 +176,10 @@ func (c *Client) ReadPump() {
 		c.CloseConnection()
 	}()
 
-	c.conn.SetReadLimit(MaxMessageSize)
-	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetReadLimit(c.limits.MaxMessageSizeInBytes)
+	c.conn.SetReadDeadline(time.Now().Add(c.limits.PongWait()))
 	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.conn.SetReadDeadline(time.Now().Add(c.limits.PongWait()))
 		return nil
 	})
 
@@ -134,7 +192,9 @@ func (c *Client) ReadPump() {
 				err,
 				websocket.CloseGoingAway,
 				websocket.CloseAbnormalClosure,
-			) {log.Printf("error: %v", err)}
+			) {
+				slog.WarnContext(c.ctx, "websocket connection closed unexpectedly", "error", err)
+			}
 
 			break
 		}
@@ -142,10 +202,31 @@ func (c *Client) ReadPump() {
 	}
 }
 
+//Queued messages are written without waiting for new ones, close frame is written after them
+func (c *Client) flushAndSendGoingAway() {
+	c.conn.SetWriteDeadline(time.Now().Add(c.limits.PongWait()))
+
+	for {
+		select {
+		case message, ok := <-c.sendBuffer:
+			if !ok {
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		default:
+			closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, c.goingAwayReason)
+			c.conn.WriteMessage(websocket.CloseMessage, closeMessage)
+			return
+		}
+	}
+}
+
 //Read messages from the connection and send them to the message receiver
 func (c *Client) ProcessAndSendMessages() {
 	for message := range c.receiveBuffer {
-		c.msgReceiver.HandleMessage(message)
+		c.msgReceiver.HandleMessage(c.ctx, message)
 	}
 }
 
@@ -0,0 +1,178 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"symphony_chat/internal/application/cookies"
+	tx "symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/users"
+	config "symphony_chat/internal/infrastructure/configs"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
+	chatService "symphony_chat/internal/service/chat"
+	jwtService "symphony_chat/internal/service/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+//ConnectionCloser closes realtime connections of the user (websocket hub)
+type ConnectionCloser interface {
+	DisconnectClientsOfUser(userID uuid.UUID)
+}
+
+type AccountService struct {
+	authUserRepo       users.AuthUserRepository
+	chatUserRepo       users.ChatUserRepository
+	jwtService         *jwtService.JWTtokenService
+	chatService        *chatService.ChatService
+	connectionCloser   ConnectionCloser
+	transactionManager tx.TransactionManager
+	passwordHasher     *passwordhash.PasswordHasher
+	cookieManager      *cookies.CookieManager
+}
+
+type AccountConfiguration func(*AccountService) error
+
+func NewAccountService(configs ...AccountConfiguration) (*AccountService, error) {
+	as := &AccountService{
+		passwordHasher: passwordhash.DefaultPasswordHasher(),
+		cookieManager:  cookies.NewCookieManager(config.DefaultCookieConfig()),
+	}
+
+	for _, cfg := range configs {
+		err := cfg(as)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return as, nil
+}
+
+func WithAuthUserRepository(au users.AuthUserRepository) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.authUserRepo = au
+		return nil
+	}
+}
+
+func WithChatUserRepository(cu users.ChatUserRepository) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.chatUserRepo = cu
+		return nil
+	}
+}
+
+func WithJWTtokenService(js *jwtService.JWTtokenService) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.jwtService = js
+		return nil
+	}
+}
+
+func WithChatService(cs *chatService.ChatService) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.chatService = cs
+		return nil
+	}
+}
+
+func WithConnectionCloser(cc ConnectionCloser) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.connectionCloser = cc
+		return nil
+	}
+}
+
+func WithPasswordHasher(ph *passwordhash.PasswordHasher) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.passwordHasher = ph
+		return nil
+	}
+}
+
+func WithCookieManager(cm *cookies.CookieManager) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.cookieManager = cm
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) AccountConfiguration {
+	return func(as *AccountService) error {
+		as.transactionManager = tm
+		return nil
+	}
+}
+
+//Method deletes account of the user in one transaction:
+//owned chats are transferred or dissolved, memberships are removed, messages are passed to deleted user placeholder,
+//sessions and refresh tokens are deleted. After commit access tokens of all sessions are revoked and websocket connections are closed
+func (as *AccountService) DeleteAccount(ctx context.Context, accessTokenClaims jwt.TokenClaims, password string) error {
+	userID := accessTokenClaims.UserID
+	var affectedChatIDs []uuid.UUID
+	var deletedSessionIDs []uuid.UUID
+
+	err := as.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		authUser, err := as.authUserRepo.GetAuthUserById(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		//Accounts without password confirm deletion by recent sign in through identity provider
+		if authUser.HasPassword() {
+			if matched, _ := as.passwordHasher.Verify(password, authUser.GetPassword()); !matched {
+				return users.ErrWrongPassword
+			}
+		} else if err := as.jwtService.CheckRecentAuthentication(txCtx, userID, accessTokenClaims.SessionID); err != nil {
+			return err
+		}
+
+		affectedChatIDs, err = as.chatService.DetachDeletedUser(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		//Accounts that were created before chat profiles existed have no chat user
+		if _, err := as.chatUserRepo.GetChatUserByID(txCtx, userID); err == nil {
+			if err := as.chatUserRepo.DeleteChatUserByID(txCtx, userID); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, users.ErrChatUserNotFound) {
+			return err
+		}
+
+		deletedSessionIDs, err = as.jwtService.DeleteAllSessions(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		return as.authUserRepo.DeleteAuthUser(txCtx, userID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	//Roles of successors changed too, so every affected chat is invalidated, not only memberships of the user
+	for _, chatID := range affectedChatIDs {
+		as.chatService.InvalidateCachedPermissionsOfChat(chatID)
+	}
+	as.chatService.InvalidateCachedPermissionsOfUser(userID)
+
+	if as.connectionCloser != nil {
+		as.connectionCloser.DisconnectClientsOfUser(userID)
+	}
+
+	//Session of current access token is deleted too, but it is revoked explicitly in case it had no session row
+	if err := as.jwtService.RevokeAccessToken(ctx, accessTokenClaims); err != nil {
+		return err
+	}
+
+	return as.jwtService.RevokeSessionAccessTokens(ctx, deletedSessionIDs...)
+}
+
+func (as *AccountService) ClearRefreshTokenCookie(c *gin.Context) {
+	as.cookieManager.ClearRefreshToken(c)
+}
@@ -2,13 +2,21 @@ package authentication
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"symphony_chat/internal/application/cookies"
 	publicDto "symphony_chat/internal/application/dto"
 	tx "symphony_chat/internal/application/transaction"
 	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/sessions"
+	twofactor "symphony_chat/internal/domain/two_factor"
 	"symphony_chat/internal/domain/users"
 	authdto "symphony_chat/internal/dto/auth"
+	lockoutService "symphony_chat/internal/service/auth/lockout"
+	twoFactorService "symphony_chat/internal/service/auth/two_factor"
+	config "symphony_chat/internal/infrastructure/configs"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
 	jwtService "symphony_chat/internal/service/jwt"
-	utils "symphony_chat/utils/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -18,7 +26,12 @@ import (
 type AuthenticationService struct {
 	jwtService *jwtService.JWTtokenService
 	userRepo   users.AuthUserRepository
+	sessionRepo sessions.SessionRepository
 	transactionManager tx.TransactionManager
+	lockoutService *lockoutService.LockoutService
+	twoFactorService *twoFactorService.TwoFactorService
+	passwordHasher *passwordhash.PasswordHasher
+	cookieManager *cookies.CookieManager
 }
 
 type AuthenticationConfiguration func(*AuthenticationService) error
@@ -37,6 +50,13 @@ func WithAuthUserRepository(au users.AuthUserRepository) AuthenticationConfigura
 	}
 }
 
+func WithSessionRepository(sr sessions.SessionRepository) AuthenticationConfiguration {
+	return func(as *AuthenticationService) error {
+		as.sessionRepo = sr
+		return nil
+	}
+}
+
 func WithTransactionManager(tm tx.TransactionManager) AuthenticationConfiguration {
 	return func(as *AuthenticationService) error {
 		as.transactionManager = tm
@@ -44,9 +64,42 @@ func WithTransactionManager(tm tx.TransactionManager) AuthenticationConfiguratio
 	}
 }
 
+//Without lockout service login attempts are not limited
+func WithLockoutService(ls *lockoutService.LockoutService) AuthenticationConfiguration {
+	return func(as *AuthenticationService) error {
+		as.lockoutService = ls
+		return nil
+	}
+}
+
+//Without two factor service second step of login is never required
+func WithTwoFactorService(ts *twoFactorService.TwoFactorService) AuthenticationConfiguration {
+	return func(as *AuthenticationService) error {
+		as.twoFactorService = ts
+		return nil
+	}
+}
+
+//Password hashes created with other algorithm or parameters are replaced after successful login
+func WithPasswordHasher(ph *passwordhash.PasswordHasher) AuthenticationConfiguration {
+	return func(as *AuthenticationService) error {
+		as.passwordHasher = ph
+		return nil
+	}
+}
+
+func WithCookieManager(cm *cookies.CookieManager) AuthenticationConfiguration {
+	return func(as *AuthenticationService) error {
+		as.cookieManager = cm
+		return nil
+	}
+}
 
 func NewAuthenticationService(configs ...AuthenticationConfiguration) (*AuthenticationService, error) {
-	as := &AuthenticationService{}
+	as := &AuthenticationService{
+		passwordHasher: passwordhash.DefaultPasswordHasher(),
+		cookieManager: cookies.NewCookieManager(config.DefaultCookieConfig()),
+	}
 
 	for _, cfg := range configs {
 		err := cfg(as)
@@ -58,11 +111,15 @@ func NewAuthenticationService(configs ...AuthenticationConfiguration) (*Authenti
 	return as, nil
 }
 
-func (as *AuthenticationService) LogIn(ctx context.Context,userInput publicDto.LoginCredentials) (authdto.AuthTokens, error) {
+//Method checks password and issues tokens
+//If user has enabled two factor, only challenge is issued and login must be finished with LogInWithTwoFactor
+func (as *AuthenticationService) LogIn(ctx context.Context,userInput publicDto.LoginCredentials, device sessions.DeviceInfo) (authdto.LoginResult, error) {
 
-	authTokens := authdto.AuthTokens{}
+	loginResult := authdto.LoginResult{}
+	var rehashUserID uuid.UUID
 
-	err := as.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+	//Locked login or ip is rejected before password check, so locked account can't be brute forced
+	err := as.runLoginAttempt(ctx, userInput.Login, device.IP, isFailedCredentialsError, func(txCtx context.Context) error {
 		authUser, err := as.userRepo.GetAuthUserByLogin(txCtx,userInput.Login)
 		if err != nil {
 			if err == users.ErrAuthUserNotFound {
@@ -75,11 +132,35 @@ func (as *AuthenticationService) LogIn(ctx context.Context,userInput publicDto.L
 			}
 		}
 
-		if !utils.CheckPassword(userInput.Password, authUser.GetPassword()) {
+		matched, needsRehash := as.passwordHasher.Verify(userInput.Password, authUser.GetPassword())
+		if !matched {
 			return users.ErrWrongPassword
 		}
 
-		authTokens, err = as.jwtService.GetUpdatedPairTokens(txCtx, authUser.GetID())
+		if needsRehash {
+			rehashUserID = authUser.GetID()
+		}
+
+		if as.twoFactorService != nil {
+			enabled, err := as.twoFactorService.IsEnabled(txCtx, authUser.GetID())
+			if err != nil {
+				return err
+			}
+
+			if enabled {
+				challengeToken, expiresAt, err := as.twoFactorService.CreateChallenge(txCtx, authUser.GetID())
+				if err != nil {
+					return err
+				}
+
+				loginResult.TwoFactorRequired = true
+				loginResult.ChallengeToken = challengeToken
+				loginResult.ChallengeExpiresAt = expiresAt
+				return nil
+			}
+		}
+
+		loginResult.Tokens, err = as.jwtService.GetUpdatedPairTokens(txCtx, authUser.GetID(), device)
 		if err != nil {
 			return &jwt.TokenError{
 				Code: "CREATE_JWT_TOKENS_ERROR",
@@ -91,17 +172,130 @@ func (as *AuthenticationService) LogIn(ctx context.Context,userInput publicDto.L
 		return nil
 	})
 
+	if err != nil {
+		return authdto.LoginResult{}, err
+	}
+
+	//Hash is replaced after transaction, so failed update can't break login
+	if rehashUserID != uuid.Nil {
+		as.rehashPassword(ctx, rehashUserID, userInput.Password)
+	}
+
+	//Failed attempts are reset only when login is finished
+	if !loginResult.TwoFactorRequired {
+		as.registerSuccessfulAttempt(ctx, userInput.Login)
+	}
+
+	return loginResult, nil
+}
+
+//Method finishes login of user with enabled two factor
+//Code can be totp code or one of recovery codes, wrong codes are counted as failed login attempts
+func (as *AuthenticationService) LogInWithTwoFactor(ctx context.Context, challengeToken string, code string, device sessions.DeviceInfo) (authdto.AuthTokens, error) {
+	if as.twoFactorService == nil {
+		return authdto.AuthTokens{}, twofactor.ErrTwoFactorNotEnabled
+	}
+
+	userID, err := as.twoFactorService.GetChallengeUserID(ctx, challengeToken)
 	if err != nil {
 		return authdto.AuthTokens{}, err
 	}
 
+	authUser, err := as.userRepo.GetAuthUserById(ctx, userID)
+	if err != nil {
+		return authdto.AuthTokens{}, err
+	}
+
+	err = as.runLoginAttempt(ctx, authUser.GetLogin(), device.IP, isInvalidTwoFactorCodeError, func(txCtx context.Context) error {
+		_, err := as.twoFactorService.VerifyChallenge(txCtx, challengeToken, code)
+		return err
+	})
+	if err != nil {
+		return authdto.AuthTokens{}, err
+	}
+
+	var authTokens authdto.AuthTokens
+	err = as.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		authTokens, err = as.jwtService.GetUpdatedPairTokens(txCtx, userID, device)
+		if err != nil {
+			return &jwt.TokenError{
+				Code: "CREATE_JWT_TOKENS_ERROR",
+				Message: "failed to generate new jwt tokens",
+				Err: err,
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return authdto.AuthTokens{}, err
+	}
+
+	as.registerSuccessfulAttempt(ctx, authUser.GetLogin())
+
 	return authTokens, nil
 }
 
-func (as *AuthenticationService) LogOut(ctx context.Context, userID uuid.UUID) error {
+//Old hash stays valid if it can't be replaced, so error only is logged
+func (as *AuthenticationService) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hashedPassword, err := as.passwordHasher.Hash(password)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to rehash password", "user_id", userID, "error", err)
+		return
+	}
+
+	if err := as.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		slog.ErrorContext(ctx, "failed to save rehashed password", "user_id", userID, "error", err)
+	}
+}
+
+//Attempt is run in one transaction under lockout of login and ip, without lockout service it is just run in transaction
+//Transaction is committed after failed attempt too, so failures that attempt records itself are kept
+func (as *AuthenticationService) runLoginAttempt(ctx context.Context, login string, ip string, isFailure func(error) bool, attempt func(txCtx context.Context) error) error {
+	if as.lockoutService != nil {
+		return as.lockoutService.Attempt(ctx, login, ip, attempt, isFailure)
+	}
 
+	var attemptErr error
 	err := as.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
-		err := as.jwtService.InvalidateRefreshToken(txCtx, userID)
+		attemptErr = attempt(txCtx)
+		if attemptErr != nil && !isFailure(attemptErr) {
+			return attemptErr
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return attemptErr
+}
+
+//Unknown login is counted too, otherwise lockout would reveal which logins exist
+func isFailedCredentialsError(err error) bool {
+	return err == users.ErrWrongPassword || err == users.ErrAuthUserNotFound
+}
+
+func isInvalidTwoFactorCodeError(err error) bool {
+	return errors.Is(err, twofactor.ErrInvalidTwoFactorCode)
+}
+
+func (as *AuthenticationService) registerSuccessfulAttempt(ctx context.Context, login string) {
+	if as.lockoutService == nil {
+		return
+	}
+
+	if err := as.lockoutService.RegisterSuccess(ctx, login); err != nil {
+		slog.ErrorContext(ctx, "failed to reset failed login attempts", "error", err)
+	}
+}
+
+//Method ends current session and revokes its access tokens
+func (as *AuthenticationService) LogOut(ctx context.Context, accessTokenClaims jwt.TokenClaims) error {
+
+	err := as.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		err := as.jwtService.InvalidateSession(txCtx, accessTokenClaims.UserID, accessTokenClaims.SessionID)
 		if err != nil {
 			return &users.AuthError{
 				Code: "LOGOUT_ERROR",
@@ -113,17 +307,60 @@ func (as *AuthenticationService) LogOut(ctx context.Context, userID uuid.UUID) e
 		return nil
 	})
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	return as.jwtService.RevokeSessionAccessTokens(ctx, accessTokenClaims.SessionID)
 }
 
-func (as *AuthenticationService) UpdateRefreshTokenInHTTPCookie(c *gin.Context, refreshToken string) {
-	c.SetCookie("refresh_token", refreshToken, int(as.jwtService.GetRefreshTokenTTL()), "/", "localhost", false, true)
+//Method exchanges refresh token for a new pair of tokens
+//Refresh token must be stored in jwt_token and must not be rotated or revoked
+func (as *AuthenticationService) RefreshTokens(ctx context.Context, refreshToken string, device sessions.DeviceInfo) (authdto.AuthTokens, error) {
+	return as.jwtService.RotateRefreshToken(ctx, refreshToken, device)
+}
+
+func (as *AuthenticationService) GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]sessions.Session, error) {
+	return as.sessionRepo.GetActiveSessionsOfUser(ctx, userID)
+}
+
+//Access tokens of the session are revoked after commit, so its websocket connections are closed too
+func (as *AuthenticationService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	err := as.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		return as.jwtService.InvalidateSession(txCtx, userID, sessionID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return as.jwtService.RevokeSessionAccessTokens(ctx, sessionID)
 }
 
-func (as *AuthenticationService) UpdateAccessTokenInHTTPCookie(c *gin.Context, accessToken string) {
-	c.SetCookie("access_token", accessToken, int(as.jwtService.GetAccessTokenTTL()), "/", "localhost", false, true)
+func (as *AuthenticationService) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID uuid.UUID) ([]uuid.UUID, error) {
+	var revokedSessionIDs []uuid.UUID
+
+	err := as.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		revokedSessionIDs, err = as.jwtService.InvalidateOtherSessions(txCtx, userID, currentSessionID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := as.jwtService.RevokeSessionAccessTokens(ctx, revokedSessionIDs...); err != nil {
+		return nil, err
+	}
+
+	return revokedSessionIDs, nil
+}
+
+func (as *AuthenticationService) UpdateRefreshTokenInHTTPCookie(c *gin.Context, refreshToken string) {
+	as.cookieManager.SetRefreshToken(c, refreshToken, int(as.jwtService.GetRefreshTokenTTL()))
 }
 
 func (as *AuthenticationService) ClearRefreshTokenCookie(c *gin.Context) {
-	c.SetCookie("refresh_token", "", 0, "/", "localhost", false, true)
+	as.cookieManager.ClearRefreshToken(c)
 }
@@ -0,0 +1,220 @@
+package lockout
+
+import (
+	"context"
+	"log/slog"
+	tx "symphony_chat/internal/application/transaction"
+	loginattempt "symphony_chat/internal/domain/login_attempt"
+	"time"
+)
+
+//Policies that are used if other policies were not configured
+//Ip policy is softer, because many users can share one ip
+var (
+	defaultLoginPolicy = loginattempt.LockoutPolicy{
+		MaxFailedAttempts: 5,
+		BaseLockout:       30 * time.Second,
+		MaxLockout:        time.Hour,
+		ResetAfter:        time.Hour,
+	}
+
+	defaultIPPolicy = loginattempt.LockoutPolicy{
+		MaxFailedAttempts: 20,
+		BaseLockout:       30 * time.Second,
+		MaxLockout:        time.Hour,
+		ResetAfter:        time.Hour,
+	}
+)
+
+//LockoutService tracks failed login attempts per login and per ip and locks them with exponential backoff
+type LockoutService struct {
+	loginAttemptRepo   loginattempt.LoginAttemptRepository
+	transactionManager tx.TransactionManager
+	loginPolicy        loginattempt.LockoutPolicy
+	ipPolicy           loginattempt.LockoutPolicy
+}
+
+type LockoutConfiguration func(*LockoutService) error
+
+func NewLockoutService(configs ...LockoutConfiguration) (*LockoutService, error) {
+	ls := &LockoutService{
+		loginPolicy: defaultLoginPolicy,
+		ipPolicy:    defaultIPPolicy,
+	}
+
+	for _, cfg := range configs {
+		err := cfg(ls)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ls, nil
+}
+
+func WithLoginAttemptRepository(lr loginattempt.LoginAttemptRepository) LockoutConfiguration {
+	return func(ls *LockoutService) error {
+		ls.loginAttemptRepo = lr
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) LockoutConfiguration {
+	return func(ls *LockoutService) error {
+		ls.transactionManager = tm
+		return nil
+	}
+}
+
+func WithLoginPolicy(policy loginattempt.LockoutPolicy) LockoutConfiguration {
+	return func(ls *LockoutService) error {
+		ls.loginPolicy = policy
+		return nil
+	}
+}
+
+func WithIPPolicy(policy loginattempt.LockoutPolicy) LockoutConfiguration {
+	return func(ls *LockoutService) error {
+		ls.ipPolicy = policy
+		return nil
+	}
+}
+
+//Method runs login attempt in one transaction with the lockout check and the update of failed attempts counters
+//Counter of login is locked during the attempt, so concurrent attempts of one login wait for each other
+//and can't pass lockout check before their failures are counted
+//Counter of ip is only read for the check and is locked just before failure is counted,
+//so logins of different users behind one ip don't wait for each other's password checks
+//AccountLockedError is returned without running attempt if login or ip is locked now
+//Error of attempt is counted as failure if isFailure returns true for it, then transaction is committed and the error is returned,
+//other errors roll transaction back
+func (ls *LockoutService) Attempt(ctx context.Context, login string, ip string, attempt func(txCtx context.Context) error, isFailure func(error) bool) error {
+	var attemptErr error
+
+	err := ls.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		now := time.Now()
+
+		loginAttempts, err := ls.loginAttemptRepo.LockLoginAttempts(txCtx, loginKey(login))
+		if err != nil {
+			return err
+		}
+		retryAfter := loginAttempts.RetryAfter(now)
+
+		if ip != "" {
+			ipAttempts, err := ls.loginAttemptRepo.GetLoginAttempts(txCtx, ipKey(ip))
+			if err != nil {
+				return err
+			}
+			retryAfter = max(retryAfter, ipAttempts.RetryAfter(now))
+		}
+
+		if retryAfter > 0 {
+			return loginattempt.NewAccountLockedError(retryAfter)
+		}
+
+		attemptErr = attempt(txCtx)
+		if attemptErr == nil {
+			return nil
+		}
+		if !isFailure(attemptErr) {
+			return attemptErr
+		}
+
+		counters := []loginattempt.LoginAttempts{loginAttempts}
+
+		//Login is always locked before ip, so concurrent attempts can't deadlock
+		if ip != "" {
+			ipAttempts, err := ls.loginAttemptRepo.LockLoginAttempts(txCtx, ipKey(ip))
+			if err != nil {
+				return err
+			}
+			counters = append(counters, ipAttempts)
+		}
+
+		return ls.registerFailure(txCtx, counters, time.Now())
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return attemptErr
+}
+
+//Every lockout is recorded as lockout event
+func (ls *LockoutService) registerFailure(txCtx context.Context, counters []loginattempt.LoginAttempts, now time.Time) error {
+	for _, attempts := range counters {
+		key := attempts.GetKey()
+		event, locked := attempts.RegisterFailure(now, ls.policyOf(key.Type))
+
+		if err := ls.loginAttemptRepo.SaveLoginAttempts(txCtx, attempts); err != nil {
+			return err
+		}
+
+		if locked {
+			if err := ls.loginAttemptRepo.AddLockoutEvent(txCtx, event); err != nil {
+				return err
+			}
+			slog.WarnContext(txCtx, "login lockout",
+				"key_type", key.Type, "key_value", key.Value,
+				"locked_until", event.GetLockedUntil().Format(time.RFC3339), "failed_attempts", event.GetFailedCount())
+		}
+	}
+
+	return nil
+}
+
+//Method resets failed attempts of login after successful login
+//Ip counter is not reset, otherwise attacker could reset it by logging into his own account
+func (ls *LockoutService) RegisterSuccess(ctx context.Context, login string) error {
+	return ls.loginAttemptRepo.DeleteLoginAttempts(ctx, loginKey(login))
+}
+
+//Method deletes counters that are not locked and whose failures are older than reset period of their policy,
+//such counters would be reset by the next failure anyway
+func (ls *LockoutService) PurgeStaleAttempts(ctx context.Context) (int64, error) {
+	now := time.Now()
+	var deleted int64
+
+	for _, keyType := range []loginattempt.KeyType{loginattempt.LoginKey, loginattempt.IPKey} {
+		count, err := ls.loginAttemptRepo.DeleteStaleLoginAttempts(ctx, keyType, now.Add(-ls.policyOf(keyType).ResetAfter), now)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+
+	return deleted, nil
+}
+
+//Method purges stale counters every interval until ctx is done
+func (ls *LockoutService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := ls.PurgeStaleAttempts(ctx); err != nil {
+				slog.ErrorContext(ctx, "failed to purge stale login attempts", "error", err)
+			}
+		}
+	}
+}
+
+func (ls *LockoutService) policyOf(keyType loginattempt.KeyType) loginattempt.LockoutPolicy {
+	if keyType == loginattempt.IPKey {
+		return ls.ipPolicy
+	}
+	return ls.loginPolicy
+}
+
+func loginKey(login string) loginattempt.AttemptKey {
+	return loginattempt.AttemptKey{Type: loginattempt.LoginKey, Value: login}
+}
+
+func ipKey(ip string) loginattempt.AttemptKey {
+	return loginattempt.AttemptKey{Type: loginattempt.IPKey, Value: ip}
+}
@@ -0,0 +1,371 @@
+package oidcservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"symphony_chat/internal/application/cookies"
+	tx "symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/oidc"
+	"symphony_chat/internal/domain/sessions"
+	"symphony_chat/internal/domain/users"
+	authdto "symphony_chat/internal/dto/auth"
+	config "symphony_chat/internal/infrastructure/configs"
+	twoFactorService "symphony_chat/internal/service/auth/two_factor"
+	jwtService "symphony_chat/internal/service/jwt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+//How long user can stay on the page of identity provider if other ttl was not configured
+const defaultStateTTL = 10 * time.Minute
+
+type OIDCService struct {
+	providers          map[string]oidc.IdentityProvider
+	oidcRepo           oidc.OIDCRepository
+	authUserRepo       users.AuthUserRepository
+	chatUserRepo       users.ChatUserRepository
+	jwtService         *jwtService.JWTtokenService
+	twoFactorService   *twoFactorService.TwoFactorService
+	transactionManager tx.TransactionManager
+	cookieManager      *cookies.CookieManager
+	stateTTL           time.Duration
+}
+
+type OIDCConfiguration func(*OIDCService) error
+
+func NewOIDCService(configs ...OIDCConfiguration) (*OIDCService, error) {
+	oi := &OIDCService{
+		providers:     make(map[string]oidc.IdentityProvider),
+		cookieManager: cookies.NewCookieManager(config.DefaultCookieConfig()),
+		stateTTL:      defaultStateTTL,
+	}
+
+	for _, cfg := range configs {
+		err := cfg(oi)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return oi, nil
+}
+
+//Option can be used several times, providers are distinguished by name
+func WithIdentityProvider(provider oidc.IdentityProvider) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		if _, exists := oi.providers[provider.GetName()]; exists {
+			return errors.New("oidc provider " + provider.GetName() + " is configured twice")
+		}
+		oi.providers[provider.GetName()] = provider
+		return nil
+	}
+}
+
+func WithOIDCRepository(or oidc.OIDCRepository) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		oi.oidcRepo = or
+		return nil
+	}
+}
+
+func WithAuthUserRepository(au users.AuthUserRepository) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		oi.authUserRepo = au
+		return nil
+	}
+}
+
+func WithChatUserRepository(cu users.ChatUserRepository) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		oi.chatUserRepo = cu
+		return nil
+	}
+}
+
+func WithJWTtokenService(js *jwtService.JWTtokenService) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		oi.jwtService = js
+		return nil
+	}
+}
+
+//Without two factor service users with enabled two factor are logged in by identity provider only
+func WithTwoFactorService(ts *twoFactorService.TwoFactorService) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		oi.twoFactorService = ts
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		oi.transactionManager = tm
+		return nil
+	}
+}
+
+func WithCookieManager(cm *cookies.CookieManager) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		oi.cookieManager = cm
+		return nil
+	}
+}
+
+func WithStateTTL(ttl time.Duration) OIDCConfiguration {
+	return func(oi *OIDCService) error {
+		if ttl <= 0 {
+			return errors.New("oidc state ttl must be positive")
+		}
+		oi.stateTTL = ttl
+		return nil
+	}
+}
+
+//Method returns url of identity provider where user must be redirected for login and raw state of the flow
+func (oi *OIDCService) StartLogin(ctx context.Context, providerName string) (string, string, error) {
+	return oi.startAuthorization(ctx, providerName, uuid.Nil)
+}
+
+//Method returns url of identity provider and raw state of the flow, after callback external account is linked to the user
+func (oi *OIDCService) StartLink(ctx context.Context, providerName string, userID uuid.UUID) (string, string, error) {
+	return oi.startAuthorization(ctx, providerName, userID)
+}
+
+func (oi *OIDCService) startAuthorization(ctx context.Context, providerName string, linkUserID uuid.UUID) (string, string, error) {
+	provider, exists := oi.providers[providerName]
+	if !exists {
+		return "", "", oidc.ErrProviderNotFound
+	}
+
+	state, rawState, err := oidc.NewLoginState(providerName, linkUserID, oi.stateTTL)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := oi.oidcRepo.AddLoginState(ctx, state); err != nil {
+		return "", "", err
+	}
+
+	return provider.GetAuthorizationURL(rawState, state.GetNonce(), oidc.CodeChallengeS256(state.GetCodeVerifier())), rawState, nil
+}
+
+//Cookie keeps hash of the state until state expires, callback is accepted only in the browser that has it
+func (oi *OIDCService) SetStateCookie(c *gin.Context, rawState string) {
+	oi.cookieManager.SetOIDCState(c, oidc.HashState(rawState), int(oi.stateTTL.Seconds()))
+}
+
+func (oi *OIDCService) ClearStateCookie(c *gin.Context) {
+	oi.cookieManager.ClearOIDCState(c)
+}
+
+//Method finishes authorization code flow
+//State must match state cookie of the browser, otherwise url of the flow that was started by attacker
+//could log victim into attacker's account or link victim's external account to attacker's user
+//State is marked as used before code exchange, so it can't be replayed even if exchange fails
+//External account is never linked to existing user by email, only by explicit linking of logged in user
+func (oi *OIDCService) HandleCallback(ctx context.Context, providerName string, rawState string, stateCookie string, code string, device sessions.DeviceInfo) (authdto.OIDCCallbackResult, error) {
+	provider, exists := oi.providers[providerName]
+	if !exists {
+		return authdto.OIDCCallbackResult{}, oidc.ErrProviderNotFound
+	}
+
+	if subtle.ConstantTimeCompare([]byte(oidc.HashState(rawState)), []byte(stateCookie)) != 1 {
+		return authdto.OIDCCallbackResult{}, oidc.ErrLoginStateMismatch
+	}
+
+	var state oidc.LoginState
+	err := oi.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		state, err = oi.oidcRepo.GetLoginStateByHash(txCtx, oidc.HashState(rawState))
+		if err != nil {
+			return err
+		}
+
+		//State of other provider is treated as unknown
+		if state.GetProvider() != providerName {
+			return oidc.ErrLoginStateNotFound
+		}
+
+		now := time.Now()
+		if err := state.Validate(now); err != nil {
+			return err
+		}
+
+		return oi.oidcRepo.MarkLoginStateUsed(txCtx, state.GetStateHash(), now)
+	})
+	if err != nil {
+		return authdto.OIDCCallbackResult{}, err
+	}
+
+	claims, err := provider.ExchangeCode(ctx, code, state.GetCodeVerifier(), state.GetNonce())
+	if err != nil {
+		return authdto.OIDCCallbackResult{}, err
+	}
+
+	if state.IsLink() {
+		err = oi.linkIdentity(ctx, providerName, state.GetLinkAuthUserID(), claims)
+		if err != nil {
+			return authdto.OIDCCallbackResult{}, err
+		}
+		return authdto.OIDCCallbackResult{Linked: true}, nil
+	}
+
+	return oi.logIn(ctx, providerName, claims, device)
+}
+
+func (oi *OIDCService) linkIdentity(ctx context.Context, providerName string, userID uuid.UUID, claims oidc.Claims) error {
+	return oi.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		identity, err := oi.oidcRepo.GetIdentity(txCtx, providerName, claims.Subject)
+		switch {
+		case err == nil:
+			if identity.GetAuthUserID() != userID {
+				return oidc.ErrIdentityLinkedToOtherUser
+			}
+			//Account is already linked
+			return nil
+		case !errors.Is(err, oidc.ErrIdentityNotFound):
+			return err
+		}
+
+		if _, err := oi.oidcRepo.GetIdentityOfUser(txCtx, providerName, userID); err == nil {
+			return oidc.ErrProviderAlreadyLinked
+		} else if !errors.Is(err, oidc.ErrIdentityNotFound) {
+			return err
+		}
+
+		return oi.oidcRepo.AddIdentity(txCtx, oidc.NewIdentity(userID, providerName, claims.Subject, claims.Email))
+	})
+}
+
+//Method logs in user of linked identity, user with chat profile is created on first login
+func (oi *OIDCService) logIn(ctx context.Context, providerName string, claims oidc.Claims, device sessions.DeviceInfo) (authdto.OIDCCallbackResult, error) {
+	result := authdto.OIDCCallbackResult{}
+
+	err := oi.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var userID uuid.UUID
+
+		identity, err := oi.oidcRepo.GetIdentity(txCtx, providerName, claims.Subject)
+		switch {
+		case err == nil:
+			userID = identity.GetAuthUserID()
+			if err := oi.oidcRepo.UpdateIdentityLastLogin(txCtx, identity.GetID(), claims.Email, time.Now()); err != nil {
+				return err
+			}
+		case errors.Is(err, oidc.ErrIdentityNotFound):
+			userID, err = oi.createUser(txCtx, providerName, claims)
+			if err != nil {
+				return err
+			}
+			result.Created = true
+		default:
+			return err
+		}
+
+		if oi.twoFactorService != nil {
+			enabled, err := oi.twoFactorService.IsEnabled(txCtx, userID)
+			if err != nil {
+				return err
+			}
+
+			if enabled {
+				challengeToken, expiresAt, err := oi.twoFactorService.CreateChallenge(txCtx, userID)
+				if err != nil {
+					return err
+				}
+
+				result.Login.TwoFactorRequired = true
+				result.Login.ChallengeToken = challengeToken
+				result.Login.ChallengeExpiresAt = expiresAt
+				return nil
+			}
+		}
+
+		result.Login.Tokens, err = oi.jwtService.GetCreatedPairTokens(txCtx, userID, device)
+		if err != nil {
+			return &jwt.TokenError{
+				Code: "CREATE_JWT_TOKENS_ERROR",
+				Message: "failed to generate new jwt tokens",
+				Err: err,
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return authdto.OIDCCallbackResult{}, err
+	}
+
+	return result, nil
+}
+
+//Method creates auth user without password, its chat profile and linked identity
+//Login of the user is not known to the user, it can log in only through identity provider
+func (oi *OIDCService) createUser(txCtx context.Context, providerName string, claims oidc.Claims) (uuid.UUID, error) {
+	userID := uuid.New()
+	now := time.Now()
+
+	//Empty password never matches any password
+	authUser := users.NewAuthUser(userID, "oidc:"+userID.String(), "", now)
+	if err := oi.authUserRepo.AddAuthUser(txCtx, authUser); err != nil {
+		return uuid.Nil, &users.AuthError{
+			Code: "CREATE_AUTH_USER_ERROR",
+			Message: "failed to create new auth user",
+			Err: err,
+		}
+	}
+
+	username, err := oi.chooseUsername(txCtx, claims.PreferredUsername)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := oi.chatUserRepo.AddChatUser(txCtx, users.NewChatUser(userID, username, users.Offline, now, now)); err != nil {
+		return uuid.Nil, &users.AuthError{
+			Code: "CREATE_CHAT_USER_ERROR",
+			Message: "failed to create new chat user",
+			Err: err,
+		}
+	}
+
+	if err := oi.oidcRepo.AddIdentity(txCtx, oidc.NewIdentity(userID, providerName, claims.Subject, claims.Email)); err != nil {
+		return uuid.Nil, err
+	}
+
+	slog.InfoContext(txCtx, "user was created by oidc provider", "created_user_id", userID, "provider", providerName)
+
+	return userID, nil
+}
+
+//Preferred username of identity provider is used if it is valid and free, otherwise random username is generated
+//User can change it later
+func (oi *OIDCService) chooseUsername(txCtx context.Context, preferredUsername string) (string, error) {
+	username := users.NormalizeUsername(preferredUsername)
+	if users.ValidateUsername(username) == nil {
+		_, err := oi.chatUserRepo.GetChatUserByUsername(txCtx, username)
+		if errors.Is(err, users.ErrChatUserNotFound) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	randomBytes := make([]byte, 6)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", &users.AuthError{
+			Code: "CREATE_CHAT_USER_ERROR",
+			Message: "username cant be generated",
+			Err: err,
+		}
+	}
+
+	return "user_" + hex.EncodeToString(randomBytes), nil
+}
@@ -0,0 +1,254 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tx "symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/notifications"
+	passwordreset "symphony_chat/internal/domain/password_reset"
+	"symphony_chat/internal/domain/users"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
+	jwtService "symphony_chat/internal/service/jwt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//How long reset token can be used if other ttl was not configured
+const defaultResetTokenTTL = 30 * time.Minute
+
+type PasswordService struct {
+	authUserRepo       users.AuthUserRepository
+	passwordResetRepo  passwordreset.PasswordResetRepository
+	jwtService         *jwtService.JWTtokenService
+	notifier           notifications.Notifier
+	transactionManager tx.TransactionManager
+	resetTokenTTL      time.Duration
+	passwordHasher     *passwordhash.PasswordHasher
+	passwordPolicy     passwordhash.PasswordPolicy
+}
+
+type PasswordConfiguration func(*PasswordService) error
+
+func NewPasswordService(configs ...PasswordConfiguration) (*PasswordService, error) {
+	ps := &PasswordService{
+		resetTokenTTL:  defaultResetTokenTTL,
+		passwordHasher: passwordhash.DefaultPasswordHasher(),
+		passwordPolicy: passwordhash.DefaultPasswordPolicy(),
+	}
+
+	for _, cfg := range configs {
+		err := cfg(ps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ps, nil
+}
+
+func WithAuthUserRepository(au users.AuthUserRepository) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		ps.authUserRepo = au
+		return nil
+	}
+}
+
+func WithPasswordResetRepository(pr passwordreset.PasswordResetRepository) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		ps.passwordResetRepo = pr
+		return nil
+	}
+}
+
+func WithJWTtokenService(js *jwtService.JWTtokenService) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		ps.jwtService = js
+		return nil
+	}
+}
+
+func WithNotifier(n notifications.Notifier) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		ps.notifier = n
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		ps.transactionManager = tm
+		return nil
+	}
+}
+
+func WithResetTokenTTL(ttl time.Duration) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		if ttl <= 0 {
+			return errors.New("reset token ttl must be positive")
+		}
+		ps.resetTokenTTL = ttl
+		return nil
+	}
+}
+
+func WithPasswordHasher(ph *passwordhash.PasswordHasher) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		ps.passwordHasher = ph
+		return nil
+	}
+}
+
+func WithPasswordPolicy(pp passwordhash.PasswordPolicy) PasswordConfiguration {
+	return func(ps *PasswordService) error {
+		ps.passwordPolicy = pp
+		return nil
+	}
+}
+
+//Method changes password of logged in user
+//Accounts without password (OIDC) set their first password, recent sign in is required instead of current password
+//All other sessions of the user are revoked, current session stays valid
+func (ps *PasswordService) ChangePassword(ctx context.Context, userID uuid.UUID, currentSessionID uuid.UUID, currentPassword string, newPassword string) ([]uuid.UUID, error) {
+	var revokedSessionIDs []uuid.UUID
+
+	if err := ps.passwordPolicy.Validate(newPassword); err != nil {
+		return nil, err
+	}
+
+	err := ps.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		authUser, err := ps.authUserRepo.GetAuthUserById(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		if authUser.HasPassword() {
+			if matched, _ := ps.passwordHasher.Verify(currentPassword, authUser.GetPassword()); !matched {
+				return users.ErrWrongPassword
+			}
+		} else if err := ps.jwtService.CheckRecentAuthentication(txCtx, userID, currentSessionID); err != nil {
+			return err
+		}
+
+		if err := ps.setPassword(txCtx, userID, newPassword); err != nil {
+			return err
+		}
+
+		revokedSessionIDs, err = ps.jwtService.InvalidateOtherSessions(txCtx, userID, currentSessionID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.jwtService.RevokeSessionAccessTokens(ctx, revokedSessionIDs...); err != nil {
+		return nil, err
+	}
+
+	return revokedSessionIDs, nil
+}
+
+//Method sends reset token to the user with this login
+//Nothing is sent if user doesn't exist, but no error is returned, so logins can't be enumerated
+func (ps *PasswordService) RequestPasswordReset(ctx context.Context, login string) error {
+	var rawToken string
+	var resetToken passwordreset.PasswordResetToken
+
+	err := ps.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		authUser, err := ps.authUserRepo.GetAuthUserByLogin(txCtx, login)
+		if err != nil {
+			return err
+		}
+
+		//Only the last requested token can be used
+		if err := ps.passwordResetRepo.InvalidatePasswordResetTokensOfUser(txCtx, authUser.GetID(), time.Now()); err != nil {
+			return err
+		}
+
+		resetToken, rawToken, err = passwordreset.NewPasswordResetToken(authUser.GetID(), ps.resetTokenTTL)
+		if err != nil {
+			return err
+		}
+
+		return ps.passwordResetRepo.AddPasswordResetToken(txCtx, resetToken)
+	})
+
+	if err != nil {
+		if errors.Is(err, users.ErrAuthUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return ps.notifier.Notify(ctx, notifications.Notification{
+		Recipient: login,
+		Subject:   "Password reset",
+		Body: fmt.Sprintf(
+			"Use this token to reset your password: %s\nToken expires at %s.",
+			rawToken, resetToken.GetExpiresAt().UTC().Format(time.RFC3339),
+		),
+	})
+}
+
+//Method sets new password using reset token, token can be used only once
+//All sessions of the user are revoked
+func (ps *PasswordService) ResetPassword(ctx context.Context, rawToken string, newPassword string) error {
+	if err := ps.passwordPolicy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	var revokedSessionIDs []uuid.UUID
+
+	err := ps.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		resetToken, err := ps.passwordResetRepo.GetPasswordResetTokenByHash(txCtx, passwordreset.HashResetToken(rawToken))
+		if err != nil {
+			return err
+		}
+
+		if resetToken.IsUsed() {
+			return passwordreset.ErrResetTokenUsed
+		}
+
+		now := time.Now()
+		if resetToken.IsExpired(now) {
+			return passwordreset.ErrResetTokenExpired
+		}
+
+		if err := ps.passwordResetRepo.MarkPasswordResetTokenUsed(txCtx, resetToken.GetID(), now); err != nil {
+			return err
+		}
+
+		if err := ps.setPassword(txCtx, resetToken.GetAuthUserID(), newPassword); err != nil {
+			return err
+		}
+
+		revokedSessionIDs, err = ps.jwtService.InvalidateAllSessions(txCtx, resetToken.GetAuthUserID())
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ps.jwtService.RevokeSessionAccessTokens(ctx, revokedSessionIDs...)
+}
+
+func (ps *PasswordService) setPassword(txCtx context.Context, userID uuid.UUID, newPassword string) error {
+	hashedPassword, err := ps.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return &users.AuthError{
+			Code: "PASSWORD_HASHING_ERROR",
+			Message: "failed to hash password",
+			Err: err,
+		}
+	}
+
+	if err := ps.authUserRepo.UpdatePassword(txCtx, userID, hashedPassword); err != nil {
+		return err
+	}
+
+	//Reset tokens that were requested before password change can't be used anymore
+	return ps.passwordResetRepo.InvalidatePasswordResetTokensOfUser(txCtx, userID, time.Now())
+}
@@ -2,13 +2,16 @@ package registration
 
 import (
 	"context"
+	"symphony_chat/internal/application/cookies"
 	publicDto "symphony_chat/internal/application/dto"
 	tx "symphony_chat/internal/application/transaction"
 	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/sessions"
 	"symphony_chat/internal/domain/users"
 	authdto "symphony_chat/internal/dto/auth"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
+	config "symphony_chat/internal/infrastructure/configs"
 	jwtService "symphony_chat/internal/service/jwt"
-	utils "symphony_chat/utils/service"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,14 +20,22 @@ import (
 
 type RegistrationService struct {
 	authUserRepo users.AuthUserRepository
+	chatUserRepo users.ChatUserRepository
 	jwtService   *jwtService.JWTtokenService
 	transactionManager tx.TransactionManager
+	passwordHasher *passwordhash.PasswordHasher
+	passwordPolicy passwordhash.PasswordPolicy
+	cookieManager *cookies.CookieManager
 }
 
 type RegistrationConfiguration func(*RegistrationService) error
 
 func NewRegistrationService(configs ...RegistrationConfiguration) (*RegistrationService, error) {
-	rs := &RegistrationService{}
+	rs := &RegistrationService{
+		passwordHasher: passwordhash.DefaultPasswordHasher(),
+		passwordPolicy: passwordhash.DefaultPasswordPolicy(),
+		cookieManager: cookies.NewCookieManager(config.DefaultCookieConfig()),
+	}
 
 	for _, cfgFunc := range configs {
 		error := cfgFunc(rs)
@@ -44,6 +55,13 @@ func WithAuthUserRepository(au users.AuthUserRepository) RegistrationConfigurati
 	}
 }
 
+func WithChatUserRepository(cu users.ChatUserRepository) RegistrationConfiguration {
+	return func(rs *RegistrationService) error {
+		rs.chatUserRepo = cu
+		return nil
+	}
+}
+
 func WithJWTtokenService(jwtService *jwtService.JWTtokenService) RegistrationConfiguration {
 	return func(rs *RegistrationService) error {
 		rs.jwtService = jwtService
@@ -58,10 +76,41 @@ func WithTransactionManager(tm tx.TransactionManager) RegistrationConfiguration
 	}
 }
 
-func (rs *RegistrationService) SignUpUser(ctx context.Context, userInput publicDto.LoginCredentials) (authdto.AuthTokens, error) {
+func WithPasswordHasher(ph *passwordhash.PasswordHasher) RegistrationConfiguration {
+	return func(rs *RegistrationService) error {
+		rs.passwordHasher = ph
+		return nil
+	}
+}
+
+func WithPasswordPolicy(pp passwordhash.PasswordPolicy) RegistrationConfiguration {
+	return func(rs *RegistrationService) error {
+		rs.passwordPolicy = pp
+		return nil
+	}
+}
+
+func WithCookieManager(cm *cookies.CookieManager) RegistrationConfiguration {
+	return func(rs *RegistrationService) error {
+		rs.cookieManager = cm
+		return nil
+	}
+}
+
+//Method creates auth user and its chat profile in one transaction
+func (rs *RegistrationService) SignUpUser(ctx context.Context, userInput publicDto.SignUpRequest, device sessions.DeviceInfo) (authdto.AuthTokens, error) {
 
 	authTokens := authdto.AuthTokens{}
 
+	username := users.NormalizeUsername(userInput.Username)
+	if err := users.ValidateUsername(username); err != nil {
+		return authdto.AuthTokens{}, err
+	}
+
+	if err := rs.passwordPolicy.Validate(userInput.Password); err != nil {
+		return authdto.AuthTokens{}, err
+	}
+
 	err := rs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
 		
 		//Validation user input
@@ -79,7 +128,7 @@ func (rs *RegistrationService) SignUpUser(ctx context.Context, userInput publicD
 		}
 
 		//Hashing password
-		hashedPassword, err := utils.HashPassword(userInput.Password)
+		hashedPassword, err := rs.passwordHasher.Hash(userInput.Password)
 		if err != nil {
 			return &users.AuthError{
 				Code: "PASSWORD_HASHING_ERROR",
@@ -98,8 +147,22 @@ func (rs *RegistrationService) SignUpUser(ctx context.Context, userInput publicD
 			}
 		}
 
+		//Creating ChatUser, it has the same id as AuthUser
+		now := time.Now()
+		err = rs.chatUserRepo.AddChatUser(txCtx, users.NewChatUser(authUser.GetID(), username, users.Offline, now, now))
+		if err != nil {
+			if err == users.ErrUsernameAlreadyTaken {
+				return err
+			}
+			return &users.AuthError{
+				Code: "CREATE_CHAT_USER_ERROR",
+				Message: "failed to create new chat user",
+				Err: err,
+			}
+		}
+
 		//Creating pair of jwt tokens(access and refresh)
-		authTokens, err = rs.jwtService.GetCreatedPairTokens(txCtx, authUser.GetID())
+		authTokens, err = rs.jwtService.GetCreatedPairTokens(txCtx, authUser.GetID(), device)
 		if err != nil {
 			return &jwt.TokenError{
 				Code: "CREATE_JWT_TOKENS_ERROR",
@@ -130,10 +193,6 @@ func (rs *RegistrationService) CreateAuthUser(ctx context.Context, login string,
 
 
 func (rs *RegistrationService) SetRefreshTokenInHTTPCookie(c *gin.Context, refreshToken string) {
-	c.SetCookie("refresh_token", refreshToken, int(rs.jwtService.GetRefreshTokenTTL()), "/", "localhost", false, true)
-}
-
-func (rs *RegistrationService) SetAccessTokenInHTTPCookie(c *gin.Context, accessToken string) {
-	c.SetCookie("access_token", accessToken, int(rs.jwtService.GetAccessTokenTTL()), "/", "localhost", false, true)
+	rs.cookieManager.SetRefreshToken(c, refreshToken, int(rs.jwtService.GetRefreshTokenTTL()))
 }
 
@@ -0,0 +1,373 @@
+package twofactorservice
+
+import (
+	"context"
+	"errors"
+	tx "symphony_chat/internal/application/transaction"
+	twofactor "symphony_chat/internal/domain/two_factor"
+	"symphony_chat/internal/domain/users"
+	authdto "symphony_chat/internal/dto/auth"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
+	jwtService "symphony_chat/internal/service/jwt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Values that are used if others were not configured
+const (
+	defaultIssuer       = "symphony_chat"
+	defaultChallengeTTL = 5 * time.Minute
+)
+
+type TwoFactorService struct {
+	authUserRepo       users.AuthUserRepository
+	twoFactorRepo      twofactor.TwoFactorRepository
+	transactionManager tx.TransactionManager
+	issuer             string
+	challengeTTL       time.Duration
+	passwordHasher     *passwordhash.PasswordHasher
+	jwtService         *jwtService.JWTtokenService
+}
+
+type TwoFactorConfiguration func(*TwoFactorService) error
+
+func NewTwoFactorService(configs ...TwoFactorConfiguration) (*TwoFactorService, error) {
+	ts := &TwoFactorService{
+		issuer:         defaultIssuer,
+		challengeTTL:   defaultChallengeTTL,
+		passwordHasher: passwordhash.DefaultPasswordHasher(),
+	}
+
+	for _, cfg := range configs {
+		err := cfg(ts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ts, nil
+}
+
+func WithAuthUserRepository(au users.AuthUserRepository) TwoFactorConfiguration {
+	return func(ts *TwoFactorService) error {
+		ts.authUserRepo = au
+		return nil
+	}
+}
+
+func WithTwoFactorRepository(tr twofactor.TwoFactorRepository) TwoFactorConfiguration {
+	return func(ts *TwoFactorService) error {
+		ts.twoFactorRepo = tr
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) TwoFactorConfiguration {
+	return func(ts *TwoFactorService) error {
+		ts.transactionManager = tm
+		return nil
+	}
+}
+
+func WithPasswordHasher(ph *passwordhash.PasswordHasher) TwoFactorConfiguration {
+	return func(ts *TwoFactorService) error {
+		ts.passwordHasher = ph
+		return nil
+	}
+}
+
+func WithJWTtokenService(js *jwtService.JWTtokenService) TwoFactorConfiguration {
+	return func(ts *TwoFactorService) error {
+		ts.jwtService = js
+		return nil
+	}
+}
+
+//Issuer is the name of the service that is shown in authenticator app
+func WithIssuer(issuer string) TwoFactorConfiguration {
+	return func(ts *TwoFactorService) error {
+		ts.issuer = issuer
+		return nil
+	}
+}
+
+func WithChallengeTTL(ttl time.Duration) TwoFactorConfiguration {
+	return func(ts *TwoFactorService) error {
+		ts.challengeTTL = ttl
+		return nil
+	}
+}
+
+//Method creates new not enabled authenticator, previous not confirmed enrollment is replaced
+func (ts *TwoFactorService) Enroll(ctx context.Context, userID uuid.UUID) (authdto.TwoFactorEnrollment, error) {
+	var enrollment authdto.TwoFactorEnrollment
+
+	err := ts.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		current, err := ts.twoFactorRepo.GetTwoFactor(txCtx, userID)
+		if err != nil && !errors.Is(err, twofactor.ErrTwoFactorNotEnrolled) {
+			return err
+		}
+		if err == nil && current.IsEnabled() {
+			return twofactor.ErrTwoFactorAlreadyEnabled
+		}
+
+		authUser, err := ts.authUserRepo.GetAuthUserById(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		twoFactor, err := twofactor.NewTwoFactor(userID)
+		if err != nil {
+			return err
+		}
+
+		if err := ts.twoFactorRepo.SaveTwoFactor(txCtx, twoFactor); err != nil {
+			return err
+		}
+
+		enrollment = authdto.TwoFactorEnrollment{
+			Secret:          twoFactor.GetSecret(),
+			ProvisioningURI: twofactor.TOTPProvisioningURI(ts.issuer, authUser.GetLogin(), twoFactor.GetSecret()),
+		}
+		return nil
+	})
+
+	if err != nil {
+		return authdto.TwoFactorEnrollment{}, err
+	}
+
+	return enrollment, nil
+}
+
+//Method enables enrolled authenticator after first valid code and returns new recovery codes
+func (ts *TwoFactorService) Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	var rawCodes []string
+
+	err := ts.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		twoFactor, err := ts.twoFactorRepo.GetTwoFactor(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		if twoFactor.IsEnabled() {
+			return twofactor.ErrTwoFactorAlreadyEnabled
+		}
+
+		now := time.Now()
+		if !twoFactor.VerifyCode(code, now) {
+			return twofactor.ErrInvalidTwoFactorCode
+		}
+		twoFactor.Enable(now)
+
+		if err := ts.twoFactorRepo.SaveTwoFactor(txCtx, twoFactor); err != nil {
+			return err
+		}
+
+		rawCodes, err = ts.replaceRecoveryCodes(txCtx, userID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rawCodes, nil
+}
+
+//Method disables two factor, both password and totp or recovery code are required
+//Accounts without password (OIDC) need recent sign in of current session instead of password
+func (ts *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, password string, code string) error {
+	return ts.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		authUser, err := ts.authUserRepo.GetAuthUserById(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		if authUser.HasPassword() {
+			if matched, _ := ts.passwordHasher.Verify(password, authUser.GetPassword()); !matched {
+				return users.ErrWrongPassword
+			}
+		} else if err := ts.jwtService.CheckRecentAuthentication(txCtx, userID, sessionID); err != nil {
+			return err
+		}
+
+		twoFactor, err := ts.getEnabledTwoFactor(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		valid, err := ts.verifySecondFactor(txCtx, &twoFactor, code, time.Now())
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return twofactor.ErrInvalidTwoFactorCode
+		}
+
+		if err := ts.twoFactorRepo.DeleteRecoveryCodesOfUser(txCtx, userID); err != nil {
+			return err
+		}
+
+		return ts.twoFactorRepo.DeleteTwoFactor(txCtx, userID)
+	})
+}
+
+//Method replaces all recovery codes of the user, totp code is required
+func (ts *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	var rawCodes []string
+
+	err := ts.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		twoFactor, err := ts.getEnabledTwoFactor(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !twoFactor.VerifyCode(code, time.Now()) {
+			return twofactor.ErrInvalidTwoFactorCode
+		}
+
+		if err := ts.twoFactorRepo.SaveTwoFactor(txCtx, twoFactor); err != nil {
+			return err
+		}
+
+		rawCodes, err = ts.replaceRecoveryCodes(txCtx, userID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rawCodes, nil
+}
+
+//Method can be called with txCtx
+func (ts *TwoFactorService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	twoFactor, err := ts.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrTwoFactorNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return twoFactor.IsEnabled(), nil
+}
+
+//Method issues challenge after correct password, it can be called with txCtx
+func (ts *TwoFactorService) CreateChallenge(ctx context.Context, userID uuid.UUID) (string, time.Time, error) {
+	challenge, rawToken, err := twofactor.NewChallenge(userID, ts.challengeTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := ts.twoFactorRepo.AddChallenge(ctx, challenge); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return rawToken, challenge.GetExpiresAt(), nil
+}
+
+//Method returns user of the challenge if challenge can still be used
+func (ts *TwoFactorService) GetChallengeUserID(ctx context.Context, rawToken string) (uuid.UUID, error) {
+	challenge, err := ts.twoFactorRepo.GetChallengeByHash(ctx, twofactor.HashChallengeToken(rawToken))
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := challenge.Validate(time.Now()); err != nil {
+		return uuid.Nil, err
+	}
+
+	return challenge.GetAuthUserID(), nil
+}
+
+//Method checks code for the challenge and marks challenge as used, it must be called in transaction of the caller
+//Wrong code is counted even though error is returned, so caller commits transaction on ErrInvalidTwoFactorCode
+//and challenge can't be brute forced
+func (ts *TwoFactorService) VerifyChallenge(txCtx context.Context, rawToken string, code string) (uuid.UUID, error) {
+	challenge, err := ts.twoFactorRepo.GetChallengeByHash(txCtx, twofactor.HashChallengeToken(rawToken))
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now()
+	if err := challenge.Validate(now); err != nil {
+		return uuid.Nil, err
+	}
+
+	twoFactor, err := ts.getEnabledTwoFactor(txCtx, challenge.GetAuthUserID())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	valid, err := ts.verifySecondFactor(txCtx, &twoFactor, code, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if !valid {
+		if err := ts.twoFactorRepo.IncrementChallengeFailedAttempts(txCtx, challenge.GetID()); err != nil {
+			return uuid.Nil, err
+		}
+		return uuid.Nil, twofactor.ErrInvalidTwoFactorCode
+	}
+
+	if err := ts.twoFactorRepo.MarkChallengeUsed(txCtx, challenge.GetID(), now); err != nil {
+		return uuid.Nil, err
+	}
+
+	return challenge.GetAuthUserID(), nil
+}
+
+func (ts *TwoFactorService) getEnabledTwoFactor(txCtx context.Context, userID uuid.UUID) (twofactor.TwoFactor, error) {
+	twoFactor, err := ts.twoFactorRepo.GetTwoFactor(txCtx, userID)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrTwoFactorNotEnrolled) {
+			return twofactor.TwoFactor{}, twofactor.ErrTwoFactorNotEnabled
+		}
+		return twofactor.TwoFactor{}, err
+	}
+
+	if !twoFactor.IsEnabled() {
+		return twofactor.TwoFactor{}, twofactor.ErrTwoFactorNotEnabled
+	}
+
+	return twoFactor, nil
+}
+
+//Method accepts totp code or not used recovery code
+func (ts *TwoFactorService) verifySecondFactor(txCtx context.Context, twoFactor *twofactor.TwoFactor, code string, now time.Time) (bool, error) {
+	if twoFactor.VerifyCode(code, now) {
+		//Last used step is saved, so the same code can't be replayed
+		return true, ts.twoFactorRepo.SaveTwoFactor(txCtx, *twoFactor)
+	}
+
+	err := ts.twoFactorRepo.UseRecoveryCode(txCtx, twoFactor.GetAuthUserID(), twofactor.HashRecoveryCode(code), now)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrInvalidTwoFactorCode) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (ts *TwoFactorService) replaceRecoveryCodes(txCtx context.Context, userID uuid.UUID) ([]string, error) {
+	codes, rawCodes, err := twofactor.NewRecoveryCodes(userID, twofactor.RecoveryCodesCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ts.twoFactorRepo.DeleteRecoveryCodesOfUser(txCtx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := ts.twoFactorRepo.AddRecoveryCodes(txCtx, codes); err != nil {
+		return nil, err
+	}
+
+	return rawCodes, nil
+}
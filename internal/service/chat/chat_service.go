@@ -2,13 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"slices"
+	"strconv"
 	"symphony_chat/internal/application/transaction"
 	"symphony_chat/internal/domain/chat"
+	"symphony_chat/internal/domain/chat_audit"
 	"symphony_chat/internal/domain/chat_participant"
 	"symphony_chat/internal/domain/messages"
 	"symphony_chat/internal/domain/roles"
 	"symphony_chat/internal/domain/users"
+	"symphony_chat/internal/infrastructure/tracing"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,7 +25,11 @@ type ChatService struct {
 	chatRepo            chat.ChatRepository
 	chatRolesRepo       roles.ChatRoleRepository
 	chatMessageRepo     messages.ChatMessageRepository
+	chatAuditRepo       chataudit.ChatAuditRepository
+	userBlockRepo       users.UserBlockRepository
 	transactionManager  transaction.TransactionManager
+	//Can be nil, then permissions are always loaded from the database
+	permissionCache *PermissionCache
 }
 
 type ChatServiceConfiguration func(*ChatService) error
@@ -60,6 +69,20 @@ func WithChatMessageRepository(chatMessageRepo messages.ChatMessageRepository) C
 	}
 }
 
+func WithChatAuditRepository(chatAuditRepo chataudit.ChatAuditRepository) ChatServiceConfiguration {
+	return func(s *ChatService) error {
+		s.chatAuditRepo = chatAuditRepo
+		return nil
+	}
+}
+
+func WithUserBlockRepository(userBlockRepo users.UserBlockRepository) ChatServiceConfiguration {
+	return func(s *ChatService) error {
+		s.userBlockRepo = userBlockRepo
+		return nil
+	}
+}
+
 func WithTransactionManager(tm transaction.TransactionManager) ChatServiceConfiguration {
 	return func(s *ChatService) error {
 		s.transactionManager = tm
@@ -67,6 +90,13 @@ func WithTransactionManager(tm transaction.TransactionManager) ChatServiceConfig
 	}
 }
 
+func WithPermissionCache(permissionCache *PermissionCache) ChatServiceConfiguration {
+	return func(s *ChatService) error {
+		s.permissionCache = permissionCache
+		return nil
+	}
+}
+
 
 func NewChatService(configs ...ChatServiceConfiguration) (*ChatService, error) {
 	cs := &ChatService{}
@@ -83,6 +113,9 @@ func NewChatService(configs ...ChatServiceConfiguration) (*ChatService, error) {
 
 
 func (cs *ChatService) CreateChat(ctx context.Context, createrID uuid.UUID, chatName string) (chat.Chat, error) {
+	ctx, span := tracing.Start(ctx, "ChatService.CreateChat")
+	defer span.End()
+
 	createdChat, err := chat.NewChat(chatName)
 	if err != nil {
 		return chat.Chat{}, err
@@ -108,7 +141,9 @@ func (cs *ChatService) CreateChat(ctx context.Context, createrID uuid.UUID, chat
 }
 
 func (cs *ChatService) GetChatsOfUser(ctx context.Context, userID uuid.UUID) ([]chat.Chat, error) {
-	
+	ctx, span := tracing.Start(ctx, "ChatService.GetChatsOfUser")
+	defer span.End()
+
 	var chats []chat.Chat
 	
 	err := cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
@@ -130,6 +165,8 @@ func (cs *ChatService) GetChatsOfUser(ctx context.Context, userID uuid.UUID) ([]
 }
 
 func (cs *ChatService) DeleteChat(ctx context.Context, chatID uuid.UUID, deletingInitiatorID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.DeleteChat")
+	defer span.End()
 
 	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, deletingInitiatorID, roles.PermissionDeleteChat)
 	if err != nil {
@@ -142,23 +179,9 @@ func (cs *ChatService) DeleteChat(ctx context.Context, chatID uuid.UUID, deletin
 
 	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
 
-		//Deleting chat participants
-		if err := cs.chatParticipantRepo.DeleteAllChatParticipants(txCtx, chatID); err != nil {
-			return err
-		}
-
-		//Deleting chat messages
-		if err := cs.chatMessageRepo.DeleteAllChatMessagesByChatID(txCtx, chatID); err != nil {
-			return err
-		}
-
-		//Deleting chat
-		if err := cs.chatRepo.DeleteChat(txCtx, chatID); err != nil {
-			return err
-		}
-
-		return nil
+		return cs.deleteChatWithContent(txCtx, chatID, deletingInitiatorID)
 	})
+	cs.permissionCache.InvalidateChat(chatID)
 
 	if err != nil {
 		return err
@@ -168,6 +191,8 @@ func (cs *ChatService) DeleteChat(ctx context.Context, chatID uuid.UUID, deletin
 }
 
 func (cs *ChatService) RenameChat(ctx context.Context, chatID uuid.UUID, newName string, renamingInitiatorID uuid.UUID) (string, error) {
+	ctx, span := tracing.Start(ctx, "ChatService.RenameChat")
+	defer span.End()
 
 	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, renamingInitiatorID, roles.PermissionUpdateChatName)
 	if err != nil {
@@ -179,11 +204,16 @@ func (cs *ChatService) RenameChat(ctx context.Context, chatID uuid.UUID, newName
 	}
 
 	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		renamingChat, err := cs.chatRepo.GetChatByID(txCtx, chatID)
+		if err != nil {
+			return err
+		}
+
 		if err := cs.chatRepo.UpdateChatName(txCtx, chatID, newName); err != nil {
 			return err
 		}
 
-		return nil
+		return cs.AddChatAuditEntry(txCtx, chatID, renamingInitiatorID, uuid.Nil, chataudit.ChatRenamedAction, renamingChat.GetName(), newName)
 	})
 
 	if err != nil {
@@ -193,7 +223,58 @@ func (cs *ChatService) RenameChat(ctx context.Context, chatID uuid.UUID, newName
 	return newName, nil
 }
 
+func (cs *ChatService) SetChatSlowMode(ctx context.Context, chatID uuid.UUID, interval time.Duration, settingInitiatorID uuid.UUID) (time.Duration, error) {
+	ctx, span := tracing.Start(ctx, "ChatService.SetChatSlowMode")
+	defer span.End()
+
+	if err := chat.ValidateSlowModeInterval(interval); err != nil {
+		return 0, err
+	}
+
+	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, settingInitiatorID, roles.PermissionManageSlowMode)
+	if err != nil {
+		return 0, err
+	}
+
+	if !isEnoughPermissions {
+		return 0, roles.ErrInsufficientPermissions
+	}
+
+	//Slow mode works with whole seconds
+	interval = interval.Truncate(time.Second)
+
+	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		updatingChat, err := cs.chatRepo.GetChatByID(txCtx, chatID)
+		if err != nil {
+			return err
+		}
+
+		if err := cs.chatRepo.UpdateChatSlowModeInterval(txCtx, chatID, interval); err != nil {
+			return err
+		}
+
+		return cs.AddChatAuditEntry(
+			txCtx,
+			chatID,
+			settingInitiatorID,
+			uuid.Nil,
+			chataudit.ChatSlowModeChangedAction,
+			strconv.FormatInt(int64(updatingChat.GetSlowModeInterval()/time.Second), 10),
+			strconv.FormatInt(int64(interval/time.Second), 10),
+		)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return interval, nil
+}
+
 func (cs *ChatService) AddUserToChat(ctx context.Context, chatID uuid.UUID, inviterUserID uuid.UUID, invitedUserID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.AddUserToChat")
+	defer span.End()
+
 	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, inviterUserID, roles.PermissionAddMember)
 	if err != nil {
 		return err
@@ -204,23 +285,34 @@ func (cs *ChatService) AddUserToChat(ctx context.Context, chatID uuid.UUID, invi
 	}
 
 	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		//User that blocked the inviter can't be added to chats by him
+		isBlocked, err := cs.userBlockRepo.IsBlocked(txCtx, invitedUserID, inviterUserID)
+		if err != nil {
+			return err
+		}
+
+		if isBlocked {
+			return users.ErrBlockedByUser
+		}
+
 		if err := cs.CreateChatMember(txCtx, chatID, invitedUserID); err != nil {
 			return err
 		}
 
-		return nil
+		return cs.AddChatAuditEntry(txCtx, chatID, inviterUserID, invitedUserID, chataudit.MemberAddedAction, "", roles.MemberChatRole.GetName())
 	})
 
 	if err != nil {
 		return err
 	}
 
-
-
 	return nil 
 }
 
 func (cs *ChatService) RemoveUserFromChat(ctx context.Context, chatID uuid.UUID, removerUserID uuid.UUID, removedUserID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.RemoveUserFromChat")
+	defer span.End()
+
 	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, removerUserID, roles.PermissionRemoveMember)
 	if err != nil {
 		return err
@@ -231,13 +323,19 @@ func (cs *ChatService) RemoveUserFromChat(ctx context.Context, chatID uuid.UUID,
 	}
 
 	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		removedRoleName, err := cs.GetChatParticipantRoleName(txCtx, chatID, removedUserID)
+		if err != nil {
+			return err
+		}
+
 		if err := cs.chatParticipantRepo.DeleteChatParticipant(txCtx, chatID, removedUserID); err != nil {
 			return err
 		}
 
 		//We are not deleting messages of the removed user from the chat
-		return nil
+		return cs.AddChatAuditEntry(txCtx, chatID, removerUserID, removedUserID, chataudit.MemberKickedAction, removedRoleName, "")
 	})
+	cs.permissionCache.InvalidateParticipant(chatID, removedUserID)
 
 	if err != nil {
 		return err
@@ -247,6 +345,9 @@ func (cs *ChatService) RemoveUserFromChat(ctx context.Context, chatID uuid.UUID,
 }
 
 func (cs *ChatService) PromoteUserToChatAdmin(ctx context.Context, chatID uuid.UUID, promoterUserID uuid.UUID, promotedUserID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.PromoteUserToChatAdmin")
+	defer span.End()
+
 	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, promoterUserID, roles.PermissionManageRoles)
 	if err != nil {
 		return err
@@ -257,11 +358,18 @@ func (cs *ChatService) PromoteUserToChatAdmin(ctx context.Context, chatID uuid.U
 	}
 
 	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		previousRoleName, err := cs.GetChatParticipantRoleName(txCtx, chatID, promotedUserID)
+		if err != nil {
+			return err
+		}
+
 		if err := cs.chatParticipantRepo.UpdateChatParticipantRole(txCtx, chatID, promotedUserID, roles.AdminChatRole.GetID()); err != nil {
 			return err
 		}
-		return nil
+
+		return cs.AddChatAuditEntry(txCtx, chatID, promoterUserID, promotedUserID, chataudit.MemberPromotedToAdminAction, previousRoleName, roles.AdminChatRole.GetName())
 	})
+	cs.permissionCache.InvalidateParticipant(chatID, promotedUserID)
 
 	if err != nil {
 		return err
@@ -271,6 +379,9 @@ func (cs *ChatService) PromoteUserToChatAdmin(ctx context.Context, chatID uuid.U
 }
 
 func (cs *ChatService) DemoteChatAdminToChatMember(ctx context.Context, chatID uuid.UUID, demoterUserID uuid.UUID, adminUserID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.DemoteChatAdminToChatMember")
+	defer span.End()
+
 	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, demoterUserID, roles.PermissionManageRoles)
 	if err != nil {
 		return err
@@ -281,11 +392,18 @@ func (cs *ChatService) DemoteChatAdminToChatMember(ctx context.Context, chatID u
 	}
 
 	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		previousRoleName, err := cs.GetChatParticipantRoleName(txCtx, chatID, adminUserID)
+		if err != nil {
+			return err
+		}
+
 		if err := cs.chatParticipantRepo.UpdateChatParticipantRole(txCtx, chatID, adminUserID, roles.MemberChatRole.GetID()); err != nil {
 			return err
 		}
-		return nil
+
+		return cs.AddChatAuditEntry(txCtx, chatID, demoterUserID, adminUserID, chataudit.AdminDemotedToMemberAction, previousRoleName, roles.MemberChatRole.GetName())
 	})
+	cs.permissionCache.InvalidateParticipant(chatID, adminUserID)
 
 	if err != nil {
 		return err
@@ -295,9 +413,13 @@ func (cs *ChatService) DemoteChatAdminToChatMember(ctx context.Context, chatID u
 }
 
 func (cs *ChatService) LeaveChat(ctx context.Context, chatID uuid.UUID, leavingUserID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.LeaveChat")
+	defer span.End()
+
 	err := cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
 		return cs.chatParticipantRepo.DeleteChatParticipant(txCtx, chatID, leavingUserID)
 	})
+	cs.permissionCache.InvalidateParticipant(chatID, leavingUserID)
 	if err != nil {
 		return err
 	}
@@ -306,6 +428,9 @@ func (cs *ChatService) LeaveChat(ctx context.Context, chatID uuid.UUID, leavingU
 }
 
 func (cs *ChatService) SendMessage(ctx context.Context, chatID uuid.UUID, senderID uuid.UUID, message string) (uuid.UUID,error) {
+	ctx, span := tracing.Start(ctx, "ChatService.SendMessage")
+	defer span.End()
+
 	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, senderID, roles.PermissionAddMessage)
 	if err != nil {
 		return uuid.Nil,err
@@ -318,6 +443,10 @@ func (cs *ChatService) SendMessage(ctx context.Context, chatID uuid.UUID, sender
 	var messageID uuid.UUID
 
 	err = cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := cs.CheckSlowMode(txCtx, chatID, senderID, time.Now()); err != nil {
+			return err
+		}
+
 		messageID, err = cs.CreateChatMessage(txCtx, chatID, senderID, message)
 		if err != nil {
 			return err
@@ -333,6 +462,9 @@ func (cs *ChatService) SendMessage(ctx context.Context, chatID uuid.UUID, sender
 }
 
 func (cs *ChatService) EditMessage(ctx context.Context, chatID uuid.UUID, messageID uuid.UUID, senderID uuid.UUID, newMessage string) (string, error) {
+	ctx, span := tracing.Start(ctx, "ChatService.EditMessage")
+	defer span.End()
+
 	err := cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
 
 		senderIDfromDB, err := cs.chatMessageRepo.GetChatMessageSenderID(txCtx, messageID)
@@ -365,6 +497,9 @@ func (cs *ChatService) EditMessage(ctx context.Context, chatID uuid.UUID, messag
 }
 
 func (cs *ChatService) DeleteMessage(ctx context.Context, chatID uuid.UUID, messageID uuid.UUID, senderID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.DeleteMessage")
+	defer span.End()
+
 	err := cs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
 		
 		senderIDfromDB, err := cs.chatMessageRepo.GetChatMessageSenderID(txCtx, messageID)
@@ -391,7 +526,177 @@ func (cs *ChatService) DeleteMessage(ctx context.Context, chatID uuid.UUID, mess
 	return nil
 }
 
+//Method returns audit log of the chat, only owners and admins can see it
+func (cs *ChatService) GetChatAuditLog(ctx context.Context, chatID uuid.UUID, requesterID uuid.UUID, filter chataudit.ChatAuditFilter) ([]chataudit.ChatAuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "ChatService.GetChatAuditLog")
+	defer span.End()
+
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	isEnoughPermissions, err := cs.IsUserHasEnoughPermissions(ctx, chatID, requesterID, roles.PermissionViewAuditLog)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEnoughPermissions {
+		return nil, roles.ErrInsufficientPermissions
+	}
+
+	return cs.chatAuditRepo.GetChatAuditEntries(ctx, chatID, filter)
+}
+
+//Method removes user from all chats, it must be called inside of the transaction of account deletion
+//Owned chats are passed to the oldest admin (or the oldest member), chats without other participants are dissolved
+//Messages of the user stay in chats, but their sender becomes deleted user placeholder
+//Method returns ids of affected chats, caller invalidates cached permissions of these chats after commit
+func (cs *ChatService) DetachDeletedUser(txCtx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	txCtx, span := tracing.Start(txCtx, "ChatService.DetachDeletedUser")
+	defer span.End()
+
+	chatIDs, err := cs.chatParticipantRepo.GetAllChatsByUserID(txCtx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chatID := range chatIDs {
+		participants, err := cs.chatParticipantRepo.GetAllChatParticipantsByChatID(txCtx, chatID)
+		if err != nil {
+			return nil, err
+		}
+
+		var isOwner bool
+		otherParticipants := make([]chatparticipant.ChatParticipant, 0, len(participants))
+		for _, participant := range participants {
+			if participant.GetUserID() == userID {
+				isOwner = participant.GetRoleID() == roles.OwnerChatRole.GetID()
+				continue
+			}
+			otherParticipants = append(otherParticipants, participant)
+		}
+
+		if !isOwner {
+			if err := cs.chatParticipantRepo.DeleteChatParticipant(txCtx, chatID, userID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		successor, found := pickChatSuccessor(otherParticipants)
+		if !found {
+			if err := cs.deleteChatWithContent(txCtx, chatID, userID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := cs.chatParticipantRepo.UpdateChatParticipantRole(txCtx, chatID, successor.GetUserID(), roles.OwnerChatRole.GetID()); err != nil {
+			return nil, err
+		}
+
+		if err := cs.chatParticipantRepo.DeleteChatParticipant(txCtx, chatID, userID); err != nil {
+			return nil, err
+		}
+
+		successorRole, err := roles.GetBuiltInChatRoleByID(successor.GetRoleID())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cs.AddChatAuditEntry(txCtx, chatID, userID, successor.GetUserID(), chataudit.ChatOwnershipTransferredAction, successorRole.GetName(), roles.OwnerChatRole.GetName()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cs.chatMessageRepo.ReassignChatMessagesOfSender(txCtx, userID, users.DeletedChatUser.GetID()); err != nil {
+		return nil, err
+	}
+
+	return chatIDs, nil
+}
+
+//Function picks new owner of the chat: the oldest admin, if there are no admins - the oldest member
+func pickChatSuccessor(participants []chatparticipant.ChatParticipant) (chatparticipant.ChatParticipant, bool) {
+	var successor chatparticipant.ChatParticipant
+	var found bool
+
+	for _, participant := range participants {
+		isAdmin := participant.GetRoleID() == roles.AdminChatRole.GetID()
+		successorIsAdmin := found && successor.GetRoleID() == roles.AdminChatRole.GetID()
+
+		switch {
+		case !found,
+			isAdmin && !successorIsAdmin,
+			isAdmin == successorIsAdmin && participant.GetJoinedAt().Before(successor.GetJoinedAt()):
+			successor = participant
+			found = true
+		}
+	}
+
+	return successor, found
+}
+
+//Method deletes chat with its participants, messages and audit log, it must be called inside of the transaction
+func (cs *ChatService) deleteChatWithContent(txCtx context.Context, chatID uuid.UUID, initiatorID uuid.UUID) error {
+	txCtx, span := tracing.Start(txCtx, "ChatService.deleteChatWithContent")
+	defer span.End()
+
+	deletingChat, err := cs.chatRepo.GetChatByID(txCtx, chatID)
+	if err != nil {
+		return err
+	}
+
+	//Deleting chat participants
+	if err := cs.chatParticipantRepo.DeleteAllChatParticipants(txCtx, chatID); err != nil {
+		return err
+	}
+
+	//Deleting chat messages (chat can have no messages)
+	if err := cs.chatMessageRepo.DeleteAllChatMessagesByChatID(txCtx, chatID); err != nil && !errors.Is(err, messages.ErrChatMessageNotFound) {
+		return err
+	}
+
+	//Deleting chat (its audit log is deleted by the database)
+	if err := cs.chatRepo.DeleteChat(txCtx, chatID); err != nil {
+		return err
+	}
+
+	slog.InfoContext(txCtx, "chat was deleted", "deleted_chat_id", chatID, "chat_name", deletingChat.GetName(), "deleted_by", initiatorID)
+	return nil
+}
+
+//Method writes audit entry, it must be called inside of the transaction of the audited action
+func (cs *ChatService) AddChatAuditEntry(txCtx context.Context, chatID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID, action chataudit.AuditAction, beforeValue string, afterValue string) error {
+	txCtx, span := tracing.Start(txCtx, "ChatService.AddChatAuditEntry")
+	defer span.End()
+
+	entry := chataudit.NewChatAuditEntry(chatID, actorID, targetID, action, beforeValue, afterValue)
+	return cs.chatAuditRepo.AddChatAuditEntry(txCtx, entry)
+}
+
+func (cs *ChatService) GetChatParticipantRoleName(ctx context.Context, chatID uuid.UUID, userID uuid.UUID) (string, error) {
+	ctx, span := tracing.Start(ctx, "ChatService.GetChatParticipantRoleName")
+	defer span.End()
+
+	chatParticipant, err := cs.chatParticipantRepo.GetChatParticipantByIDs(ctx, chatID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	chatRole, err := roles.GetBuiltInChatRoleByID(chatParticipant.GetRoleID())
+	if err != nil {
+		return "", err
+	}
+
+	return chatRole.GetName(), nil
+}
+
 func (cs *ChatService) CreateChatOwner(ctx context.Context, chatID uuid.UUID, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.CreateChatOwner")
+	defer span.End()
+
 	chatOwner := chatparticipant.NewChatParticipant(chatID, userID, roles.OwnerChatRole.GetID(), time.Now())
 	err := cs.chatParticipantRepo.AddChatParticipant(ctx, chatOwner)
 	if err != nil {
@@ -401,6 +706,9 @@ func (cs *ChatService) CreateChatOwner(ctx context.Context, chatID uuid.UUID, us
 }
 
 func (cs *ChatService) CreateChatMember(ctx context.Context, chatID uuid.UUID, userID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "ChatService.CreateChatMember")
+	defer span.End()
+
 	chatMember := chatparticipant.NewChatParticipant(chatID, userID, roles.MemberChatRole.GetID(), time.Now())
 	err := cs.chatParticipantRepo.AddChatParticipant(ctx, chatMember)
 	if err != nil {
@@ -410,6 +718,8 @@ func (cs *ChatService) CreateChatMember(ctx context.Context, chatID uuid.UUID, u
 }
 
 func (cs *ChatService) CreateChatMessage(ctx context.Context, chatID uuid.UUID, senderID uuid.UUID, message string) (uuid.UUID, error) {
+	ctx, span := tracing.Start(ctx, "ChatService.CreateChatMessage")
+	defer span.End()
 
 	if message == "" {
 		return uuid.Nil, messages.ErrEmptyChatMessage
@@ -425,19 +735,74 @@ func (cs *ChatService) CreateChatMessage(ctx context.Context, chatID uuid.UUID,
 	return chatMessage.GetID(), nil
 }
 
+//Method checks that sender doesn't violate slow mode of the chat
+//Owners and admins are not affected by slow mode
+//It must be called in the transaction that adds the message, otherwise the lock of the sender is released before it
+func (cs *ChatService) CheckSlowMode(ctx context.Context, chatID uuid.UUID, senderID uuid.UUID, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "ChatService.CheckSlowMode")
+	defer span.End()
+
+	foundChat, err := cs.chatRepo.GetChatByID(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	if !foundChat.IsSlowModeEnabled() {
+		return nil
+	}
+
+	//Row of the sender is locked until the message is added, so concurrent messages of the sender are checked one by one
+	chatParticipant, err := cs.chatParticipantRepo.LockChatParticipant(ctx, chatID, senderID)
+	if err != nil {
+		return err
+	}
+
+	if chatParticipant.GetRoleID() != roles.MemberChatRole.GetID() {
+		return nil
+	}
+
+	lastMessageAt, err := cs.chatMessageRepo.GetLastChatMessageCreatedAtBySenderID(ctx, chatID, senderID)
+	if err != nil {
+		if errors.Is(err, messages.ErrChatMessageNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	nextAllowedAt := lastMessageAt.Add(foundChat.GetSlowModeInterval())
+	if now.Before(nextAllowedAt) {
+		return messages.NewSlowModeError(nextAllowedAt.Sub(now))
+	}
+
+	return nil
+}
+
 func (cs *ChatService) IsUserHasEnoughPermissions(ctx context.Context, chatID uuid.UUID, userID uuid.UUID, requiredPermissions ...roles.Permission) (bool, error) {
-	chatParticipant, err := cs.chatParticipantRepo.GetChatParticipantByIDs(ctx, chatID, userID)
+	ctx, span := tracing.Start(ctx, "ChatService.IsUserHasEnoughPermissions")
+	defer span.End()
+
+	roleID, err := cs.permissionCache.RoleOfParticipant(chatID, userID, func() (uuid.UUID, error) {
+		chatParticipant, err := cs.chatParticipantRepo.GetChatParticipantByIDs(ctx, chatID, userID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return chatParticipant.GetRoleID(), nil
+	})
 	if err != nil {
 		return false, err
 	}
 
-	chatRole, err := cs.chatRolesRepo.GetChatRoleByID(ctx, chatParticipant.GetRoleID())
+	userPermissions, err := cs.permissionCache.PermissionsOfRole(roleID, func() ([]roles.Permission, error) {
+		chatRole, err := cs.chatRolesRepo.GetChatRoleByID(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		return chatRole.GetPermissions(), nil
+	})
 	if err != nil {
 		return false, err
 	}
 
-	userPermissions := chatRole.GetPermissions()
-
 	for _, requiredPermission := range requiredPermissions {
 		if !slices.Contains(userPermissions, requiredPermission) {
 			return false, nil
@@ -450,4 +815,18 @@ func (cs *ChatService) IsUserHasEnoughPermissions(ctx context.Context, chatID uu
 
 
 
-	
\ No newline at end of file
+	
+
+//Methods are used when membership changes are committed outside of methods of the service (hub events, account deletion)
+
+func (cs *ChatService) InvalidateCachedPermissions(chatID uuid.UUID, userID uuid.UUID) {
+	cs.permissionCache.InvalidateParticipant(chatID, userID)
+}
+
+func (cs *ChatService) InvalidateCachedPermissionsOfChat(chatID uuid.UUID) {
+	cs.permissionCache.InvalidateChat(chatID)
+}
+
+func (cs *ChatService) InvalidateCachedPermissionsOfUser(userID uuid.UUID) {
+	cs.permissionCache.InvalidateUser(userID)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"symphony_chat/internal/domain/roles"
+	"symphony_chat/internal/infrastructure/metrics"
+	"symphony_chat/utils/lru"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	roleCacheName       = "role"
+	membershipCacheName = "membership"
+)
+
+type membershipKey struct {
+	chatID uuid.UUID
+	userID uuid.UUID
+}
+
+//PermissionCache keeps permissions of chat roles and roles of chat participants,
+//so permission checks of websocket actions don't query the database every time
+//
+//Only found values are cached, memberships are invalidated by writes of ChatService and by hub events
+//Roles are changed only by role sync before the server starts, so their entries are refreshed only by ttl
+//Methods can be called on nil *PermissionCache, then values are always loaded
+type PermissionCache struct {
+	rolePermissions *lru.Cache[uuid.UUID, []roles.Permission]
+	memberships     *lru.Cache[membershipKey, uuid.UUID]
+	metrics         *metrics.Metrics
+}
+
+func NewPermissionCache(maxEntries int, ttl time.Duration, m *metrics.Metrics) *PermissionCache {
+	return &PermissionCache{
+		rolePermissions: lru.NewCache(maxEntries, ttl,
+			lru.WithEvictionCallback[uuid.UUID, []roles.Permission](func(reason string) {
+				m.ObservePermissionCacheEviction(roleCacheName, reason)
+			}),
+		),
+		memberships: lru.NewCache(maxEntries, ttl,
+			lru.WithEvictionCallback[membershipKey, uuid.UUID](func(reason string) {
+				m.ObservePermissionCacheEviction(membershipCacheName, reason)
+			}),
+		),
+		metrics: m,
+	}
+}
+
+//Method returns role of the participant from the cache or loads it
+func (pc *PermissionCache) RoleOfParticipant(chatID uuid.UUID, userID uuid.UUID, load func() (uuid.UUID, error)) (uuid.UUID, error) {
+	if pc == nil {
+		return load()
+	}
+	return getOrLoad(pc.memberships, membershipKey{chatID: chatID, userID: userID}, load, pc.metrics, membershipCacheName)
+}
+
+//Method returns permissions of the role from the cache or loads them
+func (pc *PermissionCache) PermissionsOfRole(roleID uuid.UUID, load func() ([]roles.Permission, error)) ([]roles.Permission, error) {
+	if pc == nil {
+		return load()
+	}
+	return getOrLoad(pc.rolePermissions, roleID, load, pc.metrics, roleCacheName)
+}
+
+func (pc *PermissionCache) InvalidateParticipant(chatID uuid.UUID, userID uuid.UUID) {
+	if pc == nil {
+		return
+	}
+
+	pc.memberships.Delete(membershipKey{chatID: chatID, userID: userID})
+	pc.metrics.ObservePermissionCacheInvalidation(membershipCacheName)
+}
+
+//Method is used when roles of several participants can change at once, like when owner leaves the chat
+func (pc *PermissionCache) InvalidateChat(chatID uuid.UUID) {
+	if pc == nil {
+		return
+	}
+
+	pc.memberships.DeleteFunc(func(key membershipKey) bool {
+		return key.chatID == chatID
+	})
+	pc.metrics.ObservePermissionCacheInvalidation(membershipCacheName)
+}
+
+func (pc *PermissionCache) InvalidateUser(userID uuid.UUID) {
+	if pc == nil {
+		return
+	}
+
+	pc.memberships.DeleteFunc(func(key membershipKey) bool {
+		return key.userID == userID
+	})
+	pc.metrics.ObservePermissionCacheInvalidation(membershipCacheName)
+}
+
+func (pc *PermissionCache) EntriesCount() map[string]int {
+	if pc == nil {
+		return nil
+	}
+
+	return map[string]int{
+		roleCacheName:       pc.rolePermissions.Len(),
+		membershipCacheName: pc.memberships.Len(),
+	}
+}
+
+func getOrLoad[K comparable, V any](cache *lru.Cache[K, V], key K, load func() (V, error), m *metrics.Metrics, cacheName string) (V, error) {
+	if value, found := cache.Get(key); found {
+		m.ObservePermissionCacheLookup(cacheName, true)
+		return value, nil
+	}
+	m.ObservePermissionCacheLookup(cacheName, false)
+
+	//Generation is taken before loading, so value is not stored if it was invalidated while it was loading
+	generation := cache.Generation()
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	cache.SetIfGeneration(key, value, generation)
+	return value, nil
+}
@@ -0,0 +1,176 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	healthdto "symphony_chat/internal/dto/health"
+	"symphony_chat/internal/infrastructure/database"
+	"sync/atomic"
+	"time"
+)
+
+//ConnectionAcceptor tells if new realtime connections are accepted (websocket handler)
+type ConnectionAcceptor interface {
+	IsAcceptingConnections() bool
+}
+
+//HealthService reports liveness of the process and readiness of its dependencies
+//Components that were not configured are not checked
+type HealthService struct {
+	db                       *sql.DB
+	expectedMigrationVersion uint
+	connectionAcceptor       ConnectionAcceptor
+	//Every component check gets its own timeout, so one hanging dependency doesn't hide state of the others
+	checkTimeout time.Duration
+	draining     atomic.Bool
+}
+
+type HealthConfiguration func(*HealthService) error
+
+func NewHealthService(configs ...HealthConfiguration) (*HealthService, error) {
+	hs := &HealthService{
+		checkTimeout: 2 * time.Second,
+	}
+
+	for _, cfg := range configs {
+		err := cfg(hs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return hs, nil
+}
+
+func WithDatabase(db *sql.DB) HealthConfiguration {
+	return func(hs *HealthService) error {
+		hs.db = db
+		return nil
+	}
+}
+
+//Without expected version migrations are not checked
+func WithExpectedMigrationVersion(version uint) HealthConfiguration {
+	return func(hs *HealthService) error {
+		hs.expectedMigrationVersion = version
+		return nil
+	}
+}
+
+func WithConnectionAcceptor(ca ConnectionAcceptor) HealthConfiguration {
+	return func(hs *HealthService) error {
+		hs.connectionAcceptor = ca
+		return nil
+	}
+}
+
+//Process is alive while it can handle requests, dependencies are not checked
+//so orchestrator doesn't restart the instance when database is unavailable
+func (hs *HealthService) Liveness(ctx context.Context) healthdto.HealthReport {
+	return newHealthReport(map[string]healthdto.ComponentStatus{
+		"server": {Status: healthdto.StatusUp},
+	})
+}
+
+//Method is called on shutdown before server stops accepting connections,
+//so readiness probe fails and load balancer removes instance while requests are still served
+func (hs *HealthService) StartDraining() {
+	hs.draining.Store(true)
+}
+
+//Instance is ready when database is reachable, schema is migrated and websocket connections are accepted
+//Details of failed checks are logged, report is public and contains only generic descriptions
+func (hs *HealthService) Readiness(ctx context.Context) healthdto.HealthReport {
+	if hs.draining.Load() {
+		return healthdto.HealthReport{
+			Status:     healthdto.StatusDraining,
+			Components: map[string]healthdto.ComponentStatus{},
+		}
+	}
+
+	components := make(map[string]healthdto.ComponentStatus)
+
+	if hs.db != nil {
+		components["database"] = hs.checkDatabase(ctx)
+
+		if hs.expectedMigrationVersion != 0 {
+			components["migrations"] = hs.checkMigrations(ctx)
+		}
+	}
+
+	if hs.connectionAcceptor != nil {
+		components["websocket"] = hs.checkWebsocket()
+	}
+
+	return newHealthReport(components)
+}
+
+//Ping goes through the pool, so exhausted pool makes instance not ready too
+func (hs *HealthService) checkDatabase(ctx context.Context) healthdto.ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, hs.checkTimeout)
+	defer cancel()
+
+	if err := hs.db.PingContext(ctx); err != nil {
+		slog.WarnContext(ctx, "readiness check failed", "component", "database", "error", err)
+		return componentDown("database is unreachable")
+	}
+
+	return healthdto.ComponentStatus{Status: healthdto.StatusUp}
+}
+
+func (hs *HealthService) checkMigrations(ctx context.Context) healthdto.ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, hs.checkTimeout)
+	defer cancel()
+
+	version, dirty, err := database.CurrentMigrationVersion(ctx, hs.db)
+	if err != nil {
+		slog.WarnContext(ctx, "readiness check failed", "component", "migrations", "error", err)
+		return componentDown("migration version can't be read")
+	}
+
+	if dirty {
+		return componentDown(fmt.Sprintf("migration %d failed, database is dirty", version))
+	}
+
+	if version != hs.expectedMigrationVersion {
+		return componentDown(fmt.Sprintf("expected migration version %d, database is at %d", hs.expectedMigrationVersion, version))
+	}
+
+	return healthdto.ComponentStatus{
+		Status:  healthdto.StatusUp,
+		Details: fmt.Sprintf("version %d", version),
+	}
+}
+
+func (hs *HealthService) checkWebsocket() healthdto.ComponentStatus {
+	if !hs.connectionAcceptor.IsAcceptingConnections() {
+		return componentDown("new connections are not accepted")
+	}
+
+	return healthdto.ComponentStatus{Status: healthdto.StatusUp}
+}
+
+func componentDown(details string) healthdto.ComponentStatus {
+	return healthdto.ComponentStatus{
+		Status:  healthdto.StatusDown,
+		Details: details,
+	}
+}
+
+func newHealthReport(components map[string]healthdto.ComponentStatus) healthdto.HealthReport {
+	report := healthdto.HealthReport{
+		Status:     healthdto.StatusUp,
+		Components: components,
+	}
+
+	for _, component := range components {
+		if component.Status != healthdto.StatusUp {
+			report.Status = healthdto.StatusDown
+			break
+		}
+	}
+
+	return report
+}
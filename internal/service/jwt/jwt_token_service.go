@@ -3,9 +3,14 @@ package service
 import (
 	"context"
 	"errors"
+	"log/slog"
+	tx "symphony_chat/internal/application/transaction"
 	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/sessions"
+	"symphony_chat/internal/domain/users"
 	authdto "symphony_chat/internal/dto/auth"
 	config "symphony_chat/internal/infrastructure/configs"
+	"time"
 
 	JWT "github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -13,7 +18,11 @@ import (
 
 type JWTtokenService struct {
 	jwtRepo   jwt.JwtRepository
+	sessionRepo sessions.SessionRepository
 	jwtConfig config.JWTConfig
+	transactionManager tx.TransactionManager
+	tokenDenylist *TokenDenylist
+	keySet jwt.KeySet
 }
 
 type JWTtokenConfiguration func(*JWTtokenService) error
@@ -45,9 +54,41 @@ func WithJWTtokenRepository(jt jwt.JwtRepository) JWTtokenConfiguration {
 	}
 }
 
+func WithSessionRepository(sr sessions.SessionRepository) JWTtokenConfiguration {
+	return func(js *JWTtokenService) error {
+		js.sessionRepo = sr
+		return nil
+	}
+}
+
+//Key set with signing key and all keys that are still accepted for verification
+func WithKeySet(ks jwt.KeySet) JWTtokenConfiguration {
+	return func(js *JWTtokenService) error {
+		if !ks.GetSigningKey().CanSign() {
+			return jwt.ErrSigningKeyNotFound
+		}
+		js.keySet = ks
+		return nil
+	}
+}
+
+func WithTokenDenylist(td *TokenDenylist) JWTtokenConfiguration {
+	return func(js *JWTtokenService) error {
+		js.tokenDenylist = td
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) JWTtokenConfiguration {
+	return func(js *JWTtokenService) error {
+		js.transactionManager = tm
+		return nil
+	}
+}
+
 // /Function for getting new access token
-func (js *JWTtokenService) GetUpdatedAccessToken(userID uuid.UUID) (jwt.JWTtoken, error) {
-	accessToken, err := jwt.NewJWT(userID, js.jwtConfig.AccessTTLinMinutes, 0, []byte(js.jwtConfig.SecretKey))
+func (js *JWTtokenService) GetUpdatedAccessToken(userID uuid.UUID, sessionID uuid.UUID) (jwt.JWTtoken, error) {
+	accessToken, err := jwt.NewJWT(userID, sessionID, jwt.AccessTokenType, js.jwtConfig.AccessTTLinMinutes, 0, js.keySet.GetSigningKey())
 	if err != nil {
 		return jwt.JWTtoken{}, &jwt.TokenError{
 			Code: "ACCESS_TOKEN_NOT_CREATED",
@@ -60,8 +101,8 @@ func (js *JWTtokenService) GetUpdatedAccessToken(userID uuid.UUID) (jwt.JWTtoken
 }
 
 // /Function for getting new refresh token
-func (js *JWTtokenService) GetUpdatedRefreshToken(userID uuid.UUID) (jwt.JWTtoken, error) {
-	refreshToken, err := jwt.NewJWT(userID, 0, js.jwtConfig.RefreshTTLinDays, []byte(js.jwtConfig.SecretKey))
+func (js *JWTtokenService) GetUpdatedRefreshToken(userID uuid.UUID, sessionID uuid.UUID) (jwt.JWTtoken, error) {
+	refreshToken, err := jwt.NewJWT(userID, sessionID, jwt.RefreshTokenType, 0, js.jwtConfig.RefreshTTLinDays, js.keySet.GetSigningKey())
 	if err != nil {
 		return jwt.JWTtoken{}, &jwt.TokenError{
 			Code: "REFRESH_TOKEN_NOT_CREATED",
@@ -74,43 +115,29 @@ func (js *JWTtokenService) GetUpdatedRefreshToken(userID uuid.UUID) (jwt.JWTtoke
 }
 
 // /Function that used when user again write login and password (when refresh token expires)
-func (js *JWTtokenService) GetUpdatedPairTokens(txCtx context.Context, userID uuid.UUID) (authdto.AuthTokens, error) {
-	accessToken, err := js.GetUpdatedAccessToken(userID)
-	if err != nil {
-		return authdto.AuthTokens{}, &jwt.TokenError{
-			Code: "ACCESS_TOKEN_CREATION_FAILED",
-			Message: "access token cant be created",
-			Err: err,
-		}
-	}
+// /Every login creates new session, so sessions of other devices stay valid
+func (js *JWTtokenService) GetUpdatedPairTokens(txCtx context.Context, userID uuid.UUID, device sessions.DeviceInfo) (authdto.AuthTokens, error) {
+	return js.createSessionTokens(txCtx, userID, device)
+}
 
-	refreshToken, err := js.GetUpdatedRefreshToken(userID)
-	if err != nil {
-		return authdto.AuthTokens{}, &jwt.TokenError{
-			Code: "REFRESH_TOKEN_CREATION_FAILED",
-			Message: "refresh token cant be created",
-			Err: err,
-		}
-	}
+// /Function that used when user first time write login and password 
+func (js *JWTtokenService) GetCreatedPairTokens(txCtx context.Context, userID uuid.UUID, device sessions.DeviceInfo) (authdto.AuthTokens, error) {
+	return js.createSessionTokens(txCtx, userID, device)
+}
+
+func (js *JWTtokenService) createSessionTokens(txCtx context.Context, userID uuid.UUID, device sessions.DeviceInfo) (authdto.AuthTokens, error) {
+	session := sessions.NewSession(userID, device)
 
-	err = js.jwtRepo.UpdateJWTtoken(txCtx, userID, refreshToken.GetToken())
+	err := js.sessionRepo.AddSession(txCtx, session)
 	if err != nil {
 		return authdto.AuthTokens{}, &jwt.TokenError{
-			Code: "REFRESH_TOKEN_UPDATE_FAILED",
-			Message: "refresh token cant be updated",
+			Code: "SESSION_CREATION_FAILED",
+			Message: "session cant be created",
 			Err: err,
 		}
 	}
 
-	return authdto.AuthTokens{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
-}
-
-// /Function that used when user first time write login and password 
-func (js *JWTtokenService) GetCreatedPairTokens(txCtx context.Context, userID uuid.UUID) (authdto.AuthTokens, error) {
-	accessToken, err := js.GetUpdatedAccessToken(userID)
+	accessToken, err := js.GetUpdatedAccessToken(userID, session.GetID())
 	if err != nil {
 		return authdto.AuthTokens{}, &jwt.TokenError{
 			Code: "ACCESS_TOKEN_CREATION_FAILED",
@@ -119,7 +146,7 @@ func (js *JWTtokenService) GetCreatedPairTokens(txCtx context.Context, userID uu
 		}
 	}
 
-	refreshToken, err := js.GetUpdatedRefreshToken(userID)
+	refreshToken, err := js.GetUpdatedRefreshToken(userID, session.GetID())
 	if err != nil {
 		return authdto.AuthTokens{}, &jwt.TokenError{
 			Code: "REFRESH_TOKEN_CREATION_FAILED",
@@ -143,41 +170,224 @@ func (js *JWTtokenService) GetCreatedPairTokens(txCtx context.Context, userID uu
 	}, nil
 }
 
+// /Function that exchanges refresh token for a new pair of tokens
+// /Old refresh token becomes invalid, new one stays in the same family (session)
+// /If already rotated refresh token is presented again, whole family is revoked
+func (js *JWTtokenService) RotateRefreshToken(ctx context.Context, refreshToken string, device sessions.DeviceInfo) (authdto.AuthTokens, error) {
+	claims, err := js.ParseRefreshTokenClaims(refreshToken)
+	if err != nil {
+		return authdto.AuthTokens{}, err
+	}
+
+	var authTokens authdto.AuthTokens
+	var reuseDetected bool
+	var reusedSessionID uuid.UUID
+
+	err = js.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		storedToken, err := js.jwtRepo.GetStoredRefreshToken(txCtx, refreshToken)
+		if err != nil {
+			return err
+		}
+
+		if storedToken.GetToken().GetAuthUserID() != claims.UserID {
+			return jwt.ErrTokenNotFound
+		}
+
+		if storedToken.IsRevoked() {
+			return jwt.ErrRefreshTokenRevoked
+		}
+
+		sessionID := storedToken.GetToken().GetFamilyID()
+
+		if storedToken.IsRotated() {
+			//Revocation must be committed, so error is returned after the transaction
+			reuseDetected = true
+			reusedSessionID = sessionID
+			return js.revokeSession(txCtx, sessionID)
+		}
+
+		if err := js.jwtRepo.MarkJWTtokenRotated(txCtx, refreshToken, time.Now()); err != nil {
+			return err
+		}
+
+		if err := js.sessionRepo.UpdateSessionLastUsed(txCtx, sessionID, time.Now(), device.IP); err != nil {
+			return err
+		}
+
+		accessToken, err := js.GetUpdatedAccessToken(claims.UserID, sessionID)
+		if err != nil {
+			return err
+		}
+
+		newRefreshToken, err := js.GetUpdatedRefreshToken(claims.UserID, sessionID)
+		if err != nil {
+			return err
+		}
+
+		if err := js.jwtRepo.AddJWTtoken(txCtx, newRefreshToken); err != nil {
+			return err
+		}
+
+		authTokens = authdto.AuthTokens{
+			AccessToken:  accessToken,
+			RefreshToken: newRefreshToken,
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return authdto.AuthTokens{}, err
+	}
+
+	if reuseDetected {
+		//Stolen refresh token could already be exchanged for access token, so access tokens of the session are revoked too
+		if err := js.RevokeSessionAccessTokens(ctx, reusedSessionID); err != nil {
+			return authdto.AuthTokens{}, err
+		}
+		return authdto.AuthTokens{}, jwt.ErrRefreshTokenReused
+	}
+
+	return authTokens, nil
+}
+
 // /Functon that used for validating access token
 func (js *JWTtokenService) ValidateToken(tokenString string) (uuid.UUID, error) {
+	claims, err := js.ParseAccessTokenClaims(tokenString)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+// /Function that validates access token and returns its claims
+// /Access tokens that are in denylist or belong to revoked session are rejected
+func (js *JWTtokenService) ParseAccessTokenClaims(tokenString string) (jwt.TokenClaims, error) {
+	claims, err := js.parseTokenClaimsOfType(tokenString, jwt.AccessTokenType)
+	if err != nil {
+		return jwt.TokenClaims{}, err
+	}
+
+	if js.IsAccessTokenRevoked(claims.TokenID) || js.IsAccessTokenRevoked(claims.SessionID) {
+		return jwt.TokenClaims{}, jwt.ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// /Function that puts access token in denylist until its expiration
+func (js *JWTtokenService) RevokeAccessToken(ctx context.Context, claims jwt.TokenClaims) error {
+	if js.tokenDenylist == nil {
+		return nil
+	}
+
+	return js.tokenDenylist.Revoke(ctx, claims.TokenID, claims.ExpiresAt)
+}
+
+// /Function that revokes all access tokens of the sessions, it must be called after revocation of sessions is committed
+// /Access tokens are not stored, so session id is kept in denylist until the last token of the session expires
+func (js *JWTtokenService) RevokeSessionAccessTokens(ctx context.Context, sessionIDs ...uuid.UUID) error {
+	if js.tokenDenylist == nil {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(time.Duration(js.jwtConfig.AccessTTLinMinutes) * time.Minute)
+	for _, sessionID := range sessionIDs {
+		if err := js.tokenDenylist.Revoke(ctx, sessionID, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (js *JWTtokenService) IsAccessTokenRevoked(tokenID uuid.UUID) bool {
+	if js.tokenDenylist == nil {
+		return false
+	}
+
+	return js.tokenDenylist.IsRevoked(tokenID)
+}
+
+// /Function that validates refresh token and returns its claims
+func (js *JWTtokenService) ParseRefreshTokenClaims(tokenString string) (jwt.TokenClaims, error) {
+	return js.parseTokenClaimsOfType(tokenString, jwt.RefreshTokenType)
+}
+
+func (js *JWTtokenService) parseTokenClaimsOfType(tokenString string, tokenType jwt.TokenType) (jwt.TokenClaims, error) {
+	claims, err := js.ParseTokenClaims(tokenString)
+	if err != nil {
+		return jwt.TokenClaims{}, err
+	}
+
+	if claims.Type != tokenType {
+		return jwt.TokenClaims{}, jwt.ErrWrongTokenType
+	}
+
+	return claims, nil
+}
+
+// /Function that validates token and returns its claims
+func (js *JWTtokenService) ParseTokenClaims(tokenString string) (jwt.TokenClaims, error) {
 	token, err := JWT.Parse(tokenString, func(t *JWT.Token) (interface{}, error) {
-		if _, ok := t.Method.(*JWT.SigningMethodHMAC); !ok {
+		keyID, ok := t.Header["kid"].(string)
+		if !ok {
+			return nil, &jwt.TokenError{
+				Code: "KID_HEADER_WAS_NOT_PROVIDED_IN_TOKEN",
+				Message: "kid header was not provided in token",
+				Err: errors.New("kid header was not provided in token"),
+			}
+		}
+
+		key, exists := js.keySet.GetVerificationKey(keyID)
+		if !exists {
+			return nil, &jwt.TokenError{
+				Code: "UNKNOWN_TOKEN_KEY_ID",
+				Message: "token was signed with unknown key",
+				Err: errors.New("unknown kid: " + keyID),
+			}
+		}
+
+		//Algorithm is taken from the key, not from the token, so "none" or HS256 with public key can't be used
+		if t.Method.Alg() != key.GetMethod().Alg() {
 			return nil, &jwt.TokenError{
 				Code: "INVALID_TOKEN_SIGNING_METHOD",
 				Message: "invalid token signing method",
 				Err: errors.New("invalid token signing method"),
 			}
 		}
-		return []byte(js.jwtConfig.SecretKey), nil
+
+		return key.GetPublicKey(), nil
 	})
 
 	if err != nil {
+		//Error that was returned by key func
+		var keyErr *jwt.TokenError
 
 		switch {
 		case errors.Is(err, JWT.ErrTokenExpired):
-			return uuid.Nil, jwt.ErrTokenExpired
+			return jwt.TokenClaims{}, jwt.ErrTokenExpired
 
 		case errors.Is(err, JWT.ErrTokenMalformed):
-			return uuid.Nil, &jwt.TokenError{
+			return jwt.TokenClaims{}, &jwt.TokenError{
 				Code: "INVALID_TOKEN_FORMAT",
 				Message: "invalid token format",
 				Err: err,
 			}
 
 		case errors.Is(err, JWT.ErrTokenSignatureInvalid):
-			return uuid.Nil, &jwt.TokenError{
+			return jwt.TokenClaims{}, &jwt.TokenError{
 				Code: "INVALID_TOKEN_SIGNATURE",
 				Message: "invalid token signature",
 				Err: err,
 			}
 
+		case errors.As(err, &keyErr):
+			return jwt.TokenClaims{}, keyErr
+
 		default:
-			return uuid.Nil, &jwt.TokenError{
+			return jwt.TokenClaims{}, &jwt.TokenError{
 				Code: "PARSE_TOKEN_ERROR",
 				Message: "unexpected error while parsing token",
 				Err: err,
@@ -188,7 +398,7 @@ func (js *JWTtokenService) ValidateToken(tokenString string) (uuid.UUID, error)
 
 	claims, ok := token.Claims.(JWT.MapClaims)
 	if !ok {
-		return uuid.Nil, &jwt.TokenError{
+		return jwt.TokenClaims{}, &jwt.TokenError{
 			Code: "INVALID_TOKEN_CLAIMS_FORMAT",
 			Message: "invalid token claims format",
 			Err: errors.New("invalid token claims format"),
@@ -197,7 +407,7 @@ func (js *JWTtokenService) ValidateToken(tokenString string) (uuid.UUID, error)
 
 	userIDStr, ok := claims["sub"].(string)
 	if !ok {
-		return uuid.Nil, &jwt.TokenError{
+		return jwt.TokenClaims{}, &jwt.TokenError{
 			Code: "SUB_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
 			Message: "sub claim was not provided in token claims",
 			Err: errors.New("sub claim was not provided in token claims"),
@@ -206,28 +416,189 @@ func (js *JWTtokenService) ValidateToken(tokenString string) (uuid.UUID, error)
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		return uuid.Nil, &jwt.TokenError{
+		return jwt.TokenClaims{}, &jwt.TokenError{
 			Code: "SUB_CLAIM_CANT_BE_PARSED_TO_UUID",
 			Message: "sub claim cant be parsed to uuid",
 			Err: err,
 		}
 	}
 
-	return userID, nil
-}
+	sessionIDStr, ok := claims["sid"].(string)
+	if !ok {
+		return jwt.TokenClaims{}, &jwt.TokenError{
+			Code: "SID_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+			Message: "sid claim was not provided in token claims",
+			Err: errors.New("sid claim was not provided in token claims"),
+		}
+	}
 
-func (js *JWTtokenService) InvalidateRefreshToken(txCtx context.Context, userID uuid.UUID) error {
-	err := js.jwtRepo.DeleteJWTtoken(txCtx, userID)
+	sessionID, err := uuid.Parse(sessionIDStr)
 	if err != nil {
-		return &jwt.TokenError{
-			Code: "REFRESH_TOKEN_INVALDIATION_FAILED",
-			Message: "refresh token cant be deleted",
+		return jwt.TokenClaims{}, &jwt.TokenError{
+			Code: "SID_CLAIM_CANT_BE_PARSED_TO_UUID",
+			Message: "sid claim cant be parsed to uuid",
 			Err: err,
 		}
 	}
+
+	tokenIDStr, ok := claims["jti"].(string)
+	if !ok {
+		return jwt.TokenClaims{}, &jwt.TokenError{
+			Code: "JTI_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+			Message: "jti claim was not provided in token claims",
+			Err: errors.New("jti claim was not provided in token claims"),
+		}
+	}
+
+	tokenID, err := uuid.Parse(tokenIDStr)
+	if err != nil {
+		return jwt.TokenClaims{}, &jwt.TokenError{
+			Code: "JTI_CLAIM_CANT_BE_PARSED_TO_UUID",
+			Message: "jti claim cant be parsed to uuid",
+			Err: err,
+		}
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return jwt.TokenClaims{}, &jwt.TokenError{
+			Code: "EXP_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+			Message: "exp claim was not provided in token claims",
+			Err: errors.New("exp claim was not provided in token claims"),
+		}
+	}
+
+	tokenType, ok := claims["typ"].(string)
+	if !ok {
+		return jwt.TokenClaims{}, &jwt.TokenError{
+			Code: "TYP_CLAIM_WAS_NOT_PROVIDED_IN_TOKEN_CLAIMS",
+			Message: "typ claim was not provided in token claims",
+			Err: errors.New("typ claim was not provided in token claims"),
+		}
+	}
+
+	return jwt.TokenClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Type:      jwt.TokenType(tokenType),
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt.Time,
+	}, nil
+}
+
+// /Function that confirms identity of user without password (OIDC account)
+// /Session is created on every sign in and kept on refresh, so its creation time is time of the last authentication
+func (js *JWTtokenService) CheckRecentAuthentication(txCtx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	session, err := js.sessionRepo.GetSessionByID(txCtx, sessionID)
+	if err != nil {
+		if errors.Is(err, sessions.ErrSessionNotFound) {
+			return users.ErrReauthenticationRequired
+		}
+		return err
+	}
+
+	if session.GetAuthUserID() != userID || session.IsRevoked() {
+		return users.ErrReauthenticationRequired
+	}
+
+	reauthWindow := time.Duration(js.jwtConfig.ReauthWindowInMinutes) * time.Minute
+	if time.Since(session.GetCreatedAt()) > reauthWindow {
+		return users.ErrReauthenticationRequired
+	}
+
 	return nil
 }
 
+// /Function that ends session, refresh tokens of this session can't be used anymore
+func (js *JWTtokenService) InvalidateSession(txCtx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	session, err := js.sessionRepo.GetSessionByID(txCtx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	//Users can't see or revoke sessions of other users
+	if session.GetAuthUserID() != userID {
+		return sessions.ErrSessionNotFound
+	}
+
+	if session.IsRevoked() {
+		return sessions.ErrSessionRevoked
+	}
+
+	return js.revokeSession(txCtx, sessionID)
+}
+
+// /Function that ends all sessions of user except current one
+func (js *JWTtokenService) InvalidateOtherSessions(txCtx context.Context, userID uuid.UUID, currentSessionID uuid.UUID) ([]uuid.UUID, error) {
+	revokedAt := time.Now()
+
+	revokedSessionIDs, err := js.sessionRepo.RevokeOtherSessionsOfUser(txCtx, userID, currentSessionID, revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sessionID := range revokedSessionIDs {
+		if err := js.jwtRepo.RevokeJWTtokenFamily(txCtx, sessionID, revokedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	return revokedSessionIDs, nil
+}
+
+// /Function that ends all sessions of user (used after password reset)
+func (js *JWTtokenService) InvalidateAllSessions(txCtx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return js.InvalidateOtherSessions(txCtx, userID, uuid.Nil)
+}
+
+// /Function that deletes refresh tokens that have already expired
+// /Rotated and revoked tokens are kept until then, so their reuse is still detected
+func (js *JWTtokenService) PurgeExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	return js.jwtRepo.DeleteExpiredJWTtokens(ctx, time.Now().Add(-time.Duration(js.GetRefreshTokenTTL())*time.Second))
+}
+
+// /Function that purges expired refresh tokens every interval until ctx is done
+func (js *JWTtokenService) RunRefreshTokenPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := js.PurgeExpiredRefreshTokens(ctx); err != nil {
+				slog.ErrorContext(ctx, "failed to purge expired refresh tokens", "error", err)
+			}
+		}
+	}
+}
+
+// /Function that deletes all refresh tokens and sessions of user (used when account is deleted)
+// /Ids of deleted sessions are returned, so their access tokens can be revoked after commit
+func (js *JWTtokenService) DeleteAllSessions(txCtx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	if err := js.jwtRepo.DeleteJWTtoken(txCtx, userID); err != nil {
+		return nil, err
+	}
+
+	return js.sessionRepo.DeleteSessionsOfUser(txCtx, userID)
+}
+
+func (js *JWTtokenService) revokeSession(txCtx context.Context, sessionID uuid.UUID) error {
+	revokedAt := time.Now()
+
+	if err := js.sessionRepo.RevokeSession(txCtx, sessionID, revokedAt); err != nil && !errors.Is(err, sessions.ErrSessionNotFound) {
+		return err
+	}
+
+	return js.jwtRepo.RevokeJWTtokenFamily(txCtx, sessionID, revokedAt)
+}
+
+///Function for getting keys that are published in JWKS
+func (js *JWTtokenService) GetVerificationKeys() []jwt.SigningKey {
+	return js.keySet.GetVerificationKeys()
+}
+
 ///Function for getting refresh token TTL in seconds
 func (js *JWTtokenService) GetRefreshTokenTTL() uint {
 	return js.jwtConfig.RefreshTTLinDays * 24 * 3600
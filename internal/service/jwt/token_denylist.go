@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"symphony_chat/internal/domain/jwt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//TokenDenylist keeps ids (jti) of revoked access tokens and ids (sid) of sessions whose access tokens are all revoked
+//Revocations are stored in database and cached in memory, so checking token doesn't hit database
+//Cache of every instance is synced with database every sync interval
+type TokenDenylist struct {
+	revokedTokenRepo jwt.RevokedTokenRepository
+
+	mu            sync.RWMutex
+	revokedTokens map[uuid.UUID]time.Time
+	listeners     []func(revokedID uuid.UUID)
+}
+
+func NewTokenDenylist(revokedTokenRepo jwt.RevokedTokenRepository) *TokenDenylist {
+	return &TokenDenylist{
+		revokedTokenRepo: revokedTokenRepo,
+		revokedTokens:    make(map[uuid.UUID]time.Time),
+	}
+}
+
+//Method registers function that is called for every newly revoked token or session id
+//It is used for closing websocket connections that were opened with revoked token or in revoked session
+func (td *TokenDenylist) OnRevoke(listener func(revokedID uuid.UUID)) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	td.listeners = append(td.listeners, listener)
+}
+
+//Method revokes token until its expiration
+func (td *TokenDenylist) Revoke(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	if err := td.revokedTokenRepo.AddRevokedToken(ctx, tokenID, expiresAt); err != nil {
+		return err
+	}
+
+	td.addToCache(map[uuid.UUID]time.Time{tokenID: expiresAt})
+	return nil
+}
+
+func (td *TokenDenylist) IsRevoked(tokenID uuid.UUID) bool {
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+
+	_, revoked := td.revokedTokens[tokenID]
+	return revoked
+}
+
+//Method loads revoked tokens from database into cache and purges expired entries
+func (td *TokenDenylist) Sync(ctx context.Context) error {
+	now := time.Now()
+
+	if _, err := td.revokedTokenRepo.DeleteExpiredRevokedTokens(ctx, now); err != nil {
+		return err
+	}
+
+	revokedTokens, err := td.revokedTokenRepo.GetRevokedTokens(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	td.purgeCache(now)
+	td.addToCache(revokedTokens)
+	return nil
+}
+
+//Method syncs denylist every interval until ctx is done
+func (td *TokenDenylist) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := td.Sync(ctx); err != nil {
+				slog.ErrorContext(ctx, "failed to sync token denylist", "error", err)
+			}
+		}
+	}
+}
+
+func (td *TokenDenylist) addToCache(revokedTokens map[uuid.UUID]time.Time) {
+	var newlyRevoked []uuid.UUID
+
+	td.mu.Lock()
+	for tokenID, expiresAt := range revokedTokens {
+		if _, exists := td.revokedTokens[tokenID]; !exists {
+			newlyRevoked = append(newlyRevoked, tokenID)
+		}
+		td.revokedTokens[tokenID] = expiresAt
+	}
+	listeners := td.listeners
+	td.mu.Unlock()
+
+	//Listeners are called without lock, so they can check denylist themselves
+	for _, tokenID := range newlyRevoked {
+		for _, listener := range listeners {
+			listener(tokenID)
+		}
+	}
+}
+
+func (td *TokenDenylist) purgeCache(now time.Time) {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	for tokenID, expiresAt := range td.revokedTokens {
+		if !expiresAt.After(now) {
+			delete(td.revokedTokens, tokenID)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package profile
+
+import (
+	"context"
+	tx "symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/users"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//PresenceProvider tells if user has active websocket connection
+type PresenceProvider interface {
+	IsUserOnline(userID uuid.UUID) bool
+}
+
+//Profile summary that is returned by user search
+type UserSummary struct {
+	User   users.ChatUser
+	Online bool
+}
+
+type ProfileService struct {
+	chatUserRepo       users.ChatUserRepository
+	userBlockRepo      users.UserBlockRepository
+	presenceProvider   PresenceProvider
+	transactionManager tx.TransactionManager
+}
+
+type ProfileConfiguration func(*ProfileService) error
+
+func NewProfileService(configs ...ProfileConfiguration) (*ProfileService, error) {
+	ps := &ProfileService{}
+
+	for _, cfg := range configs {
+		err := cfg(ps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ps, nil
+}
+
+func WithChatUserRepository(cu users.ChatUserRepository) ProfileConfiguration {
+	return func(ps *ProfileService) error {
+		ps.chatUserRepo = cu
+		return nil
+	}
+}
+
+func WithUserBlockRepository(ub users.UserBlockRepository) ProfileConfiguration {
+	return func(ps *ProfileService) error {
+		ps.userBlockRepo = ub
+		return nil
+	}
+}
+
+func WithPresenceProvider(pp PresenceProvider) ProfileConfiguration {
+	return func(ps *ProfileService) error {
+		ps.presenceProvider = pp
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) ProfileConfiguration {
+	return func(ps *ProfileService) error {
+		ps.transactionManager = tm
+		return nil
+	}
+}
+
+func (ps *ProfileService) GetProfile(ctx context.Context, userID uuid.UUID) (users.ChatUser, error) {
+	return ps.chatUserRepo.GetChatUserByID(ctx, userID)
+}
+
+//Username is compared regardless of case
+func (ps *ProfileService) GetProfileByUsername(ctx context.Context, username string) (users.ChatUser, error) {
+	username = users.NormalizeUsername(username)
+	if username == users.DeletedChatUser.GetUsername() {
+		return users.ChatUser{}, users.ErrChatUserNotFound
+	}
+
+	return ps.chatUserRepo.GetChatUserByUsername(ctx, username)
+}
+
+//Method validates new username and returns updated profile
+func (ps *ProfileService) ChangeUsername(ctx context.Context, userID uuid.UUID, newUsername string) (users.ChatUser, error) {
+	newUsername = users.NormalizeUsername(newUsername)
+	if err := users.ValidateUsername(newUsername); err != nil {
+		return users.ChatUser{}, err
+	}
+
+	var chatUser users.ChatUser
+
+	err := ps.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		current, err := ps.chatUserRepo.GetChatUserByID(txCtx, userID)
+		if err != nil {
+			return err
+		}
+
+		if current.GetUsername() != newUsername {
+			if err := ps.chatUserRepo.UpdateUsername(txCtx, userID, newUsername); err != nil {
+				return err
+			}
+		}
+
+		chatUser, err = ps.chatUserRepo.GetChatUserByID(txCtx, userID)
+		return err
+	})
+
+	if err != nil {
+		return users.ChatUser{}, err
+	}
+
+	return chatUser, nil
+}
+
+//Method returns page of users whose username starts with query or is similar to it
+func (ps *ProfileService) SearchUsers(ctx context.Context, query users.UserSearchQuery) ([]UserSummary, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	chatUsers, err := ps.chatUserRepo.SearchChatUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]UserSummary, 0, len(chatUsers))
+	for _, chatUser := range chatUsers {
+		summaries = append(summaries, UserSummary{
+			User:   chatUser,
+			Online: ps.presenceProvider != nil && ps.presenceProvider.IsUserOnline(chatUser.GetID()),
+		})
+	}
+
+	return summaries, nil
+}
+
+func (ps *ProfileService) BlockUser(ctx context.Context, blockerID uuid.UUID, username string) error {
+	blocked, err := ps.getBlockTarget(ctx, blockerID, username)
+	if err != nil {
+		return err
+	}
+
+	return ps.userBlockRepo.BlockUser(ctx, blockerID, blocked.GetID(), time.Now())
+}
+
+func (ps *ProfileService) UnblockUser(ctx context.Context, blockerID uuid.UUID, username string) error {
+	blocked, err := ps.getBlockTarget(ctx, blockerID, username)
+	if err != nil {
+		return err
+	}
+
+	return ps.userBlockRepo.UnblockUser(ctx, blockerID, blocked.GetID())
+}
+
+func (ps *ProfileService) getBlockTarget(ctx context.Context, blockerID uuid.UUID, username string) (users.ChatUser, error) {
+	blocked, err := ps.GetProfileByUsername(ctx, username)
+	if err != nil {
+		return users.ChatUser{}, err
+	}
+
+	if blocked.GetID() == blockerID {
+		return users.ChatUser{}, users.ErrCantBlockYourself
+	}
+
+	return blocked, nil
+}
@@ -0,0 +1,189 @@
+package rolesync
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	tx "symphony_chat/internal/application/transaction"
+	"symphony_chat/internal/domain/roles"
+
+	"github.com/google/uuid"
+)
+
+//RoleDrift is a difference between built-in role in Go and the role that is stored in the database
+type RoleDrift struct {
+	RoleID   uuid.UUID
+	RoleName string
+	//Role is not stored at all
+	Missing bool
+	//Name that is stored if it differs from RoleName
+	StoredName string
+	//Permissions of the definition that are not stored
+	MissingPermissions []roles.Permission
+	//Stored permissions that are not in the definition
+	ExtraPermissions []roles.Permission
+	//Stored role is not one of the built-in roles, it is reported but not deleted because participants can have it
+	Unknown bool
+}
+
+func (rd RoleDrift) String() string {
+	switch {
+	case rd.Missing:
+		return fmt.Sprintf("role %s (%s) is missing", rd.RoleName, rd.RoleID)
+	case rd.Unknown:
+		return fmt.Sprintf("role %s (%s) is not a built-in role", rd.RoleName, rd.RoleID)
+	}
+
+	var changes []string
+	if rd.StoredName != "" {
+		changes = append(changes, "stored name is "+rd.StoredName)
+	}
+	if len(rd.MissingPermissions) > 0 {
+		changes = append(changes, fmt.Sprintf("missing permissions %v", rd.MissingPermissions))
+	}
+	if len(rd.ExtraPermissions) > 0 {
+		changes = append(changes, fmt.Sprintf("extra permissions %v", rd.ExtraPermissions))
+	}
+
+	return fmt.Sprintf("role %s (%s): %s", rd.RoleName, rd.RoleID, strings.Join(changes, ", "))
+}
+
+type RoleSyncService struct {
+	chatRoleRepo       roles.ChatRoleRepository
+	transactionManager tx.TransactionManager
+}
+
+type RoleSyncConfiguration func(*RoleSyncService) error
+
+func NewRoleSyncService(configs ...RoleSyncConfiguration) (*RoleSyncService, error) {
+	rs := &RoleSyncService{}
+
+	for _, cfg := range configs {
+		err := cfg(rs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rs, nil
+}
+
+func WithChatRoleRepository(cr roles.ChatRoleRepository) RoleSyncConfiguration {
+	return func(rs *RoleSyncService) error {
+		rs.chatRoleRepo = cr
+		return nil
+	}
+}
+
+func WithTransactionManager(tm tx.TransactionManager) RoleSyncConfiguration {
+	return func(rs *RoleSyncService) error {
+		rs.transactionManager = tm
+		return nil
+	}
+}
+
+//Method compares built-in roles with the database without changing it
+func (rs *RoleSyncService) CheckDrift(ctx context.Context) ([]RoleDrift, error) {
+	storedRoles, err := rs.chatRoleRepo.GetChatRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return findDrift(roles.BuiltInChatRoles(), storedRoles), nil
+}
+
+//Method saves built-in roles whose stored name or permissions differ from Go definitions
+//Returned drift is what was found before it was fixed, unknown roles are left as they are
+func (rs *RoleSyncService) Sync(ctx context.Context) ([]RoleDrift, error) {
+	var drift []RoleDrift
+
+	err := rs.transactionManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		storedRoles, err := rs.chatRoleRepo.GetChatRoles(txCtx)
+		if err != nil {
+			return err
+		}
+
+		drift = findDrift(roles.BuiltInChatRoles(), storedRoles)
+
+		for _, roleDrift := range drift {
+			if roleDrift.Unknown {
+				continue
+			}
+
+			builtInRole, err := roles.GetBuiltInChatRoleByID(roleDrift.RoleID)
+			if err != nil {
+				return err
+			}
+
+			if err := rs.chatRoleRepo.SaveChatRole(txCtx, builtInRole); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return drift, nil
+}
+
+func findDrift(builtInRoles []roles.ChatRole, storedRoles []roles.ChatRole) []RoleDrift {
+	storedByID := make(map[uuid.UUID]roles.ChatRole, len(storedRoles))
+	for _, storedRole := range storedRoles {
+		storedByID[storedRole.GetID()] = storedRole
+	}
+
+	var drift []RoleDrift
+
+	for _, builtInRole := range builtInRoles {
+		storedRole, exists := storedByID[builtInRole.GetID()]
+		delete(storedByID, builtInRole.GetID())
+
+		roleDrift := RoleDrift{
+			RoleID:   builtInRole.GetID(),
+			RoleName: builtInRole.GetName(),
+		}
+
+		if !exists {
+			roleDrift.Missing = true
+			drift = append(drift, roleDrift)
+			continue
+		}
+
+		if storedRole.GetName() != builtInRole.GetName() {
+			roleDrift.StoredName = storedRole.GetName()
+		}
+		roleDrift.MissingPermissions = difference(builtInRole.GetPermissions(), storedRole.GetPermissions())
+		roleDrift.ExtraPermissions = difference(storedRole.GetPermissions(), builtInRole.GetPermissions())
+
+		if roleDrift.StoredName != "" || len(roleDrift.MissingPermissions) > 0 || len(roleDrift.ExtraPermissions) > 0 {
+			drift = append(drift, roleDrift)
+		}
+	}
+
+	//Roles that are left were added by hand
+	for _, storedRole := range storedByID {
+		drift = append(drift, RoleDrift{
+			RoleID:   storedRole.GetID(),
+			RoleName: storedRole.GetName(),
+			Unknown:  true,
+		})
+	}
+
+	return drift
+}
+
+//Function returns permissions of a that are not in b
+func difference(a []roles.Permission, b []roles.Permission) []roles.Permission {
+	var result []roles.Permission
+	for _, permission := range a {
+		if !slices.Contains(b, permission) {
+			result = append(result, permission)
+		}
+	}
+	return result
+}
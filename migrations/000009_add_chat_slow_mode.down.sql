@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_chat_message_chat_id_sender_id_created_at;
+ALTER TABLE chat DROP COLUMN IF EXISTS slow_mode_interval_seconds;
@@ -0,0 +1,4 @@
+ALTER TABLE chat
+    ADD COLUMN slow_mode_interval_seconds INTEGER NOT NULL DEFAULT 0 CHECK (slow_mode_interval_seconds >= 0);
+
+CREATE INDEX idx_chat_message_chat_id_sender_id_created_at ON chat_message(chat_id, sender_id, created_at DESC);
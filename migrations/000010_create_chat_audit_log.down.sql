@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_chat_audit_log_chat_id_created_at;
+DROP TABLE IF EXISTS chat_audit_log;
@@ -0,0 +1,13 @@
+CREATE TABLE chat_audit_log (
+    id UUID PRIMARY KEY,
+    -- no foreign key on chat, entries must outlive deleted chats
+    chat_id UUID NOT NULL,
+    actor_id UUID NOT NULL,
+    target_id UUID,
+    action VARCHAR(64) NOT NULL,
+    before_value TEXT NOT NULL DEFAULT '',
+    after_value TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_chat_audit_log_chat_id_created_at ON chat_audit_log(chat_id, created_at DESC);
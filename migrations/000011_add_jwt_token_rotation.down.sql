@@ -0,0 +1,12 @@
+-- signed refresh tokens don't fit into VARCHAR(255), they are deleted and their users have to log in again
+DELETE FROM jwt_token WHERE length(token) > 255;
+
+DROP INDEX IF EXISTS idx_jwt_token_family_id;
+DROP INDEX IF EXISTS idx_jwt_token_token;
+
+ALTER TABLE jwt_token
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS rotated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS family_id,
+    ALTER COLUMN token TYPE VARCHAR(255);
@@ -0,0 +1,9 @@
+ALTER TABLE jwt_token
+    ALTER COLUMN token TYPE TEXT,
+    ADD COLUMN family_id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN rotated_at TIMESTAMPTZ,
+    ADD COLUMN revoked_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX idx_jwt_token_token ON jwt_token(token);
+CREATE INDEX idx_jwt_token_family_id ON jwt_token(family_id);
@@ -0,0 +1,6 @@
+ALTER TABLE jwt_token
+    DROP CONSTRAINT IF EXISTS fk_jwt_token_family_id,
+    ALTER COLUMN family_id SET DEFAULT uuid_generate_v4();
+
+DROP INDEX IF EXISTS idx_auth_session_auth_user_id;
+DROP TABLE IF EXISTS auth_session;
@@ -0,0 +1,21 @@
+CREATE TABLE auth_session (
+    id UUID PRIMARY KEY,
+    auth_user_id UUID NOT NULL REFERENCES auth_user(id),
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_auth_session_auth_user_id ON auth_session(auth_user_id);
+
+-- every existing token family becomes a session
+INSERT INTO auth_session (id, auth_user_id, created_at, last_used_at, revoked_at)
+SELECT DISTINCT ON (family_id) family_id, auth_user_id, created_at, created_at, revoked_at
+FROM jwt_token
+ORDER BY family_id, created_at DESC;
+
+ALTER TABLE jwt_token
+    ALTER COLUMN family_id DROP DEFAULT,
+    ADD CONSTRAINT fk_jwt_token_family_id FOREIGN KEY (family_id) REFERENCES auth_session(id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_revoked_access_token_expires_at;
+DROP TABLE IF EXISTS revoked_access_token;
@@ -0,0 +1,7 @@
+CREATE TABLE revoked_access_token (
+    token_id UUID PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_access_token_expires_at ON revoked_access_token(expires_at);
@@ -0,0 +1 @@
+DELETE FROM chat_user WHERE id = '00000000-0000-0000-0000-00000000dead';
@@ -0,0 +1,4 @@
+-- placeholder that becomes sender of messages of deleted accounts
+INSERT INTO chat_user (id, username, status)
+VALUES ('00000000-0000-0000-0000-00000000dead', 'deleted_user', 'offline')
+ON CONFLICT (id) DO NOTHING;
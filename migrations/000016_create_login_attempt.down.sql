@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_login_lockout_event_key;
+DROP TABLE IF EXISTS login_lockout_event;
+DROP TABLE IF EXISTS login_attempt;
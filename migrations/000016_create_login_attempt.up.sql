@@ -0,0 +1,19 @@
+CREATE TABLE login_attempt (
+    key_type VARCHAR(16) NOT NULL,
+    key_value VARCHAR(255) NOT NULL,
+    failed_count INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (key_type, key_value)
+);
+
+CREATE TABLE login_lockout_event (
+    id UUID PRIMARY KEY,
+    key_type VARCHAR(16) NOT NULL,
+    key_value VARCHAR(255) NOT NULL,
+    failed_count INT NOT NULL,
+    locked_until TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_lockout_event_key ON login_lockout_event(key_type, key_value, created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_two_factor_challenge_auth_user_id;
+DROP TABLE IF EXISTS two_factor_challenge;
+DROP INDEX IF EXISTS idx_two_factor_recovery_code_auth_user_id;
+DROP TABLE IF EXISTS two_factor_recovery_code;
+DROP TABLE IF EXISTS two_factor;
@@ -0,0 +1,29 @@
+CREATE TABLE two_factor (
+    auth_user_id UUID PRIMARY KEY REFERENCES auth_user(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE two_factor_recovery_code (
+    id UUID PRIMARY KEY,
+    auth_user_id UUID NOT NULL REFERENCES auth_user(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_two_factor_recovery_code_auth_user_id ON two_factor_recovery_code(auth_user_id);
+
+CREATE TABLE two_factor_challenge (
+    id UUID PRIMARY KEY,
+    auth_user_id UUID NOT NULL REFERENCES auth_user(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_two_factor_challenge_auth_user_id ON two_factor_challenge(auth_user_id);
@@ -0,0 +1,2 @@
+-- backfilled chat profiles can't be told apart from profiles created at signup, so they are kept
+SELECT 1;
@@ -0,0 +1,6 @@
+-- accounts that were created before signup started creating chat profiles get generated username
+INSERT INTO chat_user (id, username, status)
+SELECT au.id, 'user_' || LEFT(REPLACE(au.id::text, '-', ''), 12), 'offline'
+FROM auth_user au
+WHERE NOT EXISTS (SELECT 1 FROM chat_user cu WHERE cu.id = au.id)
+ON CONFLICT DO NOTHING;
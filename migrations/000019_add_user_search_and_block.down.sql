@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_block_blocked_id;
+DROP TABLE IF EXISTS user_block;
+DROP INDEX IF EXISTS idx_chat_user_username_trgm;
@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_chat_user_username_trgm ON chat_user USING GIN (username gin_trgm_ops);
+
+CREATE TABLE user_block (
+    blocker_id UUID NOT NULL REFERENCES chat_user(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES chat_user(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE INDEX idx_user_block_blocked_id ON user_block(blocked_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS oidc_login_state;
+DROP INDEX IF EXISTS idx_oidc_identity_auth_user_id;
+DROP TABLE IF EXISTS oidc_identity;
@@ -0,0 +1,24 @@
+CREATE TABLE oidc_identity (
+    id UUID PRIMARY KEY,
+    auth_user_id UUID NOT NULL REFERENCES auth_user(id) ON DELETE CASCADE,
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, subject),
+    UNIQUE (provider, auth_user_id)
+);
+
+CREATE INDEX idx_oidc_identity_auth_user_id ON oidc_identity(auth_user_id);
+
+CREATE TABLE oidc_login_state (
+    state_hash VARCHAR(64) PRIMARY KEY,
+    provider VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(128) NOT NULL,
+    link_auth_user_id UUID REFERENCES auth_user(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
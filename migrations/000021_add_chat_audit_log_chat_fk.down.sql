@@ -0,0 +1 @@
+ALTER TABLE chat_audit_log DROP CONSTRAINT IF EXISTS chat_audit_log_chat_id_fkey;
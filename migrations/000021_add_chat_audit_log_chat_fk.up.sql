@@ -0,0 +1,6 @@
+-- audit log belongs to its chat: deleting a chat deletes its audit log
+-- entries of chats that were deleted before can't be read by anyone, so they are removed
+DELETE FROM chat_audit_log a WHERE NOT EXISTS (SELECT 1 FROM chat c WHERE c.id = a.chat_id);
+
+ALTER TABLE chat_audit_log
+    ADD CONSTRAINT chat_audit_log_chat_id_fkey FOREIGN KEY (chat_id) REFERENCES chat(id) ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+//Migrations are embedded into the binary, so database can be migrated without migrations directory
+//
+//go:embed *.sql
+var FS embed.FS
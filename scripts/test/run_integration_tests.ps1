@@ -33,19 +33,6 @@ function New-TestDatabase {
     Write-Host "Test database created successfully" -ForegroundColor Green
 }
 
-function Start-Migrations {
-    Write-Host "Applying migrations..."
-
-    $conn_to_test_db = "postgres://$($env:TEST_DB_USER):$($env:TEST_DB_PASSWORD)@$($env:TEST_DB_HOST):$($env:TEST_DB_PORT)/$($env:TEST_DB_NAME)?sslmode=$($env:TEST_DB_SSLMODE)"
-
-    migrate -database $conn_to_test_db -path migrations up
-    if ($LASTEXITCODE -ne 0) {
-        throw "Failed to apply migrations"
-    } 
-
-    Write-Host "Migrations applied successfully" -ForegroundColor Green
-}
-
 function Remove_TestDatabase {
     Write-Host "Dropping test database..."
 
@@ -68,10 +55,7 @@ function Main {
         # Creating test database
         New-TestDatabase
 
-        # Applying migrations
-        Start-Migrations
-
-        # Running integration tests
+        # Running integration tests (test setup applies embedded migrations)
         Write-Host "Running integration tests..."
         go test ./tests/integration/... -v
         $testResult = $LASTEXITCODE
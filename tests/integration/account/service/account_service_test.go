@@ -0,0 +1,233 @@
+package service_test
+
+import (
+	"context"
+	"symphony_chat/internal/domain/chat_audit"
+	"symphony_chat/internal/domain/jwt"
+	"symphony_chat/internal/domain/roles"
+	"symphony_chat/internal/domain/sessions"
+	"symphony_chat/internal/domain/users"
+	config "symphony_chat/internal/infrastructure/configs"
+	jwtKeys "symphony_chat/internal/infrastructure/jwt/keys"
+	jwtRepo "symphony_chat/internal/infrastructure/jwt/postgres"
+	passwordhash "symphony_chat/internal/infrastructure/password_hash"
+	sessionRepo "symphony_chat/internal/infrastructure/sessions/postgres"
+	tx "symphony_chat/internal/infrastructure/transaction/postgres"
+	"symphony_chat/internal/infrastructure/users/postgres"
+	accountService "symphony_chat/internal/service/account"
+	chatService "symphony_chat/internal/service/chat"
+	jwtService "symphony_chat/internal/service/jwt"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const deletedUserPassword = "deletedUserPassword1"
+
+//connectionCloser remembers users whose connections were closed
+type connectionCloser struct {
+	userIDs []uuid.UUID
+}
+
+func (cc *connectionCloser) DisconnectClientsOfUser(userID uuid.UUID) {
+	cc.userIDs = append(cc.userIDs, userID)
+}
+
+type accountTestEnv struct {
+	accountService   *accountService.AccountService
+	chatService      *chatService.ChatService
+	jwtService       *jwtService.JWTtokenService
+	connectionCloser *connectionCloser
+}
+
+func setupAccountService(t *testing.T, db *setup.TestDB) accountTestEnv {
+	keySet, err := jwtKeys.GenerateEphemeralKeySet()
+	require.NoError(t, err)
+
+	jwtService, err := jwtService.NewJWTtokenService(
+		jwtService.WithJWTtokenRepository(jwtRepo.NewPostgresJWTtokenRepo(db.DB)),
+		jwtService.WithJWTConfig(config.NewJWTConfig(15, 30)),
+		jwtService.WithKeySet(keySet),
+		jwtService.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+		jwtService.WithSessionRepository(sessionRepo.NewPostgresSessionRepo(db.DB)),
+		jwtService.WithTokenDenylist(jwtService.NewTokenDenylist(jwtRepo.NewPostgresRevokedTokenRepo(db.DB))),
+	)
+	require.NoError(t, err)
+
+	//Ttl is longer than the test, so only invalidation makes changed roles visible
+	chatService := chatServiceSetup.SetupChatService(t, db, chatService.NewPermissionCache(100, time.Hour, nil))
+	closer := &connectionCloser{}
+
+	accountService, err := accountService.NewAccountService(
+		accountService.WithAuthUserRepository(postgres.NewPostgresAuthUserRepo(db.DB)),
+		accountService.WithChatUserRepository(postgres.NewPostgresChatUserRepo(db.DB)),
+		accountService.WithJWTtokenService(jwtService),
+		accountService.WithChatService(chatService),
+		accountService.WithConnectionCloser(closer),
+		accountService.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+	)
+	require.NoError(t, err)
+
+	return accountTestEnv{
+		accountService:   accountService,
+		chatService:      chatService,
+		jwtService:       jwtService,
+		connectionCloser: closer,
+	}
+}
+
+//Function signs user in and returns claims and access token of new session
+func logIn(t *testing.T, db *setup.TestDB, js *jwtService.JWTtokenService, userID uuid.UUID) (jwt.TokenClaims, string) {
+	var claims jwt.TokenClaims
+	var accessToken string
+
+	err := tx.NewPostgresTransactionManager(db.DB).WithinTransaction(context.Background(), func(txCtx context.Context) error {
+		tokens, err := js.GetCreatedPairTokens(txCtx, userID, sessions.DeviceInfo{UserAgent: "test", IP: "127.0.0.1"})
+		if err != nil {
+			return err
+		}
+
+		accessToken = tokens.AccessToken.GetToken()
+		claims, err = js.ParseAccessTokenClaims(accessToken)
+		return err
+	})
+	require.NoError(t, err)
+
+	return claims, accessToken
+}
+
+func setPassword(t *testing.T, db *setup.TestDB, userID uuid.UUID, password string) {
+	hash, err := passwordhash.DefaultPasswordHasher().Hash(password)
+	require.NoError(t, err)
+
+	require.NoError(t, postgres.NewPostgresAuthUserRepo(db.DB).UpdatePassword(context.Background(), userID, hash))
+}
+
+func countUserRows(t *testing.T, db *setup.TestDB, query string, userID uuid.UUID) int {
+	var count int
+	require.NoError(t, db.DB.QueryRow(query, userID).Scan(&count))
+	return count
+}
+
+func TestDeleteAccount(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	env := setupAccountService(t, db)
+
+	require.NoError(t, db.TruncateAllTables())
+
+	deletedID := chatServiceSetup.CreateUser(t, db, "deleted")
+	setPassword(t, db, deletedID, deletedUserPassword)
+	adminID := chatServiceSetup.CreateUser(t, db, "admin")
+	memberID := chatServiceSetup.CreateUser(t, db, "member")
+
+	//Owned chat with admin and member goes to admin
+	chatWithAdmin, err := env.chatService.CreateChat(ctx, deletedID, "chat with admin")
+	require.NoError(t, err)
+	require.NoError(t, env.chatService.AddUserToChat(ctx, chatWithAdmin.GetID(), deletedID, memberID))
+	require.NoError(t, env.chatService.AddUserToChat(ctx, chatWithAdmin.GetID(), deletedID, adminID))
+	require.NoError(t, env.chatService.PromoteUserToChatAdmin(ctx, chatWithAdmin.GetID(), deletedID, adminID))
+
+	//Owned chat with member only goes to member
+	chatWithMember, err := env.chatService.CreateChat(ctx, deletedID, "chat with member")
+	require.NoError(t, err)
+	require.NoError(t, env.chatService.AddUserToChat(ctx, chatWithMember.GetID(), deletedID, memberID))
+
+	//Owned chat without other members is deleted
+	lonelyChat, err := env.chatService.CreateChat(ctx, deletedID, "lonely chat")
+	require.NoError(t, err)
+
+	//Chat of other user stays, only membership is removed
+	otherChat, err := env.chatService.CreateChat(ctx, memberID, "other chat")
+	require.NoError(t, err)
+	require.NoError(t, env.chatService.AddUserToChat(ctx, otherChat.GetID(), memberID, deletedID))
+
+	messageID, err := env.chatService.SendMessage(ctx, chatWithAdmin.GetID(), deletedID, "message of deleted user")
+	require.NoError(t, err)
+
+	//Member role is cached before deletion
+	canDeleteChat, err := env.chatService.IsUserHasEnoughPermissions(ctx, chatWithMember.GetID(), memberID, roles.PermissionDeleteChat)
+	require.NoError(t, err)
+	require.False(t, canDeleteChat)
+
+	otherSession, otherAccessToken := logIn(t, db, env.jwtService, deletedID)
+	currentSession, _ := logIn(t, db, env.jwtService, deletedID)
+
+	t.Run("Wrong password", func(t *testing.T) {
+		err := env.accountService.DeleteAccount(ctx, currentSession, "wrongPassword1")
+		require.ErrorIs(t, err, users.ErrWrongPassword)
+
+		require.Equal(t, 1, countUserRows(t, db, "SELECT COUNT(*) FROM auth_user WHERE id = $1", deletedID))
+		require.Equal(t, 2, countUserRows(t, db, "SELECT COUNT(*) FROM auth_session WHERE auth_user_id = $1", deletedID))
+		require.Empty(t, env.connectionCloser.userIDs)
+
+		_, err = env.jwtService.ParseAccessTokenClaims(otherAccessToken)
+		require.NoError(t, err)
+	})
+
+	t.Run("Account is deleted", func(t *testing.T) {
+		require.NoError(t, env.accountService.DeleteAccount(ctx, currentSession, deletedUserPassword))
+
+		require.Equal(t, 0, countUserRows(t, db, "SELECT COUNT(*) FROM auth_user WHERE id = $1", deletedID))
+		require.Equal(t, 0, countUserRows(t, db, "SELECT COUNT(*) FROM chat_user WHERE id = $1", deletedID))
+		require.Equal(t, 0, countUserRows(t, db, "SELECT COUNT(*) FROM chat_participant WHERE user_id = $1", deletedID))
+		require.Equal(t, []uuid.UUID{deletedID}, env.connectionCloser.userIDs)
+	})
+
+	t.Run("Sessions and tokens are removed", func(t *testing.T) {
+		require.Equal(t, 0, countUserRows(t, db, "SELECT COUNT(*) FROM auth_session WHERE auth_user_id = $1", deletedID))
+		require.Equal(t, 0, countUserRows(t, db, "SELECT COUNT(*) FROM jwt_token WHERE auth_user_id = $1", deletedID))
+		require.True(t, env.jwtService.IsAccessTokenRevoked(currentSession.TokenID))
+		require.NotEqual(t, otherSession.SessionID, currentSession.SessionID)
+	})
+
+	t.Run("Access token of other session is rejected", func(t *testing.T) {
+		_, err := env.jwtService.ParseAccessTokenClaims(otherAccessToken)
+		require.ErrorIs(t, err, jwt.ErrTokenRevoked)
+	})
+
+	t.Run("Ownership goes to the oldest admin", func(t *testing.T) {
+		roleName, err := env.chatService.GetChatParticipantRoleName(ctx, chatWithAdmin.GetID(), adminID)
+		require.NoError(t, err)
+		require.Equal(t, roles.OwnerChatRole.GetName(), roleName)
+
+		roleName, err = env.chatService.GetChatParticipantRoleName(ctx, chatWithAdmin.GetID(), memberID)
+		require.NoError(t, err)
+		require.Equal(t, roles.MemberChatRole.GetName(), roleName)
+
+		entries, err := env.chatService.GetChatAuditLog(ctx, chatWithAdmin.GetID(), adminID, chataudit.ChatAuditFilter{
+			Actions: []chataudit.AuditAction{chataudit.ChatOwnershipTransferredAction},
+		})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, deletedID, entries[0].GetActorID())
+		require.Equal(t, adminID, entries[0].GetTargetID())
+	})
+
+	t.Run("Ownership goes to the oldest member without admins", func(t *testing.T) {
+		//Cached member role was invalidated after commit
+		canDeleteChat, err := env.chatService.IsUserHasEnoughPermissions(ctx, chatWithMember.GetID(), memberID, roles.PermissionDeleteChat)
+		require.NoError(t, err)
+		require.True(t, canDeleteChat)
+	})
+
+	t.Run("Chat without other members is deleted", func(t *testing.T) {
+		require.Equal(t, 0, countUserRows(t, db, "SELECT COUNT(*) FROM chat WHERE id = $1", lonelyChat.GetID()))
+		require.Equal(t, 1, countUserRows(t, db, "SELECT COUNT(*) FROM chat WHERE id = $1", otherChat.GetID()))
+		require.Equal(t, 1, countUserRows(t, db, "SELECT COUNT(*) FROM chat_participant WHERE chat_id = $1", otherChat.GetID()))
+	})
+
+	t.Run("Messages are passed to deleted user placeholder", func(t *testing.T) {
+		var senderID uuid.UUID
+		err := db.DB.QueryRow("SELECT sender_id FROM chat_message WHERE id = $1", messageID).Scan(&senderID)
+		require.NoError(t, err)
+		require.Equal(t, users.DeletedChatUser.GetID(), senderID)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	publicDto "symphony_chat/internal/application/dto"
 	"symphony_chat/internal/application/middleware"
 	"symphony_chat/internal/domain/users"
@@ -15,8 +16,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestSignUpHandler(t *testing.T) {
@@ -28,16 +31,17 @@ func TestSignUpHandler(t *testing.T) {
 
 	testCases := []struct {
 		name string
-		credentials publicDto.LoginCredentials
+		credentials publicDto.SignUpRequest
 		expectedHttpCode int
 		expectedErrCode string
 		beforeTestAction func(t *testing.T, testDB *setup.TestDB)
 	}{
 		{
 			name: "Success registration",
-			credentials: publicDto.LoginCredentials {
+			credentials: publicDto.SignUpRequest {
 				Login: "Bomj.Obichnyi@gmail.com",
 				Password: "eptakaktakto228",
+				Username: "bomj_obichnyi",
 			},
 			expectedHttpCode: http.StatusOK,
 			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
@@ -47,9 +51,10 @@ func TestSignUpHandler(t *testing.T) {
 		},
 		{
 			name: "Duplicate User",
-			credentials: publicDto.LoginCredentials {
+			credentials: publicDto.SignUpRequest {
 				Login: "Alexey.Gnida2003.gmail.com",
 				Password: "hesusOneLover335",
+				Username: "alexey_gnida",
 			},
 			expectedHttpCode: http.StatusConflict,
 			expectedErrCode: users.ErrLoginAlreadyExists.Code,
@@ -57,9 +62,10 @@ func TestSignUpHandler(t *testing.T) {
 				err := testDB.TruncateAllTables()
 				require.NoError(t, err)
 
-				body := publicDto.LoginCredentials {
+				body := publicDto.SignUpRequest {
 					Login: "Alexey.Gnida2003.gmail.com",
 					Password: "hesusOneLover335",
+					Username: "alexey_gnida",
 				}
 
 				jsonBody, err := json.Marshal(body)
@@ -74,9 +80,10 @@ func TestSignUpHandler(t *testing.T) {
 		},
 		{
 			name: "Invalid login format (short Login)",
-			credentials: publicDto.LoginCredentials{
+			credentials: publicDto.SignUpRequest{
 				Login: "gmail",
 				Password: "kolomin.andrey2005",
+				Username: "short_login",
 			},
 			expectedHttpCode: http.StatusBadRequest,
 			expectedErrCode: "INVALID_LOGIN_OR_PASSWORD_FORMAT",
@@ -87,9 +94,10 @@ func TestSignUpHandler(t *testing.T) {
 		},
 		{
 			name: "Invalid login format (long Login)",
-			credentials: publicDto.LoginCredentials {
+			credentials: publicDto.SignUpRequest {
 				Login: "cnqibqvqgbnqognbqbqiomvevnevevne.gmail.com",
 				Password: "kolomin.andrey2005",
+				Username: "long_login",
 			},
 			expectedHttpCode: http.StatusBadRequest,
 			expectedErrCode: "INVALID_LOGIN_OR_PASSWORD_FORMAT",
@@ -100,9 +108,10 @@ func TestSignUpHandler(t *testing.T) {
 		},
 		{
 			name: "Invalid login format (disallowed symbols)",
-			credentials: publicDto.LoginCredentials {
+			credentials: publicDto.SignUpRequest {
 				Login: "cbevvbev%^gmail.com",
 				Password: "kolomin.andrey2005",
+				Username: "disallowed_symbols",
 			},
 			expectedHttpCode: http.StatusBadRequest,
 			expectedErrCode: "INVALID_LOGIN_OR_PASSWORD_FORMAT",
@@ -113,9 +122,10 @@ func TestSignUpHandler(t *testing.T) {
 		},
 		{
 			name: "Invalid password format (short Password)",
-			credentials: publicDto.LoginCredentials {
+			credentials: publicDto.SignUpRequest {
 				Login: "Kolomin.Andrey@gmail.com",
 				Password: "12345",
+				Username: "short_password",
 			},
 			expectedHttpCode: http.StatusBadRequest,
 			expectedErrCode: "INVALID_LOGIN_OR_PASSWORD_FORMAT",
@@ -126,9 +136,10 @@ func TestSignUpHandler(t *testing.T) {
 		},
 		{
 			name: "Invalid password format (long Password)",
-			credentials: publicDto.LoginCredentials {
+			credentials: publicDto.SignUpRequest {
 				Login: "Kolomin.Andrey@gmail.com",
-				Password: "kolomin.andrey2.andrey2005",
+				Password: strings.Repeat("kolomin.andrey2005", 8),
+				Username: "long_password",
 			},
 			expectedHttpCode: http.StatusBadRequest,
 			expectedErrCode: "INVALID_LOGIN_OR_PASSWORD_FORMAT",
@@ -137,6 +148,61 @@ func TestSignUpHandler(t *testing.T) {
 				require.NoError(t, err)
 			},
 		},
+		{
+			name: "Duplicate username",
+			credentials: publicDto.SignUpRequest {
+				Login: "Second.User@gmail.com",
+				Password: "secondUserPassword1",
+				Username: "Taken_Username",
+			},
+			expectedHttpCode: http.StatusConflict,
+			expectedErrCode: users.ErrUsernameAlreadyTaken.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
+				err := testDB.TruncateAllTables()
+				require.NoError(t, err)
+
+				jsonBody, err := json.Marshal(publicDto.SignUpRequest {
+					Login: "First.User@gmail.com",
+					Password: "firstUserPassword1",
+					Username: "taken_username",
+				})
+				require.NoError(t, err)
+
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest("POST", "/auth/signup", bytes.NewBuffer(jsonBody))
+				req.Header.Set("Content-Type", "application/json")
+				router.ServeHTTP(w, req)
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "Invalid username format",
+			credentials: publicDto.SignUpRequest {
+				Login: "Kolomin.Andrey@gmail.com",
+				Password: "kolomin.andrey2005",
+				Username: "1_bad username",
+			},
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode: users.ErrInvalidUsername.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
+				err := testDB.TruncateAllTables()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Reserved username",
+			credentials: publicDto.SignUpRequest {
+				Login: "Kolomin.Andrey@gmail.com",
+				Password: "kolomin.andrey2005",
+				Username: users.DeletedChatUser.GetUsername(),
+			},
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode: users.ErrUsernameReserved.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
+				err := testDB.TruncateAllTables()
+				require.NoError(t, err)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -171,9 +237,10 @@ func TestSignUpHandler(t *testing.T) {
 				cookies := w.Result().Cookies()
 				var foundRefreshToken bool 
 				for _, cookie := range cookies {
+					//Access token is sent only in Authorization header, cookie with it wouldn't be protected by csrf token
+					require.NotEqual(t, "access_token", cookie.Name)
 					if cookie.Name == "refresh_token" {
 						foundRefreshToken = true
-						break
 					}
 				}
 
@@ -212,9 +279,10 @@ func TestLogInHandler(t *testing.T) {
 				err := testDB.TruncateAllTables()
 				require.NoError(t, err)
 
-				bodyJson, err := json.Marshal(publicDto.LoginCredentials {
+				bodyJson, err := json.Marshal(publicDto.SignUpRequest {
 					Login:    "Andrei.Karpukh2000@gmail.com",
 					Password: "andrei_kriper2004boi",
+					Username: "test_user",
 				})
 
 				require.NoError(t, err)
@@ -238,9 +306,10 @@ func TestLogInHandler(t *testing.T) {
 				err := testDB.TruncateAllTables()
 				require.NoError(t, err)
 
-				bodyJson, err := json.Marshal(publicDto.LoginCredentials {
+				bodyJson, err := json.Marshal(publicDto.SignUpRequest {
 					Login:    "Andrei.Karpukh2000@gmail.com",
 					Password: "andrei_kriper2004boi",
+					Username: "test_user",
 				})
 
 				require.NoError(t, err)
@@ -326,6 +395,64 @@ func TestLogInHandler(t *testing.T) {
 	}
 }
 
+func TestLogInRehashesBcryptPassword(t *testing.T) {
+	testDB, err := setup.NewTestDB()
+	require.NoError(t, err)
+
+	defer func() {
+		err := testDB.Close()
+		require.NoError(t, err)
+	}()
+
+	router := authhttp.SetupRouter(t, testDB)
+
+	err = testDB.TruncateAllTables()
+	require.NoError(t, err)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Andrei.Karpukh2000@gmail.com",
+		Password: "andrei_kriper2004boi",
+	}
+
+	bodyJson, err := json.Marshal(publicDto.SignUpRequest{
+		Login:    credentials.Login,
+		Password: credentials.Password,
+		Username: "test_user",
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/auth/signup", bytes.NewBuffer(bodyJson))
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	//Replacing hash with hash that was created before argon2id
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte(credentials.Password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	_, err = testDB.DB.Exec("UPDATE auth_user SET password = $1 WHERE login = $2", string(legacyHash), credentials.Login)
+	require.NoError(t, err)
+
+	bodyJson, err = json.Marshal(credentials)
+	require.NoError(t, err)
+
+	req = httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(bodyJson))
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var storedHash string
+	err = testDB.DB.QueryRow("SELECT password FROM auth_user WHERE login = $1", credentials.Login).Scan(&storedHash)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(storedHash, "$argon2id$"))
+
+	//Password still works with the new hash
+	req = httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(bodyJson))
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+}
+
 func TestLogOutHandler(t *testing.T) {
 	testDB, err := setup.NewTestDB()
 	require.NoError(t, err)
@@ -350,9 +477,10 @@ func TestLogOutHandler(t *testing.T) {
 				err := testDB.TruncateAllTables()
 				require.NoError(t, err)
 
-				jsonBody, err := json.Marshal(publicDto.LoginCredentials {
+				jsonBody, err := json.Marshal(publicDto.SignUpRequest {
 					Login:    "Andrei.Karpukh2000@gmail.com",
 					Password: "fhigbgiwgwwhnwihwgwb",
+					Username: "test_user",
 				})
 
 				require.NoError(t, err)
@@ -426,14 +554,16 @@ func TestLogOutHandler(t *testing.T) {
 
 		{
 			name: "Expired access token",
-			expectedHttpCode: http.StatusOK,
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode: "TOKEN_EXPIRED",
 			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
 				err := testDB.TruncateAllTables()
 				require.NoError(t, err)
 
-				jsonBody, err := json.Marshal(publicDto.LoginCredentials {
+				jsonBody, err := json.Marshal(publicDto.SignUpRequest {
 					Login:    "Andrei.Karpukh2000@gmail.com",
 					Password: "fhigbgiwgwwhnwihwgwb",
+					Username: "test_user",
 				})
 
 				require.NoError(t, err)
@@ -445,16 +575,20 @@ func TestLogOutHandler(t *testing.T) {
 				require.Equal(t, res.Code, http.StatusOK)
 
 				var foundRefreshTokenInCookies bool
+				var csrfToken string
 
 				cookies := res.Result().Cookies()
 				for _, cookie := range cookies {
 					if cookie.Name == "refresh_token" {
 						foundRefreshTokenInCookies = true
-						break
+					}
+					if cookie.Name == "csrf_token" {
+						csrfToken = cookie.Value
 					}
 				}
 
 				require.True(t, foundRefreshTokenInCookies)
+				require.NotEmpty(t, csrfToken)
 
 				var responseBody map[string]publicDto.JWTTokenDTO
 
@@ -465,6 +599,13 @@ func TestLogOutHandler(t *testing.T) {
 
 				req = httptest.NewRequest("POST", "/auth/logout", nil)
 
+				//Refresh token cookie and csrf token are sent, but middleware must not refresh access token by them
+				req.AddCookie(&http.Cookie{
+					Name:  "csrf_token",
+					Value: csrfToken,
+				})
+				req.Header.Set("X-CSRF-Token", csrfToken)
+
 				duration := os.Getenv("ACCESS_TTL_IN_MINUTES")
 				require.NotEmpty(t, duration)
 				durationInt, err := strconv.Atoi(duration)
@@ -501,6 +642,7 @@ func TestLogOutHandler(t *testing.T) {
 				err := json.Unmarshal(res.Body.Bytes(), &response)
 				require.NoError(t, err)
 				assert.Equal(t, tc.expectedErrCode, response["code"])
+				assert.Empty(t, res.Header().Get("New-Access-Token"))
 			} else {
 				var responseBody map[string]string
 				err := json.Unmarshal(res.Body.Bytes(), &responseBody)
@@ -509,10 +651,6 @@ func TestLogOutHandler(t *testing.T) {
 				assert.Equal(t, "LOGOUT_SUCCESSFUL", responseBody["code"])
 				assert.Equal(t, "clear_tokens", responseBody["message"])
 
-				if tc.name == "Expired access token" {
-					require.NotEmpty(t, res.Header().Get("New-Access-Token"))
-				}
-
 				var IsCookieContainsInvalidatedRefreshToken bool
 
 				cookies := res.Result().Cookies()
@@ -528,4 +666,328 @@ func TestLogOutHandler(t *testing.T) {
 
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestRefreshHandlerRequiresCSRFToken(t *testing.T) {
+	testDB, err := setup.NewTestDB()
+	require.NoError(t, err)
+
+	defer func() {
+		err := testDB.Close()
+		require.NoError(t, err)
+	}()
+
+	router := authhttp.SetupRouter(t, testDB)
+
+	err = testDB.TruncateAllTables()
+	require.NoError(t, err)
+
+	jsonBody, err := json.Marshal(publicDto.SignUpRequest {
+		Login:    "Andrei.Karpukh2000@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+		Username: "test_user",
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/auth/signup", bytes.NewBuffer(jsonBody))
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var refreshToken, csrfToken string
+	for _, cookie := range res.Result().Cookies() {
+		switch cookie.Name {
+		case "refresh_token":
+			refreshToken = cookie.Value
+		case "csrf_token":
+			csrfToken = cookie.Value
+			assert.False(t, cookie.HttpOnly)
+		}
+	}
+
+	require.NotEmpty(t, refreshToken)
+	require.NotEmpty(t, csrfToken)
+	require.Equal(t, csrfToken, res.Header().Get("X-CSRF-Token"))
+
+	//Cookie is sent by browser automatically, but forged request can't read csrf token
+	req = httptest.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: csrfToken})
+	res = httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusForbidden, res.Code)
+
+	var errorBody map[string]string
+	err = json.Unmarshal(res.Body.Bytes(), &errorBody)
+	require.NoError(t, err)
+	assert.Equal(t, middleware.ErrInvalidCSRFToken.Code, errorBody["code"])
+
+	req = httptest.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: csrfToken})
+	req.Header.Set("X-CSRF-Token", csrfToken)
+	res = httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestRefreshHandler(t *testing.T) {
+	testDB, err := setup.NewTestDB()
+	require.NoError(t, err)
+
+	defer func() {
+		err := testDB.Close()
+		require.NoError(t, err)
+	}()
+
+	router := authhttp.SetupRouter(t, testDB)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Refresh.Rotation@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+	}
+
+	testCases := []struct {
+		name             string
+		expectedHttpCode int
+		expectedErrCode  string
+		beforeTestAction func(t *testing.T) *http.Request
+		afterTestAction  func(t *testing.T, res *httptest.ResponseRecorder)
+	}{
+		{
+			name:             "Refresh token is rotated",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T) *http.Request {
+				session := signUpSession(t, router, credentials)
+				return newRefreshRequest(session.refreshToken, session.csrfToken)
+			},
+			afterTestAction: func(t *testing.T, res *httptest.ResponseRecorder) {
+				var responseBody map[string]publicDto.JWTTokenDTO
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &responseBody))
+				require.NotEmpty(t, responseBody["access_token"].Token)
+				require.NotEmpty(t, responseBody["refresh_token"].Token)
+
+				refreshCookie := findCookie(res, "refresh_token")
+				require.NotNil(t, refreshCookie)
+				assert.Equal(t, responseBody["refresh_token"].Token, refreshCookie.Value)
+
+				//New access token is accepted by protected routes
+				req := httptest.NewRequest("POST", "/auth/logout", nil)
+				req.Header.Set("Authorization", "Bearer "+responseBody["access_token"].Token)
+				logoutRes := httptest.NewRecorder()
+				router.ServeHTTP(logoutRes, req)
+				assert.Equal(t, http.StatusOK, logoutRes.Code)
+			},
+		},
+		{
+			name:             "Access token is sent as refresh token",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "WRONG_TOKEN_TYPE",
+			beforeTestAction: func(t *testing.T) *http.Request {
+				session := signUpSession(t, router, credentials)
+				return newRefreshRequest(session.accessToken, session.csrfToken)
+			},
+			afterTestAction: func(t *testing.T, res *httptest.ResponseRecorder) {
+				refreshCookie := findCookie(res, "refresh_token")
+				require.NotNil(t, refreshCookie)
+				assert.Empty(t, refreshCookie.Value)
+			},
+		},
+		{
+			name:             "Old refresh token is reused",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "REFRESH_TOKEN_REUSE_DETECTED",
+			beforeTestAction: func(t *testing.T) *http.Request {
+				session := signUpSession(t, router, credentials)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(session.refreshToken, session.csrfToken))
+				require.Equal(t, http.StatusOK, res.Code)
+
+				return newRefreshRequest(session.refreshToken, session.csrfToken)
+			},
+		},
+		{
+			name:             "Refresh token of the family that was revoked by reuse",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "REFRESH_TOKEN_REVOKED",
+			beforeTestAction: func(t *testing.T) *http.Request {
+				session := signUpSession(t, router, credentials)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(session.refreshToken, session.csrfToken))
+				require.Equal(t, http.StatusOK, res.Code)
+				rotatedRefreshToken := findCookie(res, "refresh_token")
+				require.NotNil(t, rotatedRefreshToken)
+
+				res = httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(session.refreshToken, session.csrfToken))
+				require.Equal(t, http.StatusUnauthorized, res.Code)
+
+				return newRefreshRequest(rotatedRefreshToken.Value, session.csrfToken)
+			},
+		},
+		{
+			name:             "Rotated refresh token is rotated again in the same session",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T) *http.Request {
+				session := signUpSession(t, router, credentials)
+				sessionID := currentSessionID(t, router, session.accessToken)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(session.refreshToken, session.csrfToken))
+				require.Equal(t, http.StatusOK, res.Code)
+				rotated := sessionFromResponse(t, res)
+				require.NotEqual(t, session.refreshToken, rotated.refreshToken)
+
+				//Rotation doesn't create new session
+				require.Equal(t, sessionID, currentSessionID(t, router, rotated.accessToken))
+
+				return newRefreshRequest(rotated.refreshToken, rotated.csrfToken)
+			},
+			afterTestAction: func(t *testing.T, res *httptest.ResponseRecorder) {
+				rotated := sessionFromResponse(t, res)
+
+				sessionsRes := getSessions(router, rotated.accessToken)
+				require.Equal(t, http.StatusOK, sessionsRes.Code)
+
+				var response struct {
+					Sessions []publicDto.SessionDTO `json:"sessions"`
+				}
+				require.NoError(t, json.Unmarshal(sessionsRes.Body.Bytes(), &response))
+				assert.Len(t, response.Sessions, 1)
+			},
+		},
+		{
+			name:             "Reuse of older token revokes the newest token of the family",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "REFRESH_TOKEN_REVOKED",
+			beforeTestAction: func(t *testing.T) *http.Request {
+				session := signUpSession(t, router, credentials)
+
+				refreshToken := session.refreshToken
+				for range 2 {
+					res := httptest.NewRecorder()
+					router.ServeHTTP(res, newRefreshRequest(refreshToken, session.csrfToken))
+					require.Equal(t, http.StatusOK, res.Code)
+					refreshToken = findCookie(res, "refresh_token").Value
+				}
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(session.refreshToken, session.csrfToken))
+				require.Equal(t, http.StatusUnauthorized, res.Code)
+
+				return newRefreshRequest(refreshToken, session.csrfToken)
+			},
+		},
+		{
+			name:             "Reuse doesn't revoke families of other sessions",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T) *http.Request {
+				stolen := signUpSession(t, router, credentials)
+				other := logInSession(t, router, credentials)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(stolen.refreshToken, stolen.csrfToken))
+				require.Equal(t, http.StatusOK, res.Code)
+
+				res = httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(stolen.refreshToken, stolen.csrfToken))
+				require.Equal(t, http.StatusUnauthorized, res.Code)
+
+				return newRefreshRequest(other.refreshToken, other.csrfToken)
+			},
+		},
+		{
+			name:             "Refresh token cookie is not set",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "REFRESH_TOKEN_WAS_NOT_SET_IN_COOKIE",
+			beforeTestAction: func(t *testing.T) *http.Request {
+				session := signUpSession(t, router, credentials)
+
+				req := httptest.NewRequest("POST", "/auth/refresh", nil)
+				req.AddCookie(&http.Cookie{Name: "csrf_token", Value: session.csrfToken})
+				req.Header.Set("X-CSRF-Token", session.csrfToken)
+				return req
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, testDB.TruncateAllTables())
+
+			req := tc.beforeTestAction(t)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.expectedHttpCode, res.Code)
+
+			if tc.expectedErrCode != "" {
+				var response map[string]string
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedErrCode, response["code"])
+			}
+
+			if tc.afterTestAction != nil {
+				tc.afterTestAction(t, res)
+			}
+		})
+	}
+}
+
+//Tokens and csrf token that client gets after sign up
+type authSession struct {
+	accessToken  string
+	refreshToken string
+	csrfToken    string
+}
+
+func signUpSession(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials) authSession {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/signup", publicDto.SignUpRequest{
+		Login:    credentials.Login,
+		Password: credentials.Password,
+		Username: "test_user",
+	}))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	return sessionFromResponse(t, res)
+}
+
+//Function reads access token from body, refresh and csrf tokens from cookies
+func sessionFromResponse(t *testing.T, res *httptest.ResponseRecorder) authSession {
+	var responseBody map[string]publicDto.JWTTokenDTO
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &responseBody))
+
+	refreshCookie := findCookie(res, "refresh_token")
+	csrfCookie := findCookie(res, "csrf_token")
+	require.NotNil(t, refreshCookie)
+	require.NotNil(t, csrfCookie)
+
+	return authSession{
+		accessToken:  responseBody["access_token"].Token,
+		refreshToken: refreshCookie.Value,
+		csrfToken:    csrfCookie.Value,
+	}
+}
+
+func newRefreshRequest(refreshToken string, csrfToken string) *http.Request {
+	req := httptest.NewRequest("POST", "/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: csrfToken})
+	req.Header.Set("X-CSRF-Token", csrfToken)
+	return req
+}
+
+func findCookie(res *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range res.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	publicDto "symphony_chat/internal/application/dto"
+	loginattempt "symphony_chat/internal/domain/login_attempt"
+	loginAttemptRepo "symphony_chat/internal/infrastructure/login_attempt/postgres"
+	tx "symphony_chat/internal/infrastructure/transaction/postgres"
+	"symphony_chat/internal/service/auth/lockout"
+	authhttp "symphony_chat/tests/integration/auth/http"
+	"symphony_chat/tests/integration/setup"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+//Default login policy locks login after 5 failed attempts
+const maxFailedLoginAttempts = 5
+
+func TestLogInLockout(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	router := authhttp.SetupRouter(t, db)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Locked.User@gmail.com",
+		Password: "lockedUserPassword1",
+	}
+	wrongCredentials := publicDto.LoginCredentials{
+		Login:    credentials.Login,
+		Password: "wrongPassword1",
+	}
+
+	testCases := []struct {
+		name             string
+		expectedHttpCode int
+		expectedErrCode  string
+		beforeTestAction func(t *testing.T, testDB *setup.TestDB)
+		afterTestAction  func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder)
+	}{
+		{
+			name:             "Login is locked after max failed attempts",
+			expectedHttpCode: http.StatusTooManyRequests,
+			expectedErrCode:  loginattempt.AccountTemporarilyLockedCode,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+
+				for range maxFailedLoginAttempts {
+					require.Equal(t, http.StatusUnauthorized, logIn(t, router, wrongCredentials).Code)
+				}
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				require.NotEmpty(t, res.Header().Get("Retry-After"))
+				require.Equal(t, 1, countRows(t, testDB, "login_lockout_event"))
+			},
+		},
+		{
+			name:             "Login can be used after lockout expired",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+
+				for range maxFailedLoginAttempts {
+					require.Equal(t, http.StatusUnauthorized, logIn(t, router, wrongCredentials).Code)
+				}
+				require.Equal(t, http.StatusTooManyRequests, logIn(t, router, credentials).Code)
+
+				_, err := testDB.DB.Exec("UPDATE login_attempt SET locked_until = NOW() - INTERVAL '1 second'")
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:             "Successful login resets failed attempts",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+
+				for range maxFailedLoginAttempts - 1 {
+					require.Equal(t, http.StatusUnauthorized, logIn(t, router, wrongCredentials).Code)
+				}
+				require.Equal(t, http.StatusOK, logIn(t, router, credentials).Code)
+
+				//Without reset the first of these failures would lock the login
+				for range maxFailedLoginAttempts - 1 {
+					require.Equal(t, http.StatusUnauthorized, logIn(t, router, wrongCredentials).Code)
+				}
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				var count int
+				err := testDB.DB.QueryRow("SELECT COUNT(*) FROM login_attempt WHERE key_type = 'LOGIN'").Scan(&count)
+				require.NoError(t, err)
+				require.Equal(t, 0, count)
+			},
+		},
+		{
+			name:             "Concurrent failed attempts can't pass lockout check",
+			expectedHttpCode: http.StatusTooManyRequests,
+			expectedErrCode:  loginattempt.AccountTemporarilyLockedCode,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+
+				attemptsCount := 3 * maxFailedLoginAttempts
+				codes := make(chan int, attemptsCount)
+
+				var wg sync.WaitGroup
+				for range attemptsCount {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						codes <- logIn(t, router, wrongCredentials).Code
+					}()
+				}
+				wg.Wait()
+				close(codes)
+
+				counts := make(map[int]int)
+				for code := range codes {
+					counts[code]++
+				}
+
+				require.Equal(t, maxFailedLoginAttempts, counts[http.StatusUnauthorized])
+				require.Equal(t, attemptsCount-maxFailedLoginAttempts, counts[http.StatusTooManyRequests])
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				var failedCount int
+				err := testDB.DB.QueryRow("SELECT failed_count FROM login_attempt WHERE key_type = 'LOGIN'").Scan(&failedCount)
+				require.NoError(t, err)
+				require.Equal(t, maxFailedLoginAttempts, failedCount)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.beforeTestAction(t, db)
+
+			res := logIn(t, router, credentials)
+			require.Equal(t, tc.expectedHttpCode, res.Code, res.Body.String())
+
+			if tc.expectedErrCode != "" {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				require.Equal(t, tc.expectedErrCode, response["code"])
+			}
+
+			if tc.afterTestAction != nil {
+				tc.afterTestAction(t, db, res)
+			}
+		})
+	}
+}
+
+func logIn(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/login", credentials))
+	return res
+}
+
+func TestPurgeStaleLoginAttempts(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.TruncateAllTables())
+
+	lockoutService, err := lockout.NewLockoutService(
+		lockout.WithLoginAttemptRepository(loginAttemptRepo.NewPostgresLoginAttemptRepo(db.DB)),
+		lockout.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+	)
+	require.NoError(t, err)
+
+	//Default policies reset counters one hour after the last failure
+	_, err = db.DB.Exec(`INSERT INTO login_attempt (key_type, key_value, failed_count, last_failed_at, locked_until) VALUES
+		('LOGIN', 'recent failure', 1, NOW() - INTERVAL '10 minutes', NULL),
+		('LOGIN', 'old failure', 3, NOW() - INTERVAL '2 hours', NULL),
+		('LOGIN', 'still locked', 10, NOW() - INTERVAL '2 hours', NOW() + INTERVAL '1 hour'),
+		('LOGIN', 'without failures', 0, NULL, NULL),
+		('IP', '10.0.0.1', 2, NOW() - INTERVAL '2 hours', NOW() - INTERVAL '1 hour')`)
+	require.NoError(t, err)
+
+	deleted, err := lockoutService.PurgeStaleAttempts(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 3, deleted)
+
+	rows, err := db.DB.Query("SELECT key_value FROM login_attempt")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var kept []string
+	for rows.Next() {
+		var keyValue string
+		require.NoError(t, rows.Scan(&keyValue))
+		kept = append(kept, keyValue)
+	}
+	require.NoError(t, rows.Err())
+	require.ElementsMatch(t, []string{"recent failure", "still locked"}, kept)
+}
@@ -0,0 +1,713 @@
+package http_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"symphony_chat/internal/application/cookies"
+	publicDto "symphony_chat/internal/application/dto"
+	"symphony_chat/internal/domain/oidc"
+	oidcProvider "symphony_chat/internal/infrastructure/oidc/provider"
+	authhttp "symphony_chat/tests/integration/auth/http"
+	"symphony_chat/tests/integration/setup"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	stubClientID     = "symphony-chat-test"
+	stubClientSecret = "stub-secret"
+	stubRedirectURL  = "http://symphony.test/auth/oidc/stub/callback"
+)
+
+//stubIdP is minimal OpenID Connect provider with authorization code flow and PKCE
+//It signs in user with subject from nextUser without any login page
+type stubIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu         sync.Mutex
+	nextUser   stubUser
+	wrongNonce bool
+	codes      map[string]stubAuthorization
+}
+
+type stubUser struct {
+	Subject           string
+	Email             string
+	PreferredUsername string
+}
+
+type stubAuthorization struct {
+	user          stubUser
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &stubIdP{
+		key:   key,
+		codes: make(map[string]stubAuthorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *stubIdP) setUser(user stubUser, wrongNonce bool) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.nextUser = user
+	idp.wrongNonce = wrongNonce
+}
+
+func (idp *stubIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 idp.server.URL,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+func (idp *stubIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != stubClientID || query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	codeBytes := make([]byte, 16)
+	rand.Read(codeBytes)
+	code := base64.RawURLEncoding.EncodeToString(codeBytes)
+
+	idp.mu.Lock()
+	idp.codes[code] = stubAuthorization{
+		user:          idp.nextUser,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+	}
+	idp.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (idp *stubIdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != stubClientID || clientSecret != stubClientSecret {
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	idp.mu.Lock()
+	authorization, exists := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	wrongNonce := idp.wrongNonce
+	idp.mu.Unlock()
+
+	//Code is single-use and is bound to redirect uri and PKCE challenge
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !exists || authorization.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(verifierHash[:]) != authorization.codeChallenge {
+		writeTokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	nonce := authorization.nonce
+	if wrongNonce {
+		nonce = "other-nonce"
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                idp.server.URL,
+		"aud":                stubClientID,
+		"sub":                authorization.user.Subject,
+		"email":              authorization.user.Email,
+		"email_verified":     true,
+		"preferred_username": authorization.user.PreferredUsername,
+		"nonce":              nonce,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+	})
+	idToken.Header["kid"] = "stub-key"
+
+	signedIDToken, err := idToken.SignedString(idp.key)
+	if err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "stub-access-token",
+		"token_type":   "Bearer",
+		"id_token":     signedIDToken,
+	})
+}
+
+func (idp *stubIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "stub-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func TestOIDCHandlers(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	idp := newStubIdP(t)
+
+	provider, err := oidcProvider.NewOIDCProvider(context.Background(), oidcProvider.ProviderConfig{
+		Name:         "stub",
+		IssuerURL:    idp.server.URL,
+		ClientID:     stubClientID,
+		ClientSecret: stubClientSecret,
+		RedirectURL:  stubRedirectURL,
+	}, idp.server.Client())
+	require.NoError(t, err)
+
+	router := authhttp.SetupRouter(t, db, provider)
+
+	oidcUser := stubUser{
+		Subject:           "stub-subject-1",
+		Email:             "oidc.user@example.com",
+		PreferredUsername: "oidc_user",
+	}
+
+	testCases := []struct {
+		name             string
+		expectedHttpCode int
+		expectedErrCode  string
+		beforeTestAction func(t *testing.T, testDB *setup.TestDB) *http.Request
+		afterTestAction  func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder)
+	}{
+		{
+			name:             "First login creates user with chat profile",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, false)
+
+				return authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router))
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				var response struct {
+					AccessToken publicDto.JWTTokenDTO `json:"access_token"`
+					Created     bool                  `json:"created"`
+				}
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				require.NotEmpty(t, response.AccessToken.Token)
+				require.True(t, response.Created)
+
+				var username string
+				err := testDB.DB.QueryRow("SELECT username FROM chat_user WHERE id = $1", response.AccessToken.AuthUserID).Scan(&username)
+				require.NoError(t, err)
+				require.Equal(t, oidcUser.PreferredUsername, username)
+			},
+		},
+		{
+			name:             "Second login uses linked user",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, false)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router)))
+				require.Equal(t, http.StatusOK, res.Code)
+
+				return authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router))
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				require.Equal(t, false, response["created"])
+				require.Equal(t, 1, countRows(t, testDB, "auth_user"))
+			},
+		},
+		{
+			name:             "State can't be used twice",
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode:  "OIDC_STATE_ALREADY_USED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, false)
+
+				callback := authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router))
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, callback)
+				require.Equal(t, http.StatusOK, res.Code)
+
+				//Replayed request has the same state cookie
+				replay := httptest.NewRequest("GET", callback.URL.String(), nil)
+				for _, cookie := range callback.Cookies() {
+					replay.AddCookie(cookie)
+				}
+				return replay
+			},
+		},
+		{
+			name:             "Unknown state",
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode:  "OIDC_STATE_NOT_FOUND",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				req := httptest.NewRequest("GET", "/auth/oidc/stub/callback?code=some-code&state=unknown-state", nil)
+				req.AddCookie(&http.Cookie{Name: cookies.OIDCStateCookie, Value: oidc.HashState("unknown-state")})
+				return req
+			},
+		},
+		{
+			name:             "Callback without state cookie is rejected",
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode:  "OIDC_STATE_MISMATCH",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, false)
+
+				return authorizeAtStubIdPWithoutCookie(t, idp, startOIDCLogin(t, router))
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				require.Equal(t, 0, countRows(t, testDB, "auth_user"))
+				for _, cookie := range res.Result().Cookies() {
+					require.NotEqual(t, cookies.RefreshTokenCookie, cookie.Name)
+				}
+			},
+		},
+		{
+			name:             "Link started by other user can't be finished in victim's browser",
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode:  "OIDC_STATE_MISMATCH",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, false)
+
+				attacker := publicDto.LoginCredentials{
+					Login:    "Attacker.User@gmail.com",
+					Password: "attackerUserPassword1",
+				}
+				signUp(t, router, attacker)
+				attackerFlow := startOIDCLink(t, router, attacker)
+
+				//Victim has state cookie of its own login flow, not of the flow of attacker
+				victimFlow := startOIDCLogin(t, router)
+				req := authorizeAtStubIdPWithoutCookie(t, idp, attackerFlow)
+				req.AddCookie(&http.Cookie{Name: victimFlow.stateCookie.Name, Value: victimFlow.stateCookie.Value})
+				return req
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				require.Equal(t, 0, countRows(t, testDB, "oidc_identity"))
+			},
+		},
+		{
+			name:             "Id token with other nonce is rejected",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "OIDC_INVALID_ID_TOKEN",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, true)
+
+				return authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router))
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				require.Equal(t, 0, countRows(t, testDB, "auth_user"))
+			},
+		},
+		{
+			name:             "Provider denied authorization",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "OIDC_AUTHORIZATION_DENIED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				return httptest.NewRequest("GET", "/auth/oidc/stub/callback?error=access_denied", nil)
+			},
+		},
+		{
+			name:             "Unknown provider",
+			expectedHttpCode: http.StatusNotFound,
+			expectedErrCode:  "OIDC_PROVIDER_NOT_FOUND",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				return httptest.NewRequest("GET", "/auth/oidc/unknown/login", nil)
+			},
+		},
+		{
+			name:             "Logged in user links external account",
+			expectedHttpCode: http.StatusOK,
+			expectedErrCode:  "OIDC_IDENTITY_LINKED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, false)
+
+				credentials := publicDto.LoginCredentials{
+					Login:    "Linked.User@gmail.com",
+					Password: "linkedUserPassword1",
+				}
+				signUp(t, router, credentials)
+
+				return authorizeAtStubIdP(t, router, idp, startOIDCLink(t, router, credentials))
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				//Login through provider gets existing user instead of creating new one
+				loginRes := httptest.NewRecorder()
+				router.ServeHTTP(loginRes, authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router)))
+				require.Equal(t, http.StatusOK, loginRes.Code)
+
+				var response struct {
+					AccessToken publicDto.JWTTokenDTO `json:"access_token"`
+					Created     bool                  `json:"created"`
+				}
+				require.NoError(t, json.Unmarshal(loginRes.Body.Bytes(), &response))
+				require.False(t, response.Created)
+				require.Equal(t, 1, countRows(t, testDB, "auth_user"))
+
+				var login string
+				err := testDB.DB.QueryRow("SELECT login FROM auth_user WHERE id = $1", response.AccessToken.AuthUserID).Scan(&login)
+				require.NoError(t, err)
+				require.Equal(t, "Linked.User@gmail.com", login)
+			},
+		},
+		{
+			name:             "External account linked to other user can't be linked",
+			expectedHttpCode: http.StatusConflict,
+			expectedErrCode:  "OIDC_IDENTITY_LINKED_TO_OTHER_USER",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				idp.setUser(oidcUser, false)
+
+				//External account gets its own user on first login
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router)))
+				require.Equal(t, http.StatusOK, res.Code)
+
+				credentials := publicDto.LoginCredentials{
+					Login:    "Linked.User@gmail.com",
+					Password: "linkedUserPassword1",
+				}
+				signUp(t, router, credentials)
+
+				return authorizeAtStubIdP(t, router, idp, startOIDCLink(t, router, credentials))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.beforeTestAction(t, db)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.expectedHttpCode, res.Code, res.Body.String())
+
+			if tc.expectedErrCode != "" {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				require.Equal(t, tc.expectedErrCode, response["code"])
+			}
+
+			if tc.afterTestAction != nil {
+				tc.afterTestAction(t, db, res)
+			}
+		})
+	}
+}
+
+//Flow that was started by the client: url of identity provider and state cookie of the browser
+type oidcFlow struct {
+	authorizationURL string
+	stateCookie      *http.Cookie
+}
+
+func startOIDCLogin(t *testing.T, router *gin.Engine) oidcFlow {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/auth/oidc/stub/login", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	return readOIDCFlow(t, res)
+}
+
+func startOIDCLink(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials) oidcFlow {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/login", credentials))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var tokens map[string]publicDto.JWTTokenDTO
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &tokens))
+
+	req := httptest.NewRequest("POST", "/auth/oidc/stub/link", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens["access_token"].Token)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	return readOIDCFlow(t, res)
+}
+
+func readOIDCFlow(t *testing.T, res *httptest.ResponseRecorder) oidcFlow {
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+
+	flow := oidcFlow{authorizationURL: response["authorization_url"]}
+	for _, cookie := range res.Result().Cookies() {
+		if cookie.Name == cookies.OIDCStateCookie {
+			flow.stateCookie = cookie
+		}
+	}
+	require.NotNil(t, flow.stateCookie, "state cookie must be set")
+	require.True(t, flow.stateCookie.HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, flow.stateCookie.SameSite)
+
+	return flow
+}
+
+//Function opens authorization url like a browser and returns request to callback where stub provider redirected
+//Request has state cookie of the browser that started the flow
+func authorizeAtStubIdP(t *testing.T, router *gin.Engine, idp *stubIdP, flow oidcFlow) *http.Request {
+	req := authorizeAtStubIdPWithoutCookie(t, idp, flow)
+	req.AddCookie(&http.Cookie{Name: flow.stateCookie.Name, Value: flow.stateCookie.Value})
+	return req
+}
+
+//Request is sent by browser that didn't start the flow, like victim who opened url of attacker
+func authorizeAtStubIdPWithoutCookie(t *testing.T, idp *stubIdP, flow oidcFlow) *http.Request {
+	client := idp.server.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	res, err := client.Get(flow.authorizationURL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, stubRedirectURL, location.Scheme+"://"+location.Host+location.Path)
+
+	return httptest.NewRequest("GET", location.RequestURI(), nil)
+}
+
+func countRows(t *testing.T, testDB *setup.TestDB, table string) int {
+	var count int
+	err := testDB.DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
+
+//Accounts created through identity provider have no password, recent sign in confirms sensitive actions instead
+func TestPasswordlessAccountReauthentication(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	idp := newStubIdP(t)
+
+	provider, err := oidcProvider.NewOIDCProvider(context.Background(), oidcProvider.ProviderConfig{
+		Name:         "stub",
+		IssuerURL:    idp.server.URL,
+		ClientID:     stubClientID,
+		ClientSecret: stubClientSecret,
+		RedirectURL:  stubRedirectURL,
+	}, idp.server.Client())
+	require.NoError(t, err)
+
+	router := authhttp.SetupRouter(t, db, provider)
+
+	idp.setUser(stubUser{
+		Subject:           "stub-subject-1",
+		Email:             "oidc.user@example.com",
+		PreferredUsername: "oidc_user",
+	}, false)
+
+	testCases := []struct {
+		name             string
+		expectedHttpCode int
+		expectedErrCode  string
+		beforeTestAction func(t *testing.T, testDB *setup.TestDB) *http.Request
+		afterTestAction  func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder)
+	}{
+		{
+			name:             "Recent sign in sets first password",
+			expectedHttpCode: http.StatusOK,
+			expectedErrCode:  "PASSWORD_CHANGED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				accessToken := oidcLogIn(t, router, idp)
+
+				req := newJSONRequest(t, "/auth/password/change", publicDto.ChangePasswordRequest{NewPassword: "newOidcPassword1"})
+				req.Header.Set("Authorization", "Bearer "+accessToken)
+				return req
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				var password string
+				err := testDB.DB.QueryRow("SELECT password FROM auth_user").Scan(&password)
+				require.NoError(t, err)
+				require.NotEmpty(t, password)
+			},
+		},
+		{
+			name:             "Old sign in can't set password",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "REAUTHENTICATION_REQUIRED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				accessToken := oidcLogIn(t, router, idp)
+				ageSessions(t, testDB)
+
+				req := newJSONRequest(t, "/auth/password/change", publicDto.ChangePasswordRequest{NewPassword: "newOidcPassword1"})
+				req.Header.Set("Authorization", "Bearer "+accessToken)
+				return req
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				var password string
+				err := testDB.DB.QueryRow("SELECT password FROM auth_user").Scan(&password)
+				require.NoError(t, err)
+				require.Empty(t, password)
+			},
+		},
+		{
+			name:             "Recent sign in disables two factor without password",
+			expectedHttpCode: http.StatusOK,
+			expectedErrCode:  "TWO_FACTOR_DISABLED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				accessToken := oidcLogIn(t, router, idp)
+				_, _, recoveryCodes := enableTwoFactorWithAccessToken(t, router, accessToken)
+
+				req := newJSONRequest(t, "/auth/2fa/disable", publicDto.DisableTwoFactorRequest{Code: recoveryCodes[0]})
+				req.Header.Set("Authorization", "Bearer "+accessToken)
+				return req
+			},
+		},
+		{
+			name:             "Old sign in can't disable two factor",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "REAUTHENTICATION_REQUIRED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+				accessToken := oidcLogIn(t, router, idp)
+				_, _, recoveryCodes := enableTwoFactorWithAccessToken(t, router, accessToken)
+				ageSessions(t, testDB)
+
+				req := newJSONRequest(t, "/auth/2fa/disable", publicDto.DisableTwoFactorRequest{Code: recoveryCodes[0]})
+				req.Header.Set("Authorization", "Bearer "+accessToken)
+				return req
+			},
+			afterTestAction: func(t *testing.T, testDB *setup.TestDB, res *httptest.ResponseRecorder) {
+				require.Equal(t, 1, countRows(t, testDB, "two_factor"))
+			},
+		},
+		{
+			name:             "Account with password still needs current password",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "WRONG_PASSWORD",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) *http.Request {
+				require.NoError(t, testDB.TruncateAllTables())
+
+				credentials := publicDto.LoginCredentials{
+					Login:    "Password.User@gmail.com",
+					Password: "passwordUserPassword1",
+				}
+				signUp(t, router, credentials)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newJSONRequest(t, "/auth/login", credentials))
+				require.Equal(t, http.StatusOK, res.Code)
+
+				var tokens map[string]publicDto.JWTTokenDTO
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &tokens))
+
+				req := newJSONRequest(t, "/auth/password/change", publicDto.ChangePasswordRequest{NewPassword: "newPasswordUser1"})
+				req.Header.Set("Authorization", "Bearer "+tokens["access_token"].Token)
+				return req
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.beforeTestAction(t, db)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.expectedHttpCode, res.Code, res.Body.String())
+
+			var response map[string]any
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+			require.Equal(t, tc.expectedErrCode, response["code"])
+
+			if tc.afterTestAction != nil {
+				tc.afterTestAction(t, db, res)
+			}
+		})
+	}
+}
+
+//Function signs in user of stub provider and returns access token
+func oidcLogIn(t *testing.T, router *gin.Engine, idp *stubIdP) string {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, authorizeAtStubIdP(t, router, idp, startOIDCLogin(t, router)))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var response struct {
+		AccessToken publicDto.JWTTokenDTO `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.NotEmpty(t, response.AccessToken.Token)
+
+	return response.AccessToken.Token
+}
+
+//Function moves sign in of all sessions out of reauthentication window
+func ageSessions(t *testing.T, testDB *setup.TestDB) {
+	_, err := testDB.DB.Exec("UPDATE auth_session SET created_at = created_at - INTERVAL '1 day'")
+	require.NoError(t, err)
+}
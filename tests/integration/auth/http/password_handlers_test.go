@@ -0,0 +1,298 @@
+package http_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	publicDto "symphony_chat/internal/application/dto"
+	passwordreset "symphony_chat/internal/domain/password_reset"
+	authhttp "symphony_chat/tests/integration/auth/http"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordResetHandlers(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	router := authhttp.SetupRouter(t, db)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Reset.Password@gmail.com",
+		Password: "oldPassword123",
+	}
+	newPassword := "newPassword456"
+
+	testCases := []struct {
+		name             string
+		expectedHttpCode int
+		expectedErrCode  string
+		beforeTestAction func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request)
+		afterTestAction  func(t *testing.T)
+	}{
+		{
+			name:             "Forgot password for unknown login",
+			expectedHttpCode: http.StatusAccepted,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/password/forgot", publicDto.ForgotPasswordRequest{Login: "Unknown.User@gmail.com"})
+			},
+			afterTestAction: func(t *testing.T) {
+				assert.Empty(t, readResetTokens(t, "Unknown.User@gmail.com"))
+			},
+		},
+		{
+			name:             "Reset password with sent token",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+				requestReset(t, router, credentials.Login)
+
+				tokens := readResetTokens(t, credentials.Login)
+				require.NotEmpty(t, tokens)
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/password/reset", publicDto.ResetPasswordRequest{
+					Token:       tokens[len(tokens)-1],
+					NewPassword: newPassword,
+				})
+			},
+			afterTestAction: func(t *testing.T) {
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newJSONRequest(t, "/auth/login", publicDto.LoginCredentials{Login: credentials.Login, Password: newPassword}))
+				assert.Equal(t, http.StatusOK, res.Code)
+			},
+		},
+		{
+			name:             "Reset token can be used only once",
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode:  passwordreset.ErrResetTokenUsed.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+				requestReset(t, router, credentials.Login)
+
+				tokens := readResetTokens(t, credentials.Login)
+				require.NotEmpty(t, tokens)
+				resetRequest := publicDto.ResetPasswordRequest{Token: tokens[len(tokens)-1], NewPassword: newPassword}
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newJSONRequest(t, "/auth/password/reset", resetRequest))
+				require.Equal(t, http.StatusOK, res.Code)
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/password/reset", resetRequest)
+			},
+		},
+		{
+			name:             "Unknown reset token",
+			expectedHttpCode: http.StatusBadRequest,
+			expectedErrCode:  passwordreset.ErrResetTokenNotFound.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/password/reset", publicDto.ResetPasswordRequest{
+					Token:       "unknown-token",
+					NewPassword: newPassword,
+				})
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, req := tc.beforeTestAction(t, db)
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedHttpCode, res.Code)
+
+			if tc.expectedErrCode != "" {
+				var response map[string]string
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedErrCode, response["code"])
+			}
+
+			if tc.afterTestAction != nil {
+				tc.afterTestAction(t)
+			}
+		})
+	}
+}
+
+func newJSONRequest(t *testing.T, path string, body any) *http.Request {
+	jsonBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	return httptest.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+}
+
+func signUp(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials) {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/signup", publicDto.SignUpRequest{
+		Login:    credentials.Login,
+		Password: credentials.Password,
+		Username: "test_user",
+	}))
+	require.Equal(t, http.StatusOK, res.Code)
+}
+
+func requestReset(t *testing.T, router *gin.Engine, login string) {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/password/forgot", publicDto.ForgotPasswordRequest{Login: login}))
+	require.Equal(t, http.StatusAccepted, res.Code)
+}
+
+//Function reads reset tokens that file notifier sent to the recipient
+func readResetTokens(t *testing.T, recipient string) []string {
+	file, err := os.Open(os.Getenv("NOTIFICATIONS_FILE"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer file.Close()
+
+	const tokenPrefix = "Use this token to reset your password: "
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var notification struct {
+			Recipient string `json:"recipient"`
+			Body      string `json:"body"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &notification))
+
+		if notification.Recipient != recipient {
+			continue
+		}
+
+		firstLine := strings.SplitN(notification.Body, "\n", 2)[0]
+		tokens = append(tokens, strings.TrimPrefix(firstLine, tokenPrefix))
+	}
+	require.NoError(t, scanner.Err())
+
+	return tokens
+}
+
+func TestChangePasswordHandler(t *testing.T) {
+	testDB, err := setup.NewTestDB()
+	require.NoError(t, err)
+
+	defer func() {
+		err := testDB.Close()
+		require.NoError(t, err)
+	}()
+
+	router := authhttp.SetupRouter(t, testDB)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Change.Password@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+	}
+	newPassword := "newPasswordOfUser1"
+
+	testCases := []struct {
+		name            string
+		request         publicDto.ChangePasswordRequest
+		expectedStatus  int
+		expectedCode    string
+		passwordChanged bool
+	}{
+		{
+			name:            "Password is changed",
+			request:         publicDto.ChangePasswordRequest{CurrentPassword: credentials.Password, NewPassword: newPassword},
+			expectedStatus:  http.StatusOK,
+			expectedCode:    "PASSWORD_CHANGED",
+			passwordChanged: true,
+		},
+		{
+			name:           "Wrong current password",
+			request:        publicDto.ChangePasswordRequest{CurrentPassword: "wrongPasswordOfUser1", NewPassword: newPassword},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "WRONG_PASSWORD",
+		},
+		{
+			name:           "New password is too short",
+			request:        publicDto.ChangePasswordRequest{CurrentPassword: credentials.Password, NewPassword: "short1"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_PASSWORD_FORMAT",
+		},
+		{
+			name:           "New password is too long",
+			request:        publicDto.ChangePasswordRequest{CurrentPassword: credentials.Password, NewPassword: strings.Repeat("a", 129)},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_PASSWORD_FORMAT",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, testDB.TruncateAllTables())
+
+			current := signUpSession(t, router, credentials)
+			first := logInSession(t, router, credentials)
+			second := logInSession(t, router, credentials)
+			otherSessionIDs := []string{
+				currentSessionID(t, router, first.accessToken),
+				currentSessionID(t, router, second.accessToken),
+			}
+
+			req := newJSONRequest(t, "/auth/password/change", tc.request)
+			req.Header.Set("Authorization", "Bearer "+current.accessToken)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			require.Equal(t, tc.expectedStatus, res.Code, res.Body.String())
+
+			var response struct {
+				Code              string   `json:"code"`
+				RevokedSessionIDs []string `json:"revoked_session_ids"`
+			}
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+			assert.Equal(t, tc.expectedCode, response.Code)
+
+			expectedPassword, rejectedPassword := credentials.Password, newPassword
+			if tc.passwordChanged {
+				expectedPassword, rejectedPassword = newPassword, credentials.Password
+			}
+
+			res = logIn(t, router, publicDto.LoginCredentials{Login: credentials.Login, Password: expectedPassword})
+			assert.Equal(t, http.StatusOK, res.Code)
+			res = logIn(t, router, publicDto.LoginCredentials{Login: credentials.Login, Password: rejectedPassword})
+			assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+			//Current session stays, other sessions are revoked only if password was changed
+			assert.Equal(t, http.StatusOK, getSessions(router, current.accessToken).Code)
+
+			if !tc.passwordChanged {
+				for _, other := range []authSession{first, second} {
+					assert.Equal(t, http.StatusOK, getSessions(router, other.accessToken).Code)
+				}
+				return
+			}
+
+			assert.ElementsMatch(t, otherSessionIDs, response.RevokedSessionIDs)
+
+			for _, other := range []authSession{first, second} {
+				assert.Equal(t, http.StatusUnauthorized, getSessions(router, other.accessToken).Code)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(other.refreshToken, other.csrfToken))
+				assert.Equal(t, http.StatusUnauthorized, res.Code)
+			}
+
+			res = httptest.NewRecorder()
+			router.ServeHTTP(res, newRefreshRequest(current.refreshToken, current.csrfToken))
+			assert.Equal(t, http.StatusOK, res.Code)
+		})
+	}
+}
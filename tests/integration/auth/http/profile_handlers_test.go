@@ -0,0 +1,238 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	publicDto "symphony_chat/internal/application/dto"
+	"symphony_chat/internal/domain/users"
+	authhttp "symphony_chat/tests/integration/auth/http"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileHandlers(t *testing.T) {
+	testDB, err := setup.NewTestDB()
+	require.NoError(t, err)
+
+	defer func() {
+		err := testDB.Close()
+		require.NoError(t, err)
+	}()
+
+	router := authhttp.SetupRouter(t, testDB)
+
+	ownerCredentials := publicDto.LoginCredentials{
+		Login:    "Profile.Owner@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+	}
+	otherCredentials := publicDto.LoginCredentials{
+		Login:    "Profile.Other@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+	}
+
+	t.Run("GET /me", func(t *testing.T) {
+		require.NoError(t, testDB.TruncateAllTables())
+		accessToken := signUpAs(t, router, ownerCredentials, "Profile_Owner")
+
+		res := profileRequest(t, router, "GET", "/me", accessToken, nil)
+		require.Equal(t, http.StatusOK, res.Code)
+
+		profile := decodeProfile(t, res)
+		require.Equal(t, "profile_owner", profile.Username)
+		require.Equal(t, string(users.Offline), profile.Status)
+		require.False(t, profile.CreatedAt.IsZero())
+
+		res = profileRequest(t, router, "GET", "/me", "", nil)
+		require.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+
+	t.Run("PATCH /me", func(t *testing.T) {
+		testCases := []struct {
+			name             string
+			body             any
+			expectedStatus   int
+			expectedCode     string
+			expectedUsername string
+		}{
+			{
+				name:             "Username is changed and normalized",
+				body:             map[string]string{"username": "  New_Name "},
+				expectedStatus:   http.StatusOK,
+				expectedUsername: "new_name",
+			},
+			{
+				name:             "Same username",
+				body:             map[string]string{"username": "profile_owner"},
+				expectedStatus:   http.StatusOK,
+				expectedUsername: "profile_owner",
+			},
+			{
+				name:           "Nothing to update",
+				body:           map[string]string{"status": "ONLINE"},
+				expectedStatus: http.StatusBadRequest,
+				expectedCode:   "INVALID_INPUT",
+			},
+			{
+				name:           "Too short username",
+				body:           map[string]string{"username": "ab"},
+				expectedStatus: http.StatusBadRequest,
+				expectedCode:   users.ErrInvalidUsername.Code,
+			},
+			{
+				name:           "Username with invalid characters",
+				body:           map[string]string{"username": "new-name"},
+				expectedStatus: http.StatusBadRequest,
+				expectedCode:   users.ErrInvalidUsername.Code,
+			},
+			{
+				name:           "Reserved username",
+				body:           map[string]string{"username": "Admin"},
+				expectedStatus: http.StatusBadRequest,
+				expectedCode:   users.ErrUsernameReserved.Code,
+			},
+			{
+				name:           "Username of deleted accounts",
+				body:           map[string]string{"username": users.DeletedChatUser.GetUsername()},
+				expectedStatus: http.StatusBadRequest,
+				expectedCode:   users.ErrUsernameReserved.Code,
+			},
+			{
+				name:           "Username of other user",
+				body:           map[string]string{"username": "Profile_Other"},
+				expectedStatus: http.StatusConflict,
+				expectedCode:   users.ErrUsernameAlreadyTaken.Code,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				require.NoError(t, testDB.TruncateAllTables())
+				accessToken := signUpAs(t, router, ownerCredentials, "profile_owner")
+				signUpAs(t, router, otherCredentials, "profile_other")
+
+				res := profileRequest(t, router, "PATCH", "/me", accessToken, tc.body)
+				require.Equal(t, tc.expectedStatus, res.Code, res.Body.String())
+
+				if tc.expectedCode != "" {
+					var response map[string]any
+					require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+					require.Equal(t, tc.expectedCode, response["code"])
+				}
+
+				//Username is changed only by successful request
+				expectedUsername := tc.expectedUsername
+				if expectedUsername == "" {
+					expectedUsername = "profile_owner"
+				}
+				require.Equal(t, expectedUsername, decodeProfile(t, profileRequest(t, router, "GET", "/me", accessToken, nil)).Username)
+			})
+		}
+	})
+
+	t.Run("GET /users/:username", func(t *testing.T) {
+		require.NoError(t, testDB.TruncateAllTables())
+		accessToken := signUpAs(t, router, ownerCredentials, "profile_owner")
+		otherAccessToken := signUpAs(t, router, otherCredentials, "profile_other")
+		otherProfile := decodeProfile(t, profileRequest(t, router, "GET", "/me", otherAccessToken, nil))
+
+		testCases := []struct {
+			name           string
+			username       string
+			expectedStatus int
+		}{
+			{
+				name:           "Profile of other user",
+				username:       "profile_other",
+				expectedStatus: http.StatusOK,
+			},
+			{
+				name:           "Username is case insensitive",
+				username:       "Profile_Other",
+				expectedStatus: http.StatusOK,
+			},
+			{
+				name:           "Unknown user",
+				username:       "profile_unknown",
+				expectedStatus: http.StatusNotFound,
+			},
+			{
+				name:           "Placeholder of deleted accounts",
+				username:       users.DeletedChatUser.GetUsername(),
+				expectedStatus: http.StatusNotFound,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				res := profileRequest(t, router, "GET", "/users/"+tc.username, accessToken, nil)
+				require.Equal(t, tc.expectedStatus, res.Code)
+
+				if tc.expectedStatus != http.StatusOK {
+					return
+				}
+
+				require.Equal(t, otherProfile, decodeProfile(t, res))
+
+				//Public view has only profile fields, login and credentials of the account are not exposed
+				var fields map[string]any
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &fields))
+				require.ElementsMatch(t, []string{"id", "username", "status", "created_at", "last_seen_at"}, keysOf(fields))
+				require.NotContains(t, res.Body.String(), "Profile.Other@gmail.com")
+			})
+		}
+
+		res := profileRequest(t, router, "GET", "/users/profile_other", "", nil)
+		require.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+}
+
+//Function signs user up with the username and returns access token
+func signUpAs(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials, username string) string {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/signup", publicDto.SignUpRequest{
+		Login:    credentials.Login,
+		Password: credentials.Password,
+		Username: username,
+	}))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	return sessionFromResponse(t, res).accessToken
+}
+
+func profileRequest(t *testing.T, router *gin.Engine, method string, path string, accessToken string, body any) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		require.NoError(t, err)
+		req = httptest.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res
+}
+
+func decodeProfile(t *testing.T, res *httptest.ResponseRecorder) publicDto.ProfileDTO {
+	var profile publicDto.ProfileDTO
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &profile))
+	return profile
+}
+
+func keysOf(fields map[string]any) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,65 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	publicDto "symphony_chat/internal/application/dto"
+	config "symphony_chat/internal/infrastructure/configs"
+	jwtKeys "symphony_chat/internal/infrastructure/jwt/keys"
+	jwtRepo "symphony_chat/internal/infrastructure/jwt/postgres"
+	sessionRepo "symphony_chat/internal/infrastructure/sessions/postgres"
+	tx "symphony_chat/internal/infrastructure/transaction/postgres"
+	jwtService "symphony_chat/internal/service/jwt"
+	authhttp "symphony_chat/tests/integration/auth/http"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeExpiredRefreshTokens(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.TruncateAllTables())
+
+	router := authhttp.SetupRouter(t, db)
+
+	keySet, err := jwtKeys.GenerateEphemeralKeySet()
+	require.NoError(t, err)
+
+	//Refresh tokens live 30 days
+	js, err := jwtService.NewJWTtokenService(
+		jwtService.WithJWTtokenRepository(jwtRepo.NewPostgresJWTtokenRepo(db.DB)),
+		jwtService.WithJWTConfig(config.NewJWTConfig(15, 30)),
+		jwtService.WithKeySet(keySet),
+		jwtService.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+		jwtService.WithSessionRepository(sessionRepo.NewPostgresSessionRepo(db.DB)),
+	)
+	require.NoError(t, err)
+
+	session := signUpSession(t, router, publicDto.LoginCredentials{
+		Login:    "Purged.User@gmail.com",
+		Password: "purgedUserPassword1",
+	})
+
+	//Rotated token of the family stays until it expires, so its reuse is still detected
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newRefreshRequest(session.refreshToken, session.csrfToken))
+	require.Equal(t, http.StatusOK, res.Code)
+	refreshed := sessionFromResponse(t, res)
+	require.Equal(t, 2, countRows(t, db, "jwt_token"))
+
+	_, err = db.DB.Exec("UPDATE jwt_token SET created_at = NOW() - INTERVAL '31 days' WHERE token = $1", session.refreshToken)
+	require.NoError(t, err)
+
+	deleted, err := js.PurgeExpiredRefreshTokens(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleted)
+
+	var token string
+	require.NoError(t, db.DB.QueryRow("SELECT token FROM jwt_token").Scan(&token))
+	require.Equal(t, refreshed.refreshToken, token)
+}
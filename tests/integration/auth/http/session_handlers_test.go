@@ -0,0 +1,381 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	publicDto "symphony_chat/internal/application/dto"
+	authhttp "symphony_chat/tests/integration/auth/http"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRevocationRevokesAccessTokens(t *testing.T) {
+	testDB, err := setup.NewTestDB()
+	require.NoError(t, err)
+
+	defer func() {
+		err := testDB.Close()
+		require.NoError(t, err)
+	}()
+
+	router := authhttp.SetupRouter(t, testDB)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Session.Revocation@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+	}
+
+	testCases := []struct {
+		name string
+		//Function revokes sessions and returns access tokens that must be rejected and that must stay valid
+		revoke func(t *testing.T) (revokedAccessTokens []string, validAccessTokens []string)
+	}{
+		{
+			name: "Session is revoked by other session",
+			revoke: func(t *testing.T) ([]string, []string) {
+				current := signUpSession(t, router, credentials)
+				other := logInSession(t, router, credentials)
+
+				otherSessionID := currentSessionID(t, router, other.accessToken)
+
+				req := httptest.NewRequest("DELETE", "/sessions/"+otherSessionID, nil)
+				req.Header.Set("Authorization", "Bearer "+current.accessToken)
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, req)
+				require.Equal(t, http.StatusOK, res.Code)
+
+				return []string{other.accessToken}, []string{current.accessToken}
+			},
+		},
+		{
+			name: "Other sessions are revoked",
+			revoke: func(t *testing.T) ([]string, []string) {
+				current := signUpSession(t, router, credentials)
+				first := logInSession(t, router, credentials)
+				second := logInSession(t, router, credentials)
+
+				req := httptest.NewRequest("POST", "/sessions/revoke-others", nil)
+				req.Header.Set("Authorization", "Bearer "+current.accessToken)
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, req)
+				require.Equal(t, http.StatusOK, res.Code)
+
+				return []string{first.accessToken, second.accessToken}, []string{current.accessToken}
+			},
+		},
+		{
+			name: "Session is revoked because refresh token was reused",
+			revoke: func(t *testing.T) ([]string, []string) {
+				other := signUpSession(t, router, credentials)
+				stolen := logInSession(t, router, credentials)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(stolen.refreshToken, stolen.csrfToken))
+				require.Equal(t, http.StatusOK, res.Code)
+				var refreshed map[string]publicDto.JWTTokenDTO
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &refreshed))
+
+				res = httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(stolen.refreshToken, stolen.csrfToken))
+				require.Equal(t, http.StatusUnauthorized, res.Code)
+
+				//Both the first access token and the one that was got by refresh belong to the revoked session
+				return []string{stolen.accessToken, refreshed["access_token"].Token}, []string{other.accessToken}
+			},
+		},
+		{
+			name: "Logout revokes access tokens of the session that were got by refresh",
+			revoke: func(t *testing.T) ([]string, []string) {
+				session := signUpSession(t, router, credentials)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newRefreshRequest(session.refreshToken, session.csrfToken))
+				require.Equal(t, http.StatusOK, res.Code)
+				var refreshed map[string]publicDto.JWTTokenDTO
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &refreshed))
+
+				req := httptest.NewRequest("POST", "/auth/logout", nil)
+				req.Header.Set("Authorization", "Bearer "+refreshed["access_token"].Token)
+				res = httptest.NewRecorder()
+				router.ServeHTTP(res, req)
+				require.Equal(t, http.StatusOK, res.Code)
+
+				return []string{session.accessToken, refreshed["access_token"].Token}, nil
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, testDB.TruncateAllTables())
+
+			revokedAccessTokens, validAccessTokens := tc.revoke(t)
+
+			for _, accessToken := range revokedAccessTokens {
+				res := getSessions(router, accessToken)
+				require.Equal(t, http.StatusUnauthorized, res.Code)
+
+				var response map[string]string
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				assert.Equal(t, "TOKEN_REVOKED", response["code"])
+			}
+
+			for _, accessToken := range validAccessTokens {
+				assert.Equal(t, http.StatusOK, getSessions(router, accessToken).Code)
+			}
+		})
+	}
+}
+
+func logInSession(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials) authSession {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/login", credentials))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	return sessionFromResponse(t, res)
+}
+
+func getSessions(router *gin.Engine, accessToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res
+}
+
+func currentSessionID(t *testing.T, router *gin.Engine, accessToken string) string {
+	res := getSessions(router, accessToken)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var response struct {
+		Sessions []publicDto.SessionDTO `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+
+	for _, session := range response.Sessions {
+		if session.Current {
+			return session.ID.String()
+		}
+	}
+
+	require.Fail(t, "current session is not listed")
+	return ""
+}
+
+func TestSessionHandlers(t *testing.T) {
+	testDB, err := setup.NewTestDB()
+	require.NoError(t, err)
+
+	defer func() {
+		err := testDB.Close()
+		require.NoError(t, err)
+	}()
+
+	router := authhttp.SetupRouter(t, testDB)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Session.Handlers@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+	}
+	otherCredentials := publicDto.LoginCredentials{
+		Login:    "Session.Handlers.Other@gmail.com",
+		Password: "fhigbgiwgwwhnwihwgwb",
+	}
+
+	t.Run("GET /sessions lists active sessions of the user", func(t *testing.T) {
+		require.NoError(t, testDB.TruncateAllTables())
+
+		current := signUpSession(t, router, credentials)
+
+		req := newJSONRequest(t, "/auth/login", credentials)
+		req.Header.Set("User-Agent", "Phone Browser")
+		req.RemoteAddr = "203.0.113.7:5000"
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		require.Equal(t, http.StatusOK, res.Code)
+		phone := sessionFromResponse(t, res)
+
+		//Sessions of other users and revoked sessions are not listed
+		signUpSessionAs(t, router, otherCredentials, "other_user")
+		revoked := logInSession(t, router, credentials)
+		logOut(t, router, revoked.accessToken)
+
+		sessions := listSessions(t, router, current.accessToken)
+		require.Len(t, sessions, 2)
+
+		var currentSessions, phoneSessions int
+		for _, session := range sessions {
+			if session.Current {
+				currentSessions++
+				continue
+			}
+
+			phoneSessions++
+			assert.Equal(t, "Phone Browser", session.UserAgent)
+			assert.Equal(t, "203.0.113.7", session.IP)
+			assert.False(t, session.CreatedAt.IsZero())
+			assert.False(t, session.LastUsedAt.Before(session.CreatedAt))
+		}
+		assert.Equal(t, 1, currentSessions)
+		assert.Equal(t, 1, phoneSessions)
+
+		//Current session is marked for the session of the access token
+		phoneSessionID := currentSessionID(t, router, phone.accessToken)
+		for _, session := range sessions {
+			assert.Equal(t, session.ID.String() == phoneSessionID, !session.Current)
+		}
+	})
+
+	t.Run("DELETE /sessions/:session_id", func(t *testing.T) {
+		testCases := []struct {
+			name             string
+			expectedHttpCode int
+			expectedErrCode  string
+			//Function returns access token of the requester and id of the revoked session
+			beforeTestAction func(t *testing.T) (string, string)
+			expectedSessions int
+		}{
+			{
+				name:             "Other session is revoked",
+				expectedHttpCode: http.StatusOK,
+				beforeTestAction: func(t *testing.T) (string, string) {
+					current := signUpSession(t, router, credentials)
+					other := logInSession(t, router, credentials)
+					return current.accessToken, currentSessionID(t, router, other.accessToken)
+				},
+				expectedSessions: 1,
+			},
+			{
+				name:             "Session of other user",
+				expectedHttpCode: http.StatusNotFound,
+				expectedErrCode:  "SESSION_NOT_FOUND",
+				beforeTestAction: func(t *testing.T) (string, string) {
+					current := signUpSession(t, router, credentials)
+					other := signUpSessionAs(t, router, otherCredentials, "other_user")
+					return current.accessToken, currentSessionID(t, router, other.accessToken)
+				},
+				expectedSessions: 1,
+			},
+			{
+				name:             "Already revoked session",
+				expectedHttpCode: http.StatusNotFound,
+				expectedErrCode:  "SESSION_NOT_FOUND",
+				beforeTestAction: func(t *testing.T) (string, string) {
+					current := signUpSession(t, router, credentials)
+					other := logInSession(t, router, credentials)
+					otherSessionID := currentSessionID(t, router, other.accessToken)
+					logOut(t, router, other.accessToken)
+					return current.accessToken, otherSessionID
+				},
+				expectedSessions: 1,
+			},
+			{
+				name:             "Unknown session",
+				expectedHttpCode: http.StatusNotFound,
+				expectedErrCode:  "SESSION_NOT_FOUND",
+				beforeTestAction: func(t *testing.T) (string, string) {
+					current := signUpSession(t, router, credentials)
+					return current.accessToken, "00000000-0000-0000-0000-000000000001"
+				},
+				expectedSessions: 1,
+			},
+			{
+				name:             "Session id is not uuid",
+				expectedHttpCode: http.StatusBadRequest,
+				expectedErrCode:  "INVALID_INPUT",
+				beforeTestAction: func(t *testing.T) (string, string) {
+					current := signUpSession(t, router, credentials)
+					logInSession(t, router, credentials)
+					return current.accessToken, "not-uuid"
+				},
+				expectedSessions: 2,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				require.NoError(t, testDB.TruncateAllTables())
+
+				accessToken, sessionID := tc.beforeTestAction(t)
+
+				req := httptest.NewRequest("DELETE", "/sessions/"+sessionID, nil)
+				req.Header.Set("Authorization", "Bearer "+accessToken)
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, req)
+				require.Equal(t, tc.expectedHttpCode, res.Code)
+
+				if tc.expectedErrCode != "" {
+					var response map[string]any
+					require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+					assert.Equal(t, tc.expectedErrCode, response["code"])
+				}
+
+				assert.Len(t, listSessions(t, router, accessToken), tc.expectedSessions)
+			})
+		}
+	})
+
+	t.Run("POST /sessions/revoke-others keeps only current session", func(t *testing.T) {
+		require.NoError(t, testDB.TruncateAllTables())
+
+		current := signUpSession(t, router, credentials)
+		logInSession(t, router, credentials)
+		logInSession(t, router, credentials)
+		other := signUpSessionAs(t, router, otherCredentials, "other_user")
+
+		req := httptest.NewRequest("POST", "/sessions/revoke-others", nil)
+		req.Header.Set("Authorization", "Bearer "+current.accessToken)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		require.Equal(t, http.StatusOK, res.Code)
+
+		var response struct {
+			RevokedSessionIDs []string `json:"revoked_session_ids"`
+		}
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+		assert.Len(t, response.RevokedSessionIDs, 2)
+
+		sessions := listSessions(t, router, current.accessToken)
+		require.Len(t, sessions, 1)
+		assert.True(t, sessions[0].Current)
+
+		//Sessions of other users are not touched
+		assert.Len(t, listSessions(t, router, other.accessToken), 1)
+	})
+}
+
+func signUpSessionAs(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials, username string) authSession {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/signup", publicDto.SignUpRequest{
+		Login:    credentials.Login,
+		Password: credentials.Password,
+		Username: username,
+	}))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	return sessionFromResponse(t, res)
+}
+
+func listSessions(t *testing.T, router *gin.Engine, accessToken string) []publicDto.SessionDTO {
+	res := getSessions(router, accessToken)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var response struct {
+		Sessions []publicDto.SessionDTO `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	return response.Sessions
+}
+
+func logOut(t *testing.T, router *gin.Engine, accessToken string) {
+	req := httptest.NewRequest("POST", "/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+}
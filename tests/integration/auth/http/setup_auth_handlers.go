@@ -2,16 +2,32 @@ package http
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
 	authHandlers "symphony_chat/internal/application/auth/http"
+	"symphony_chat/internal/application/cookies"
 	"symphony_chat/internal/application/middleware"
+	usersHandlers "symphony_chat/internal/application/users/http"
+	"symphony_chat/internal/domain/oidc"
 	config "symphony_chat/internal/infrastructure/configs"
+	jwtKeys "symphony_chat/internal/infrastructure/jwt/keys"
+	localNotifier "symphony_chat/internal/infrastructure/notifications/local"
+	passwordResetRepo "symphony_chat/internal/infrastructure/password_reset/postgres"
+	oidcRepo "symphony_chat/internal/infrastructure/oidc/postgres"
 	jwtRepo "symphony_chat/internal/infrastructure/jwt/postgres"
+	sessionRepo "symphony_chat/internal/infrastructure/sessions/postgres"
+	loginAttemptRepo "symphony_chat/internal/infrastructure/login_attempt/postgres"
+	twoFactorRepo "symphony_chat/internal/infrastructure/two_factor/postgres"
 	tx "symphony_chat/internal/infrastructure/transaction/postgres"
 	"symphony_chat/internal/infrastructure/users/postgres"
 	"symphony_chat/internal/service/auth/authentication"
+	"symphony_chat/internal/service/auth/lockout"
+	oidcService "symphony_chat/internal/service/auth/oidc"
+	"symphony_chat/internal/service/auth/password"
 	"symphony_chat/internal/service/auth/registration"
+	twoFactorService "symphony_chat/internal/service/auth/two_factor"
 	jwtService "symphony_chat/internal/service/jwt"
+	"symphony_chat/internal/service/profile"
 	"symphony_chat/tests/integration/setup"
 	"testing"
 
@@ -19,10 +35,16 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func SetupRouter(t *testing.T, db *setup.TestDB) *gin.Engine {
+//Identity providers are served on /auth/oidc/:provider routes
+func SetupRouter(t *testing.T, db *setup.TestDB, identityProviders ...oidc.IdentityProvider) *gin.Engine {
 	// Отключаем логи gin в тестах
     gin.SetMode(gin.TestMode)
 
+    // Cookie без Secure, тесты работают по http
+    cookieConfig, err := config.NewCookieConfig("", "/", false, "lax")
+    require.NoError(t, err)
+    cookieManager := cookies.NewCookieManager(cookieConfig)
+
     // JWT конфигурация
     accessTTL, err := strconv.ParseUint(os.Getenv("ACCESS_TTL_IN_MINUTES"), 10, 32)
     require.NoError(t, err)
@@ -30,22 +52,44 @@ func SetupRouter(t *testing.T, db *setup.TestDB) *gin.Engine {
     require.NoError(t, err)
 
     jwtConfig := config.NewJWTConfig(
-        os.Getenv("JWT_SECRET"),
         uint(accessTTL),
         uint(refreshTTL),
     )
 
+    keySet, err := jwtKeys.GenerateEphemeralKeySet()
+    require.NoError(t, err)
+
     // Создаем сервисы
     jwtService, err := jwtService.NewJWTtokenService(
         jwtService.WithJWTtokenRepository(jwtRepo.NewPostgresJWTtokenRepo(db.DB)),
         jwtService.WithJWTConfig(jwtConfig),
+        jwtService.WithKeySet(keySet),
+        jwtService.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+        jwtService.WithSessionRepository(sessionRepo.NewPostgresSessionRepo(db.DB)),
+        jwtService.WithTokenDenylist(jwtService.NewTokenDenylist(jwtRepo.NewPostgresRevokedTokenRepo(db.DB))),
     )
     require.NoError(t, err)
 
     registrationService, err := registration.NewRegistrationService(
         registration.WithAuthUserRepository(postgres.NewPostgresAuthUserRepo(db.DB)),
+        registration.WithChatUserRepository(postgres.NewPostgresChatUserRepo(db.DB)),
         registration.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
         registration.WithJWTtokenService(jwtService),
+        registration.WithCookieManager(cookieManager),
+    )
+    require.NoError(t, err)
+
+    lockoutService, err := lockout.NewLockoutService(
+        lockout.WithLoginAttemptRepository(loginAttemptRepo.NewPostgresLoginAttemptRepo(db.DB)),
+        lockout.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+    )
+    require.NoError(t, err)
+
+    twoFactorService, err := twoFactorService.NewTwoFactorService(
+        twoFactorService.WithAuthUserRepository(postgres.NewPostgresAuthUserRepo(db.DB)),
+        twoFactorService.WithTwoFactorRepository(twoFactorRepo.NewPostgresTwoFactorRepo(db.DB)),
+        twoFactorService.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+        twoFactorService.WithJWTtokenService(jwtService),
     )
     require.NoError(t, err)
 
@@ -53,17 +97,83 @@ func SetupRouter(t *testing.T, db *setup.TestDB) *gin.Engine {
         authentication.WithAuthUserRepository(postgres.NewPostgresAuthUserRepo(db.DB)),
         authentication.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
         authentication.WithJWTtokenService(jwtService),
+        authentication.WithSessionRepository(sessionRepo.NewPostgresSessionRepo(db.DB)),
+        authentication.WithLockoutService(lockoutService),
+        authentication.WithTwoFactorService(twoFactorService),
+        authentication.WithCookieManager(cookieManager),
     )
     require.NoError(t, err)
 
+    // Уведомления пишутся в файл, путь к нему доступен тестам через NOTIFICATIONS_FILE
+    notificationsFile := filepath.Join(t.TempDir(), "notifications.jsonl")
+    t.Setenv("NOTIFICATIONS_FILE", notificationsFile)
+
+    passwordService, err := password.NewPasswordService(
+        password.WithAuthUserRepository(postgres.NewPostgresAuthUserRepo(db.DB)),
+        password.WithPasswordResetRepository(passwordResetRepo.NewPostgresPasswordResetRepo(db.DB)),
+        password.WithJWTtokenService(jwtService),
+        password.WithNotifier(localNotifier.NewFileNotifier(notificationsFile)),
+        password.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+    )
+    require.NoError(t, err)
+
+    oidcConfigs := []oidcService.OIDCConfiguration{
+        oidcService.WithOIDCRepository(oidcRepo.NewPostgresOIDCRepo(db.DB)),
+        oidcService.WithAuthUserRepository(postgres.NewPostgresAuthUserRepo(db.DB)),
+        oidcService.WithChatUserRepository(postgres.NewPostgresChatUserRepo(db.DB)),
+        oidcService.WithJWTtokenService(jwtService),
+        oidcService.WithTwoFactorService(twoFactorService),
+        oidcService.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+        oidcService.WithCookieManager(cookieManager),
+    }
+    for _, identityProvider := range identityProviders {
+        oidcConfigs = append(oidcConfigs, oidcService.WithIdentityProvider(identityProvider))
+    }
+
+    oidcService, err := oidcService.NewOIDCService(oidcConfigs...)
+    require.NoError(t, err)
+
+    profileService, err := profile.NewProfileService(
+        profile.WithChatUserRepository(postgres.NewPostgresChatUserRepo(db.DB)),
+        profile.WithUserBlockRepository(postgres.NewPostgresUserBlockRepo(db.DB)),
+        profile.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+    )
+    require.NoError(t, err)
+
+    authMiddleware := middleware.AuthMiddleware(jwtService)
+
     // Создаем роутер
     router := gin.New()
+    // Как и в main, прокси не доверяем: X-Forwarded-For не меняет ip для блокировки
+    require.NoError(t, router.SetTrustedProxies(nil))
     authHandler := authHandlers.NewAuthHandler(registrationService, authenticationService)
+    passwordHandler := authHandlers.NewPasswordHandler(passwordService)
+    twoFactorHandler := authHandlers.NewTwoFactorHandler(twoFactorService)
+    oidcHandler := authHandlers.NewOIDCHandler(oidcService, authenticationService)
+    profileHandler := usersHandlers.NewProfileHandler(profileService)
 
     // Регистрируем маршруты
     router.POST("/auth/signup", authHandler.SignUp)
     router.POST("/auth/login", authHandler.LogIn)
-    router.POST("/auth/logout", middleware.AuthMiddleware(jwtService), authHandler.LogOut)
+    router.POST("/auth/login/2fa", authHandler.LogInTwoFactor)
+    router.POST("/auth/refresh", middleware.CSRFMiddleware(cookieManager), authHandler.Refresh)
+    router.POST("/auth/logout", authMiddleware, authHandler.LogOut)
+    router.POST("/auth/password/change", authMiddleware, passwordHandler.ChangePassword)
+    router.POST("/auth/password/forgot", passwordHandler.ForgotPassword)
+    router.POST("/auth/password/reset", passwordHandler.ResetPassword)
+    router.POST("/auth/2fa/enroll", authMiddleware, twoFactorHandler.Enroll)
+    router.POST("/auth/2fa/enable", authMiddleware, twoFactorHandler.Enable)
+    router.POST("/auth/2fa/disable", authMiddleware, twoFactorHandler.Disable)
+    router.GET("/auth/oidc/:provider/login", oidcHandler.StartLogin)
+    router.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+    router.POST("/auth/oidc/:provider/link", authMiddleware, oidcHandler.StartLink)
+    router.GET("/sessions", authMiddleware, authHandler.GetSessions)
+    router.DELETE("/sessions/:session_id", authMiddleware, authHandler.RevokeSession)
+    router.POST("/sessions/revoke-others", authMiddleware, authHandler.RevokeOtherSessions)
+    router.GET("/me", authMiddleware, profileHandler.GetMyProfile)
+    router.PATCH("/me", authMiddleware, profileHandler.UpdateMyProfile)
+    router.GET("/users", authMiddleware, profileHandler.SearchUsers)
+    router.GET("/users/:username", authMiddleware, profileHandler.GetProfileByUsername)
 
     return router
 }
\ No newline at end of file
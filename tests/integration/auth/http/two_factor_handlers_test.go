@@ -0,0 +1,188 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	publicDto "symphony_chat/internal/application/dto"
+	twofactor "symphony_chat/internal/domain/two_factor"
+	authhttp "symphony_chat/tests/integration/auth/http"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorHandlers(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	router := authhttp.SetupRouter(t, db)
+
+	credentials := publicDto.LoginCredentials{
+		Login:    "Two.Factor@gmail.com",
+		Password: "twoFactorPassword123",
+	}
+
+	testCases := []struct {
+		name             string
+		expectedHttpCode int
+		expectedErrCode  string
+		beforeTestAction func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request)
+	}{
+		{
+			name:             "Login with enabled two factor returns challenge",
+			expectedHttpCode: http.StatusOK,
+			expectedErrCode:  "TWO_FACTOR_REQUIRED",
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+				enableTwoFactor(t, router, credentials)
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/login", credentials)
+			},
+		},
+		{
+			name:             "Finish login with totp code",
+			expectedHttpCode: http.StatusOK,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+				secret, _, _ := enableTwoFactor(t, router, credentials)
+
+				//Code of current step was used for enabling, so code of next step is sent
+				code, err := twofactor.TOTPCode(secret, twofactor.TOTPStep(time.Now())+1)
+				require.NoError(t, err)
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/login/2fa", publicDto.TwoFactorLoginRequest{
+					ChallengeToken: getChallengeToken(t, router, credentials),
+					Code:           code,
+				})
+			},
+		},
+		{
+			name:             "Used totp code is rejected",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  twofactor.ErrInvalidTwoFactorCode.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+				_, usedCode, _ := enableTwoFactor(t, router, credentials)
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/login/2fa", publicDto.TwoFactorLoginRequest{
+					ChallengeToken: getChallengeToken(t, router, credentials),
+					Code:           usedCode,
+				})
+			},
+		},
+		{
+			name:             "Recovery code can be used only once",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  twofactor.ErrInvalidTwoFactorCode.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+				signUp(t, router, credentials)
+				_, _, recoveryCodes := enableTwoFactor(t, router, credentials)
+				require.Len(t, recoveryCodes, twofactor.RecoveryCodesCount)
+
+				res := httptest.NewRecorder()
+				router.ServeHTTP(res, newJSONRequest(t, "/auth/login/2fa", publicDto.TwoFactorLoginRequest{
+					ChallengeToken: getChallengeToken(t, router, credentials),
+					Code:           recoveryCodes[0],
+				}))
+				require.Equal(t, http.StatusOK, res.Code)
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/login/2fa", publicDto.TwoFactorLoginRequest{
+					ChallengeToken: getChallengeToken(t, router, credentials),
+					Code:           recoveryCodes[0],
+				})
+			},
+		},
+		{
+			name:             "Unknown challenge",
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  twofactor.ErrChallengeNotFound.Code,
+			beforeTestAction: func(t *testing.T, testDB *setup.TestDB) (*httptest.ResponseRecorder, *http.Request) {
+				require.NoError(t, testDB.TruncateAllTables())
+
+				return httptest.NewRecorder(), newJSONRequest(t, "/auth/login/2fa", publicDto.TwoFactorLoginRequest{
+					ChallengeToken: "unknown-challenge",
+					Code:           "123456",
+				})
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, req := tc.beforeTestAction(t, db)
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedHttpCode, res.Code)
+
+			if tc.expectedErrCode != "" {
+				var response map[string]string
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedErrCode, response["code"])
+			}
+		})
+	}
+}
+
+//Function enrolls and enables two factor, it returns totp secret, code that was used for enabling and recovery codes
+func enableTwoFactor(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials) (string, string, []string) {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/login", credentials))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var tokens map[string]publicDto.JWTTokenDTO
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &tokens))
+
+	return enableTwoFactorWithAccessToken(t, router, tokens["access_token"].Token)
+}
+
+func enableTwoFactorWithAccessToken(t *testing.T, router *gin.Engine, accessToken string) (string, string, []string) {
+	req := newJSONRequest(t, "/auth/2fa/enroll", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var enrollment map[string]string
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &enrollment))
+	secret := enrollment["secret"]
+	require.NotEmpty(t, secret)
+
+	code, err := twofactor.TOTPCode(secret, twofactor.TOTPStep(time.Now()))
+	require.NoError(t, err)
+
+	req = newJSONRequest(t, "/auth/2fa/enable", publicDto.TwoFactorCodeRequest{Code: code})
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var enabled struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &enabled))
+
+	return secret, code, enabled.RecoveryCodes
+}
+
+func getChallengeToken(t *testing.T, router *gin.Engine, credentials publicDto.LoginCredentials) string {
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newJSONRequest(t, "/auth/login", credentials))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+	require.Equal(t, "TWO_FACTOR_REQUIRED", response["code"])
+
+	return response["challenge_token"]
+}
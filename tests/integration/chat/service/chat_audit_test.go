@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"context"
+	"symphony_chat/internal/domain/chat_audit"
+	"symphony_chat/internal/domain/chat_participant"
+	"symphony_chat/internal/domain/roles"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatAuditLog(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	chatService := chatServiceSetup.SetupChatService(t, db, nil)
+
+	require.NoError(t, db.TruncateAllTables())
+	ownerID := chatServiceSetup.CreateUser(t, db, "owner")
+	adminID := chatServiceSetup.CreateUser(t, db, "admin")
+	memberID := chatServiceSetup.CreateUser(t, db, "member")
+	kickedID := chatServiceSetup.CreateUser(t, db, "kicked")
+	outsiderID := chatServiceSetup.CreateUser(t, db, "outsider")
+
+	createdChat, err := chatService.CreateChat(ctx, ownerID, "test chat")
+	require.NoError(t, err)
+	chatID := createdChat.GetID()
+
+	require.NoError(t, chatService.AddUserToChat(ctx, chatID, ownerID, adminID))
+	require.NoError(t, chatService.PromoteUserToChatAdmin(ctx, chatID, ownerID, adminID))
+	require.NoError(t, chatService.AddUserToChat(ctx, chatID, adminID, memberID))
+	require.NoError(t, chatService.AddUserToChat(ctx, chatID, memberID, kickedID))
+	require.NoError(t, chatService.RemoveUserFromChat(ctx, chatID, adminID, kickedID))
+
+	t.Run("Every action is written", func(t *testing.T) {
+		entries, err := chatService.GetChatAuditLog(ctx, chatID, ownerID, chataudit.ChatAuditFilter{})
+		require.NoError(t, err)
+
+		type auditRecord struct {
+			actorID     uuid.UUID
+			targetID    uuid.UUID
+			action      chataudit.AuditAction
+			beforeValue string
+			afterValue  string
+		}
+
+		records := make([]auditRecord, 0, len(entries))
+		for _, entry := range entries {
+			require.Equal(t, chatID, entry.GetChatID())
+			records = append(records, auditRecord{
+				actorID:     entry.GetActorID(),
+				targetID:    entry.GetTargetID(),
+				action:      entry.GetAction(),
+				beforeValue: entry.GetBeforeValue(),
+				afterValue:  entry.GetAfterValue(),
+			})
+		}
+
+		require.ElementsMatch(t, []auditRecord{
+			{ownerID, adminID, chataudit.MemberAddedAction, "", roles.MemberChatRole.GetName()},
+			{ownerID, adminID, chataudit.MemberPromotedToAdminAction, roles.MemberChatRole.GetName(), roles.AdminChatRole.GetName()},
+			{adminID, memberID, chataudit.MemberAddedAction, "", roles.MemberChatRole.GetName()},
+			{memberID, kickedID, chataudit.MemberAddedAction, "", roles.MemberChatRole.GetName()},
+			{adminID, kickedID, chataudit.MemberKickedAction, roles.MemberChatRole.GetName(), ""},
+		}, records)
+	})
+
+	t.Run("Entries are filtered by action and target", func(t *testing.T) {
+		entries, err := chatService.GetChatAuditLog(ctx, chatID, ownerID, chataudit.ChatAuditFilter{
+			Actions:  []chataudit.AuditAction{chataudit.MemberAddedAction},
+			TargetID: kickedID,
+		})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, memberID, entries[0].GetActorID())
+	})
+
+	t.Run("Admin can read audit log", func(t *testing.T) {
+		entries, err := chatService.GetChatAuditLog(ctx, chatID, adminID, chataudit.ChatAuditFilter{})
+		require.NoError(t, err)
+		require.Len(t, entries, 5)
+	})
+
+	t.Run("Member can't read audit log", func(t *testing.T) {
+		_, err := chatService.GetChatAuditLog(ctx, chatID, memberID, chataudit.ChatAuditFilter{})
+		require.ErrorIs(t, err, roles.ErrInsufficientPermissions)
+	})
+
+	t.Run("Kicked user can't read audit log", func(t *testing.T) {
+		_, err := chatService.GetChatAuditLog(ctx, chatID, kickedID, chataudit.ChatAuditFilter{})
+		require.ErrorIs(t, err, chatparticipant.ErrChatParticipantNotFound)
+	})
+
+	t.Run("User outside of the chat can't read audit log", func(t *testing.T) {
+		_, err := chatService.GetChatAuditLog(ctx, chatID, outsiderID, chataudit.ChatAuditFilter{})
+		require.ErrorIs(t, err, chatparticipant.ErrChatParticipantNotFound)
+	})
+
+	//Chat is deleted, so this case must be the last one
+	t.Run("Audit log is deleted together with the chat", func(t *testing.T) {
+		otherChat, err := chatService.CreateChat(ctx, ownerID, "other chat")
+		require.NoError(t, err)
+		_, err = chatService.RenameChat(ctx, otherChat.GetID(), "renamed other chat", ownerID)
+		require.NoError(t, err)
+
+		require.NoError(t, chatService.DeleteChat(ctx, chatID, ownerID))
+
+		require.Equal(t, 0, countAuditEntries(t, db, chatID))
+		require.Equal(t, 1, countAuditEntries(t, db, otherChat.GetID()))
+	})
+
+	t.Run("Entry of unknown chat is rejected by the database", func(t *testing.T) {
+		_, err := db.DB.Exec(
+			"INSERT INTO chat_audit_log (id, chat_id, actor_id, action) VALUES ($1, $2, $3, $4)",
+			uuid.New(), uuid.New(), ownerID, chataudit.ChatRenamedAction,
+		)
+		require.Error(t, err)
+	})
+}
+
+func countAuditEntries(t *testing.T, db *setup.TestDB, chatID uuid.UUID) int {
+	var count int
+	err := db.DB.QueryRow("SELECT COUNT(*) FROM chat_audit_log WHERE chat_id = $1", chatID).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
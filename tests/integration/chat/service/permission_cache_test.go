@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"context"
+	"symphony_chat/internal/domain/chat_participant"
+	"symphony_chat/internal/domain/roles"
+	chatService "symphony_chat/internal/service/chat"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+//Ttl is longer than the test, so only invalidation makes changed roles visible
+func TestCachedPermissionsAreInvalidatedOnMembershipChanges(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	chatService := chatServiceSetup.SetupChatService(t, db, chatService.NewPermissionCache(100, time.Hour, nil))
+
+	require.NoError(t, db.TruncateAllTables())
+	ownerID := chatServiceSetup.CreateUser(t, db, "owner")
+	memberID := chatServiceSetup.CreateUser(t, db, "member")
+
+	createdChat, err := chatService.CreateChat(ctx, ownerID, "test chat")
+	require.NoError(t, err)
+	chatID := createdChat.GetID()
+
+	require.NoError(t, chatService.AddUserToChat(ctx, chatID, ownerID, memberID))
+
+	//Only admins can view audit log, member role is cached by this check
+	canViewAuditLog, err := chatService.IsUserHasEnoughPermissions(ctx, chatID, memberID, roles.PermissionViewAuditLog)
+	require.NoError(t, err)
+	require.False(t, canViewAuditLog)
+
+	//Promote
+	require.NoError(t, chatService.PromoteUserToChatAdmin(ctx, chatID, ownerID, memberID))
+
+	canViewAuditLog, err = chatService.IsUserHasEnoughPermissions(ctx, chatID, memberID, roles.PermissionViewAuditLog)
+	require.NoError(t, err)
+	require.True(t, canViewAuditLog)
+
+	//Demote
+	require.NoError(t, chatService.DemoteChatAdminToChatMember(ctx, chatID, ownerID, memberID))
+
+	canViewAuditLog, err = chatService.IsUserHasEnoughPermissions(ctx, chatID, memberID, roles.PermissionViewAuditLog)
+	require.NoError(t, err)
+	require.False(t, canViewAuditLog)
+
+	//Kick
+	require.NoError(t, chatService.RemoveUserFromChat(ctx, chatID, ownerID, memberID))
+
+	_, err = chatService.IsUserHasEnoughPermissions(ctx, chatID, memberID, roles.PermissionAddMessage)
+	require.ErrorIs(t, err, chatparticipant.ErrChatParticipantNotFound)
+}
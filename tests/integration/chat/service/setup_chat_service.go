@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"symphony_chat/internal/domain/users"
+	chatPostgresRepo "symphony_chat/internal/infrastructure/chat/postgres"
+	tx "symphony_chat/internal/infrastructure/transaction/postgres"
+	"symphony_chat/internal/infrastructure/users/postgres"
+	chatService "symphony_chat/internal/service/chat"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+//Permission cache can be nil, then permissions are always loaded from the database
+func SetupChatService(t *testing.T, db *setup.TestDB, permissionCache *chatService.PermissionCache) *chatService.ChatService {
+	chatService, err := chatService.NewChatService(
+		chatService.WithChatUserRepository(postgres.NewPostgresChatUserRepo(db.DB)),
+		chatService.WithChatRepository(chatPostgresRepo.NewPostgresChatRepo(db.DB)),
+		chatService.WithChatParticipantRepository(chatPostgresRepo.NewPostgresChatParticipantRepo(db.DB)),
+		chatService.WithChatRolesRepository(chatPostgresRepo.NewPostgresChatRoleRepo(db.DB)),
+		chatService.WithChatMessageRepository(chatPostgresRepo.NewPostgresChatMessageRepo(db.DB)),
+		chatService.WithChatAuditRepository(chatPostgresRepo.NewPostgresChatAuditRepo(db.DB)),
+		chatService.WithUserBlockRepository(postgres.NewPostgresUserBlockRepo(db.DB)),
+		chatService.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+		chatService.WithPermissionCache(permissionCache),
+	)
+	require.NoError(t, err)
+
+	return chatService
+}
+
+//Function creates auth user with chat profile and returns its id
+func CreateUser(t *testing.T, db *setup.TestDB, username string) uuid.UUID {
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now()
+
+	err := postgres.NewPostgresAuthUserRepo(db.DB).AddAuthUser(ctx, users.NewAuthUser(userID, username+"@example.com", "", now))
+	require.NoError(t, err)
+
+	err = postgres.NewPostgresChatUserRepo(db.DB).AddChatUser(ctx, users.NewChatUser(userID, username, users.Offline, now, now))
+	require.NoError(t, err)
+
+	return userID
+}
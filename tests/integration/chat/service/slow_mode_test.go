@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"symphony_chat/internal/domain/messages"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowMode(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	cs := chatServiceSetup.SetupChatService(t, db, nil)
+
+	require.NoError(t, db.TruncateAllTables())
+
+	ownerID := chatServiceSetup.CreateUser(t, db, "owner")
+	adminID := chatServiceSetup.CreateUser(t, db, "admin")
+	memberID := chatServiceSetup.CreateUser(t, db, "member")
+	otherMemberID := chatServiceSetup.CreateUser(t, db, "other_member")
+
+	slowChat, err := cs.CreateChat(ctx, ownerID, "slow chat")
+	require.NoError(t, err)
+	require.NoError(t, cs.AddUserToChat(ctx, slowChat.GetID(), ownerID, adminID))
+	require.NoError(t, cs.PromoteUserToChatAdmin(ctx, slowChat.GetID(), ownerID, adminID))
+	require.NoError(t, cs.AddUserToChat(ctx, slowChat.GetID(), ownerID, memberID))
+	require.NoError(t, cs.AddUserToChat(ctx, slowChat.GetID(), ownerID, otherMemberID))
+
+	t.Run("Messages are not limited without slow mode", func(t *testing.T) {
+		for range 3 {
+			_, err := cs.SendMessage(ctx, slowChat.GetID(), memberID, "message")
+			require.NoError(t, err)
+		}
+	})
+
+	interval, err := cs.SetChatSlowMode(ctx, slowChat.GetID(), time.Minute, ownerID)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, interval)
+
+	t.Run("Member can't send messages more often than interval", func(t *testing.T) {
+		deleteMessages(t, db)
+
+		_, err := cs.SendMessage(ctx, slowChat.GetID(), memberID, "first")
+		require.NoError(t, err)
+
+		_, err = cs.SendMessage(ctx, slowChat.GetID(), memberID, "second")
+		var rateLimitErr *messages.ChatMessageRateLimitError
+		require.ErrorAs(t, err, &rateLimitErr)
+		require.Equal(t, messages.SlowModeActiveCode, rateLimitErr.Code)
+		require.Greater(t, rateLimitErr.RetryAfter, 55*time.Second)
+		require.LessOrEqual(t, rateLimitErr.RetryAfter, time.Minute)
+
+		//Interval is counted for every member
+		_, err = cs.SendMessage(ctx, slowChat.GetID(), otherMemberID, "first")
+		require.NoError(t, err)
+
+		//Message is allowed after interval
+		require.NoError(t, cs.CheckSlowMode(ctx, slowChat.GetID(), memberID, time.Now().Add(time.Minute+time.Second)))
+	})
+
+	t.Run("Owner and admins are not affected", func(t *testing.T) {
+		deleteMessages(t, db)
+
+		for range 2 {
+			_, err := cs.SendMessage(ctx, slowChat.GetID(), ownerID, "message")
+			require.NoError(t, err)
+			_, err = cs.SendMessage(ctx, slowChat.GetID(), adminID, "message")
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Concurrent messages of member are checked one by one", func(t *testing.T) {
+		deleteMessages(t, db)
+
+		const senders = 10
+		var wg sync.WaitGroup
+		errs := make(chan error, senders)
+		for range senders {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cs.SendMessage(ctx, slowChat.GetID(), memberID, "message")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		var sent, limited int
+		for err := range errs {
+			var rateLimitErr *messages.ChatMessageRateLimitError
+			switch {
+			case err == nil:
+				sent++
+			case errors.As(err, &rateLimitErr):
+				limited++
+			default:
+				require.NoError(t, err)
+			}
+		}
+		require.Equal(t, 1, sent)
+		require.Equal(t, senders-1, limited)
+	})
+
+	t.Run("Messages are not limited after slow mode is disabled", func(t *testing.T) {
+		_, err := cs.SetChatSlowMode(ctx, slowChat.GetID(), 0, adminID)
+		require.NoError(t, err)
+
+		for range 2 {
+			_, err := cs.SendMessage(ctx, slowChat.GetID(), memberID, "message")
+			require.NoError(t, err)
+		}
+	})
+}
+
+//Slow mode is counted from the last message, so every case starts without messages
+func deleteMessages(t *testing.T, db *setup.TestDB) {
+	_, err := db.DB.Exec("DELETE FROM chat_message")
+	require.NoError(t, err)
+}
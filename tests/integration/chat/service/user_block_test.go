@@ -0,0 +1,54 @@
+package service_test
+
+import (
+	"context"
+	"symphony_chat/internal/domain/users"
+	"symphony_chat/internal/infrastructure/users/postgres"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockedUserCantAddBlocker(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	cs := chatServiceSetup.SetupChatService(t, db, nil)
+	userBlockRepo := postgres.NewPostgresUserBlockRepo(db.DB)
+
+	require.NoError(t, db.TruncateAllTables())
+
+	ownerID := chatServiceSetup.CreateUser(t, db, "owner")
+	blockerID := chatServiceSetup.CreateUser(t, db, "blocker")
+	otherID := chatServiceSetup.CreateUser(t, db, "other")
+
+	require.NoError(t, userBlockRepo.BlockUser(ctx, blockerID, ownerID, time.Now()))
+
+	createdChat, err := cs.CreateChat(ctx, ownerID, "chat")
+	require.NoError(t, err)
+
+	err = cs.AddUserToChat(ctx, createdChat.GetID(), ownerID, blockerID)
+	require.ErrorIs(t, err, users.ErrBlockedByUser)
+
+	_, err = cs.GetChatParticipantRoleName(ctx, createdChat.GetID(), blockerID)
+	require.Error(t, err)
+
+	//Block works in one direction, blocker still can be added by others and can add the blocked user
+	require.NoError(t, cs.AddUserToChat(ctx, createdChat.GetID(), ownerID, otherID))
+	require.NoError(t, cs.AddUserToChat(ctx, createdChat.GetID(), otherID, blockerID))
+
+	blockerChat, err := cs.CreateChat(ctx, blockerID, "chat of blocker")
+	require.NoError(t, err)
+	require.NoError(t, cs.AddUserToChat(ctx, blockerChat.GetID(), blockerID, ownerID))
+
+	//Unblocked user can be added again
+	require.NoError(t, userBlockRepo.UnblockUser(ctx, blockerID, ownerID))
+	otherChat, err := cs.CreateChat(ctx, ownerID, "other chat")
+	require.NoError(t, err)
+	require.NoError(t, cs.AddUserToChat(ctx, otherChat.GetID(), ownerID, blockerID))
+}
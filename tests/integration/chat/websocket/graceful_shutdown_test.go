@@ -0,0 +1,187 @@
+package websocket_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	wsHandler "symphony_chat/internal/application/websocket/handler"
+	"symphony_chat/internal/domain/jwt"
+	config "symphony_chat/internal/infrastructure/configs"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+)
+
+const slowRequestDuration = 200 * time.Millisecond
+
+//Server is stopped in the same order as in cmd/main.go: http server first, then websocket connections
+type testServer struct {
+	server *http.Server
+	handler *wsHandler.WebsocketHandler
+	router *gin.Engine
+	url string
+	slowRequestStarted chan struct{}
+}
+
+func newTestServer(t *testing.T, db *setup.TestDB, userID uuid.UUID) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cs := chatServiceSetup.SetupChatService(t, db, nil)
+	ts := &testServer{
+		handler: wsHandler.NewWebsocketHandler(cs, config.DefaultWebSocketConfig(), nil),
+		slowRequestStarted: make(chan struct{}, 1),
+	}
+
+	ts.router = gin.New()
+	ts.router.GET("/slow", func(c *gin.Context) {
+		ts.slowRequestStarted <- struct{}{}
+		time.Sleep(slowRequestDuration)
+		c.String(http.StatusOK, "done")
+	})
+	//AuthMiddleware is replaced with claims of the user
+	ts.router.GET("/ws", func(c *gin.Context) {
+		c.Set("token_claims", jwt.TokenClaims{
+			UserID: userID,
+			TokenID: uuid.New(),
+			SessionID: uuid.New(),
+		})
+		c.Next()
+	}, ts.handler.HandleWebSocket)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ts.server = &http.Server{Handler: ts.router}
+	ts.url = listener.Addr().String()
+	go ts.server.Serve(listener)
+	t.Cleanup(func() {
+		ts.server.Close()
+	})
+
+	return ts
+}
+
+func (ts *testServer) shutdown(ctx context.Context) error {
+	if err := ts.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	return ts.handler.Shutdown(ctx)
+}
+
+func (ts *testServer) dial(t *testing.T) *websocket.Conn {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws://"+ts.url+"/ws", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	require.Eventually(t, func() bool {
+		return ts.handler.ActiveClientsCount() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	return conn
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.TruncateAllTables())
+	userID := chatServiceSetup.CreateUser(t, db, "user")
+
+	t.Run("In-flight request is finished", func(t *testing.T) {
+		ts := newTestServer(t, db, userID)
+
+		type result struct {
+			status int
+			body string
+			err error
+		}
+		results := make(chan result, 1)
+		go func() {
+			resp, err := http.Get("http://" + ts.url + "/slow")
+			if err != nil {
+				results <- result{err: err}
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			results <- result{status: resp.StatusCode, body: string(body), err: err}
+		}()
+
+		<-ts.slowRequestStarted
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, ts.shutdown(ctx))
+
+		res := <-results
+		require.NoError(t, res.err)
+		require.Equal(t, http.StatusOK, res.status)
+		require.Equal(t, "done", res.body)
+	})
+
+	t.Run("Websocket client gets close frame", func(t *testing.T) {
+		ts := newTestServer(t, db, userID)
+		conn := ts.dial(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, ts.shutdown(ctx))
+		require.Zero(t, ts.handler.ActiveClientsCount())
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		for {
+			_, _, err := conn.ReadMessage()
+			if err == nil {
+				continue
+			}
+
+			var closeErr *websocket.CloseError
+			require.True(t, errors.As(err, &closeErr), "expected close frame, got %v", err)
+			require.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+			require.Equal(t, "server is shutting down", closeErr.Text)
+			break
+		}
+	})
+
+	t.Run("New upgrade is rejected after gate is closed", func(t *testing.T) {
+		ts := newTestServer(t, db, userID)
+		require.True(t, ts.handler.IsAcceptingConnections())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, ts.handler.Shutdown(ctx))
+		require.False(t, ts.handler.IsAcceptingConnections())
+
+		//Request that was accepted by http server before it was stopped
+		req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		w := httptest.NewRecorder()
+		ts.router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Equal(t, "SERVER_SHUTTING_DOWN", body["code"])
+		require.Zero(t, ts.handler.ActiveClientsCount())
+	})
+}
@@ -0,0 +1,79 @@
+package websocket_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	actions "symphony_chat/internal/domain/chat_actions"
+	websocketmessage "symphony_chat/internal/infrastructure/websocket/websocket_message"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+)
+
+func TestSetSlowModeWithInvalidPayload(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	require.NoError(t, db.TruncateAllTables())
+	ownerID := chatServiceSetup.CreateUser(t, db, "owner")
+
+	cs := chatServiceSetup.SetupChatService(t, db, nil)
+	slowChat, err := cs.CreateChat(ctx, ownerID, "slow chat")
+	require.NoError(t, err)
+
+	ts := newTestServer(t, db, ownerID)
+	conn := ts.dial(t)
+
+	testCases := []struct {
+		name string
+		payload map[string]interface{}
+	}{
+		{
+			name: "Missing interval",
+			payload: map[string]interface{}{},
+		},
+		{
+			name: "Interval is not a number",
+			payload: map[string]interface{}{"slow_mode_interval_seconds": "30"},
+		},
+		{
+			name: "Negative interval",
+			payload: map[string]interface{}{"slow_mode_interval_seconds": -5},
+		},
+		{
+			name: "Non-integral interval",
+			payload: map[string]interface{}{"slow_mode_interval_seconds": 1.5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.payload["chat_id"] = slowChat.GetID().String()
+			tc.payload["user_id"] = ownerID.String()
+
+			require.NoError(t, conn.WriteJSON(websocketmessage.WsMessageRequest{
+				ChatAction: actions.SetChatSlowModeAction,
+				Payload: tc.payload,
+			}))
+
+			var wsRes websocketmessage.WsMessageResponse
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+			require.NoError(t, conn.ReadJSON(&wsRes))
+
+			require.Equal(t, actions.Failed, wsRes.ChatActionResult)
+			require.Equal(t, "INVALID_SLOW_MODE_INTERVAL", wsRes.Payload["code"])
+			require.Equal(t, slowChat.GetID().String(), wsRes.Payload["chat_id"])
+
+			//Slow mode of the chat is not changed
+			chats, err := cs.GetChatsOfUser(ctx, ownerID)
+			require.NoError(t, err)
+			require.Len(t, chats, 1)
+			require.Zero(t, chats[0].GetSlowModeInterval())
+		})
+	}
+}
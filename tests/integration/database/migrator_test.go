@@ -0,0 +1,175 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"symphony_chat/internal/infrastructure/database"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+//Migrations of the test are applied in their own schema, so schema of other test packages is not touched
+const testSchema = "migrator_test"
+
+var testMigrations = fstest.MapFS{
+	"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+	"000001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"000002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INT); CREATE TABLE b_2 (id INT);")},
+	"000002_create_b.down.sql": {Data: []byte("DROP TABLE b_2; DROP TABLE b;")},
+	"000003_create_c.up.sql":   {Data: []byte("CREATE TABLE c (id INT);")},
+	"000003_create_c.down.sql": {Data: []byte("DROP TABLE c;")},
+}
+
+//Function opens connection whose search path is the test schema
+//Only one connection is opened, so the search path is kept by every query of the migrator
+func newTestSchemaDB(t *testing.T) *sql.DB {
+	db, err := database.NewPostgresConnection(database.PostgresConfig{
+		Host:     os.Getenv("TEST_DB_HOST"),
+		Port:     os.Getenv("TEST_DB_PORT"),
+		User:     os.Getenv("TEST_DB_USER"),
+		Password: os.Getenv("TEST_DB_PASSWORD"),
+		DBName:   os.Getenv("TEST_DB_NAME"),
+		SSLMode:  os.Getenv("TEST_DB_SSLMODE"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	for _, query := range []string{
+		`DROP SCHEMA IF EXISTS ` + testSchema + ` CASCADE`,
+		`CREATE SCHEMA ` + testSchema,
+		`SET search_path TO ` + testSchema,
+	} {
+		_, err := db.Exec(query)
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() { db.Exec(`DROP SCHEMA IF EXISTS ` + testSchema + ` CASCADE`) })
+
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var exists bool
+	require.NoError(t, db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, testSchema+"."+table).Scan(&exists))
+	return exists
+}
+
+func appliedVersions(status database.MigrationStatus) []uint {
+	var versions []uint
+	for _, migration := range status.Migrations {
+		if migration.Applied {
+			versions = append(versions, migration.Version)
+		}
+	}
+	return versions
+}
+
+func versionsOf(migrations []database.Migration) []uint {
+	var versions []uint
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := newTestSchemaDB(t)
+
+	migrator, err := database.NewMigrator(db, testMigrations)
+	require.NoError(t, err)
+
+	t.Run("Status of new database", func(t *testing.T) {
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint(0), status.Version)
+		require.Len(t, status.Migrations, 3)
+		require.Empty(t, appliedVersions(status))
+
+		//Status only reads the database
+		require.False(t, tableExists(t, db, "schema_migrations"))
+	})
+
+	t.Run("Up applies all migrations", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []uint{1, 2, 3}, versionsOf(applied))
+
+		for _, table := range []string{"a", "b", "b_2", "c"} {
+			require.True(t, tableExists(t, db, table))
+		}
+
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint(3), status.Version)
+		require.False(t, status.Dirty)
+		require.Equal(t, []uint{1, 2, 3}, appliedVersions(status))
+	})
+
+	t.Run("Up without new migrations", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		require.Empty(t, applied)
+	})
+
+	t.Run("Down rolls back newest migrations", func(t *testing.T) {
+		rolledBack, err := migrator.Down(ctx, 2)
+		require.NoError(t, err)
+		require.Equal(t, []uint{3, 2}, versionsOf(rolledBack))
+
+		require.True(t, tableExists(t, db, "a"))
+		for _, table := range []string{"b", "b_2", "c"} {
+			require.False(t, tableExists(t, db, table))
+		}
+
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint(1), status.Version)
+		require.Equal(t, []uint{1}, appliedVersions(status))
+	})
+
+	t.Run("Up applies rolled back migrations again", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []uint{2, 3}, versionsOf(applied))
+	})
+
+	t.Run("Down stops at the first migration", func(t *testing.T) {
+		rolledBack, err := migrator.Down(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, []uint{3, 2, 1}, versionsOf(rolledBack))
+		require.False(t, tableExists(t, db, "a"))
+
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint(0), status.Version)
+		require.Empty(t, appliedVersions(status))
+	})
+
+	t.Run("Failed migration is rolled back", func(t *testing.T) {
+		failing := fstest.MapFS{
+			"000001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id INT); CREATE TABLE a (id INT);")},
+		}
+		failingMigrator, err := database.NewMigrator(db, failing)
+		require.NoError(t, err)
+
+		_, err = failingMigrator.Up(ctx)
+		require.Error(t, err)
+		require.False(t, tableExists(t, db, "a"))
+
+		status, err := failingMigrator.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint(0), status.Version)
+		require.False(t, status.Dirty)
+	})
+
+	t.Run("Down with invalid number of steps", func(t *testing.T) {
+		_, err := migrator.Down(ctx, 0)
+		require.Error(t, err)
+	})
+}
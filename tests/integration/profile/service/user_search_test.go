@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"context"
+	"symphony_chat/internal/domain/users"
+	tx "symphony_chat/internal/infrastructure/transaction/postgres"
+	"symphony_chat/internal/infrastructure/users/postgres"
+	"symphony_chat/internal/service/profile"
+	chatServiceSetup "symphony_chat/tests/integration/chat/service"
+	"symphony_chat/tests/integration/setup"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func usernamesOf(summaries []profile.UserSummary) []string {
+	usernames := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		usernames = append(usernames, summary.User.GetUsername())
+	}
+	return usernames
+}
+
+func TestSearchUsers(t *testing.T) {
+	db, err := setup.NewTestDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	profileService, err := profile.NewProfileService(
+		profile.WithChatUserRepository(postgres.NewPostgresChatUserRepo(db.DB)),
+		profile.WithUserBlockRepository(postgres.NewPostgresUserBlockRepo(db.DB)),
+		profile.WithTransactionManager(tx.NewPostgresTransactionManager(db.DB)),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, db.TruncateAllTables())
+
+	//Searcher matches the query too, but never finds himself
+	searcherID := chatServiceSetup.CreateUser(t, db, "alexis")
+	for _, username := range []string{"alexander", "aleks", "alex", "alexa", "xander"} {
+		chatServiceSetup.CreateUser(t, db, username)
+	}
+	blockerID := chatServiceSetup.CreateUser(t, db, "alexey")
+	require.NoError(t, profileService.BlockUser(ctx, blockerID, "alexis"))
+	//Users that are blocked by searcher are still found
+	require.NoError(t, profileService.BlockUser(ctx, searcherID, "alexa"))
+
+	testCases := []struct {
+		name              string
+		query             users.UserSearchQuery
+		expectedUsernames []string
+		expectedErr       error
+	}{
+		{
+			name:  "Prefix matches go first, then similar usernames",
+			query: users.UserSearchQuery{Query: "alex", SearcherID: searcherID},
+			//Prefix matches are ordered by similarity, blocker and searcher are excluded
+			expectedUsernames: []string{"alex", "alexa", "alexander", "aleks"},
+		},
+		{
+			name:              "Query is case insensitive",
+			query:             users.UserSearchQuery{Query: "ALEX", SearcherID: searcherID, Limit: 1},
+			expectedUsernames: []string{"alex"},
+		},
+		{
+			name:              "First page",
+			query:             users.UserSearchQuery{Query: "alex", SearcherID: searcherID, Limit: 2},
+			expectedUsernames: []string{"alex", "alexa"},
+		},
+		{
+			name:              "Second page",
+			query:             users.UserSearchQuery{Query: "alex", SearcherID: searcherID, Limit: 2, Offset: 2},
+			expectedUsernames: []string{"alexander", "aleks"},
+		},
+		{
+			name:              "Page after the last one",
+			query:             users.UserSearchQuery{Query: "alex", SearcherID: searcherID, Limit: 2, Offset: 4},
+			expectedUsernames: []string{},
+		},
+		{
+			name:              "Blocker is found by other users",
+			query:             users.UserSearchQuery{Query: "alexey", SearcherID: uuid.New(), Limit: 1},
+			expectedUsernames: []string{"alexey"},
+		},
+		{
+			name:              "Placeholder of deleted users is not found",
+			query:             users.UserSearchQuery{Query: users.DeletedChatUser.GetUsername(), SearcherID: searcherID},
+			expectedUsernames: []string{},
+		},
+		{
+			name:        "Too short query",
+			query:       users.UserSearchQuery{Query: "a", SearcherID: searcherID},
+			expectedErr: users.ErrWrongSearchQuery,
+		},
+		{
+			name:        "Too big page",
+			query:       users.UserSearchQuery{Query: "alex", SearcherID: searcherID, Limit: users.MaxUserSearchPageSize + 1},
+			expectedErr: users.ErrWrongSearchPagination,
+		},
+		{
+			name:        "Negative offset",
+			query:       users.UserSearchQuery{Query: "alex", SearcherID: searcherID, Offset: -1},
+			expectedErr: users.ErrWrongSearchPagination,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			summaries, err := profileService.SearchUsers(ctx, tc.query)
+
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedUsernames, usernamesOf(summaries))
+		})
+	}
+}
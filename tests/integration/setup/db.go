@@ -1,10 +1,16 @@
 package setup
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"symphony_chat/internal/domain/users"
+	chatPostgresRepo "symphony_chat/internal/infrastructure/chat/postgres"
 	database "symphony_chat/internal/infrastructure/database"
+	transaction "symphony_chat/internal/infrastructure/transaction/postgres"
+	rolesync "symphony_chat/internal/service/role_sync"
+	"symphony_chat/migrations"
 )
 
 type TestDB struct {
@@ -33,6 +39,31 @@ func NewTestDB() (*TestDB, error) {
 		return nil, err
 	}
 
+	//Test packages run in parallel, advisory lock of migrator makes only one of them migrate the database
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	//Chats need built-in roles, they are not truncated between tests
+	roleSyncService, err := rolesync.NewRoleSyncService(
+		rolesync.WithChatRoleRepository(chatPostgresRepo.NewPostgresChatRoleRepo(db)),
+		rolesync.WithTransactionManager(transaction.NewPostgresTransactionManager(db)),
+	)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := roleSyncService.Sync(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to sync built-in chat roles: %w", err)
+	}
+
 	TestDBInstance = &TestDB{
 		DB: db,
 	}
@@ -45,6 +76,7 @@ func (tbd *TestDB) TruncateAllTables() error {
 		SELECT tablename 
 		FROM pg_tables
 		WHERE schemaname = 'public'
+			AND tablename NOT IN ('schema_migrations', 'chat_role', 'chat_role_permission')
 	`)
 
 	if err != nil {
@@ -77,6 +109,16 @@ func (tbd *TestDB) TruncateAllTables() error {
 		}
 	}
 
+	//Placeholder is seeded by migration, messages of deleted accounts are passed to it
+	_, err = tbd.DB.Exec(
+		`INSERT INTO chat_user (id, username, status) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO NOTHING`,
+		users.DeletedChatUser.GetID(), users.DeletedChatUser.GetUsername(), users.DeletedChatUser.GetStatus(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to seed deleted user placeholder: %w", err)
+	}
+
 	return nil
 }
 
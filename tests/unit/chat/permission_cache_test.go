@@ -0,0 +1,111 @@
+package service_test
+
+import (
+	chatService "symphony_chat/internal/service/chat"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+//Function returns loader of the role that counts its calls
+func countingLoad(roleID uuid.UUID, calls *int) func() (uuid.UUID, error) {
+	return func() (uuid.UUID, error) {
+		*calls++
+		return roleID, nil
+	}
+}
+
+func TestPermissionCacheInvalidation(t *testing.T) {
+	chatID, otherChatID := uuid.New(), uuid.New()
+	userID, otherUserID := uuid.New(), uuid.New()
+	roleID := uuid.New()
+
+	testCases := []struct {
+		name       string
+		invalidate func(pc *chatService.PermissionCache)
+		//Number of loads of user in chat and other user in other chat after invalidation
+		expectedCalls      int
+		expectedOtherCalls int
+	}{
+		{
+			name:               "Participant",
+			invalidate:         func(pc *chatService.PermissionCache) { pc.InvalidateParticipant(chatID, userID) },
+			expectedCalls:      2,
+			expectedOtherCalls: 1,
+		},
+		{
+			name:               "Chat",
+			invalidate:         func(pc *chatService.PermissionCache) { pc.InvalidateChat(otherChatID) },
+			expectedCalls:      1,
+			expectedOtherCalls: 2,
+		},
+		{
+			name:               "User",
+			invalidate:         func(pc *chatService.PermissionCache) { pc.InvalidateUser(userID) },
+			expectedCalls:      2,
+			expectedOtherCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pc := chatService.NewPermissionCache(100, time.Hour, nil)
+
+			var calls, otherCalls int
+			for range 2 {
+				_, err := pc.RoleOfParticipant(chatID, userID, countingLoad(roleID, &calls))
+				require.NoError(t, err)
+				_, err = pc.RoleOfParticipant(otherChatID, otherUserID, countingLoad(roleID, &otherCalls))
+				require.NoError(t, err)
+			}
+			require.Equal(t, 1, calls)
+			require.Equal(t, 1, otherCalls)
+
+			tc.invalidate(pc)
+
+			_, err := pc.RoleOfParticipant(chatID, userID, countingLoad(roleID, &calls))
+			require.NoError(t, err)
+			_, err = pc.RoleOfParticipant(otherChatID, otherUserID, countingLoad(roleID, &otherCalls))
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedCalls, calls)
+			require.Equal(t, tc.expectedOtherCalls, otherCalls)
+		})
+	}
+}
+
+//Role that was loaded before membership changed must not stay in the cache
+func TestRoleInvalidatedWhileLoadingIsNotCached(t *testing.T) {
+	pc := chatService.NewPermissionCache(100, time.Hour, nil)
+	chatID, userID := uuid.New(), uuid.New()
+	oldRoleID, newRoleID := uuid.New(), uuid.New()
+
+	roleID, err := pc.RoleOfParticipant(chatID, userID, func() (uuid.UUID, error) {
+		pc.InvalidateParticipant(chatID, userID)
+		return oldRoleID, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, oldRoleID, roleID)
+
+	roleID, err = pc.RoleOfParticipant(chatID, userID, func() (uuid.UUID, error) {
+		return newRoleID, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, newRoleID, roleID)
+}
+
+func TestNilPermissionCacheAlwaysLoads(t *testing.T) {
+	var pc *chatService.PermissionCache
+	chatID, userID := uuid.New(), uuid.New()
+
+	var calls int
+	for range 2 {
+		_, err := pc.RoleOfParticipant(chatID, userID, countingLoad(uuid.New(), &calls))
+		require.NoError(t, err)
+	}
+	pc.InvalidateChat(chatID)
+
+	require.Equal(t, 2, calls)
+}
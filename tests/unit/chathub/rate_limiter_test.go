@@ -0,0 +1,89 @@
+package chathub_test
+
+import (
+	"symphony_chat/internal/infrastructure/websocket/chathub"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageRateLimiter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	refillInterval := 500 * time.Millisecond
+
+	t.Run("Burst is allowed, then retry time is returned", func(t *testing.T) {
+		limiter := chathub.NewMessageRateLimiter(3, refillInterval)
+		userID := uuid.New()
+
+		for range 3 {
+			allowed, retryAfter := limiter.Allow(userID, start)
+			require.True(t, allowed)
+			require.Zero(t, retryAfter)
+		}
+
+		allowed, retryAfter := limiter.Allow(userID, start)
+		require.False(t, allowed)
+		require.Equal(t, refillInterval, retryAfter)
+
+		//Half of the token is refilled
+		allowed, retryAfter = limiter.Allow(userID, start.Add(refillInterval/2))
+		require.False(t, allowed)
+		require.Equal(t, refillInterval/2, retryAfter)
+	})
+
+	t.Run("Tokens are refilled over time up to burst", func(t *testing.T) {
+		limiter := chathub.NewMessageRateLimiter(2, refillInterval)
+		userID := uuid.New()
+
+		for range 2 {
+			allowed, _ := limiter.Allow(userID, start)
+			require.True(t, allowed)
+		}
+
+		allowed, _ := limiter.Allow(userID, start.Add(refillInterval))
+		require.True(t, allowed)
+		allowed, _ = limiter.Allow(userID, start.Add(refillInterval))
+		require.False(t, allowed)
+
+		//Long pause doesn't give more tokens than burst
+		later := start.Add(time.Hour)
+		for range 2 {
+			allowed, _ := limiter.Allow(userID, later)
+			require.True(t, allowed)
+		}
+		allowed, _ = limiter.Allow(userID, later)
+		require.False(t, allowed)
+	})
+
+	t.Run("Users have their own buckets", func(t *testing.T) {
+		limiter := chathub.NewMessageRateLimiter(1, refillInterval)
+		userID, otherUserID := uuid.New(), uuid.New()
+
+		allowed, _ := limiter.Allow(userID, start)
+		require.True(t, allowed)
+		allowed, _ = limiter.Allow(userID, start)
+		require.False(t, allowed)
+
+		allowed, _ = limiter.Allow(otherUserID, start)
+		require.True(t, allowed)
+	})
+
+	t.Run("Reconnecting doesn't reset not refilled bucket", func(t *testing.T) {
+		limiter := chathub.NewMessageRateLimiter(1, refillInterval)
+		userID := uuid.New()
+
+		allowed, _ := limiter.Allow(userID, start)
+		require.True(t, allowed)
+
+		limiter.Forget(userID, start)
+		allowed, _ = limiter.Allow(userID, start)
+		require.False(t, allowed)
+
+		//Refilled bucket is removed, new one starts with full burst
+		limiter.Forget(userID, start.Add(time.Minute))
+		allowed, _ = limiter.Allow(userID, start.Add(time.Minute))
+		require.True(t, allowed)
+	})
+}
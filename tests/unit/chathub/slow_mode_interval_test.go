@@ -0,0 +1,73 @@
+package chathub_test
+
+import (
+	"symphony_chat/internal/domain/chat"
+	"symphony_chat/internal/infrastructure/websocket/chathub"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlowModeInterval(t *testing.T) {
+	testCases := []struct {
+		name             string
+		payload          map[string]interface{}
+		expectedInterval time.Duration
+		expectedErr      error
+	}{
+		{
+			name:             "Whole number of seconds",
+			payload:          map[string]interface{}{"slow_mode_interval_seconds": float64(30)},
+			expectedInterval: 30 * time.Second,
+		},
+		{
+			name:             "Zero disables slow mode",
+			payload:          map[string]interface{}{"slow_mode_interval_seconds": float64(0)},
+			expectedInterval: 0,
+		},
+		{
+			name:        "Missing interval",
+			payload:     map[string]interface{}{},
+			expectedErr: chathub.ErrInvalidSlowModeInterval,
+		},
+		{
+			name:        "Interval is not a number",
+			payload:     map[string]interface{}{"slow_mode_interval_seconds": "30"},
+			expectedErr: chathub.ErrInvalidSlowModeInterval,
+		},
+		{
+			name:        "Interval is null",
+			payload:     map[string]interface{}{"slow_mode_interval_seconds": nil},
+			expectedErr: chathub.ErrInvalidSlowModeInterval,
+		},
+		{
+			name:        "Negative interval",
+			payload:     map[string]interface{}{"slow_mode_interval_seconds": float64(-5)},
+			expectedErr: chathub.ErrInvalidSlowModeInterval,
+		},
+		{
+			name:        "Non-integral interval",
+			payload:     map[string]interface{}{"slow_mode_interval_seconds": 1.5},
+			expectedErr: chathub.ErrInvalidSlowModeInterval,
+		},
+		{
+			name:        "Interval that would overflow duration",
+			payload:     map[string]interface{}{"slow_mode_interval_seconds": 1e30},
+			expectedErr: chat.ErrWrongSlowModeInterval,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interval, err := chathub.ParseSlowModeInterval(tc.payload)
+
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedInterval, interval)
+		})
+	}
+}
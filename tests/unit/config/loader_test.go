@@ -0,0 +1,305 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	config "symphony_chat/internal/infrastructure/configs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+//Options without defaults are required, so every case passes them
+var requiredFlags = []string{"-database.user", "chat", "-database.name", "chat", "-jwt.ephemeral_keys"}
+
+//Function writes config file into temporary directory and returns its path
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+//Environment of the test run must not change results
+func clearEnv(t *testing.T) {
+	for _, env := range []string{config.ConfigFileEnv, "DB_HOST", "DB_PORT", "DB_USER", "DB_NAME", "DB_SSLMODE",
+		"LOG_LEVEL", "SERVER_ADDR", "SHUTDOWN_TIMEOUT_IN_SECONDS", "OIDC_SCOPES", "JWT_KEYS_DIR", "JWT_EPHEMERAL_KEYS", "TRUSTED_PROXIES", "DB_MIGRATIONS_DIR"} {
+		t.Setenv(env, "")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := `
+database:
+  host: file-host
+  port: "5433"
+log:
+  level: warn
+`
+
+	testCases := []struct {
+		name         string
+		useFile      bool
+		env          map[string]string
+		args         []string
+		expectedHost string
+		expectedPort string
+		expectedLog  string
+	}{
+		{
+			name:         "Defaults",
+			expectedHost: "localhost",
+			expectedPort: "5432",
+			expectedLog:  "info",
+		},
+		{
+			name:         "File overrides defaults",
+			useFile:      true,
+			expectedHost: "file-host",
+			expectedPort: "5433",
+			expectedLog:  "warn",
+		},
+		{
+			name:         "Environment overrides file",
+			useFile:      true,
+			env:          map[string]string{"DB_HOST": "env-host"},
+			expectedHost: "env-host",
+			expectedPort: "5433",
+			expectedLog:  "warn",
+		},
+		{
+			name:         "Empty environment variable is not set",
+			useFile:      true,
+			env:          map[string]string{"DB_HOST": ""},
+			expectedHost: "file-host",
+			expectedPort: "5433",
+			expectedLog:  "warn",
+		},
+		{
+			name:         "Flags override environment",
+			useFile:      true,
+			env:          map[string]string{"DB_HOST": "env-host", "LOG_LEVEL": "error"},
+			args:         []string{"-database.host", "flag-host"},
+			expectedHost: "flag-host",
+			expectedPort: "5433",
+			expectedLog:  "error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			for env, value := range tc.env {
+				t.Setenv(env, value)
+			}
+
+			args := append([]string{}, requiredFlags...)
+			if tc.useFile {
+				//Config file is passed by environment variable, flags are checked by other cases
+				t.Setenv(config.ConfigFileEnv, writeConfigFile(t, "config.yaml", yamlFile))
+			}
+			args = append(args, tc.args...)
+
+			cfg, err := config.Load(args)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedHost, cfg.Database.Host)
+			require.Equal(t, tc.expectedPort, cfg.Database.Port)
+			require.Equal(t, tc.expectedLog, cfg.Log.Level)
+		})
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	yamlFile := `
+server:
+  addr: ":8081"
+database:
+  user: chat
+  name: chat
+  sslmode: disable
+jwt:
+  ephemeral_keys: true
+oidc:
+  scopes:
+    - openid
+    - email
+`
+	tomlFile := `
+[server]
+addr = ":8081"
+
+[database]
+user = "chat"
+name = "chat"
+sslmode = "disable"
+
+[jwt]
+ephemeral_keys = true
+
+[oidc]
+scopes = ["openid", "email"]
+`
+
+	testCases := []struct {
+		name        string
+		fileName    string
+		content     string
+		expectedErr string
+	}{
+		{
+			name:     "Yaml",
+			fileName: "config.yaml",
+			content:  yamlFile,
+		},
+		{
+			name:     "Yml",
+			fileName: "config.yml",
+			content:  yamlFile,
+		},
+		{
+			name:     "Toml",
+			fileName: "config.toml",
+			content:  tomlFile,
+		},
+		{
+			name:        "Unknown yaml key",
+			fileName:    "config.yaml",
+			content:     "database:\n  hots: localhost\n",
+			expectedErr: "field hots not found",
+		},
+		{
+			name:        "Unknown toml key",
+			fileName:    "config.toml",
+			content:     "[database]\nhots = \"localhost\"\n",
+			expectedErr: "unknown keys",
+		},
+		{
+			name:        "Unsupported format",
+			fileName:    "config.json",
+			content:     "{}",
+			expectedErr: "unsupported config file format",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			path := writeConfigFile(t, tc.fileName, tc.content)
+
+			cfg, err := config.Load([]string{"-config", path})
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, ":8081", cfg.Server.Addr)
+			require.Equal(t, "chat", cfg.Database.User)
+			require.Equal(t, "disable", cfg.Database.SSLMode)
+			require.Equal(t, []string{"openid", "email"}, cfg.OIDC.Scopes)
+			//Options that are not in the file keep defaults
+			require.Equal(t, "localhost", cfg.Database.Host)
+		})
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	testCases := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		expectedErrs []string
+	}{
+		{
+			name:         "Required options are missing",
+			expectedErrs: []string{"database.user is required", "database.name is required", "jwt.keys_dir must be set"},
+		},
+		{
+			name:         "Invalid value of option",
+			args:         append([]string{"-database.port", "70000"}, requiredFlags...),
+			expectedErrs: []string{"database.port must be a number from 1 to 65535"},
+		},
+		{
+			name:         "Invalid environment variable",
+			env:          map[string]string{"LOG_LEVEL": "", "SHUTDOWN_TIMEOUT_IN_SECONDS": "-1"},
+			args:         requiredFlags,
+			expectedErrs: []string{"invalid value of SHUTDOWN_TIMEOUT_IN_SECONDS"},
+		},
+		{
+			name:         "Trusted proxy is not IP or CIDR",
+			env:          map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.local"},
+			args:         requiredFlags,
+			expectedErrs: []string{"server.trusted_proxies must contain IPs or CIDRs, got proxy.local"},
+		},
+		{
+			name:         "Invalid flag",
+			args:         append([]string{"-server.shutdown_timeout_in_seconds", "soon"}, requiredFlags...),
+			expectedErrs: []string{"is not a non-negative integer"},
+		},
+		{
+			name:         "Unknown flag",
+			args:         append([]string{"-database.hots", "localhost"}, requiredFlags...),
+			expectedErrs: []string{"flag provided but not defined: -database.hots"},
+		},
+		{
+			name:         "Unexpected arguments",
+			args:         append(append([]string{}, requiredFlags...), "up"),
+			expectedErrs: []string{"unexpected arguments: up"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			for env, value := range tc.env {
+				t.Setenv(env, value)
+			}
+
+			_, err := config.Load(tc.args)
+			require.Error(t, err)
+			for _, expectedErr := range tc.expectedErrs {
+				require.ErrorContains(t, err, expectedErr)
+			}
+		})
+	}
+}
+
+func TestLoadListFromEnvironment(t *testing.T) {
+	clearEnv(t)
+	//Items are separated by commas or spaces
+	t.Setenv("OIDC_SCOPES", "openid,email profile")
+
+	cfg, err := config.Load(requiredFlags)
+	require.NoError(t, err)
+	require.Equal(t, []string{"openid", "email", "profile"}, cfg.OIDC.Scopes)
+	//No proxy is trusted by default
+	require.Empty(t, cfg.Server.TrustedProxies)
+}
+
+func TestLoadMigrationsDir(t *testing.T) {
+	t.Run("Embedded migrations are used by default", func(t *testing.T) {
+		clearEnv(t)
+
+		cfg, err := config.Load(requiredFlags)
+		require.NoError(t, err)
+		require.Empty(t, cfg.Database.MigrationsDir)
+	})
+
+	t.Run("Directory overrides embedded migrations", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("DB_MIGRATIONS_DIR", "/srv/migrations")
+
+		cfg, err := config.Load(requiredFlags)
+		require.NoError(t, err)
+		require.Equal(t, "/srv/migrations", cfg.Database.MigrationsDir)
+	})
+}
+
+func TestLoadWithArgsReturnsSubcommand(t *testing.T) {
+	clearEnv(t)
+
+	cfg, rest, err := config.LoadWithArgs(append(append([]string{}, requiredFlags...), "down", "2"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"down", "2"}, rest)
+	require.Equal(t, "chat", cfg.Database.Name)
+}
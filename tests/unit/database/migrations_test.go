@@ -0,0 +1,169 @@
+package database_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"symphony_chat/internal/infrastructure/database"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	testCases := []struct {
+		name               string
+		source             fstest.MapFS
+		expectedMigrations []database.Migration
+		expectedErr        string
+	}{
+		{
+			name: "Migrations are sorted by version",
+			source: fstest.MapFS{
+				"000010_create_c.up.sql":   file("CREATE TABLE c ();"),
+				"000002_create_b.up.sql":   file("CREATE TABLE b ();"),
+				"000002_create_b.down.sql": file("DROP TABLE b;"),
+				"000001_create_a.up.sql":   file("CREATE TABLE a ();"),
+				"000001_create_a.down.sql": file("DROP TABLE a;"),
+			},
+			expectedMigrations: []database.Migration{
+				{Version: 1, Name: "create_a", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"},
+				{Version: 2, Name: "create_b", Up: "CREATE TABLE b ();", Down: "DROP TABLE b;"},
+				{Version: 10, Name: "create_c", Up: "CREATE TABLE c ();"},
+			},
+		},
+		{
+			name: "Other files and directories are skipped",
+			source: fstest.MapFS{
+				"000001_create_a.up.sql":     file("CREATE TABLE a ();"),
+				"README.md":                  file("migrations"),
+				"embed.go":                   file("package migrations"),
+				"old/000002_create_b.up.sql": file("CREATE TABLE b ();"),
+			},
+			expectedMigrations: []database.Migration{
+				{Version: 1, Name: "create_a", Up: "CREATE TABLE a ();"},
+			},
+		},
+		{
+			name: "Empty up file of new migration",
+			source: fstest.MapFS{
+				"000001_create_a.up.sql":   file(""),
+				"000001_create_a.down.sql": file(""),
+			},
+			expectedMigrations: []database.Migration{
+				{Version: 1, Name: "create_a"},
+			},
+		},
+		{
+			name:               "No migrations",
+			source:             fstest.MapFS{},
+			expectedMigrations: []database.Migration{},
+		},
+		{
+			name: "Duplicate version",
+			source: fstest.MapFS{
+				"000001_create_a.up.sql": file("CREATE TABLE a ();"),
+				"000001_create_b.up.sql": file("CREATE TABLE b ();"),
+			},
+			expectedErr: "have the same version",
+		},
+		{
+			name: "Missing up file",
+			source: fstest.MapFS{
+				"000001_create_a.up.sql":   file("CREATE TABLE a ();"),
+				"000002_create_b.down.sql": file("DROP TABLE b;"),
+			},
+			expectedErr: "migration 2_create_b has no up file",
+		},
+		{
+			name: "Zero version",
+			source: fstest.MapFS{
+				"000000_create_a.up.sql": file("CREATE TABLE a ();"),
+			},
+			expectedErr: "invalid migration version",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := database.LoadMigrations(tc.source)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedMigrations, migrations)
+		})
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	testCases := []struct {
+		name             string
+		existingFiles    []string
+		migrationName    string
+		expectedBaseName string
+		expectedErr      string
+	}{
+		{
+			name:             "First migration",
+			migrationName:    "create_user",
+			expectedBaseName: "000001_create_user",
+		},
+		{
+			name:             "Version follows the newest migration",
+			existingFiles:    []string{"000001_create_user.up.sql", "000007_create_chat.up.sql", "000007_create_chat.down.sql"},
+			migrationName:    "Add Chat Name",
+			expectedBaseName: "000008_add_chat_name",
+		},
+		{
+			name:          "Invalid name",
+			migrationName: "drop-table",
+			expectedErr:   "migration name must contain only letters, digits and underscores",
+		},
+		{
+			name:          "Invalid existing migrations",
+			existingFiles: []string{"000001_create_user.down.sql"},
+			migrationName: "create_chat",
+			expectedErr:   "has no up file",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tc.existingFiles {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644))
+			}
+
+			upPath, downPath, err := database.CreateMigration(dir, tc.migrationName)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(dir, tc.expectedBaseName+".up.sql"), upPath)
+			require.Equal(t, filepath.Join(dir, tc.expectedBaseName+".down.sql"), downPath)
+
+			//Created files are loaded as the newest migration
+			migrations, err := database.LoadMigrations(os.DirFS(dir))
+			require.NoError(t, err)
+			newest := migrations[len(migrations)-1]
+			require.Equal(t, tc.expectedBaseName, fmt.Sprintf("%06d_%s", newest.Version, newest.Name))
+		})
+	}
+}
+
+func TestCreateMigrationWithoutDirectory(t *testing.T) {
+	_, _, err := database.CreateMigration(filepath.Join(t.TempDir(), "missing"), "create_user")
+	require.ErrorContains(t, err, "does not exist")
+}
@@ -0,0 +1,73 @@
+package health_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	healthHandler "symphony_chat/internal/application/health/http"
+	healthdto "symphony_chat/internal/dto/health"
+	"symphony_chat/internal/service/health"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+type connectionAcceptor struct {
+	accepting bool
+}
+
+func (ca connectionAcceptor) IsAcceptingConnections() bool {
+	return ca.accepting
+}
+
+func TestReadinessReportsDraining(t *testing.T) {
+	healthService, err := health.NewHealthService(
+		health.WithConnectionAcceptor(connectionAcceptor{accepting: true}),
+	)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/readyz", healthHandler.NewHealthHandler(healthService).Readiness)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/readyz", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	//Server still accepts connections, only readiness changes
+	healthService.StartDraining()
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, res.Code)
+
+	var report healthdto.HealthReport
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &report))
+	require.Equal(t, healthdto.StatusDraining, report.Status)
+
+	require.True(t, healthService.Liveness(context.Background()).IsUp())
+}
+
+func TestReadinessDoesNotExposeErrors(t *testing.T) {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=secret_user dbname=secret_db sslmode=disable connect_timeout=1")
+	require.NoError(t, err)
+	defer db.Close()
+
+	healthService, err := health.NewHealthService(
+		health.WithDatabase(db),
+		health.WithExpectedMigrationVersion(1),
+		health.WithConnectionAcceptor(connectionAcceptor{accepting: false}),
+	)
+	require.NoError(t, err)
+
+	report := healthService.Readiness(context.Background())
+	require.Equal(t, healthdto.StatusDown, report.Status)
+
+	require.Equal(t, healthdto.ComponentStatus{Status: healthdto.StatusDown, Details: "database is unreachable"}, report.Components["database"])
+	require.Equal(t, healthdto.ComponentStatus{Status: healthdto.StatusDown, Details: "migration version can't be read"}, report.Components["migrations"])
+	require.Equal(t, healthdto.StatusDown, report.Components["websocket"].Status)
+}
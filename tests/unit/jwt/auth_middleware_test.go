@@ -0,0 +1,141 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"symphony_chat/internal/application/middleware"
+	"symphony_chat/internal/domain/jwt"
+	config "symphony_chat/internal/infrastructure/configs"
+	jwtService "symphony_chat/internal/service/jwt"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	keySet, err := jwt.NewKeySet("current", generateEd25519Key(t, "current"))
+	require.NoError(t, err)
+
+	denylist := jwtService.NewTokenDenylist(newRevokedTokenRepo())
+	service, err := jwtService.NewJWTtokenService(
+		jwtService.WithJWTConfig(config.NewJWTConfig(15, 30)),
+		jwtService.WithKeySet(keySet),
+		jwtService.WithTokenDenylist(denylist),
+	)
+	require.NoError(t, err)
+
+	//Token of other key set has unknown key id
+	otherKeySet, err := jwt.NewKeySet("other", generateEd25519Key(t, "other"))
+	require.NoError(t, err)
+	otherService, err := jwtService.NewJWTtokenService(
+		jwtService.WithJWTConfig(config.NewJWTConfig(15, 30)),
+		jwtService.WithKeySet(otherKeySet),
+	)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	newToken := func(t *testing.T, create func(uuid.UUID, uuid.UUID) (jwt.JWTtoken, error)) string {
+		token, err := create(userID, uuid.New())
+		require.NoError(t, err)
+		return token.GetToken()
+	}
+
+	testCases := []struct {
+		name             string
+		header           func(t *testing.T) string
+		expectedHttpCode int
+		expectedErrCode  string
+	}{
+		{
+			name: "Valid access token",
+			header: func(t *testing.T) string {
+				return "Bearer " + newToken(t, service.GetUpdatedAccessToken)
+			},
+			expectedHttpCode: http.StatusNoContent,
+		},
+		{
+			name: "Header is missing",
+			header: func(t *testing.T) string {
+				return ""
+			},
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  middleware.ErrAccessTokenWasNotProvided.Code,
+		},
+		{
+			name: "Header without bearer scheme",
+			header: func(t *testing.T) string {
+				return newToken(t, service.GetUpdatedAccessToken)
+			},
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  middleware.ErrInvalidAccessTokenFormat.Code,
+		},
+		{
+			name: "Malformed token",
+			header: func(t *testing.T) string {
+				return "Bearer not-a-token"
+			},
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "INVALID_TOKEN_FORMAT",
+		},
+		{
+			name: "Token signed with unknown key",
+			header: func(t *testing.T) string {
+				return "Bearer " + newToken(t, otherService.GetUpdatedAccessToken)
+			},
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "INVALID_TOKEN_FORMAT",
+		},
+		{
+			name: "Refresh token is not accepted",
+			header: func(t *testing.T) string {
+				return "Bearer " + newToken(t, service.GetUpdatedRefreshToken)
+			},
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "WRONG_TOKEN_TYPE",
+		},
+		{
+			name: "Revoked session",
+			header: func(t *testing.T) string {
+				sessionID := uuid.New()
+				token, err := service.GetUpdatedAccessToken(userID, sessionID)
+				require.NoError(t, err)
+				require.NoError(t, denylist.Revoke(context.Background(), sessionID, time.Now().Add(time.Hour)))
+				return "Bearer " + token.GetToken()
+			},
+			expectedHttpCode: http.StatusUnauthorized,
+			expectedErrCode:  "TOKEN_REVOKED",
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/me", middleware.AuthMiddleware(service), func(ctx *gin.Context) {
+		require.Equal(t, userID, ctx.MustGet("user_id"))
+		ctx.Status(http.StatusNoContent)
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if header := tc.header(t); header != "" {
+				req.Header.Set("Authorization", header)
+			}
+
+			res := httptest.NewRecorder()
+			require.NotPanics(t, func() { router.ServeHTTP(res, req) })
+			require.Equal(t, tc.expectedHttpCode, res.Code, res.Body.String())
+
+			if tc.expectedErrCode != "" {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+				require.Equal(t, tc.expectedErrCode, response["code"])
+			}
+		})
+	}
+}
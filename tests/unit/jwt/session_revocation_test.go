@@ -0,0 +1,116 @@
+package jwt_test
+
+import (
+	"context"
+	"symphony_chat/internal/domain/jwt"
+	config "symphony_chat/internal/infrastructure/configs"
+	jwtService "symphony_chat/internal/service/jwt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// In-memory revoked token repository
+type revokedTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]time.Time
+}
+
+func newRevokedTokenRepo() *revokedTokenRepo {
+	return &revokedTokenRepo{tokens: make(map[uuid.UUID]time.Time)}
+}
+
+func (r *revokedTokenRepo) AddRevokedToken(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (r *revokedTokenRepo) GetRevokedTokens(ctx context.Context, now time.Time) (map[uuid.UUID]time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tokens := make(map[uuid.UUID]time.Time)
+	for tokenID, expiresAt := range r.tokens {
+		if expiresAt.After(now) {
+			tokens[tokenID] = expiresAt
+		}
+	}
+	return tokens, nil
+}
+
+func (r *revokedTokenRepo) DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for tokenID, expiresAt := range r.tokens {
+		if !expiresAt.After(now) {
+			delete(r.tokens, tokenID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func TestRevokeSessionAccessTokens(t *testing.T) {
+	keySet, err := jwt.NewKeySet("current", generateEd25519Key(t, "current"))
+	require.NoError(t, err)
+
+	repo := newRevokedTokenRepo()
+	denylist := jwtService.NewTokenDenylist(repo)
+
+	var notifiedIDs []uuid.UUID
+	denylist.OnRevoke(func(revokedID uuid.UUID) {
+		notifiedIDs = append(notifiedIDs, revokedID)
+	})
+
+	const accessTTLinMinutes = 15
+	service, err := jwtService.NewJWTtokenService(
+		jwtService.WithJWTConfig(config.NewJWTConfig(accessTTLinMinutes, 30)),
+		jwtService.WithKeySet(keySet),
+		jwtService.WithTokenDenylist(denylist),
+	)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	revokedSessionID, otherSessionID := uuid.New(), uuid.New()
+
+	firstToken, err := service.GetUpdatedAccessToken(userID, revokedSessionID)
+	require.NoError(t, err)
+	secondToken, err := service.GetUpdatedAccessToken(userID, revokedSessionID)
+	require.NoError(t, err)
+	otherToken, err := service.GetUpdatedAccessToken(userID, otherSessionID)
+	require.NoError(t, err)
+
+	require.NoError(t, service.RevokeSessionAccessTokens(context.Background(), revokedSessionID))
+
+	for _, token := range []jwt.JWTtoken{firstToken, secondToken} {
+		_, err := service.ParseAccessTokenClaims(token.GetToken())
+		assert.ErrorIs(t, err, jwt.ErrTokenRevoked)
+	}
+
+	claims, err := service.ParseAccessTokenClaims(otherToken.GetToken())
+	require.NoError(t, err)
+	assert.Equal(t, otherSessionID, claims.SessionID)
+
+	//Listener closes websocket connections of the session
+	assert.Equal(t, []uuid.UUID{revokedSessionID}, notifiedIDs)
+
+	//Session is kept in denylist until the last access token of the session expires
+	expiresAt, stored := repo.tokens[revokedSessionID]
+	require.True(t, stored)
+	assert.WithinDuration(t, time.Now().Add(accessTTLinMinutes*time.Minute), expiresAt, time.Minute)
+
+	//Other instances get revoked session from database
+	otherInstanceDenylist := jwtService.NewTokenDenylist(repo)
+	require.NoError(t, otherInstanceDenylist.Sync(context.Background()))
+	assert.True(t, otherInstanceDenylist.IsRevoked(revokedSessionID))
+	assert.False(t, otherInstanceDenylist.IsRevoked(otherSessionID))
+}
@@ -0,0 +1,306 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	authHandlers "symphony_chat/internal/application/auth/http"
+	publicDto "symphony_chat/internal/application/dto"
+	"symphony_chat/internal/domain/jwt"
+	config "symphony_chat/internal/infrastructure/configs"
+	jwtKeys "symphony_chat/internal/infrastructure/jwt/keys"
+	jwtService "symphony_chat/internal/service/jwt"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	JWT "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	_, currentKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "current.pem"), "PRIVATE KEY", mustMarshalPKCS8(t, currentKey))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "rsa.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	retiredPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	retiredDER, err := x509.MarshalPKIXPublicKey(retiredPublicKey)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "retired.pub.pem"), "PUBLIC KEY", retiredDER)
+
+	testCases := []struct {
+		name         string
+		signingKeyID string
+		expectedErr  error
+		expectedAlg  string
+	}{
+		{
+			name:         "Ed25519 signing key",
+			signingKeyID: "current",
+			expectedAlg:  "EdDSA",
+		},
+		{
+			name:         "RSA signing key",
+			signingKeyID: "rsa",
+			expectedAlg:  "RS256",
+		},
+		{
+			name:         "Public key can't sign",
+			signingKeyID: "retired",
+			expectedErr:  jwt.ErrSigningKeyNotFound,
+		},
+		{
+			name:         "Unknown signing key",
+			signingKeyID: "missing",
+			expectedErr:  jwt.ErrSigningKeyNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keySet, err := jwtKeys.LoadKeySet(dir, tc.signingKeyID)
+
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.signingKeyID, keySet.GetSigningKey().GetID())
+			assert.Equal(t, tc.expectedAlg, keySet.GetSigningKey().GetMethod().Alg())
+
+			var keyIDs []string
+			for _, key := range keySet.GetVerificationKeys() {
+				keyIDs = append(keyIDs, key.GetID())
+			}
+			assert.Equal(t, []string{"current", "retired", "rsa"}, keyIDs)
+
+			retiredKey, exists := keySet.GetVerificationKey("retired")
+			require.True(t, exists)
+			assert.False(t, retiredKey.CanSign())
+		})
+	}
+}
+
+func TestJWKSHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	currentKey := generateEd25519Key(t, "current")
+
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	retiredKey, err := jwt.NewVerificationKey("retired", &rsaPrivateKey.PublicKey)
+	require.NoError(t, err)
+
+	keySet, err := jwt.NewKeySet("current", currentKey, retiredKey)
+	require.NoError(t, err)
+	service := newJWTService(t, keySet)
+
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", authHandlers.NewJWKSHandler(service).GetJWKS)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "public, max-age=300", res.Header().Get("Cache-Control"))
+
+	//Private parts of keys must never be published
+	var rawKeys struct {
+		Keys []map[string]any `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &rawKeys))
+	for _, rawKey := range rawKeys.Keys {
+		for _, privateField := range []string{"d", "p", "q", "dp", "dq", "qi"} {
+			assert.NotContains(t, rawKey, privateField)
+		}
+	}
+
+	var jwks publicDto.JWKSDTO
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 2)
+
+	current, retired := jwks.Keys[0], jwks.Keys[1]
+
+	assert.Equal(t, "current", current.Kid)
+	assert.Equal(t, "OKP", current.Kty)
+	assert.Equal(t, "Ed25519", current.Crv)
+	assert.Equal(t, "EdDSA", current.Alg)
+	assert.Equal(t, "sig", current.Use)
+	assert.NotEmpty(t, current.X)
+	assert.Empty(t, current.N)
+
+	assert.Equal(t, "retired", retired.Kid)
+	assert.Equal(t, "RSA", retired.Kty)
+	assert.Equal(t, "RS256", retired.Alg)
+	assert.Equal(t, "sig", retired.Use)
+	assert.Equal(t, "AQAB", retired.E)
+	assert.NotEmpty(t, retired.N)
+	assert.Empty(t, retired.X)
+}
+
+func TestTokenVerificationKeyIsSelectedByKid(t *testing.T) {
+	oldKey := generateEd25519Key(t, "old")
+	newKey := generateEd25519Key(t, "new")
+
+	oldKeySet, err := jwt.NewKeySet("old", oldKey)
+	require.NoError(t, err)
+	oldService := newJWTService(t, oldKeySet)
+
+	//After rotation old key only verifies tokens that were signed before
+	oldVerificationKey, err := jwt.NewVerificationKey("old", oldKey.GetPublicKey())
+	require.NoError(t, err)
+	rotatedKeySet, err := jwt.NewKeySet("new", newKey, oldVerificationKey)
+	require.NoError(t, err)
+	rotatedService := newJWTService(t, rotatedKeySet)
+
+	newOnlyKeySet, err := jwt.NewKeySet("new", newKey)
+	require.NoError(t, err)
+	newOnlyService := newJWTService(t, newOnlyKeySet)
+
+	userID, sessionID := uuid.New(), uuid.New()
+
+	oldToken, err := oldService.GetUpdatedAccessToken(userID, sessionID)
+	require.NoError(t, err)
+	newToken, err := rotatedService.GetUpdatedAccessToken(userID, sessionID)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name            string
+		service         *jwtService.JWTtokenService
+		token           func(t *testing.T) string
+		expectedErrCode string
+	}{
+		{
+			name:    "Token of the old key is verified after rotation",
+			service: rotatedService,
+			token:   func(t *testing.T) string { return oldToken.GetToken() },
+		},
+		{
+			name:    "New tokens are signed with the new key",
+			service: newOnlyService,
+			token: func(t *testing.T) string {
+				assert.Equal(t, "new", tokenKeyID(t, newToken.GetToken()))
+				return newToken.GetToken()
+			},
+		},
+		{
+			name:            "Key of the token was removed",
+			service:         newOnlyService,
+			token:           func(t *testing.T) string { return oldToken.GetToken() },
+			expectedErrCode: "UNKNOWN_TOKEN_KEY_ID",
+		},
+		{
+			name:    "Token without kid",
+			service: rotatedService,
+			token: func(t *testing.T) string {
+				_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+				require.NoError(t, err)
+				return signToken(t, JWT.SigningMethodEdDSA, "", privateKey)
+			},
+			expectedErrCode: "KID_HEADER_WAS_NOT_PROVIDED_IN_TOKEN",
+		},
+		{
+			name:    "Kid of the other key",
+			service: rotatedService,
+			token: func(t *testing.T) string {
+				_, otherPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+				require.NoError(t, err)
+				return signToken(t, JWT.SigningMethodEdDSA, "new", otherPrivateKey)
+			},
+			expectedErrCode: "INVALID_TOKEN_SIGNATURE",
+		},
+		{
+			name:    "Algorithm that differs from algorithm of the key",
+			service: rotatedService,
+			token: func(t *testing.T) string {
+				return signToken(t, JWT.SigningMethodHS256, "new", []byte("secret"))
+			},
+			expectedErrCode: "INVALID_TOKEN_SIGNING_METHOD",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims, err := tc.service.ParseAccessTokenClaims(tc.token(t))
+
+			if tc.expectedErrCode != "" {
+				var tokenErr *jwt.TokenError
+				require.True(t, errors.As(err, &tokenErr))
+				assert.Equal(t, tc.expectedErrCode, tokenErr.Code)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, userID, claims.UserID)
+			assert.Equal(t, sessionID, claims.SessionID)
+		})
+	}
+}
+
+func newJWTService(t *testing.T, keySet jwt.KeySet) *jwtService.JWTtokenService {
+	service, err := jwtService.NewJWTtokenService(
+		jwtService.WithJWTConfig(config.NewJWTConfig(15, 30)),
+		jwtService.WithKeySet(keySet),
+	)
+	require.NoError(t, err)
+	return service
+}
+
+func generateEd25519Key(t *testing.T, id string) jwt.SigningKey {
+	key, err := jwt.GenerateSigningKey(id)
+	require.NoError(t, err)
+	return key
+}
+
+func signToken(t *testing.T, method JWT.SigningMethod, keyID string, key any) string {
+	token := JWT.NewWithClaims(method, JWT.MapClaims{
+		"sub": uuid.NewString(),
+		"sid": uuid.NewString(),
+		"jti": uuid.NewString(),
+		"typ": string(jwt.AccessTokenType),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	if keyID != "" {
+		token.Header["kid"] = keyID
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func tokenKeyID(t *testing.T, tokenString string) string {
+	token, _, err := JWT.NewParser().ParseUnverified(tokenString, JWT.MapClaims{})
+	require.NoError(t, err)
+
+	keyID, _ := token.Header["kid"].(string)
+	return keyID
+}
+
+func mustMarshalPKCS8(t *testing.T, key any) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return der
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
@@ -0,0 +1,137 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"symphony_chat/internal/infrastructure/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Function logs one record with ctx and returns its attributes
+func logRecord(t *testing.T, ctx context.Context, loggerAttrs ...any) map[string]any {
+	var buf bytes.Buffer
+	logger := slog.New(logging.ContextHandler{Handler: slog.NewJSONHandler(&buf, nil)}).With(loggerAttrs...)
+
+	logger.InfoContext(ctx, "test message")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	return record
+}
+
+func TestWithAttrs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ctx      func() context.Context
+		expected map[string]any
+		missing  []string
+	}{
+		{
+			name: "Context without attributes",
+			ctx: func() context.Context {
+				return context.Background()
+			},
+			missing: []string{logging.RequestIDKey, logging.ChatIDKey},
+		},
+		{
+			name: "Attributes are added to record",
+			ctx: func() context.Context {
+				return logging.WithAttrs(context.Background(),
+					slog.String(logging.RequestIDKey, "request-1"),
+					slog.String(logging.UserIDKey, "user-1"),
+				)
+			},
+			expected: map[string]any{
+				logging.RequestIDKey: "request-1",
+				logging.UserIDKey:    "user-1",
+			},
+		},
+		{
+			name: "Attributes of parent context are kept",
+			ctx: func() context.Context {
+				ctx := logging.WithAttrs(context.Background(), slog.String(logging.ConnectionIDKey, "connection-1"))
+				return logging.WithAttrs(ctx, slog.String(logging.UserIDKey, "user-1"))
+			},
+			expected: map[string]any{
+				logging.ConnectionIDKey: "connection-1",
+				logging.UserIDKey:       "user-1",
+			},
+		},
+		{
+			name: "Attribute with the same key is replaced",
+			ctx: func() context.Context {
+				ctx := logging.WithAttrs(context.Background(),
+					slog.String(logging.ConnectionIDKey, "connection-1"),
+					slog.String(logging.ChatIDKey, "chat-1"),
+				)
+				return logging.WithAttrs(ctx, slog.String(logging.ChatIDKey, "chat-2"))
+			},
+			expected: map[string]any{
+				logging.ConnectionIDKey: "connection-1",
+				logging.ChatIDKey:       "chat-2",
+			},
+		},
+		{
+			name: "Span that is not sampled is not logged",
+			ctx: func() context.Context {
+				return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: trace.TraceID{1},
+					SpanID:  trace.SpanID{2},
+				}))
+			},
+			missing: []string{logging.TraceIDKey, logging.SpanIDKey},
+		},
+		{
+			name: "Ids of sampled span are logged",
+			ctx: func() context.Context {
+				return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID:    trace.TraceID{1},
+					SpanID:     trace.SpanID{2},
+					TraceFlags: trace.FlagsSampled,
+				}))
+			},
+			expected: map[string]any{
+				logging.TraceIDKey: trace.TraceID{1}.String(),
+				logging.SpanIDKey:  trace.SpanID{2}.String(),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := logRecord(t, tc.ctx())
+
+			for key, value := range tc.expected {
+				assert.Equal(t, value, record[key], key)
+			}
+			for _, key := range tc.missing {
+				assert.NotContains(t, record, key)
+			}
+		})
+	}
+}
+
+func TestWithAttrsDoesNotChangeParentContext(t *testing.T) {
+	parent := logging.WithAttrs(context.Background(), slog.String(logging.ChatIDKey, "chat-1"))
+	logging.WithAttrs(parent, slog.String(logging.ChatIDKey, "chat-2"), slog.String(logging.UserIDKey, "user-1"))
+
+	record := logRecord(t, parent)
+
+	assert.Equal(t, "chat-1", record[logging.ChatIDKey])
+	assert.NotContains(t, record, logging.UserIDKey)
+}
+
+func TestContextHandlerKeepsLoggerAttrs(t *testing.T) {
+	ctx := logging.WithAttrs(context.Background(), slog.String(logging.RequestIDKey, "request-1"))
+
+	record := logRecord(t, ctx, "component", "chathub")
+
+	assert.Equal(t, "chathub", record["component"])
+	assert.Equal(t, "request-1", record[logging.RequestIDKey])
+}
@@ -0,0 +1,109 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"symphony_chat/internal/application/middleware"
+	"symphony_chat/internal/infrastructure/logging"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingMiddlewareRequestID(t *testing.T) {
+	testCases := []struct {
+		name           string
+		header         string
+		expectedHeader string
+		isGenerated    bool
+	}{
+		{
+			name:        "Request without id",
+			isGenerated: true,
+		},
+		{
+			name:           "Valid id of proxy is kept",
+			header:         "proxy-1.request_A",
+			expectedHeader: "proxy-1.request_A",
+		},
+		{
+			name:           "Id of 64 characters is kept",
+			header:         strings.Repeat("a", 64),
+			expectedHeader: strings.Repeat("a", 64),
+		},
+		{
+			name:        "Too long id is replaced",
+			header:      strings.Repeat("a", 65),
+			isGenerated: true,
+		},
+		{
+			name:        "Id with spaces is replaced",
+			header:      "request 1",
+			isGenerated: true,
+		},
+		{
+			name:        "Id with characters that break logs is replaced",
+			header:      `request"1\n`,
+			isGenerated: true,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.SetDefault(slog.New(logging.ContextHandler{Handler: slog.NewJSONHandler(&buf, nil)}))
+
+			var handlerRequestID any
+			r := gin.New()
+			r.Use(middleware.LoggingMiddleware())
+			r.GET("/ping", func(ctx *gin.Context) {
+				var handlerBuf bytes.Buffer
+				logger := slog.New(logging.ContextHandler{Handler: slog.NewJSONHandler(&handlerBuf, nil)})
+				logger.InfoContext(ctx.Request.Context(), "handler")
+
+				var record map[string]any
+				require.NoError(t, json.Unmarshal(handlerBuf.Bytes(), &record))
+				handlerRequestID = record[logging.RequestIDKey]
+
+				ctx.Status(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tc.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tc.header)
+			}
+			res := httptest.NewRecorder()
+			r.ServeHTTP(res, req)
+
+			requestID := res.Header().Get(middleware.RequestIDHeader)
+			if tc.isGenerated {
+				_, err := uuid.Parse(requestID)
+				assert.NoError(t, err, "generated request id must be uuid")
+			} else {
+				assert.Equal(t, tc.expectedHeader, requestID)
+			}
+
+			//Id of the header is the one that handler and request log see
+			assert.Equal(t, requestID, handlerRequestID)
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, "http request", record["msg"])
+			assert.Equal(t, requestID, record[logging.RequestIDKey])
+			assert.Equal(t, "/ping", record["route"])
+			assert.EqualValues(t, http.StatusNoContent, record["status"])
+		})
+	}
+}
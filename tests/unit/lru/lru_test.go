@@ -0,0 +1,149 @@
+package lru_test
+
+import (
+	"symphony_chat/utils/lru"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+//clock is moved by tests instead of waiting for ttl
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newCache(capacity int, ttl time.Duration) (*lru.Cache[string, int], *clock, *[]string) {
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var evictions []string
+
+	cache := lru.NewCache(capacity, ttl,
+		lru.WithClock[string, int](c.Now),
+		lru.WithEvictionCallback[string, int](func(reason string) {
+			evictions = append(evictions, reason)
+		}),
+	)
+
+	return cache, c, &evictions
+}
+
+func set(cache *lru.Cache[string, int], key string, value int) bool {
+	return cache.SetIfGeneration(key, value, cache.Generation())
+}
+
+func TestLeastRecentlyUsedEntryIsEvicted(t *testing.T) {
+	cache, _, evictions := newCache(2, time.Hour)
+
+	require.True(t, set(cache, "a", 1))
+	require.True(t, set(cache, "b", 2))
+
+	//Reading makes "a" recently used, so "b" is evicted by the third entry
+	_, found := cache.Get("a")
+	require.True(t, found)
+	require.True(t, set(cache, "c", 3))
+
+	_, found = cache.Get("b")
+	require.False(t, found)
+
+	value, found := cache.Get("a")
+	require.True(t, found)
+	require.Equal(t, 1, value)
+
+	require.Equal(t, 2, cache.Len())
+	require.Equal(t, []string{lru.EvictionReasonCapacity}, *evictions)
+}
+
+func TestUpdatedEntryIsNotEvicted(t *testing.T) {
+	cache, _, _ := newCache(2, time.Hour)
+
+	require.True(t, set(cache, "a", 1))
+	require.True(t, set(cache, "b", 2))
+	require.True(t, set(cache, "a", 10))
+	require.True(t, set(cache, "c", 3))
+
+	value, found := cache.Get("a")
+	require.True(t, found)
+	require.Equal(t, 10, value)
+
+	_, found = cache.Get("b")
+	require.False(t, found)
+}
+
+func TestExpiredEntryIsNotReturned(t *testing.T) {
+	cache, c, evictions := newCache(10, time.Minute)
+
+	require.True(t, set(cache, "a", 1))
+
+	c.now = c.now.Add(time.Minute - time.Second)
+	_, found := cache.Get("a")
+	require.True(t, found)
+
+	//Reading doesn't extend ttl
+	c.now = c.now.Add(time.Second)
+	_, found = cache.Get("a")
+	require.False(t, found)
+
+	require.Equal(t, 0, cache.Len())
+	require.Equal(t, []string{lru.EvictionReasonExpired}, *evictions)
+
+	//Value stored again gets new ttl
+	require.True(t, set(cache, "a", 2))
+	value, found := cache.Get("a")
+	require.True(t, found)
+	require.Equal(t, 2, value)
+}
+
+func TestValueLoadedBeforeDeleteIsNotStored(t *testing.T) {
+	testCases := []struct {
+		name   string
+		delete func(cache *lru.Cache[string, int])
+	}{
+		{
+			name: "Delete of the same key",
+			delete: func(cache *lru.Cache[string, int]) {
+				cache.Delete("a")
+			},
+		},
+		{
+			name: "Delete of other key",
+			delete: func(cache *lru.Cache[string, int]) {
+				cache.Delete("b")
+			},
+		},
+		{
+			name: "Delete by function",
+			delete: func(cache *lru.Cache[string, int]) {
+				require.Equal(t, 1, cache.DeleteFunc(func(key string) bool { return key == "c" }))
+			},
+		},
+		{
+			name: "Clear",
+			delete: func(cache *lru.Cache[string, int]) {
+				cache.Clear()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, _, _ := newCache(10, time.Hour)
+			require.True(t, set(cache, "c", 3))
+
+			generation := cache.Generation()
+			tc.delete(cache)
+
+			require.False(t, cache.SetIfGeneration("a", 1, generation))
+			_, found := cache.Get("a")
+			require.False(t, found)
+
+			//Value loaded after delete is stored
+			require.True(t, cache.SetIfGeneration("a", 1, cache.Generation()))
+			_, found = cache.Get("a")
+			require.True(t, found)
+		})
+	}
+}
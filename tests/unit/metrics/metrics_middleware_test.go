@@ -0,0 +1,73 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"symphony_chat/internal/application/middleware"
+	"symphony_chat/internal/infrastructure/metrics"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+//Function returns metrics in text format, as Prometheus scrapes them
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	res := httptest.NewRecorder()
+	m.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	return res.Body.String()
+}
+
+func TestMetricsMiddlewareLabels(t *testing.T) {
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		expectedLabel string
+	}{
+		{
+			name:          "Route pattern is used instead of path",
+			method:        http.MethodGet,
+			path:          "/users/alice",
+			expectedLabel: `method="GET",route="/users/:username",status="200"`,
+		},
+		{
+			name:          "Request that didn't match any route",
+			method:        http.MethodGet,
+			path:          "/unknown/path",
+			expectedLabel: `method="GET",route="unmatched",status="404"`,
+		},
+		{
+			name:          "Standard method",
+			method:        http.MethodPatch,
+			path:          "/users/alice",
+			expectedLabel: `method="PATCH",route="/users/:username",status="204"`,
+		},
+		{
+			name:          "Non-standard method",
+			method:        "PROPFIND",
+			path:          "/users/alice",
+			expectedLabel: `method="OTHER",route="unmatched",status="404"`,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := metrics.NewMetrics()
+
+			router := gin.New()
+			router.Use(middleware.MetricsMiddleware(m))
+			router.GET("/users/:username", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.PATCH("/users/:username", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+
+			body := scrape(t, m)
+			require.Contains(t, body, "symphony_chat_http_requests_total{"+tc.expectedLabel+"} 1")
+			require.NotContains(t, body, "alice")
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package notifications_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"symphony_chat/internal/domain/notifications"
+	localNotifier "symphony_chat/internal/infrastructure/notifications/local"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const resetToken = "secret-reset-token"
+
+//Function sends notification with reset token through LogNotifier and returns logged record
+func logNotification(t *testing.T, logBodies bool) (map[string]any, string) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+	})
+
+	err := localNotifier.NewLogNotifier(logBodies).Notify(context.Background(), notifications.Notification{
+		Recipient: "user@example.com",
+		Subject: "Password reset",
+		Body: "Your password reset token: " + resetToken,
+	})
+	require.NoError(t, err)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	return record, buf.String()
+}
+
+func TestLogNotifier(t *testing.T) {
+	t.Run("Body is redacted by default", func(t *testing.T) {
+		record, raw := logNotification(t, false)
+
+		require.NotContains(t, raw, resetToken)
+		require.Equal(t, "[REDACTED]", record["body"])
+		require.Equal(t, "user@example.com", record["recipient"])
+		require.Equal(t, "Password reset", record["subject"])
+	})
+
+	t.Run("Body is logged when it is enabled for development", func(t *testing.T) {
+		record, _ := logNotification(t, true)
+
+		require.Equal(t, "Your password reset token: "+resetToken, record["body"])
+	})
+}
@@ -0,0 +1,160 @@
+package rolesync_test
+
+import (
+	"context"
+	"symphony_chat/internal/domain/roles"
+	rolesync "symphony_chat/internal/service/role_sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+//chatRoleRepo keeps roles in memory and remembers saved ones
+type chatRoleRepo struct {
+	roles []roles.ChatRole
+	saved []roles.ChatRole
+}
+
+func (cr *chatRoleRepo) GetChatRoleByID(ctx context.Context, id uuid.UUID) (roles.ChatRole, error) {
+	for _, role := range cr.roles {
+		if role.GetID() == id {
+			return role, nil
+		}
+	}
+	return roles.ChatRole{}, roles.ErrChatRoleNotFound
+}
+
+func (cr *chatRoleRepo) GetChatRoleByName(ctx context.Context, name string) (roles.ChatRole, error) {
+	for _, role := range cr.roles {
+		if role.GetName() == name {
+			return role, nil
+		}
+	}
+	return roles.ChatRole{}, roles.ErrChatRoleNotFound
+}
+
+func (cr *chatRoleRepo) GetChatRoles(ctx context.Context) ([]roles.ChatRole, error) {
+	return cr.roles, nil
+}
+
+func (cr *chatRoleRepo) SaveChatRole(ctx context.Context, role roles.ChatRole) error {
+	cr.saved = append(cr.saved, role)
+	return nil
+}
+
+type transactionManager struct{}
+
+func (transactionManager) WithinTransaction(ctx context.Context, txFunc func(txCtx context.Context) error) error {
+	return txFunc(ctx)
+}
+
+func storedCopy(role roles.ChatRole) roles.ChatRole {
+	return roles.ChatRoleFromDB(role.GetID(), role.GetName(), role.GetPermissions())
+}
+
+func TestCheckDrift(t *testing.T) {
+	owner, admin, member := storedCopy(roles.OwnerChatRole), storedCopy(roles.AdminChatRole), storedCopy(roles.MemberChatRole)
+	unknownRoleID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		storedRoles   []roles.ChatRole
+		expectedDrift []rolesync.RoleDrift
+	}{
+		{
+			name:          "No drift",
+			storedRoles:   []roles.ChatRole{owner, admin, member},
+			expectedDrift: nil,
+		},
+		{
+			name:        "Missing role",
+			storedRoles: []roles.ChatRole{owner, member},
+			expectedDrift: []rolesync.RoleDrift{
+				{RoleID: admin.GetID(), RoleName: admin.GetName(), Missing: true},
+			},
+		},
+		{
+			name: "Renamed role",
+			storedRoles: []roles.ChatRole{
+				owner,
+				admin,
+				roles.ChatRoleFromDB(member.GetID(), "PARTICIPANT", member.GetPermissions()),
+			},
+			expectedDrift: []rolesync.RoleDrift{
+				{RoleID: member.GetID(), RoleName: member.GetName(), StoredName: "PARTICIPANT"},
+			},
+		},
+		{
+			name: "Missing and extra permissions",
+			storedRoles: []roles.ChatRole{
+				owner,
+				admin,
+				roles.ChatRoleFromDB(member.GetID(), member.GetName(), []roles.Permission{
+					roles.PermissionAddMember,
+					roles.PermissionUpdateChatName,
+					roles.PermissionAddMessage,
+					roles.PermissionDeleteMessage,
+					roles.PermissionDeleteChat,
+				}),
+			},
+			expectedDrift: []rolesync.RoleDrift{
+				{
+					RoleID:             member.GetID(),
+					RoleName:           member.GetName(),
+					MissingPermissions: []roles.Permission{roles.PermissionEditMessage},
+					ExtraPermissions:   []roles.Permission{roles.PermissionDeleteChat},
+				},
+			},
+		},
+		{
+			name: "Unknown role",
+			storedRoles: []roles.ChatRole{
+				owner,
+				admin,
+				member,
+				roles.ChatRoleFromDB(unknownRoleID, "MODERATOR", []roles.Permission{roles.PermissionDeleteMessage}),
+			},
+			expectedDrift: []rolesync.RoleDrift{
+				{RoleID: unknownRoleID, RoleName: "MODERATOR", Unknown: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &chatRoleRepo{roles: tc.storedRoles}
+			roleSyncService, err := rolesync.NewRoleSyncService(
+				rolesync.WithChatRoleRepository(repo),
+				rolesync.WithTransactionManager(transactionManager{}),
+			)
+			require.NoError(t, err)
+
+			drift, err := roleSyncService.CheckDrift(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDrift, drift)
+			require.Empty(t, repo.saved)
+		})
+	}
+}
+
+func TestSyncSavesBuiltInRolesOnly(t *testing.T) {
+	unknownRole := roles.ChatRoleFromDB(uuid.New(), "MODERATOR", nil)
+	repo := &chatRoleRepo{roles: []roles.ChatRole{
+		storedCopy(roles.OwnerChatRole),
+		roles.ChatRoleFromDB(roles.AdminChatRole.GetID(), roles.AdminChatRole.GetName(), nil),
+		unknownRole,
+	}}
+
+	roleSyncService, err := rolesync.NewRoleSyncService(
+		rolesync.WithChatRoleRepository(repo),
+		rolesync.WithTransactionManager(transactionManager{}),
+	)
+	require.NoError(t, err)
+
+	drift, err := roleSyncService.Sync(context.Background())
+	require.NoError(t, err)
+	require.Len(t, drift, 3)
+
+	require.Equal(t, []roles.ChatRole{roles.AdminChatRole, roles.MemberChatRole}, repo.saved)
+}
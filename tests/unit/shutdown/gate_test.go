@@ -0,0 +1,60 @@
+package shutdown_test
+
+import (
+	"context"
+	"symphony_chat/utils/shutdown"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateRejectsOperationsAfterClose(t *testing.T) {
+	var gate shutdown.Gate
+	require.False(t, gate.IsClosed())
+
+	require.NoError(t, gate.Close(context.Background()))
+
+	require.True(t, gate.IsClosed())
+	require.False(t, gate.Enter())
+}
+
+func TestGateCloseWaitsForActiveOperations(t *testing.T) {
+	var gate shutdown.Gate
+	require.True(t, gate.Enter())
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- gate.Close(context.Background())
+	}()
+
+	//New operations are rejected while started one is still running
+	require.Eventually(t, gate.IsClosed, time.Second, time.Millisecond)
+	require.False(t, gate.Enter())
+
+	select {
+	case <-closed:
+		t.Fatal("gate was closed before active operation finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	gate.Leave()
+
+	select {
+	case err := <-closed:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("gate wasn't closed after active operation finished")
+	}
+}
+
+func TestGateCloseStopsWaitingWhenContextIsDone(t *testing.T) {
+	var gate shutdown.Gate
+	require.True(t, gate.Enter())
+	defer gate.Leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, gate.Close(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,186 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	config "symphony_chat/internal/infrastructure/configs"
+	"symphony_chat/internal/infrastructure/tracing"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+//Function restores global tracer provider and propagator that Setup replaces
+func restoreGlobals(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func newTracingConfig(exporter string, file string) config.TracingConfig {
+	return config.TracingConfig{
+		Exporter:     exporter,
+		ServiceName:  "symphony_chat_test",
+		File:         file,
+		OTLPEndpoint: "localhost:1",
+		OTLPInsecure: true,
+		SampleRatio:  1,
+	}
+}
+
+func TestSetupExporterSelection(t *testing.T) {
+	testCases := []struct {
+		name          string
+		exporter      string
+		file          func(t *testing.T) string
+		expectedErr   string
+		isProviderSet bool
+		//Spans of network exporters are not created, collector isn't running in tests
+		isCollector      bool
+		expectedInOutput []string
+	}{
+		{
+			name:     "None exporter doesn't record spans",
+			exporter: config.TracingExporterNone,
+		},
+		{
+			name:     "Stdout exporter writes to file",
+			exporter: config.TracingExporterStdout,
+			file: func(t *testing.T) string {
+				return filepath.Join(t.TempDir(), "traces.json")
+			},
+			isProviderSet:    true,
+			expectedInOutput: []string{`"Name":"test span"`, "symphony_chat_test"},
+		},
+		{
+			name:     "Otlp file exporter writes OTLP JSON",
+			exporter: config.TracingExporterOTLPFile,
+			file: func(t *testing.T) string {
+				return filepath.Join(t.TempDir(), "traces.jsonl")
+			},
+			isProviderSet:    true,
+			expectedInOutput: []string{`"resourceSpans"`, `"name":"test span"`, "symphony_chat_test"},
+		},
+		{
+			name:          "Otlp grpc exporter",
+			exporter:      config.TracingExporterOTLPGRPC,
+			isProviderSet: true,
+			isCollector:   true,
+		},
+		{
+			name:          "Otlp http exporter",
+			exporter:      config.TracingExporterOTLPHTTP,
+			isProviderSet: true,
+			isCollector:   true,
+		},
+		{
+			name:     "Trace file can't be opened",
+			exporter: config.TracingExporterOTLPFile,
+			file: func(t *testing.T) string {
+				return filepath.Join(t.TempDir(), "missing", "traces.jsonl")
+			},
+			expectedErr: "failed to open trace file",
+		},
+		{
+			name:        "Unknown exporter",
+			exporter:    "zipkin",
+			expectedErr: `unknown trace exporter "zipkin"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			restoreGlobals(t)
+			previousProvider := otel.GetTracerProvider()
+
+			var file string
+			if tc.file != nil {
+				file = tc.file(t)
+			}
+
+			ctx := context.Background()
+			shutdown, err := tracing.Setup(ctx, newTracingConfig(tc.exporter, file))
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				assert.Equal(t, previousProvider, otel.GetTracerProvider())
+				return
+			}
+			require.NoError(t, err)
+
+			//Propagator is set even if spans are not recorded
+			assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+
+			_, isSDKProvider := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+			assert.Equal(t, tc.isProviderSet, isSDKProvider)
+
+			if !tc.isCollector {
+				_, span := tracing.Start(ctx, "test span")
+				assert.Equal(t, tc.isProviderSet, span.SpanContext().IsSampled())
+				span.End()
+			}
+
+			require.NoError(t, shutdown(ctx))
+
+			if len(tc.expectedInOutput) == 0 {
+				return
+			}
+
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			output := string(data)
+			for _, expected := range tc.expectedInOutput {
+				assert.Contains(t, output, expected)
+			}
+		})
+	}
+}
+
+func TestOTLPFileExporterUsesHexIds(t *testing.T) {
+	restoreGlobals(t)
+
+	file := filepath.Join(t.TempDir(), "traces.jsonl")
+	ctx := context.Background()
+	shutdown, err := tracing.Setup(ctx, newTracingConfig(config.TracingExporterOTLPFile, file))
+	require.NoError(t, err)
+
+	_, span := tracing.Start(ctx, "test span")
+	spanContext := span.SpanContext()
+	span.End()
+	require.NoError(t, shutdown(ctx))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID string `json:"traceId"`
+					SpanID  string `json:"spanId"`
+					Name    string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &request))
+	require.Len(t, request.ResourceSpans, 1)
+	require.Len(t, request.ResourceSpans[0].ScopeSpans, 1)
+	require.Len(t, request.ResourceSpans[0].ScopeSpans[0].Spans, 1)
+
+	exported := request.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, "test span", exported.Name)
+	assert.Equal(t, spanContext.TraceID().String(), exported.TraceID)
+	assert.Equal(t, spanContext.SpanID().String(), exported.SpanID)
+}
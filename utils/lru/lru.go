@@ -0,0 +1,175 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	EvictionReasonCapacity = "capacity"
+	EvictionReasonExpired  = "expired"
+)
+
+//Cache keeps at most capacity entries, the least recently used entry is evicted when it is full
+//Entries that are older than ttl are not returned
+//
+//Every Delete increases generation of the cache, so value that was loaded before the delete
+//is not stored by SetIfGeneration, even if loading finished after it
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[K]*list.Element
+	//Front is the most recently used entry
+	order      *list.List
+	generation uint64
+	onEvict    func(reason string)
+	now        func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type CacheConfiguration[K comparable, V any] func(*Cache[K, V])
+
+//Function is called under the lock of the cache, so it must not use the cache
+func WithEvictionCallback[K comparable, V any](onEvict func(reason string)) CacheConfiguration[K, V] {
+	return func(c *Cache[K, V]) {
+		c.onEvict = onEvict
+	}
+}
+
+func WithClock[K comparable, V any](now func() time.Time) CacheConfiguration[K, V] {
+	return func(c *Cache[K, V]) {
+		c.now = now
+	}
+}
+
+func NewCache[K comparable, V any](capacity int, ttl time.Duration, configs ...CacheConfiguration[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{
+		capacity: max(capacity, 1),
+		ttl:      ttl,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+		onEvict:  func(string) {},
+		now:      time.Now,
+	}
+
+	for _, cfg := range configs {
+		cfg(c)
+	}
+
+	return c
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.items[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+
+	e := element.Value.(*entry[K, V])
+	if !c.now().Before(e.expiresAt) {
+		c.removeElement(element)
+		c.onEvict(EvictionReasonExpired)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+//Generation must be taken before value is loaded and passed to SetIfGeneration
+func (c *Cache[K, V]) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+//Method stores value only if nothing was deleted since generation was taken and tells if it was stored
+func (c *Cache[K, V]) SetIfGeneration(key K, value V, generation uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return false
+	}
+
+	expiresAt := c.now().Add(c.ttl)
+
+	if element, exists := c.items[key]; exists {
+		e := element.Value.(*entry[K, V])
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return true
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		c.onEvict(EvictionReasonCapacity)
+	}
+
+	return true
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	//Generation is increased even if key is not cached, because it can be loading now
+	c.generation++
+	if element, exists := c.items[key]; exists {
+		c.removeElement(element)
+	}
+}
+
+//Method deletes all entries whose key matches and returns their number
+func (c *Cache[K, V]) DeleteFunc(match func(key K) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	deleted := 0
+	for key, element := range c.items {
+		if match(key) {
+			c.removeElement(element)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *Cache[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[K, V]).key)
+}
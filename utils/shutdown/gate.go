@@ -0,0 +1,58 @@
+package shutdown
+
+import (
+	"context"
+	"sync"
+)
+
+//Gate counts operations that must be finished before the server stops
+//After Close new operations are not started, Close waits for the started ones
+type Gate struct {
+	mu     sync.Mutex
+	closed bool
+	active sync.WaitGroup
+}
+
+//Method returns false if gate is closed, otherwise Leave must be called when operation is finished
+func (g *Gate) Enter() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return false
+	}
+
+	g.active.Add(1)
+	return true
+}
+
+func (g *Gate) IsClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.closed
+}
+
+func (g *Gate) Leave() {
+	g.active.Done()
+}
+
+//Method closes the gate and waits for started operations until context is done
+func (g *Gate) Close(ctx context.Context) error {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		g.active.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}